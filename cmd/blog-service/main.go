@@ -22,9 +22,11 @@ package main
 // @description JWT токен в формате Bearer {token}
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -33,11 +35,21 @@ import (
 	"gorm.io/gorm"
 
 	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/activitypub"
 	"gitlab.com/Nikolay-Yakunin/blog-service/internal/auth"
 	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/linkcheck"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/mentions"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/notifications"
 	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/reports"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/spam"
 	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/webmentions"
 	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/auth/oauth"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/events"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/mail"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/ratelimit"
 	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/swagger"
 
 	// Импорт swagger документации
@@ -109,13 +121,108 @@ func main() {
 	userRepo := users.NewUserRepository(db)
 	userService := users.NewUserService(userRepo)
 	postRepo := posts.NewPostRepository(db)
-	postService := posts.NewPostService(postRepo)
+	postService := posts.NewPostService(postRepo, cfg.Site)
+	notificationService := notifications.NewNotificationService(notifications.NewNotificationRepository(db))
+	// Письма: уведомления и ссылки подтверждения комментариев гостей
+	mailer := mail.New(cfg.Mail)
+	if cfg.Notifications.Email.Enabled {
+		notificationService.UseEmail(mailer, userRepo, cfg.Notifications.Email, cfg.Site, cfg.JWT.SecretKey)
+	}
+	// Упоминания @username в постах и комментариях
+	userMentions := mentions.NewMentionService(mentions.NewMentionRepository(db), userRepo, notificationService, cfg.Site)
+	postService.UseMentions(userMentions)
+	userService.OnDeactivate(userMentions.Unlink)
 	commentRepo := comments.NewCommentRepository(db)
-	commentService := comments.NewCommentService(commentRepo)
+	spamChecker := spam.NewChecker(spam.NewSpamRepository(db), userService, cfg.Spam)
+	commentService := comments.NewCommentService(commentRepo, notificationService, spamChecker, userMentions, cfg.Comments)
+	commentService.UseMailer(mailer)
+	// События в реальном времени (SSE): комментарии постов и новые публикации
+	eventHub := events.NewHub(cfg.Events)
+	commentService.UsePublisher(eventHub)
+	postService.UsePublisher(eventHub)
+	// Жалобы читателей на комментарии и посты
+	reportService := reports.NewReportService(reports.NewReportRepository(db),
+		reports.NewCommentTarget(commentService), reports.NewPostTarget(postService), cfg.Reports)
+	mentionRepo := webmentions.NewMentionRepository(db)
+	mentionService := webmentions.NewMentionService(mentionRepo, postService, webmentions.NewHTTPFetcher(10*time.Second), cfg.Site)
+
+	// Проверяем входящие Webmention в фоне
+	go mentionService.Run(context.Background())
+
+	// Федерация ActivityPub: рассылаем подписчикам опубликованные посты
+	federationRepo := activitypub.NewFederationRepository(db)
+	federationService := activitypub.NewFederationService(federationRepo, userService, postService, commentService, activitypub.NewHTTPClient(10*time.Second), cfg.Site)
+	postService.OnPublish(federationService.PublishPost)
+
+	// Периодически снимаем истекшие закрепления и избранное
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := postService.ExpireHighlights(); err != nil {
+				log.Printf("Failed to expire pinned/featured posts: %v", err)
+			}
+		}
+	}()
+
+	// Окончательно удаляем посты, пролежавшие в корзине дольше срока хранения
+	if cfg.Trash.RetentionDays > 0 {
+		retention := time.Duration(cfg.Trash.RetentionDays) * 24 * time.Hour
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for range ticker.C {
+				if n, err := postService.PurgeExpiredTrash(retention); err != nil {
+					log.Printf("Failed to purge expired trash: %v", err)
+				} else if n > 0 {
+					log.Printf("Purged %d posts from trash", n)
+				}
+			}
+		}()
+	}
+
+	// Отправляем накопившиеся уведомления на email
+	if cfg.Notifications.Email.Enabled {
+		go func() {
+			ticker := time.NewTicker(time.Minute)
+			defer ticker.Stop()
+			for range ticker.C {
+				if n, err := notificationService.DeliverEmails(); err != nil {
+					log.Printf("Failed to email notifications: %v", err)
+				} else if n > 0 {
+					log.Printf("Sent %d notification emails", n)
+				}
+			}
+		}()
+	}
+
+	// Удаляем комментарии гостей, не подтвердивших email вовремя
+	if cfg.Comments.Guests.Enabled {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for range ticker.C {
+				if n, err := commentService.PurgeUnconfirmed(); err != nil {
+					log.Printf("Failed to purge unconfirmed guest comments: %v", err)
+				} else if n > 0 {
+					log.Printf("Purged %d unconfirmed guest comments", n)
+				}
+			}
+		}()
+	}
+
+	// Периодически проверяем исходящие ссылки в постах
+	linkRepo := linkcheck.NewLinkRepository(db)
+	linkService := linkcheck.NewCheckService(linkRepo, postService, linkcheck.NewHTTPChecker(cfg.LinkCheck.Timeout), cfg.Site, cfg.LinkCheck)
+	go linkService.Run(context.Background())
 
 	// Инициализируем OAuth конфигурацию (возвращаем старый способ)
 	oauthConfig := oauth.NewConfig()
 
+	// Ограничение частоты запросов записи; для нескольких экземпляров сервиса
+	// нужно общее хранилище, реализующее ratelimit.Store
+	rateLimits := ratelimit.NewMemoryStore()
+
 	// Инициализируем черный список токенов
 	auth.InitTokenBlacklist(db)
 
@@ -125,12 +232,22 @@ func main() {
 	}
 	r := gin.Default()
 
+	// IP клиента (ограничения частоты, гостевые комментарии, потоки событий) берется
+	// из X-Forwarded-For только за доверенными прокси, иначе клиент может его подменить
+	var trustedProxies []string
+	if len(cfg.Server.TrustedProxies) > 0 {
+		trustedProxies = cfg.Server.TrustedProxies
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid server.trusted_proxies: %v", err)
+	}
+
 	// Подключаем CORS middleware
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"https://nikolay-yakunin.github.io"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "If-Match", "If-None-Match", "If-Modified-Since", "Last-Event-ID"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "Link", "X-Total-Count", "X-Next-Cursor", "X-Unread-Count", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
 		AllowCredentials: true,
 	}))
 
@@ -151,6 +268,7 @@ func main() {
 
 	// Auth
 	authHandler := auth.NewHandler(oauthConfig, userService)
+	authHandler.UseRateLimit(rateLimits, cfg.RateLimit.Auth)
 	authHandler.RegisterRoutes(apiV1)
 
 	// Users
@@ -159,12 +277,36 @@ func main() {
 
 	// Posts
 	postHandler := posts.NewHandler(postService, cfg) // Передаем cfg
+	postHandler.UseRateLimit(rateLimits)
+	postHandler.UseStream(eventHub)
 	postHandler.Register(r)                           // Используем существующий метод Register(*gin.Engine)
 
 	// Comments
 	commentHandler := comments.NewHandler(commentService, cfg) // Передаем cfg
+	commentHandler.UseRateLimit(rateLimits)
+	commentHandler.UseStream(eventHub)
 	commentHandler.Register(r)                                 // Используем существующий метод Register(*gin.Engine)
 
+	// Webmentions
+	mentionHandler := webmentions.NewHandler(mentionService, cfg)
+	mentionHandler.Register(r)
+
+	// ActivityPub
+	federationHandler := activitypub.NewHandler(federationService, cfg)
+	federationHandler.Register(r)
+
+	// Отчеты о битых ссылках
+	linkHandler := linkcheck.NewHandler(linkService, cfg)
+	linkHandler.Register(r)
+
+	// Уведомления пользователей
+	notificationHandler := notifications.NewHandler(notificationService, cfg)
+	notificationHandler.Register(r)
+
+	// Жалобы на комментарии и посты
+	reportHandler := reports.NewHandler(reportService, cfg)
+	reportHandler.Register(r)
+
 	// Запускаем сервер
 	port := cfg.Server.Port
 	if port == "" {
@@ -0,0 +1,108 @@
+// Команда linkcheck проверяет исходящие ссылки в постах по требованию.
+//
+// Использование:
+//
+//	go run ./cmd/linkcheck            # проверить все посты
+//	go run ./cmd/linkcheck -post 42   # проверить один пост
+//
+// Результаты сохраняются в БД так же, как при фоновой проверке в сервисе,
+// после чего команда печатает итоги и список битых ссылок.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/joho/godotenv"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/linkcheck"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+)
+
+func main() {
+	postID := flag.Uint("post", 0, "ID поста (по умолчанию проверяются все посты)")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	cfg, err := config.LoadConfig(".")
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		log.Fatal("DATABASE_URL environment variable not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	postService := posts.NewPostService(posts.NewPostRepository(db), cfg.Site)
+	linkService := linkcheck.NewCheckService(
+		linkcheck.NewLinkRepository(db),
+		postService,
+		linkcheck.NewHTTPChecker(cfg.LinkCheck.Timeout),
+		cfg.Site,
+		cfg.LinkCheck,
+	)
+
+	// Ctrl+C прерывает проверку, сохраненные результаты остаются в БД
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var summary *linkcheck.Summary
+	if *postID != 0 {
+		summary, err = linkService.CheckPost(ctx, uint(*postID))
+	} else {
+		summary, err = linkService.CheckAll(ctx)
+	}
+	if summary != nil {
+		fmt.Printf("Posts: %d, links: %d, requests: %d, broken: %d\n",
+			summary.Posts, summary.Links, summary.Checked, summary.Broken)
+	}
+	if err != nil {
+		log.Fatalf("Link check failed: %v", err)
+	}
+
+	if err := printBroken(linkService, uint(*postID)); err != nil {
+		log.Fatalf("Failed to load report: %v", err)
+	}
+}
+
+// printBroken печатает битые ссылки поста или всех постов
+func printBroken(service linkcheck.Service, postID uint) error {
+	var links []linkcheck.Link
+	if postID != 0 {
+		report, err := service.GetPostBrokenLinks(postID)
+		if err != nil {
+			return err
+		}
+		links = report.Links
+	} else {
+		var err error
+		if links, err = service.GetBrokenLinks(0, 1000); err != nil {
+			return err
+		}
+	}
+
+	for _, link := range links {
+		reason := link.Error
+		if reason == "" {
+			reason = fmt.Sprintf("HTTP %d", link.StatusCode)
+		}
+		fmt.Printf("post %d\t%s\t%s\n", link.PostID, link.URL, reason)
+	}
+	return nil
+}
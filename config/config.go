@@ -4,13 +4,31 @@ import (
     "github.com/spf13/viper"
     "strings"
     "errors"
+    "net/url"
+    "time"
+
+    "gitlab.com/Nikolay-Yakunin/blog-service/pkg/events"
+    "gitlab.com/Nikolay-Yakunin/blog-service/pkg/httpcache"
+    "gitlab.com/Nikolay-Yakunin/blog-service/pkg/mail"
+    "gitlab.com/Nikolay-Yakunin/blog-service/pkg/ratelimit"
 )
 
 type Config struct {
-    App      AppConfig
-    Server   ServerConfig
-    JWT      JWTConfig
-    Database DatabaseConfig
+    App           AppConfig
+    Server        ServerConfig
+    JWT           JWTConfig
+    Database      DatabaseConfig
+    Cache         CacheConfig
+    Site          SiteConfig
+    Trash         TrashConfig
+    LinkCheck     LinkCheckConfig `mapstructure:"linkcheck"`
+    Comments      CommentsConfig
+    Spam          SpamConfig
+    RateLimit     RateLimitConfig `mapstructure:"ratelimit"`
+    Reports       ReportsConfig
+    Mail          mail.Config
+    Notifications NotificationsConfig
+    Events        events.Config
 }
 
 type AppConfig struct {
@@ -21,6 +39,9 @@ type AppConfig struct {
 type ServerConfig struct {
     Port string
     Host string
+    // Адреса или подсети обратных прокси, которым доверяется X-Forwarded-For.
+    // Пусто - заголовок игнорируется, IP клиента берется из соединения
+    TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type JWTConfig struct {
@@ -36,6 +57,149 @@ type DatabaseConfig struct {
     Password string
 }
 
+// SiteConfig описывает публичные адреса сайта и API
+type SiteConfig struct {
+    APIURL  string `mapstructure:"api_url"`  // Внешний адрес API без завершающего слеша
+    PostURL string `mapstructure:"post_url"` // Шаблон публичной ссылки на пост, содержит {slug}
+    // Шаблон ссылки на профиль пользователя, содержит {username}
+    ProfileURL string `mapstructure:"profile_url"`
+}
+
+// PostLink возвращает публичную ссылку на пост по его slug
+func (s SiteConfig) PostLink(slug string) string {
+    return strings.Replace(s.PostURL, "{slug}", slug, 1)
+}
+
+// ProfileLink возвращает ссылку на профиль пользователя
+func (s SiteConfig) ProfileLink(username string) string {
+    return strings.Replace(s.ProfileURL, "{username}", url.PathEscape(username), 1)
+}
+
+// SlugFromURL извлекает slug из публичной ссылки на пост.
+// Возвращает false, если ссылка не соответствует шаблону PostURL.
+func (s SiteConfig) SlugFromURL(link string) (string, bool) {
+    prefix, suffix, ok := strings.Cut(s.PostURL, "{slug}")
+    if !ok {
+        return "", false
+    }
+
+    // Фрагмент и query не участвуют в сопоставлении
+    if i := strings.IndexAny(link, "?#"); i >= 0 {
+        link = link[:i]
+    }
+    if suffix == "" {
+        link = strings.TrimSuffix(link, "/")
+    }
+    if !strings.HasPrefix(link, prefix) || !strings.HasSuffix(link, suffix) || len(link) < len(prefix)+len(suffix) {
+        return "", false
+    }
+
+    slug := link[len(prefix) : len(link)-len(suffix)]
+    if slug == "" || strings.Contains(slug, "/") {
+        return "", false
+    }
+    return slug, true
+}
+
+// CacheConfig задает политики Cache-Control для публичных маршрутов чтения
+type CacheConfig struct {
+    Post         httpcache.Policy `mapstructure:"post"`          // GET /posts/:id и /posts/slug/:slug
+    PostList     httpcache.Policy `mapstructure:"post_list"`     // GET /posts
+    PostComments httpcache.Policy `mapstructure:"post_comments"` // GET /comments?postId=
+}
+
+// TrashConfig задает срок хранения удаленных постов в корзине
+type TrashConfig struct {
+    RetentionDays int `mapstructure:"retention_days"` // 0 - не удалять автоматически
+}
+
+// CommentsConfig задает параметры комментариев
+type CommentsConfig struct {
+    MaxDepth   int    `mapstructure:"max_depth"`  // Ответы глубже этого уровня поднимаются на него, 0 - без ограничения
+    Moderation string `mapstructure:"moderation"` // Политика премодерации по умолчанию: all, first_time, none
+    // Пороги оценки спама (от 0 до 1): с оценкой не ниже review_threshold комментарий
+    // ожидает модерации, не ниже spam_threshold - попадает в спам. 0 - порог не используется.
+    ReviewThreshold float64 `mapstructure:"review_threshold"`
+    SpamThreshold   float64 `mapstructure:"spam_threshold"`
+    Downvotes       bool    `mapstructure:"downvotes"` // Разрешить дизлайки комментариев
+    // Сколько после создания автор может править комментарий, 0 - без ограничения.
+    // Модераторы могут править комментарии в любое время.
+    EditWindow time.Duration `mapstructure:"edit_window"`
+    // Через сколько дней после публикации поста закрываются комментарии, 0 - никогда
+    AutoCloseDays int          `mapstructure:"auto_close_days"`
+    Guests        GuestsConfig `mapstructure:"guests"`
+}
+
+// GuestsConfig задает параметры комментариев гостей (без регистрации)
+type GuestsConfig struct {
+    Enabled bool `mapstructure:"enabled"`
+    // Шаблон ссылки подтверждения email из письма, содержит {token}
+    ConfirmURL string        `mapstructure:"confirm_url"`
+    ConfirmTTL time.Duration `mapstructure:"confirm_ttl"` // Срок действия ссылки, затем комментарий удаляется
+    // Сложность proof-of-work в битах и срок действия выданной задачи
+    Difficulty   int           `mapstructure:"pow_difficulty"`
+    ChallengeTTL time.Duration `mapstructure:"challenge_ttl"`
+}
+
+// ConfirmLink возвращает ссылку подтверждения комментария гостя
+func (g GuestsConfig) ConfirmLink(token string) string {
+    return strings.Replace(g.ConfirmURL, "{token}", url.QueryEscape(token), 1)
+}
+
+// AutoClose возвращает срок приема комментариев после публикации поста, 0 - без ограничения
+func (c CommentsConfig) AutoClose() time.Duration {
+    return time.Duration(c.AutoCloseDays) * 24 * time.Hour
+}
+
+// SpamConfig задает эвристики проверки комментариев на спам
+type SpamConfig struct {
+    MaxLinks        int           `mapstructure:"max_links"`        // Больше ссылок - признак спама, 0 - не проверять
+    BlockedWords    []string      `mapstructure:"blocked_words"`    // Запрещенные слова и фразы
+    BlockedDomains  []string      `mapstructure:"blocked_domains"`  // Запрещенные домены ссылок (с поддоменами)
+    DuplicateWindow time.Duration `mapstructure:"duplicate_window"` // Период поиска повторов текста автора, 0 - не проверять
+    MinAccountAge   time.Duration `mapstructure:"min_account_age"`  // Более новые учетные записи - признак спама, 0 - не проверять
+}
+
+// ReportsConfig задает параметры жалоб на комментарии и посты
+type ReportsConfig struct {
+    // Сколько разных пользователей должны пожаловаться, чтобы объект скрылся
+    // до решения модератора, 0 - не скрывать автоматически
+    HideThreshold int `mapstructure:"hide_threshold"`
+}
+
+// NotificationsConfig задает доставку уведомлений
+type NotificationsConfig struct {
+    Email NotificationEmailConfig `mapstructure:"email"`
+}
+
+// NotificationEmailConfig задает отправку уведомлений по email.
+// Уведомления копятся delay, чтобы несколько штук пришли одним письмом,
+// и пользователь получает не больше одного письма за interval: накопившиеся
+// за это время уведомления приходят дайджестом.
+type NotificationEmailConfig struct {
+    Enabled bool `mapstructure:"enabled"`
+    // Типы уведомлений, которые приходят на email, пока пользователь не изменил настройку
+    Types    []string      `mapstructure:"types"`
+    Delay    time.Duration `mapstructure:"delay"`
+    Interval time.Duration `mapstructure:"interval"`
+}
+
+// RateLimitConfig задает ограничения частоты запросов для групп маршрутов записи.
+// Запросы считаются по пользователю из JWT, а без авторизации - по IP.
+type RateLimitConfig struct {
+    Comments ratelimit.Limit `mapstructure:"comments"` // POST /comments
+    Posts    ratelimit.Limit `mapstructure:"posts"`    // POST /posts
+    Auth     ratelimit.Limit `mapstructure:"auth"`     // GET /auth/callback/:provider
+}
+
+// LinkCheckConfig задает параметры проверки исходящих ссылок в постах
+type LinkCheckConfig struct {
+    Interval          time.Duration `mapstructure:"interval"`            // Период фоновой проверки, 0 - только вручную
+    Timeout           time.Duration `mapstructure:"timeout"`             // Таймаут одного запроса
+    RequestsPerSecond float64       `mapstructure:"requests_per_second"` // Ограничение частоты запросов, 0 - без ограничения
+    CacheTTL          time.Duration `mapstructure:"cache_ttl"`           // Сколько результат проверки URL считается актуальным
+}
+
 func LoadConfig(path string) (*Config, error) {
     viper.AddConfigPath(path)
     viper.SetConfigName("config")
@@ -54,6 +218,10 @@ func LoadConfig(path string) (*Config, error) {
     if err := viper.BindEnv("jwt.secret_key", "JWT_SECRET_KEY"); err != nil {
         return nil, err
     }
+    // Пароль SMTP-сервера тоже не хранится в файле конфигурации
+    if err := viper.BindEnv("mail.password", "MAIL_PASSWORD"); err != nil {
+        return nil, err
+    }
 
     var config Config
     if err := viper.Unmarshal(&config); err != nil {
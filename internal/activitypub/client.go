@@ -0,0 +1,101 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/httpsig"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/safehttp"
+)
+
+// maxDocumentSize ограничивает размер загружаемых документов ActivityPub
+const maxDocumentSize = 1 << 20
+
+// userAgent передается удаленным серверам
+const userAgent = "blog-service-activitypub/1.0"
+
+// HTTPClient загружает акторов и доставляет активности по HTTP с подписью запросов
+type HTTPClient struct {
+	client *http.Client
+}
+
+// NewHTTPClient создает клиент с указанным таймаутом запроса.
+// Адреса акторов, ключей и inbox приходят от удаленных серверов (в том числе
+// до проверки подписи), поэтому клиент соединяется только с публичными адресами.
+func NewHTTPClient(timeout time.Duration) *HTTPClient {
+	return &HTTPClient{
+		client: safehttp.NewClient(timeout),
+	}
+}
+
+// FetchActor загружает документ актора.
+// Запрос подписывается, т.к. серверы в режиме secure mode не отдают акторов анонимно.
+func (c *HTTPClient) FetchActor(ctx context.Context, actorURI string, signer *Signer) (*RemoteActor, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, actorURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ContentType+`, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`)
+	req.Header.Set("User-Agent", userAgent)
+	if signer != nil {
+		if err := httpsig.Sign(req, signer.KeyID, signer.Key, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch actor %s: unexpected status %s", actorURI, resp.Status)
+	}
+
+	var actor RemoteActor
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(&actor); err != nil {
+		return nil, fmt.Errorf("fetch actor %s: %w", actorURI, err)
+	}
+
+	// Документ должен описывать именно запрошенного актора
+	if actor.ID != actorURI {
+		return nil, ErrActorMismatch
+	}
+	return &actor, nil
+}
+
+// Deliver отправляет активность в inbox
+func (c *HTTPClient) Deliver(ctx context.Context, inbox string, activity interface{}, signer *Signer) error {
+	body, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("User-Agent", userAgent)
+	if err := httpsig.Sign(req, signer.KeyID, signer.Key, body); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDocumentSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("deliver to %s: unexpected status %s", inbox, resp.Status)
+	}
+	return nil
+}
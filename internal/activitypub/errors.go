@@ -0,0 +1,28 @@
+package activitypub
+
+import "errors"
+
+var (
+	ErrActorNotFound    = errors.New("actor not found")
+	ErrObjectNotFound   = errors.New("object not found")
+	ErrInvalidResource  = errors.New("unsupported webfinger resource")
+	ErrInvalidActivity  = errors.New("invalid activity")
+	ErrInvalidSignature = errors.New("invalid http signature")
+	ErrActorMismatch    = errors.New("activity actor does not match signature")
+)
+
+// ErrorResponse представляет структуру ответа с ошибкой
+type ErrorResponse struct {
+	Code    int    `json:"code" example:"400" swagger:"description=HTTP код ошибки"`
+	Message string `json:"message" example:"Неверный формат данных" swagger:"description=Описание ошибки"`
+	Details string `json:"details,omitempty" example:"invalid http signature" swagger:"description=Дополнительные детали ошибки"`
+}
+
+// NewErrorResponse создает новый экземпляр ErrorResponse
+func NewErrorResponse(code int, message string, details string) *ErrorResponse {
+	return &ErrorResponse{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
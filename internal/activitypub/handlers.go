@@ -0,0 +1,217 @@
+package activitypub
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+)
+
+// Handler обрабатывает HTTP-запросы федерации
+type Handler struct {
+	service Service
+	config  *config.Config
+}
+
+// NewHandler создает новый обработчик HTTP-запросов федерации
+func NewHandler(service Service, cfg *config.Config) *Handler {
+	return &Handler{
+		service: service,
+		config:  cfg,
+	}
+}
+
+// Register регистрирует все пути обработки HTTP-запросов
+func (h *Handler) Register(router *gin.Engine) {
+	router.GET("/.well-known/webfinger", h.WebFinger)
+
+	ap := router.Group("/ap")
+	{
+		ap.GET("/users/:username", h.GetActor)
+		ap.GET("/users/:username/outbox", h.GetOutbox)
+		ap.GET("/users/:username/followers", h.GetFollowers)
+		ap.POST("/users/:username/inbox", h.Inbox)
+		ap.GET("/posts/:id", h.GetArticle)
+	}
+}
+
+// WebFinger находит актора автора по адресу acct:user@host
+// @Summary WebFinger
+// @Tags activitypub
+// @Produce json
+// @Param resource query string true "acct:username@host или URI актора"
+// @Success 200 {object} WebFinger
+// @Failure 400,404 {object} ErrorResponse
+// @Router /.well-known/webfinger [get]
+func (h *Handler) WebFinger(c *gin.Context) {
+	result, err := h.service.WebFinger(c.Query("resource"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", JRDContentType)
+	c.JSON(http.StatusOK, result)
+}
+
+// GetActor возвращает документ актора автора
+// @Summary Актор ActivityPub
+// @Tags activitypub
+// @Produce json
+// @Param username path string true "Имя автора"
+// @Success 200 {object} Actor
+// @Failure 404,500 {object} ErrorResponse
+// @Router /ap/users/{username} [get]
+func (h *Handler) GetActor(c *gin.Context) {
+	actor, err := h.service.GetActor(c.Param("username"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	respondActivity(c, actor)
+}
+
+// GetOutbox возвращает outbox автора: без параметра page - сводку коллекции,
+// с page - страницу активностей Create с опубликованными постами
+// @Summary Outbox автора
+// @Tags activitypub
+// @Produce json
+// @Param username path string true "Имя автора"
+// @Param page query int false "Номер страницы"
+// @Success 200 {object} OrderedCollectionPage
+// @Failure 404,500 {object} ErrorResponse
+// @Router /ap/users/{username}/outbox [get]
+func (h *Handler) GetOutbox(c *gin.Context) {
+	username := c.Param("username")
+
+	if c.Query("page") == "" {
+		outbox, err := h.service.GetOutbox(username)
+		if err != nil {
+			h.respondError(c, err)
+			return
+		}
+		respondActivity(c, outbox)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	result, err := h.service.GetOutboxPage(username, page)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	respondActivity(c, result)
+}
+
+// GetFollowers возвращает количество подписчиков автора
+// @Summary Подписчики автора
+// @Tags activitypub
+// @Produce json
+// @Param username path string true "Имя автора"
+// @Success 200 {object} OrderedCollection
+// @Failure 404,500 {object} ErrorResponse
+// @Router /ap/users/{username}/followers [get]
+func (h *Handler) GetFollowers(c *gin.Context) {
+	followers, err := h.service.GetFollowers(c.Param("username"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	respondActivity(c, followers)
+}
+
+// Inbox принимает активности от удаленных серверов
+// Запрос должен быть подписан HTTP-подписью актора
+// @Summary Inbox автора
+// @Tags activitypub
+// @Accept json
+// @Param username path string true "Имя автора"
+// @Success 202 "Accepted"
+// @Failure 400,401,404,500 {object} ErrorResponse
+// @Router /ap/users/{username}/inbox [post]
+func (h *Handler) Inbox(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxDocumentSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Failed to read activity",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := h.service.HandleInbox(c.Request.Context(), c.Param("username"), c.Request, body); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// GetArticle возвращает опубликованный пост как объект Article
+// @Summary Пост как объект ActivityPub
+// @Tags activitypub
+// @Produce json
+// @Param id path int true "ID поста"
+// @Success 200 {object} Article
+// @Failure 400,404,500 {object} ErrorResponse
+// @Router /ap/posts/{id} [get]
+func (h *Handler) GetArticle(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid post ID",
+			err.Error(),
+		))
+		return
+	}
+
+	article, err := h.service.GetArticle(uint(id))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	respondActivity(c, article)
+}
+
+// respondActivity отдает документ с типом содержимого ActivityPub
+func respondActivity(c *gin.Context, document interface{}) {
+	c.Header("Content-Type", ContentType)
+	c.JSON(http.StatusOK, document)
+}
+
+// respondError преобразует ошибку сервиса в HTTP-ответ
+func (h *Handler) respondError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	message := "Federation request failed"
+
+	switch {
+	case errors.Is(err, ErrActorNotFound):
+		status = http.StatusNotFound
+		message = "Actor not found"
+	case errors.Is(err, ErrObjectNotFound):
+		status = http.StatusNotFound
+		message = "Object not found"
+	case errors.Is(err, ErrInvalidResource), errors.Is(err, ErrInvalidActivity):
+		status = http.StatusBadRequest
+		message = "Invalid request"
+	case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrActorMismatch):
+		status = http.StatusUnauthorized
+		message = "Request signature is not valid"
+	}
+
+	c.JSON(status, NewErrorResponse(
+		status,
+		message,
+		err.Error(),
+	))
+}
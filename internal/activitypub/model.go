@@ -0,0 +1,150 @@
+// Package activitypub реализует федерацию по протоколу ActivityPub
+// (https://www.w3.org/TR/activitypub/): читатели из Mastodon и других
+// серверов могут подписываться на авторов блога.
+//
+// Основные компоненты:
+//   - KeyPair: ключи автора для подписи HTTP-запросов
+//   - Follower: подписчик автора на удаленном сервере
+//   - Reply: входящий ответ, сохраненный как комментарий
+//   - Client: загрузка удаленных акторов и доставка активностей (подменяется в тестах)
+//   - Repository: интерфейс хранилища
+//   - Service: WebFinger, актор, outbox, inbox и доставка публикаций
+package activitypub
+
+import (
+	"context"
+	"crypto/rsa"
+	"net/http"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+)
+
+// KeyPair хранит RSA-ключи автора, которыми подписываются исходящие запросы.
+// Ключи создаются при первом обращении к актору.
+type KeyPair struct {
+	UserID        uint   `gorm:"primaryKey"`
+	PublicKeyPEM  string `gorm:"column:public_key_pem;type:text;not null"`
+	PrivateKeyPEM string `gorm:"column:private_key_pem;type:text;not null"`
+	CreatedAt     time.Time
+}
+
+// TableName задает имя таблицы ключей
+func (KeyPair) TableName() string {
+	return "activitypub_keys"
+}
+
+// Follower представляет удаленного подписчика автора
+type Follower struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null"`
+	ActorURI    string    `json:"actor_uri" gorm:"size:2048;not null"`
+	Inbox       string    `json:"inbox" gorm:"size:2048;not null"`
+	SharedInbox string    `json:"shared_inbox,omitempty" gorm:"size:2048"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName задает имя таблицы подписчиков
+func (Follower) TableName() string {
+	return "activitypub_followers"
+}
+
+// Reply связывает входящую заметку (Note) с созданным по ней комментарием.
+// Используется для защиты от повторной доставки и для построения веток ответов.
+type Reply struct {
+	ID        uint   `gorm:"primaryKey"`
+	NoteURI   string `gorm:"size:2048;not null;unique"`
+	CommentID uint   `gorm:"not null"`
+	ActorURI  string `gorm:"size:2048;not null"`
+	CreatedAt time.Time
+}
+
+// TableName задает имя таблицы входящих ответов
+func (Reply) TableName() string {
+	return "activitypub_replies"
+}
+
+// Signer содержит ключ, которым подписываются исходящие запросы от имени автора
+type Signer struct {
+	KeyID string
+	Key   *rsa.PrivateKey
+}
+
+// Client загружает удаленных акторов и доставляет активности в их inbox.
+// Выделен в интерфейс, чтобы федерацию можно было тестировать без сети.
+type Client interface {
+	// FetchActor загружает документ удаленного актора
+	FetchActor(ctx context.Context, actorURI string, signer *Signer) (*RemoteActor, error)
+	// Deliver отправляет подписанную активность в inbox
+	Deliver(ctx context.Context, inbox string, activity interface{}, signer *Signer) error
+}
+
+// UserDirectory находит локальных авторов и заводит учетные записи удаленных акторов
+type UserDirectory interface {
+	// GetUser получает пользователя по ID
+	GetUser(id uint) (*users.User, error)
+	// GetUserByUsername получает пользователя по имени
+	GetUserByUsername(username string) (*users.User, error)
+	// GetOrCreateRemoteUser возвращает учетную запись удаленного актора
+	GetOrCreateRemoteUser(actorURI, username, avatar string) (*users.User, error)
+}
+
+// PostSource предоставляет посты для outbox и входящих ответов
+type PostSource interface {
+	// GetPost получает пост по ID
+	GetPost(id uint) (*posts.Post, error)
+	// GetPostBySlug получает пост по его слагу
+	GetPostBySlug(slug string) (*posts.Post, error)
+	// GetPostsByAuthor возвращает посты автора
+	GetPostsByAuthor(authorID uint) ([]posts.Post, error)
+}
+
+// CommentSink сохраняет входящие ответы как комментарии
+type CommentSink interface {
+	// GetComment получает комментарий по ID
+	GetComment(id uint) (*comments.Comment, error)
+	// CreateComment создает новый комментарий
+	CreateComment(comment *comments.Comment) error
+}
+
+// Repository описывает методы для работы с хранилищем федерации
+type Repository interface {
+	// GetKey возвращает ключи автора
+	GetKey(userID uint) (*KeyPair, error)
+	// CreateKey сохраняет ключи автора, если их еще нет
+	CreateKey(key *KeyPair) error
+	// AddFollower добавляет подписчика или обновляет адреса его inbox
+	AddFollower(follower *Follower) error
+	// RemoveFollower удаляет подписчика
+	RemoveFollower(userID uint, actorURI string) error
+	// CountFollowers возвращает количество подписчиков автора
+	CountFollowers(userID uint) (int64, error)
+	// ListFollowerInboxes возвращает адреса доставки подписчиков (shared inbox, если есть)
+	ListFollowerInboxes(userID uint) ([]string, error)
+	// GetReply возвращает входящий ответ по URI заметки
+	GetReply(noteURI string) (*Reply, error)
+	// CreateReply сохраняет связь заметки с комментарием
+	CreateReply(reply *Reply) error
+}
+
+// Service описывает бизнес-логику федерации
+type Service interface {
+	// WebFinger находит актора по ресурсу acct:user@host или URI актора
+	WebFinger(resource string) (*WebFinger, error)
+	// GetActor возвращает документ актора автора
+	GetActor(username string) (*Actor, error)
+	// GetOutbox возвращает сводку outbox автора
+	GetOutbox(username string) (*OrderedCollection, error)
+	// GetOutboxPage возвращает страницу outbox с опубликованными постами
+	GetOutboxPage(username string, page int) (*OrderedCollectionPage, error)
+	// GetFollowers возвращает коллекцию подписчиков (только количество)
+	GetFollowers(username string) (*OrderedCollection, error)
+	// GetArticle возвращает опубликованный пост как объект Article
+	GetArticle(postID uint) (*Article, error)
+	// HandleInbox проверяет подпись и обрабатывает входящую активность
+	HandleInbox(ctx context.Context, username string, req *http.Request, body []byte) error
+	// DeliverPost рассылает активность Create с постом подписчикам автора
+	DeliverPost(ctx context.Context, post posts.Post) error
+}
@@ -0,0 +1,90 @@
+package activitypub
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/database"
+)
+
+// FederationRepository реализует интерфейс Repository для работы с БД
+type FederationRepository struct {
+	database.BaseRepository
+}
+
+// NewFederationRepository создает новый экземпляр репозитория федерации
+func NewFederationRepository(db *gorm.DB) Repository {
+	return &FederationRepository{
+		BaseRepository: database.NewBaseRepository(db),
+	}
+}
+
+// GetKey возвращает ключи автора.
+// Если ключей еще нет, возвращает (nil, nil).
+func (r *FederationRepository) GetKey(userID uint) (*KeyPair, error) {
+	var key KeyPair
+	if err := r.DB.First(&key, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// CreateKey сохраняет ключи автора.
+// Если ключи уже созданы параллельным запросом, существующие не перезаписываются.
+func (r *FederationRepository) CreateKey(key *KeyPair) error {
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(key).Error
+}
+
+// AddFollower добавляет подписчика; при повторной подписке обновляет адреса inbox
+func (r *FederationRepository) AddFollower(follower *Follower) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "actor_uri"}},
+		DoUpdates: clause.AssignmentColumns([]string{"inbox", "shared_inbox"}),
+	}).Create(follower).Error
+}
+
+// RemoveFollower удаляет подписчика
+func (r *FederationRepository) RemoveFollower(userID uint, actorURI string) error {
+	return r.DB.Where("user_id = ? AND actor_uri = ?", userID, actorURI).Delete(&Follower{}).Error
+}
+
+// CountFollowers возвращает количество подписчиков автора
+func (r *FederationRepository) CountFollowers(userID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&Follower{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// ListFollowerInboxes возвращает уникальные адреса доставки подписчиков.
+// Для подписчиков с одного сервера используется общий shared inbox.
+func (r *FederationRepository) ListFollowerInboxes(userID uint) ([]string, error) {
+	var inboxes []string
+	err := r.DB.Model(&Follower{}).
+		Where("user_id = ?", userID).
+		Distinct().
+		Pluck("COALESCE(NULLIF(shared_inbox, ''), inbox)", &inboxes).Error
+	return inboxes, err
+}
+
+// GetReply возвращает входящий ответ по URI заметки.
+// Если ответ не найден, возвращает (nil, nil).
+func (r *FederationRepository) GetReply(noteURI string) (*Reply, error) {
+	var reply Reply
+	if err := r.DB.Where("note_uri = ?", noteURI).First(&reply).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// CreateReply сохраняет связь заметки с комментарием
+func (r *FederationRepository) CreateReply(reply *Reply) error {
+	return r.DB.Create(reply).Error
+}
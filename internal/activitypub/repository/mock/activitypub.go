@@ -0,0 +1,59 @@
+package mock
+
+import (
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/activitypub"
+)
+
+type ActivityPubRepositoryMock struct {
+	mock.Mock
+}
+
+func (r *ActivityPubRepositoryMock) GetKey(userID uint) (*activitypub.KeyPair, error) {
+	args := r.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*activitypub.KeyPair), args.Error(1)
+}
+
+func (r *ActivityPubRepositoryMock) CreateKey(key *activitypub.KeyPair) error {
+	args := r.Called(key)
+	return args.Error(0)
+}
+
+func (r *ActivityPubRepositoryMock) AddFollower(follower *activitypub.Follower) error {
+	args := r.Called(follower)
+	return args.Error(0)
+}
+
+func (r *ActivityPubRepositoryMock) RemoveFollower(userID uint, actorURI string) error {
+	args := r.Called(userID, actorURI)
+	return args.Error(0)
+}
+
+func (r *ActivityPubRepositoryMock) CountFollowers(userID uint) (int64, error) {
+	args := r.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *ActivityPubRepositoryMock) ListFollowerInboxes(userID uint) ([]string, error) {
+	args := r.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (r *ActivityPubRepositoryMock) GetReply(noteURI string) (*activitypub.Reply, error) {
+	args := r.Called(noteURI)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*activitypub.Reply), args.Error(1)
+}
+
+func (r *ActivityPubRepositoryMock) CreateReply(reply *activitypub.Reply) error {
+	args := r.Called(reply)
+	return args.Error(0)
+}
@@ -0,0 +1,678 @@
+package activitypub
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/httpsig"
+)
+
+const (
+	// outboxPageSize - количество постов на странице outbox
+	outboxPageSize = 20
+	// deliveryTimeout ограничивает фоновую доставку одной активности
+	deliveryTimeout = 10 * time.Minute
+	// maxAvatarLength - длина поля users.avatar
+	maxAvatarLength = 255
+)
+
+// deliveryBackoff - паузы перед попытками доставки в один inbox
+var deliveryBackoff = []time.Duration{0, 10 * time.Second, time.Minute}
+
+// FederationService реализует бизнес-логику федерации
+type FederationService struct {
+	repo     Repository
+	users    UserDirectory
+	posts    PostSource
+	comments CommentSink
+	client   Client
+	site     config.SiteConfig
+}
+
+// NewFederationService создает новый экземпляр сервиса федерации
+func NewFederationService(repo Repository, users UserDirectory, posts PostSource, comments CommentSink, client Client, site config.SiteConfig) *FederationService {
+	return &FederationService{
+		repo:     repo,
+		users:    users,
+		posts:    posts,
+		comments: comments,
+		client:   client,
+		site:     site,
+	}
+}
+
+// WebFinger находит актора по ресурсу acct:user@host или по URI актора
+func (s *FederationService) WebFinger(resource string) (*WebFinger, error) {
+	var username string
+	switch {
+	case strings.HasPrefix(resource, "acct:"):
+		name, host, ok := strings.Cut(strings.TrimPrefix(resource, "acct:"), "@")
+		if !ok || !strings.EqualFold(host, s.host()) {
+			return nil, ErrInvalidResource
+		}
+		username = name
+	case strings.HasPrefix(resource, s.site.APIURL+"/ap/users/"):
+		name, err := url.PathUnescape(strings.TrimPrefix(resource, s.site.APIURL+"/ap/users/"))
+		if err != nil {
+			return nil, ErrInvalidResource
+		}
+		username = name
+	default:
+		return nil, ErrInvalidResource
+	}
+
+	user, err := s.localUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	actorURI := s.actorURI(user.Username)
+	return &WebFinger{
+		Subject: "acct:" + user.Username + "@" + s.host(),
+		Aliases: []string{actorURI},
+		Links: []WebFingerLink{
+			{Rel: "self", Type: ContentType, Href: actorURI},
+		},
+	}, nil
+}
+
+// GetActor возвращает документ актора автора
+func (s *FederationService) GetActor(username string) (*Actor, error) {
+	user, err := s.localUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.keyPair(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	actorURI := s.actorURI(user.Username)
+	actor := &Actor{
+		Context:           []string{activityStreamsContext, securityContext},
+		ID:                actorURI,
+		Type:              "Person",
+		PreferredUsername: user.Username,
+		Name:              user.Username,
+		Summary:           html.EscapeString(user.Bio),
+		Inbox:             actorURI + "/inbox",
+		Outbox:            actorURI + "/outbox",
+		Followers:         actorURI + "/followers",
+		Published:         user.CreatedAt,
+		PublicKey: PublicKey{
+			ID:           actorURI + "#main-key",
+			Owner:        actorURI,
+			PublicKeyPem: key.PublicKeyPEM,
+		},
+	}
+	if user.Avatar != "" {
+		actor.Icon = &Image{Type: "Image", URL: user.Avatar}
+	}
+	return actor, nil
+}
+
+// GetOutbox возвращает сводку outbox автора со ссылкой на первую страницу
+func (s *FederationService) GetOutbox(username string) (*OrderedCollection, error) {
+	user, err := s.localUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	published, err := s.publishedPosts(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	outbox := s.actorURI(user.Username) + "/outbox"
+	return &OrderedCollection{
+		Context:    activityStreamsContext,
+		ID:         outbox,
+		Type:       "OrderedCollection",
+		TotalItems: int64(len(published)),
+		First:      outbox + "?page=1",
+	}, nil
+}
+
+// GetOutboxPage возвращает страницу outbox: активности Create с опубликованными постами,
+// новые первыми
+func (s *FederationService) GetOutboxPage(username string, page int) (*OrderedCollectionPage, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	user, err := s.localUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	published, err := s.publishedPosts(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	outbox := s.actorURI(user.Username) + "/outbox"
+	result := &OrderedCollectionPage{
+		Context:      activityStreamsContext,
+		ID:           fmt.Sprintf("%s?page=%d", outbox, page),
+		Type:         "OrderedCollectionPage",
+		PartOf:       outbox,
+		OrderedItems: []Activity{},
+	}
+
+	start := (page - 1) * outboxPageSize
+	if start >= len(published) {
+		return result, nil
+	}
+	end := start + outboxPageSize
+	if end < len(published) {
+		result.Next = fmt.Sprintf("%s?page=%d", outbox, page+1)
+	} else {
+		end = len(published)
+	}
+
+	for i := range published[start:end] {
+		result.OrderedItems = append(result.OrderedItems, s.createActivity(s.article(&published[start+i], user)))
+	}
+	return result, nil
+}
+
+// GetFollowers возвращает коллекцию подписчиков.
+// Список подписчиков не раскрывается, отдается только их количество.
+func (s *FederationService) GetFollowers(username string) (*OrderedCollection, error) {
+	user, err := s.localUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountFollowers(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrderedCollection{
+		Context:    activityStreamsContext,
+		ID:         s.actorURI(user.Username) + "/followers",
+		Type:       "OrderedCollection",
+		TotalItems: count,
+	}, nil
+}
+
+// GetArticle возвращает опубликованный пост как объект Article
+func (s *FederationService) GetArticle(postID uint) (*Article, error) {
+	post, err := s.posts.GetPost(postID)
+	if err != nil {
+		if err == posts.ErrPostNotFound {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	if post.Status != posts.StatusPublished {
+		return nil, ErrObjectNotFound
+	}
+
+	author, err := s.users.GetUser(post.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	if author == nil {
+		return nil, ErrObjectNotFound
+	}
+
+	article := s.article(post, author)
+	article.Context = activityStreamsContext
+	return article, nil
+}
+
+// HandleInbox проверяет HTTP-подпись и обрабатывает входящую активность.
+// Поддерживаются Follow, Undo(Follow) и Create(Note) с ответом на пост или
+// на ранее полученный ответ; остальные активности принимаются и игнорируются.
+func (s *FederationService) HandleInbox(ctx context.Context, username string, req *http.Request, body []byte) error {
+	user, err := s.localUser(username)
+	if err != nil {
+		return err
+	}
+
+	var activity incomingActivity
+	if err := json.Unmarshal(body, &activity); err != nil || activity.Type == "" {
+		return ErrInvalidActivity
+	}
+
+	// Delete для удаленных аккаунтов приходит, когда их ключ уже недоступен,
+	// а реагировать на него нам нечем
+	if activity.Type == "Delete" {
+		return nil
+	}
+
+	actorID := idOf(activity.Actor)
+	if actorID == "" {
+		return ErrInvalidActivity
+	}
+
+	signer, err := s.signer(user)
+	if err != nil {
+		return err
+	}
+
+	var remote *RemoteActor
+	_, err = httpsig.Verify(req, body, func(keyID string) (*rsa.PublicKey, error) {
+		actorURL, _, _ := strings.Cut(keyID, "#")
+		actor, err := s.client.FetchActor(ctx, actorURL, signer)
+		if err != nil {
+			return nil, err
+		}
+		if actor.PublicKey.ID != keyID {
+			return nil, ErrActorMismatch
+		}
+		remote = actor
+		return httpsig.ParsePublicKey(actor.PublicKey.PublicKeyPem)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if remote.ID != actorID {
+		return ErrActorMismatch
+	}
+
+	switch activity.Type {
+	case "Follow":
+		return s.handleFollow(user, signer, remote, activity, body)
+	case "Undo":
+		return s.handleUndo(user, remote, activity)
+	case "Create":
+		return s.handleCreate(ctx, remote, activity)
+	default:
+		return nil
+	}
+}
+
+// handleFollow сохраняет подписчика и подтверждает подписку активностью Accept
+func (s *FederationService) handleFollow(user *users.User, signer *Signer, remote *RemoteActor, activity incomingActivity, body []byte) error {
+	actorURI := s.actorURI(user.Username)
+	if idOf(activity.Object) != actorURI || remote.Inbox == "" {
+		return ErrInvalidActivity
+	}
+
+	follower := &Follower{
+		UserID:      user.ID,
+		ActorURI:    remote.ID,
+		Inbox:       remote.Inbox,
+		SharedInbox: remote.Endpoints.SharedInbox,
+	}
+	if err := s.repo.AddFollower(follower); err != nil {
+		return err
+	}
+
+	accept := Activity{
+		Context: activityStreamsContext,
+		ID:      fmt.Sprintf("%s#accepts/follows/%d", actorURI, time.Now().UnixNano()),
+		Type:    "Accept",
+		Actor:   actorURI,
+		Object:  json.RawMessage(body),
+	}
+	go s.deliverInBackground(remote.Inbox, accept, signer)
+	return nil
+}
+
+// handleUndo обрабатывает отписку
+func (s *FederationService) handleUndo(user *users.User, remote *RemoteActor, activity incomingActivity) error {
+	var inner incomingActivity
+	if err := json.Unmarshal(activity.Object, &inner); err != nil {
+		// Объект передан ссылкой - определить, что отменяется, нельзя
+		return nil
+	}
+	if inner.Type != "Follow" || idOf(inner.Actor) != remote.ID {
+		return nil
+	}
+	return s.repo.RemoveFollower(user.ID, remote.ID)
+}
+
+// handleCreate сохраняет ответ на пост как комментарий, ожидающий модерации
+func (s *FederationService) handleCreate(ctx context.Context, remote *RemoteActor, activity incomingActivity) error {
+	var reply note
+	if err := json.Unmarshal(activity.Object, &reply); err != nil {
+		return nil
+	}
+	inReplyTo := idOf(reply.InReplyTo)
+	if reply.Type != "Note" || reply.ID == "" || inReplyTo == "" {
+		return nil
+	}
+	if idOf(reply.AttributedTo) != remote.ID {
+		return ErrActorMismatch
+	}
+
+	// Повторная доставка той же заметки
+	existing, err := s.repo.GetReply(reply.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+
+	postID, parentID, err := s.resolveReplyTarget(inReplyTo)
+	if err != nil || postID == 0 {
+		return err
+	}
+
+	content := htmlToText(reply.Content)
+	if content == "" {
+		return nil
+	}
+
+	avatar := remote.IconURL()
+	if len(avatar) > maxAvatarLength {
+		avatar = ""
+	}
+	author, err := s.users.GetOrCreateRemoteUser(remote.ID, remoteHandle(remote), avatar)
+	if err != nil {
+		return err
+	}
+
+	comment := &comments.Comment{
+		Content:  content,
+		PostID:   postID,
+		AuthorID: author.ID,
+		ParentID: parentID,
+		Status:   comments.StatusPending,
+	}
+	if err := s.comments.CreateComment(comment); err != nil {
+		return err
+	}
+
+	return s.repo.CreateReply(&Reply{
+		NoteURI:   reply.ID,
+		CommentID: comment.ID,
+		ActorURI:  remote.ID,
+	})
+}
+
+// resolveReplyTarget определяет пост и родительский комментарий для ответа.
+// Возвращает нулевой postID, если ответ адресован не нашему посту.
+func (s *FederationService) resolveReplyTarget(inReplyTo string) (uint, *uint, error) {
+	var post *posts.Post
+	var err error
+
+	if id, ok := s.postIDFromURI(inReplyTo); ok {
+		post, err = s.posts.GetPost(id)
+	} else if slug, ok := s.site.SlugFromURL(inReplyTo); ok {
+		post, err = s.posts.GetPostBySlug(slug)
+	} else {
+		// Ответ на ранее полученный ответ из федерации
+		parent, err := s.repo.GetReply(inReplyTo)
+		if err != nil || parent == nil {
+			return 0, nil, err
+		}
+		comment, err := s.comments.GetComment(parent.CommentID)
+		if err != nil {
+			return 0, nil, nil
+		}
+		return comment.PostID, &comment.ID, nil
+	}
+
+	if err != nil {
+		if err == posts.ErrPostNotFound {
+			return 0, nil, nil
+		}
+		return 0, nil, err
+	}
+	if post.Status != posts.StatusPublished {
+		return 0, nil, nil
+	}
+	return post.ID, nil, nil
+}
+
+// PublishPost рассылает опубликованный пост подписчикам в фоне.
+// Подключается к posts.PostService через OnPublish.
+func (s *FederationService) PublishPost(post posts.Post) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+
+		if err := s.DeliverPost(ctx, post); err != nil {
+			log.Printf("activitypub: post %d delivery failed: %v", post.ID, err)
+		}
+	}()
+}
+
+// DeliverPost рассылает активность Create с постом по inbox подписчиков автора
+func (s *FederationService) DeliverPost(ctx context.Context, post posts.Post) error {
+	author, err := s.users.GetUser(post.AuthorID)
+	if err != nil {
+		return err
+	}
+	if author == nil || !author.IsActive || author.IsRemote() {
+		return nil
+	}
+
+	inboxes, err := s.repo.ListFollowerInboxes(author.ID)
+	if err != nil || len(inboxes) == 0 {
+		return err
+	}
+
+	signer, err := s.signer(author)
+	if err != nil {
+		return err
+	}
+
+	activity := s.createActivity(s.article(&post, author))
+	activity.Context = activityStreamsContext
+
+	var errs []error
+	for _, inbox := range inboxes {
+		if err := s.deliver(ctx, inbox, activity, signer); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliverInBackground доставляет активность, не задерживая ответ на входящий запрос
+func (s *FederationService) deliverInBackground(inbox string, activity Activity, signer *Signer) {
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+
+	if err := s.deliver(ctx, inbox, activity, signer); err != nil {
+		log.Printf("activitypub: %s delivery failed: %v", activity.Type, err)
+	}
+}
+
+// deliver доставляет активность в inbox с повторными попытками
+func (s *FederationService) deliver(ctx context.Context, inbox string, activity Activity, signer *Signer) error {
+	var err error
+	for _, delay := range deliveryBackoff {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		if err = s.client.Deliver(ctx, inbox, activity, signer); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// localUser находит локального активного автора по имени
+func (s *FederationService) localUser(username string) (*users.User, error) {
+	user, err := s.users.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive || user.IsRemote() {
+		return nil, ErrActorNotFound
+	}
+	return user, nil
+}
+
+// keyPair возвращает ключи автора, создавая их при первом обращении
+func (s *FederationService) keyPair(userID uint) (*KeyPair, error) {
+	key, err := s.repo.GetKey(userID)
+	if err != nil || key != nil {
+		return key, err
+	}
+
+	privatePEM, publicPEM, err := httpsig.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateKey(&KeyPair{UserID: userID, PublicKeyPEM: publicPEM, PrivateKeyPEM: privatePEM}); err != nil {
+		return nil, err
+	}
+
+	// Перечитываем: ключи могли быть созданы параллельным запросом
+	key, err = s.repo.GetKey(userID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, errors.New("activitypub: key pair was not saved")
+	}
+	return key, nil
+}
+
+// signer возвращает подписывающий ключ автора
+func (s *FederationService) signer(user *users.User) (*Signer, error) {
+	key, err := s.keyPair(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	private, err := httpsig.ParsePrivateKey(key.PrivateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{KeyID: s.actorURI(user.Username) + "#main-key", Key: private}, nil
+}
+
+// publishedPosts возвращает опубликованные посты автора, новые первыми
+func (s *FederationService) publishedPosts(authorID uint) ([]posts.Post, error) {
+	all, err := s.posts.GetPostsByAuthor(authorID)
+	if err != nil && err != posts.ErrPostNotFound {
+		return nil, err
+	}
+
+	published := make([]posts.Post, 0, len(all))
+	for _, post := range all {
+		if post.Status == posts.StatusPublished && post.PublishedAt != nil {
+			published = append(published, post)
+		}
+	}
+	sort.SliceStable(published, func(i, j int) bool {
+		return published[i].PublishedAt.After(*published[j].PublishedAt)
+	})
+	return published, nil
+}
+
+// article представляет пост как объект Article
+func (s *FederationService) article(post *posts.Post, author *users.User) *Article {
+	actorURI := s.actorURI(author.Username)
+	article := &Article{
+		ID:           s.articleURI(post.ID),
+		Type:         "Article",
+		AttributedTo: actorURI,
+		Name:         post.Title,
+		Summary:      html.EscapeString(post.Description),
+		Content:      post.HTMLContent,
+		MediaType:    "text/html",
+		URL:          s.site.PostLink(post.Slug),
+		Published:    post.PublishedAt,
+		To:           []string{PublicCollection},
+		Cc:           []string{actorURI + "/followers"},
+	}
+	if post.PublishedAt != nil && post.UpdatedAt.After(*post.PublishedAt) {
+		updated := post.UpdatedAt
+		article.Updated = &updated
+	}
+	for _, tag := range post.Tags {
+		article.Tag = append(article.Tag, Hashtag{Type: "Hashtag", Name: "#" + tag})
+	}
+	return article
+}
+
+// createActivity оборачивает Article в активность Create
+func (s *FederationService) createActivity(article *Article) Activity {
+	return Activity{
+		ID:        article.ID + "#create",
+		Type:      "Create",
+		Actor:     article.AttributedTo,
+		Object:    article,
+		Published: article.Published,
+		To:        article.To,
+		Cc:        article.Cc,
+	}
+}
+
+// host возвращает домен, под которым авторы видны в федерации
+func (s *FederationService) host() string {
+	parsed, err := url.Parse(s.site.APIURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
+// actorURI возвращает URI актора автора
+func (s *FederationService) actorURI(username string) string {
+	return s.site.APIURL + "/ap/users/" + url.PathEscape(username)
+}
+
+// articleURI возвращает URI объекта Article для поста
+func (s *FederationService) articleURI(postID uint) string {
+	return s.site.APIURL + "/ap/posts/" + strconv.FormatUint(uint64(postID), 10)
+}
+
+// postIDFromURI извлекает ID поста из URI объекта Article
+func (s *FederationService) postIDFromURI(uri string) (uint, bool) {
+	rest, ok := strings.CutPrefix(uri, s.site.APIURL+"/ap/posts/")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(rest, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// remoteHandle формирует имя удаленного пользователя вида name@host
+func remoteHandle(actor *RemoteActor) string {
+	name := actor.PreferredUsername
+	if name == "" {
+		name = "unknown"
+	}
+	if parsed, err := url.Parse(actor.ID); err == nil && parsed.Host != "" {
+		return name + "@" + parsed.Host
+	}
+	return name
+}
+
+// textPolicy удаляет всю разметку из входящих заметок
+var textPolicy = bluemonday.StrictPolicy()
+
+// lineBreaks заменяет переносы строк и абзацы HTML на символы перевода строки
+var lineBreaks = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p><p>", "\n\n")
+
+// htmlToText превращает HTML заметки в обычный текст комментария
+func htmlToText(content string) string {
+	text := textPolicy.Sanitize(lineBreaks.Replace(content))
+	return strings.TrimSpace(html.UnescapeString(text))
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/activitypub"
+	mockRepo "gitlab.com/Nikolay-Yakunin/blog-service/internal/activitypub/repository/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/httpsig"
+)
+
+const (
+	localActor  = "https://api.example.com/ap/users/bob"
+	remoteActor = "https://social.example/users/alice"
+	remoteInbox = "https://social.example/users/alice/inbox"
+)
+
+var testSite = config.SiteConfig{
+	APIURL:  "https://api.example.com",
+	PostURL: "https://blog.example.com/posts/{slug}",
+}
+
+// stubUsers - справочник пользователей в памяти
+type stubUsers struct {
+	local  *users.User
+	remote *users.User
+}
+
+func (u *stubUsers) GetUser(id uint) (*users.User, error) {
+	if id == u.local.ID {
+		return u.local, nil
+	}
+	return nil, nil
+}
+
+func (u *stubUsers) GetUserByUsername(username string) (*users.User, error) {
+	if username == u.local.Username {
+		return u.local, nil
+	}
+	return nil, nil
+}
+
+func (u *stubUsers) GetOrCreateRemoteUser(actorURI, username, avatar string) (*users.User, error) {
+	u.remote = &users.User{ID: 42, Username: username, Provider: users.ProviderActivityPub, ProviderID: actorURI, Avatar: avatar}
+	return u.remote, nil
+}
+
+// stubPosts - посты в памяти
+type stubPosts map[uint]*posts.Post
+
+func (p stubPosts) GetPost(id uint) (*posts.Post, error) {
+	post, ok := p[id]
+	if !ok {
+		return nil, posts.ErrPostNotFound
+	}
+	return post, nil
+}
+
+func (p stubPosts) GetPostBySlug(slug string) (*posts.Post, error) {
+	for _, post := range p {
+		if post.Slug == slug {
+			return post, nil
+		}
+	}
+	return nil, posts.ErrPostNotFound
+}
+
+func (p stubPosts) GetPostsByAuthor(authorID uint) ([]posts.Post, error) {
+	var result []posts.Post
+	for _, post := range p {
+		if post.AuthorID == authorID {
+			result = append(result, *post)
+		}
+	}
+	return result, nil
+}
+
+// stubComments запоминает созданные комментарии
+type stubComments struct {
+	created []*comments.Comment
+}
+
+func (c *stubComments) GetComment(id uint) (*comments.Comment, error) {
+	for _, comment := range c.created {
+		if comment.ID == id {
+			return comment, nil
+		}
+	}
+	return nil, comments.ErrCommentNotFound
+}
+
+func (c *stubComments) CreateComment(comment *comments.Comment) error {
+	comment.ID = uint(len(c.created) + 1)
+	c.created = append(c.created, comment)
+	return nil
+}
+
+// delivery - активность, отправленная через stubClient
+type delivery struct {
+	inbox    string
+	activity activitypub.Activity
+}
+
+// stubClient отдает заранее заданного удаленного актора и запоминает доставки
+type stubClient struct {
+	actor      *activitypub.RemoteActor
+	deliveries chan delivery
+}
+
+func (c *stubClient) FetchActor(ctx context.Context, actorURI string, signer *activitypub.Signer) (*activitypub.RemoteActor, error) {
+	if c.actor == nil || actorURI != c.actor.ID {
+		return nil, activitypub.ErrActorNotFound
+	}
+	return c.actor, nil
+}
+
+func (c *stubClient) Deliver(ctx context.Context, inbox string, activity interface{}, signer *activitypub.Signer) error {
+	c.deliveries <- delivery{inbox: inbox, activity: activity.(activitypub.Activity)}
+	return nil
+}
+
+// fixture собирает сервис с локальным автором bob и удаленным актором alice
+type fixture struct {
+	service   *activitypub.FederationService
+	repo      *mockRepo.ActivityPubRepositoryMock
+	users     *stubUsers
+	comments  *stubComments
+	client    *stubClient
+	remoteKey string
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	localPrivate, localPublic, err := httpsig.GenerateKey()
+	require.NoError(t, err)
+	remotePrivate, remotePublic, err := httpsig.GenerateKey()
+	require.NoError(t, err)
+
+	repo := new(mockRepo.ActivityPubRepositoryMock)
+	repo.On("GetKey", uint(1)).Return(&activitypub.KeyPair{UserID: 1, PublicKeyPEM: localPublic, PrivateKeyPEM: localPrivate}, nil).Maybe()
+
+	published := time.Date(2025, 1, 3, 12, 0, 0, 0, time.UTC)
+	lookup := stubPosts{
+		5: {ID: 5, Title: "Hello", Slug: "hello", HTMLContent: "<p>Hello</p>", Status: posts.StatusPublished, AuthorID: 1, PublishedAt: &published, UpdatedAt: published},
+		6: {ID: 6, Title: "Draft", Slug: "draft", Status: posts.StatusDraft, AuthorID: 1},
+	}
+
+	directory := &stubUsers{local: &users.User{ID: 1, Username: "bob", Provider: users.ProviderGithub, IsActive: true}}
+	sink := &stubComments{}
+	client := &stubClient{
+		actor: &activitypub.RemoteActor{
+			ID:                remoteActor,
+			Type:              "Person",
+			PreferredUsername: "alice",
+			Inbox:             remoteInbox,
+			PublicKey: activitypub.PublicKey{
+				ID:           remoteActor + "#main-key",
+				Owner:        remoteActor,
+				PublicKeyPem: remotePublic,
+			},
+		},
+		deliveries: make(chan delivery, 10),
+	}
+
+	return &fixture{
+		service:   activitypub.NewFederationService(repo, directory, lookup, sink, client, testSite),
+		repo:      repo,
+		users:     directory,
+		comments:  sink,
+		client:    client,
+		remoteKey: remotePrivate,
+	}
+}
+
+// signedInbox формирует подписанный ключом alice запрос в inbox bob
+func (f *fixture) signedInbox(t *testing.T, activity interface{}, keyPEM string) (*http.Request, []byte) {
+	t.Helper()
+
+	body, err := json.Marshal(activity)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, localActor+"/inbox", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Host = req.URL.Host
+
+	key, err := httpsig.ParsePrivateKey(keyPEM)
+	require.NoError(t, err)
+	require.NoError(t, httpsig.Sign(req, remoteActor+"#main-key", key, body))
+	return req, body
+}
+
+func TestFederationService_WebFinger(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name     string
+		resource string
+		wantErr  error
+	}{
+		{name: "Acct resource", resource: "acct:bob@api.example.com"},
+		{name: "Actor URI", resource: localActor},
+		{name: "Foreign host", resource: "acct:bob@other.example", wantErr: activitypub.ErrInvalidResource},
+		{name: "Unknown user", resource: "acct:eve@api.example.com", wantErr: activitypub.ErrActorNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := f.service.WebFinger(tt.resource)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "acct:bob@api.example.com", result.Subject)
+			assert.Equal(t, localActor, result.Links[0].Href)
+		})
+	}
+}
+
+func TestFederationService_Follow(t *testing.T) {
+	f := newFixture(t)
+	f.repo.On("AddFollower", mock.MatchedBy(func(follower *activitypub.Follower) bool {
+		return follower.UserID == 1 && follower.ActorURI == remoteActor && follower.Inbox == remoteInbox
+	})).Return(nil)
+
+	req, body := f.signedInbox(t, map[string]string{
+		"id":     remoteActor + "#follows/1",
+		"type":   "Follow",
+		"actor":  remoteActor,
+		"object": localActor,
+	}, f.remoteKey)
+
+	err := f.service.HandleInbox(context.Background(), "bob", req, body)
+	require.NoError(t, err)
+	f.repo.AssertExpectations(t)
+
+	select {
+	case sent := <-f.client.deliveries:
+		assert.Equal(t, remoteInbox, sent.inbox)
+		assert.Equal(t, "Accept", sent.activity.Type)
+		assert.Equal(t, localActor, sent.activity.Actor)
+	case <-time.After(time.Second):
+		t.Fatal("Accept не был доставлен")
+	}
+}
+
+func TestFederationService_InvalidSignature(t *testing.T) {
+	f := newFixture(t)
+	otherKey, _, err := httpsig.GenerateKey()
+	require.NoError(t, err)
+
+	req, body := f.signedInbox(t, map[string]string{
+		"type":   "Follow",
+		"actor":  remoteActor,
+		"object": localActor,
+	}, otherKey)
+
+	err = f.service.HandleInbox(context.Background(), "bob", req, body)
+	assert.ErrorIs(t, err, activitypub.ErrInvalidSignature)
+	f.repo.AssertNotCalled(t, "AddFollower", mock.Anything)
+}
+
+func TestFederationService_Reply(t *testing.T) {
+	tests := []struct {
+		name        string
+		inReplyTo   string
+		wantComment bool
+	}{
+		{name: "Reply to article", inReplyTo: "https://api.example.com/ap/posts/5", wantComment: true},
+		{name: "Reply to post URL", inReplyTo: "https://blog.example.com/posts/hello", wantComment: true},
+		{name: "Reply to draft", inReplyTo: "https://api.example.com/ap/posts/6", wantComment: false},
+		{name: "Reply to someone else", inReplyTo: "https://social.example/notes/1", wantComment: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			noteID := remoteActor + "/statuses/100"
+			f.repo.On("GetReply", noteID).Return(nil, nil)
+			f.repo.On("GetReply", tt.inReplyTo).Return(nil, nil).Maybe()
+			f.repo.On("CreateReply", mock.AnythingOfType("*activitypub.Reply")).Return(nil).Maybe()
+
+			req, body := f.signedInbox(t, map[string]interface{}{
+				"id":    noteID + "/activity",
+				"type":  "Create",
+				"actor": remoteActor,
+				"object": map[string]string{
+					"id":           noteID,
+					"type":         "Note",
+					"attributedTo": remoteActor,
+					"inReplyTo":    tt.inReplyTo,
+					"content":      `<p><span class="h-card"><a href="` + localActor + `">@bob</a></span> Отличный пост!</p><p>Спасибо &amp; пока</p>`,
+				},
+			}, f.remoteKey)
+
+			err := f.service.HandleInbox(context.Background(), "bob", req, body)
+			require.NoError(t, err)
+
+			if !tt.wantComment {
+				assert.Empty(t, f.comments.created)
+				return
+			}
+			require.Len(t, f.comments.created, 1)
+			comment := f.comments.created[0]
+			assert.Equal(t, uint(5), comment.PostID)
+			assert.Equal(t, uint(42), comment.AuthorID)
+			assert.Equal(t, comments.StatusPending, comment.Status)
+			assert.Equal(t, "@bob Отличный пост!\n\nСпасибо & пока", comment.Content)
+			assert.Equal(t, "alice@social.example", f.users.remote.Username)
+			f.repo.AssertCalled(t, "CreateReply", mock.MatchedBy(func(reply *activitypub.Reply) bool {
+				return reply.NoteURI == noteID && reply.CommentID == comment.ID
+			}))
+		})
+	}
+}
+
+func TestFederationService_DeliverPost(t *testing.T) {
+	f := newFixture(t)
+	inboxes := []string{"https://social.example/inbox", "https://other.example/users/carol/inbox"}
+	f.repo.On("ListFollowerInboxes", uint(1)).Return(inboxes, nil)
+
+	post := posts.Post{ID: 5, Title: "Hello", Slug: "hello", Status: posts.StatusPublished, AuthorID: 1, Tags: []string{"go"}}
+	require.NoError(t, f.service.DeliverPost(context.Background(), post))
+
+	close(f.client.deliveries)
+	var delivered []string
+	for sent := range f.client.deliveries {
+		delivered = append(delivered, sent.inbox)
+		assert.Equal(t, "Create", sent.activity.Type)
+		article := sent.activity.Object.(*activitypub.Article)
+		assert.Equal(t, "https://api.example.com/ap/posts/5", article.ID)
+		assert.Equal(t, "https://blog.example.com/posts/hello", article.URL)
+		assert.Equal(t, "#go", article.Tag[0].Name)
+	}
+	assert.ElementsMatch(t, inboxes, delivered)
+}
@@ -0,0 +1,176 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// ContentType - тип содержимого документов ActivityPub
+	ContentType = "application/activity+json"
+	// JRDContentType - тип содержимого ответа WebFinger
+	JRDContentType = "application/jrd+json"
+	// PublicCollection - адресат "все", делает объект публичным
+	PublicCollection = "https://www.w3.org/ns/activitystreams#Public"
+
+	activityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	securityContext        = "https://w3id.org/security/v1"
+)
+
+// WebFinger - ответ /.well-known/webfinger (RFC 7033)
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases,omitempty"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+// WebFingerLink - ссылка в ответе WebFinger
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href"`
+}
+
+// PublicKey - открытый ключ актора для проверки HTTP-подписей
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+// Image - изображение (аватар актора)
+type Image struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// Actor - документ локального автора (тип Person)
+type Actor struct {
+	Context           []string  `json:"@context"`
+	ID                string    `json:"id"`
+	Type              string    `json:"type"`
+	PreferredUsername string    `json:"preferredUsername"`
+	Name              string    `json:"name"`
+	Summary           string    `json:"summary,omitempty"`
+	Inbox             string    `json:"inbox"`
+	Outbox            string    `json:"outbox"`
+	Followers         string    `json:"followers"`
+	Icon              *Image    `json:"icon,omitempty"`
+	Published         time.Time `json:"published"`
+	PublicKey         PublicKey `json:"publicKey"`
+}
+
+// Hashtag - тег поста
+type Hashtag struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// Article - опубликованный пост
+type Article struct {
+	Context      string     `json:"@context,omitempty"`
+	ID           string     `json:"id"`
+	Type         string     `json:"type"`
+	AttributedTo string     `json:"attributedTo"`
+	Name         string     `json:"name"`
+	Summary      string     `json:"summary,omitempty"`
+	Content      string     `json:"content"`
+	MediaType    string     `json:"mediaType"`
+	URL          string     `json:"url"`
+	Published    *time.Time `json:"published,omitempty"`
+	Updated      *time.Time `json:"updated,omitempty"`
+	To           []string   `json:"to"`
+	Cc           []string   `json:"cc,omitempty"`
+	Tag          []Hashtag  `json:"tag,omitempty"`
+}
+
+// Activity - исходящая активность (Create, Accept)
+type Activity struct {
+	Context   string      `json:"@context,omitempty"`
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Actor     string      `json:"actor"`
+	Object    interface{} `json:"object"`
+	Published *time.Time  `json:"published,omitempty"`
+	To        []string    `json:"to,omitempty"`
+	Cc        []string    `json:"cc,omitempty"`
+}
+
+// OrderedCollection - коллекция (outbox, followers)
+type OrderedCollection struct {
+	Context    string `json:"@context"`
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	TotalItems int64  `json:"totalItems"`
+	First      string `json:"first,omitempty"`
+}
+
+// OrderedCollectionPage - страница коллекции
+type OrderedCollectionPage struct {
+	Context      string     `json:"@context"`
+	ID           string     `json:"id"`
+	Type         string     `json:"type"`
+	PartOf       string     `json:"partOf"`
+	Next         string     `json:"next,omitempty"`
+	OrderedItems []Activity `json:"orderedItems"`
+}
+
+// RemoteActor - документ удаленного актора
+type RemoteActor struct {
+	ID                string          `json:"id"`
+	Type              string          `json:"type"`
+	PreferredUsername string          `json:"preferredUsername"`
+	Name              string          `json:"name"`
+	Inbox             string          `json:"inbox"`
+	Icon              json.RawMessage `json:"icon,omitempty"`
+	PublicKey         PublicKey       `json:"publicKey"`
+	Endpoints         struct {
+		SharedInbox string `json:"sharedInbox,omitempty"`
+	} `json:"endpoints"`
+}
+
+// IconURL возвращает адрес аватара (icon может быть объектом или массивом)
+func (a *RemoteActor) IconURL() string {
+	var image Image
+	if err := json.Unmarshal(a.Icon, &image); err == nil {
+		return image.URL
+	}
+	var images []Image
+	if err := json.Unmarshal(a.Icon, &images); err == nil && len(images) > 0 {
+		return images[0].URL
+	}
+	return ""
+}
+
+// incomingActivity - входящая активность; actor и object могут быть
+// как ссылкой (строкой), так и вложенным объектом
+type incomingActivity struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Actor  json.RawMessage `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+// note - входящая заметка (ответ на пост или комментарий)
+type note struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	AttributedTo json.RawMessage `json:"attributedTo"`
+	Content      string          `json:"content"`
+	InReplyTo    json.RawMessage `json:"inReplyTo"`
+}
+
+// idOf извлекает идентификатор из ссылки или вложенного объекта
+func idOf(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	var object struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &object); err == nil {
+		return object.ID
+	}
+	return ""
+}
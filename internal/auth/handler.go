@@ -10,12 +10,17 @@ import (
 	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
 	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/auth/jwt"
 	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/auth/oauth"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/middleware"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/ratelimit"
 )
 
 type Handler struct {
 	oauth  *oauth.Provider
 	users  users.Service
 	config *oauth.Config
+	// Ограничение частоты обращений к OAuth callback, по умолчанию без ограничений
+	rateLimits ratelimit.Store
+	limit      ratelimit.Limit
 }
 
 func NewHandler(oauthConfig *oauth.Config, userService users.Service) *Handler {
@@ -139,13 +144,20 @@ func (h *Handler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "успешно вышли из системы"})
 }
 
+// UseRateLimit включает ограничение частоты обращений к OAuth callback по IP.
+// Вызывать нужно до RegisterRoutes.
+func (h *Handler) UseRateLimit(store ratelimit.Store, limit ratelimit.Limit) {
+	h.rateLimits = store
+	h.limit = limit
+}
+
 // RegisterRoutes регистрирует маршруты обработчика в Gin-роутере
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	auth := r.Group("/auth")
 	{
 		// OAuth маршруты
 		auth.GET("/login/:provider", h.Login)
-		auth.GET("/callback/:provider", h.Callback)
+		auth.GET("/callback/:provider", middleware.RateLimit(h.rateLimits, "auth", h.limit), h.Callback)
 
 		// Маршрут выхода (требует аутентификации)
 		auth.POST("/logout", h.Logout)
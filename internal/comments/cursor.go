@@ -0,0 +1,73 @@
+package comments
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor указывает на последний комментарий страницы: следующая страница
+// начинается сразу после него в порядке режима сортировки. В отличие от смещения,
+// курсор не сбивается, когда между запросами появляются новые комментарии.
+type Cursor struct {
+	Sort SortMode
+	Time time.Time // Ключ режимов newest и oldest
+	Key  float64   // Ключ режимов top, controversial и hot
+	ID   uint      // Разрешает равенство ключей
+}
+
+// CursorAfter возвращает курсор, указывающий на комментарий
+func CursorAfter(comment Comment, sort SortMode) Cursor {
+	cursor := Cursor{Sort: sort, ID: comment.ID}
+	if sort.ByTime() {
+		cursor.Time = comment.CreatedAt
+	} else {
+		cursor.Key = comment.SortKey
+	}
+	return cursor
+}
+
+// String кодирует курсор для передачи клиенту
+func (c Cursor) String() string {
+	var key string
+	if c.Sort.ByTime() {
+		key = strconv.FormatInt(c.Time.UnixMicro(), 10)
+	} else {
+		key = strconv.FormatFloat(c.Key, 'g', -1, 64)
+	}
+	raw := string(c.Sort) + "|" + key + "|" + strconv.FormatUint(uint64(c.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor декодирует курсор режима sort.
+// Возвращает ErrInvalidCursor, если курсор поврежден или выдан для другого режима.
+func ParseCursor(s string, sort SortMode) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || SortMode(parts[0]) != sort {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{Sort: sort, ID: uint(id)}
+	if sort.ByTime() {
+		micro, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		cursor.Time = time.UnixMicro(micro).UTC()
+	} else {
+		cursor.Key, err = strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return cursor, nil
+}
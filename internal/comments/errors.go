@@ -7,6 +7,29 @@ var (
 	ErrPostNotFound    = errors.New("post not found")
 	ErrUnauthorized    = errors.New("unauthorized to modify this comment")
 	ErrEmptyContent    = errors.New("comment content cannot be empty")
+	ErrVersionConflict = errors.New("comment was modified by someone else")
+	ErrInvalidStatus   = errors.New("invalid comment status")
+	ErrParentNotFound  = errors.New("parent comment not found")
+	ErrInvalidParent   = errors.New("parent comment belongs to another post")
+	ErrInvalidAction   = errors.New("moderation action must be approve, reject, hide or spam")
+	ErrNoTargets       = errors.New("no comments selected for moderation")
+	ErrTooManyTargets  = errors.New("too many comments selected for moderation")
+	ErrCommentDeleted  = errors.New("deleted comment cannot be moderated")
+	ErrNotConfirmed    = errors.New("guest has not confirmed the comment yet")
+	ErrModerationOnly  = errors.New("spam and rejected comments can only be approved through moderation")
+	ErrInvalidVote     = errors.New("vote must be a like or a dislike")
+	ErrDownvotesOff    = errors.New("dislikes are disabled")
+	ErrEditWindowOver  = errors.New("comment can no longer be edited")
+	ErrCommentLocked   = errors.New("comment cannot be edited after a moderation decision")
+	ErrCommentsClosed  = errors.New("comments are closed for this post")
+	ErrMembersOnly     = errors.New("only registered users can comment on this post")
+	ErrGuestsDisabled  = errors.New("guest comments are disabled")
+	ErrInvalidProof    = errors.New("invalid or expired proof-of-work")
+	ErrInvalidGuest    = errors.New("guest name and a valid email are required")
+	ErrInvalidToken    = errors.New("invalid confirmation token")
+	ErrTokenExpired    = errors.New("confirmation link has expired")
+	ErrInvalidSort     = errors.New("sort must be newest, oldest, top, controversial or hot")
+	ErrInvalidCursor   = errors.New("invalid pagination cursor")
 )
 
 // ErrorResponse представляет структуру ответа с ошибкой
@@ -23,4 +46,10 @@ func NewErrorResponse(code int, message string, details string) *ErrorResponse {
         Message: message,
         Details: details,
     }
-}
\ No newline at end of file
+}
+
+// VersionConflictResponse возвращается с кодом 412, когда If-Match не совпадает с текущей версией
+type VersionConflictResponse struct {
+	ErrorResponse
+	CurrentVersion uint `json:"current_version" example:"3" swagger:"description=Актуальная версия ресурса"`
+}
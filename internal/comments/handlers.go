@@ -3,18 +3,36 @@ package comments
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/events"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/httpcache"
 	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/middleware"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/query"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/ratelimit"
+)
+
+// Параметры пагинации веток комментариев по умолчанию и их предельные значения
+const (
+	defaultThreadLimit   = 20
+	maxThreadLimit       = 100
+	defaultThreadReplies = 3
+	maxThreadReplies     = 50
+	defaultThreadDepth   = 3
+	maxThreadDepth       = 10
 )
 
 // Handler обрабатывает HTTP-запросы для работы с комментариями
 // Содержит сервисный слой для бизнес-логики и конфигурацию приложения
 type Handler struct {
-	service Service        // Сервис для работы с бизнес-логикой комментариев
-	config  *config.Config // Конфигурация приложения, включая JWT настройки
+	service    Service         // Сервис для работы с бизнес-логикой комментариев
+	config     *config.Config  // Конфигурация приложения, включая JWT настройки
+	rateLimits ratelimit.Store // Хранилище ограничений частоты запросов, nil - без ограничений
+	stream     *events.Hub     // Потоки событий комментариев, nil - потоки недоступны
 }
 
 // NewHandler создает новый обработчик HTTP-запросов для комментариев
@@ -26,31 +44,95 @@ func NewHandler(service Service, cfg *config.Config) *Handler {
 	}
 }
 
+// UseRateLimit включает ограничение частоты создания комментариев (config.RateLimit.Comments).
+// Вызывать нужно до Register.
+func (h *Handler) UseRateLimit(store ratelimit.Store) {
+	h.rateLimits = store
+}
+
+// UseStream включает потоки событий о комментариях поста (GET /comments/stream).
+// Вызывать нужно до Register.
+func (h *Handler) UseStream(hub *events.Hub) {
+	h.stream = hub
+}
+
 // Register регистрирует все пути обработки HTTP-запросов
-// Группирует все эндпоинты под /api/v1 и защищает их middleware аутентификации
+// Группирует все эндпоинты под /api/v1 и защищает middleware аутентификации
+// все маршруты, кроме чтения комментариев поста
 func (h *Handler) Register(router *gin.Engine) {
 	commentsAPI := router.Group("/api/v1/comments")
+
+	// GET /api/v1/comments?postId=... - получение комментариев поста (через query)
+	// Публичный маршрут: анонимный ответ может кэшироваться CDN,
+	// для авторизованного пользователя отмечаются его лайки
+	commentsAPI.GET("", middleware.OptionalAuthMiddleware(), h.GetPostComments)
+	// GET /api/v1/comments/:id/replies - следующая страница ответов на комментарий
+	commentsAPI.GET("/:id/replies", middleware.OptionalAuthMiddleware(), h.GetCommentReplies)
+
+	// Комментарии гостей: задача proof-of-work, отправка и подтверждение email
+	commentsAPI.GET("/challenge", h.GetGuestChallenge)
+	commentsAPI.POST("/guest", middleware.RateLimit(h.rateLimits, "comments", h.config.RateLimit.Comments), h.CreateGuestComment)
+	commentsAPI.POST("/confirm", h.ConfirmGuestComment)
+
+	// GET /api/v1/comments/stream?postId=... - события о комментариях поста (SSE)
+	if h.stream != nil {
+		commentsAPI.GET("/stream", middleware.OptionalAuthMiddleware(), h.StreamComments)
+	}
+
 	commentsAPI.Use(middleware.AuthMiddleware())
 	{
-		// GET /api/v1/comments?postId=... - получение комментариев поста (через query)
-		commentsAPI.GET("", h.GetPostComments)
+		// GET /api/v1/comments/:id - получение комментария (с ETag для последующего PUT)
+		commentsAPI.GET("/:id", h.GetComment)
 		// POST /api/v1/comments - создание нового комментария (postId в теле)
-		commentsAPI.POST("", h.CreateComment)
+		commentsAPI.POST("", middleware.RateLimit(h.rateLimits, "comments", h.config.RateLimit.Comments), h.CreateComment)
 		// PUT /api/v1/comments/:id - обновление
 		commentsAPI.PUT("/:id", h.UpdateComment)
+		// GET /api/v1/comments/:id/history - история правок
+		commentsAPI.GET("/:id/history", users.RequireRoles(users.RoleModerator, users.RoleAdmin), h.GetCommentHistory)
 		// DELETE /api/v1/comments/:id - удаление
 		commentsAPI.DELETE("/:id", h.DeleteComment)
+		// PUT /api/v1/comments/:id/status - модерация (публикация, скрытие)
+		commentsAPI.PUT("/:id/status", users.RequireRoles(users.RoleModerator, users.RoleAdmin), h.SetCommentStatus)
+		// GET /api/v1/comments/queue - очередь модерации
+		commentsAPI.GET("/queue", users.RequireRoles(users.RoleModerator, users.RoleAdmin), h.GetModerationQueue)
+		// POST /api/v1/comments/moderate - массовое одобрение, отклонение или скрытие
+		commentsAPI.POST("/moderate", users.RequireRoles(users.RoleModerator, users.RoleAdmin), h.ModerateComments)
+		// PUT/DELETE /api/v1/comments/:id/like - поставить или снять лайк
+		commentsAPI.PUT("/:id/like", h.LikeComment)
+		commentsAPI.DELETE("/:id/like", h.UnlikeComment)
+		// PUT/DELETE /api/v1/comments/:id/dislike - поставить или снять дизлайк
+		commentsAPI.PUT("/:id/dislike", h.DislikeComment)
+		commentsAPI.DELETE("/:id/dislike", h.UndislikeComment)
+		// PUT/DELETE /api/v1/comments/follow?postId=... - подписка на новые комментарии к посту
+		commentsAPI.PUT("/follow", h.FollowThread)
+		commentsAPI.DELETE("/follow", h.UnfollowThread)
 	}
 }
 
-// GetPostComments возвращает все комментарии для конкретного поста
-// Поддерживает древовидную структуру комментариев (с вложенными ответами)
-// @Security JWT
+// GetPostComments возвращает страницу корневых комментариев поста
+// Поддерживает древовидную структуру комментариев (с первыми ответами),
+// сортировку, пагинацию и условные запросы (If-None-Match / If-Modified-Since).
+// Общее количество корневых комментариев передается в заголовке X-Total-Count,
+// курсор следующей страницы - в заголовке X-Next-Cursor.
+// С токеном авторизации в ответе отмечаются лайки текущего пользователя.
 // @Summary Получить комментарии поста
-// @Description Получает все комментарии для указанного поста
+// @Description Получает страницу корневых комментариев поста с первыми ответами.
+// @Description Остальные ответы загружаются через /api/v1/comments/{id}/replies с курсором replies_cursor.
+// @Description Режим сортировки применяется ко всем уровням ветки; без него корневые комментарии
+// @Description идут новыми первыми, а ответы - в порядке написания.
+// @Description Токен авторизации не обязателен и заполняет liked_by_me / disliked_by_me.
 // @Tags comments
 // @Param postId query int true "ID поста"
+// @Param sort query string false "Порядок комментариев" Enums(newest, oldest, top, controversial, hot)
+// @Param cursor query string false "Курсор из X-Next-Cursor предыдущей страницы; заменяет offset"
+// @Param offset query int false "Смещение" default(0)
+// @Param limit query int false "Количество корневых комментариев (до 100)" default(20)
+// @Param replies query int false "Количество ответов у каждого комментария (до 50)" default(3)
+// @Param depth query int false "Количество загружаемых уровней ответов (до 10)" default(3)
 // @Success 200 {array} Comment
+// @Header 200 {integer} X-Total-Count "Общее количество корневых комментариев"
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы, если она есть"
+// @Success 304 "Not Modified"
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 func (h *Handler) GetPostComments(c *gin.Context) {
@@ -75,17 +157,190 @@ func (h *Handler) GetPostComments(c *gin.Context) {
 	}
 
 	// 2. Получаем комментарии через сервисный слой
-	comments, err := h.service.GetPostComments(uint(postID))
+	thread, err := h.service.GetPostComments(uint(postID), threadOptions(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(
-			http.StatusInternalServerError,
-			"Failed to fetch comments",
+		status := http.StatusInternalServerError
+		message := "Failed to fetch comments"
+
+		if err == ErrInvalidSort || err == ErrInvalidCursor {
+			status = http.StatusBadRequest
+			message = "Invalid sort or cursor"
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
 			err.Error(),
 		))
 		return
 	}
 
-	c.JSON(http.StatusOK, comments)
+	setThreadHeaders(c, thread)
+	if httpcache.Respond(c, h.threadPolicy(c), threadValidators(thread.Comments, thread.Total)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, thread.Comments)
+}
+
+// GetCommentReplies возвращает страницу прямых ответов на комментарий
+// Используется для подгрузки ответов, не вошедших в ветку ("показать еще").
+// Общее количество прямых ответов передается в заголовке X-Total-Count,
+// курсор следующей страницы - в заголовке X-Next-Cursor.
+// @Summary Получить ответы на комментарий
+// @Description Получает страницу прямых ответов на комментарий с их первыми ответами
+// @Tags comments
+// @Param id path int true "ID комментария"
+// @Param sort query string false "Порядок ответов, по умолчанию oldest" Enums(newest, oldest, top, controversial, hot)
+// @Param cursor query string false "Курсор из X-Next-Cursor или replies_cursor; заменяет offset"
+// @Param offset query int false "Смещение" default(0)
+// @Param limit query int false "Количество ответов (до 100)" default(20)
+// @Param replies query int false "Количество вложенных ответов у каждого ответа (до 50)" default(3)
+// @Param depth query int false "Количество загружаемых уровней вложенных ответов (до 10)" default(3)
+// @Success 200 {array} Comment
+// @Header 200 {integer} X-Total-Count "Общее количество прямых ответов"
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы, если она есть"
+// @Success 304 "Not Modified"
+// @Failure 400,404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) GetCommentReplies(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid comment ID",
+			err.Error(),
+		))
+		return
+	}
+
+	thread, err := h.service.GetCommentReplies(uint(id), threadOptions(c))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to fetch replies"
+
+		switch err {
+		case ErrCommentNotFound:
+			status = http.StatusNotFound
+			message = "Comment not found"
+		case ErrInvalidSort, ErrInvalidCursor:
+			status = http.StatusBadRequest
+			message = "Invalid sort or cursor"
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	setThreadHeaders(c, thread)
+	if httpcache.Respond(c, h.threadPolicy(c), threadValidators(thread.Comments, thread.Total)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, thread.Comments)
+}
+
+// setThreadHeaders передает общее количество комментариев уровня и курсор следующей страницы
+func setThreadHeaders(c *gin.Context, thread *Thread) {
+	c.Header("X-Total-Count", strconv.FormatInt(thread.Total, 10))
+	if thread.NextCursor != "" {
+		c.Header("X-Next-Cursor", thread.NextCursor)
+	}
+}
+
+// threadOptions извлекает параметры сортировки и пагинации ветки из query,
+// подставляя значения по умолчанию и ограничивая слишком большие
+func threadOptions(c *gin.Context) ThreadOptions {
+	return ThreadOptions{
+		Sort:     SortMode(c.Query("sort")),
+		Cursor:   c.Query("cursor"),
+		Offset:   query.Int(c, "offset", 0, 0, -1),
+		Limit:    query.Int(c, "limit", defaultThreadLimit, 1, maxThreadLimit),
+		Replies:  query.Int(c, "replies", defaultThreadReplies, 0, maxThreadReplies),
+		Depth:    query.Int(c, "depth", defaultThreadDepth, 0, maxThreadDepth),
+		ViewerID: c.GetUint("userID"),
+	}
+}
+
+// threadPolicy возвращает политику кэширования ветки комментариев.
+// Ответ авторизованному пользователю содержит его голоса и не должен
+// попадать в общие кэши; Vary разделяет анонимные и персональные ответы.
+func (h *Handler) threadPolicy(c *gin.Context) httpcache.Policy {
+	c.Header("Vary", "Authorization")
+	if c.GetUint("userID") != 0 {
+		return h.config.Cache.PostComments.Private()
+	}
+	return h.config.Cache.PostComments
+}
+
+// threadValidators возвращает валидаторы кэша для дерева комментариев.
+// Слабый ETag учитывает все загруженные ответы, их статус, лайки
+// и количество ответов, а также общее количество комментариев уровня.
+func threadValidators(comments []Comment, total int64) httpcache.Validators {
+	var lastModified time.Time
+	parts := []interface{}{total}
+
+	var walk func(list []Comment)
+	walk = func(list []Comment) {
+		for _, comment := range list {
+			parts = append(parts, comment.ID, comment.Version, comment.Status, comment.Likes, comment.Dislikes,
+				comment.LikedByMe, comment.DislikedByMe, comment.ReplyCount, comment.UpdatedAt.UnixNano())
+			if comment.UpdatedAt.After(lastModified) {
+				lastModified = comment.UpdatedAt
+			}
+			walk(comment.Replies)
+		}
+	}
+	walk(comments)
+
+	return httpcache.Validators{
+		ETag:         httpcache.WeakETag(parts...),
+		LastModified: lastModified,
+	}
+}
+
+// GetComment возвращает комментарий по ID
+// Заголовок ETag ответа используется как If-Match при обновлении
+// @Security JWT
+// @Summary Получить комментарий
+// @Description Получает комментарий по ID вместе с опубликованными прямыми ответами.
+// @Description Неопубликованный комментарий доступен только автору и модераторам,
+// @Description текст скрытых и удаленных комментариев возвращается только модераторам.
+// @Tags comments
+// @Param id path int true "ID комментария"
+// @Success 200 {object} Comment
+// @Failure 400,404,500 {object} ErrorResponse
+func (h *Handler) GetComment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid comment ID",
+			err.Error(),
+		))
+		return
+	}
+
+	comment, err := h.service.ViewComment(uint(id), c.GetUint("userID"), users.RoleFromContext(c))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to fetch comment"
+		if err == ErrCommentNotFound {
+			status = http.StatusNotFound
+			message = "Comment not found"
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.Header("ETag", httpcache.VersionETag(comment.Version))
+	c.JSON(http.StatusOK, comment)
 }
 
 // CreateCommentRequest - отдельная структура для запроса создания комментария
@@ -100,13 +355,14 @@ type CreateCommentRequest struct {
 // Поддерживает создание как корневых комментариев, так и ответов на другие комментарии
 // @Security JWT
 // @Summary Создать комментарий
-// @Description Создает новый комментарий для указанного поста
+// @Description Создает новый комментарий для указанного поста.
+// @Description Комментарии принимают только опубликованные посты с открытыми комментариями (403, если комментарии закрыты).
 // @Tags comments
 // @Accept json
 // @Produce json
 // @Param comment body CreateCommentRequest true "Данные комментария"
 // @Success 201 {object} Comment
-// @Failure 400 {object} ErrorResponse
+// @Failure 400,403,404,429 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 func (h *Handler) CreateComment(c *gin.Context) {
 	// 1. Парсим данные комментария из тела запроса
@@ -133,29 +389,148 @@ func (h *Handler) CreateComment(c *gin.Context) {
 
 	// 4. Вызываем сервис
 	if err := h.service.CreateComment(comment); err != nil {
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(
-			http.StatusInternalServerError,
-			"Failed to create comment",
+		respondCreateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// respondCreateError отвечает статусом, соответствующим ошибке создания комментария
+func respondCreateError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	message := "Failed to create comment"
+
+	switch err {
+	case ErrEmptyContent, ErrInvalidParent, ErrInvalidGuest:
+		status = http.StatusBadRequest
+		message = "Invalid comment data"
+	case ErrInvalidProof:
+		status = http.StatusBadRequest
+		message = "Invalid proof-of-work"
+	case ErrParentNotFound:
+		status = http.StatusNotFound
+		message = "Parent comment not found"
+	case ErrPostNotFound:
+		status = http.StatusNotFound
+		message = "Post not found"
+	case ErrCommentsClosed, ErrMembersOnly, ErrGuestsDisabled:
+		status = http.StatusForbidden
+		message = "Comments are not accepted"
+	case ErrInvalidToken:
+		status = http.StatusNotFound
+		message = "Confirmation link is invalid or already used"
+	case ErrTokenExpired:
+		status = http.StatusGone
+		message = "Confirmation link has expired"
+	}
+	c.JSON(status, NewErrorResponse(
+		status,
+		message,
+		err.Error(),
+	))
+}
+
+// GetGuestChallenge выдает задачу proof-of-work для комментария гостя
+// @Summary Задача proof-of-work для гостя
+// @Description Клиент подбирает nonce, при котором SHA-256 от строки "challenge:nonce"
+// @Description начинается с difficulty нулевых бит, и отправляет его с комментарием.
+// @Description Задача одноразовая и действует до expires_at.
+// @Tags comments
+// @Produce json
+// @Success 200 {object} pow.Challenge
+// @Failure 403 {object} ErrorResponse
+func (h *Handler) GetGuestChallenge(c *gin.Context) {
+	challenge, err := h.service.GuestChallenge()
+	if err != nil {
+		respondCreateError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, challenge)
+}
+
+// CreateGuestComment сохраняет комментарий гостя без регистрации
+// Комментарий не показывается, пока гость не подтвердит email по ссылке из письма.
+// @Summary Комментарий гостя
+// @Description Требует решенную задачу из /api/v1/comments/challenge.
+// @Description На email отправляется ссылка подтверждения; email не публикуется.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param comment body GuestCommentRequest true "Комментарий гостя"
+// @Success 202 {object} Comment
+// @Failure 400,403,404,429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) CreateGuestComment(c *gin.Context) {
+	var req GuestCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid comment data",
 			err.Error(),
 		))
 		return
 	}
 
-	c.JSON(http.StatusCreated, comment)
+	comment, err := h.service.CreateGuestComment(req)
+	if err != nil {
+		respondCreateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, comment)
+}
+
+// ConfirmGuestComment подтверждает email гостя по токену из письма
+// После подтверждения комментарий публикуется или попадает на премодерацию.
+// @Summary Подтвердить комментарий гостя
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param confirm body ConfirmRequest true "Токен из ссылки"
+// @Success 200 {object} Comment
+// @Failure 400,403,404,410 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) ConfirmGuestComment(c *gin.Context) {
+	var req ConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid confirmation data",
+			err.Error(),
+		))
+		return
+	}
+
+	comment, err := h.service.ConfirmGuestComment(req.Token)
+	if err != nil {
+		respondCreateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
 }
 
 // UpdateComment обновляет существующий комментарий
 // Проверяет права доступа: только автор или модератор может изменить комментарий
+// Требует заголовок If-Match с ETag редактируемой версии
 // @Security JWT
 // @Summary Обновить комментарий
-// @Description Обновляет существующий комментарий
+// @Description Обновляет существующий комментарий. Прежний текст сохраняется в истории правок.
+// @Description Автор может править комментарий только в течение окна редактирования (403 после его окончания)
+// @Description и пока он опубликован или ожидает модерации (403 для скрытых, отклоненных и спама).
+// @Description Правка автора заново проверяется на спам и политикой модерации и может вернуть комментарий на модерацию.
 // @Tags comments
 // @Accept json
 // @Produce json
 // @Param id path int true "ID комментария"
+// @Param If-Match header string true "ETag редактируемой версии комментария"
 // @Param comment body Comment true "Обновленные данные"
 // @Success 200 {object} Comment
-// @Failure 400,403 {object} ErrorResponse
+// @Failure 400,403,428 {object} ErrorResponse
+// @Failure 412 {object} VersionConflictResponse
 // @Failure 500 {object} ErrorResponse
 func (h *Handler) UpdateComment(c *gin.Context) {
 	// 1. Парсим обновленные данные комментария
@@ -181,20 +556,51 @@ func (h *Handler) UpdateComment(c *gin.Context) {
 	}
 	comment.ID = uint(id)
 
-	// 3. Получаем данные пользователя из JWT токена для проверки прав
+	// 3. Извлекаем версию, которую редактирует клиент
+	version, err := httpcache.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err {
+		case httpcache.ErrMissingIfMatch:
+			status = http.StatusPreconditionRequired
+		case httpcache.ErrWeakIfMatch:
+			status = http.StatusPreconditionFailed
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			"Invalid If-Match header",
+			err.Error(),
+		))
+		return
+	}
+	comment.Version = version
+
+	// Получаем данные пользователя из JWT токена для проверки прав
 	userID := c.GetUint("userID")
-	userRole := c.GetString("userRole")
+	userRole := users.RoleFromContext(c)
 
 	// 4. Пытаемся обновить комментарий
 	// Сервис проверит права доступа (авторство или роль модератора)
 	if err := h.service.UpdateComment(&comment, userID, userRole); err != nil {
+		if err == ErrVersionConflict {
+			h.respondVersionConflict(c, comment.ID)
+			return
+		}
+
 		// 5. Определяем правильный статус ошибки
 		status := http.StatusInternalServerError
 		message := "Failed to update comment"
 
-		if err == ErrUnauthorized {
+		switch err {
+		case ErrUnauthorized:
 			status = http.StatusForbidden // 403 для ошибок доступа
 			message = "Unauthorized to modify this comment"
+		case ErrEditWindowOver:
+			status = http.StatusForbidden
+			message = "Edit window is over"
+		case ErrCommentLocked:
+			status = http.StatusForbidden
+			message = "Comment is locked by moderation"
 		}
 		c.JSON(status, NewErrorResponse(
 			status,
@@ -207,7 +613,7 @@ func (h *Handler) UpdateComment(c *gin.Context) {
 	// 6. Получаем обновленный комментарий для ответа
 	// Это гарантирует, что клиент получит актуальные данные
 	// Получаем обновленный комментарий из базы
-	updatedComment, err := h.service.GetComment(comment.ID)
+	updatedComment, err := h.service.ViewComment(comment.ID, userID, userRole)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, NewErrorResponse(
 			http.StatusInternalServerError,
@@ -217,9 +623,75 @@ func (h *Handler) UpdateComment(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", httpcache.VersionETag(updatedComment.Version))
 	c.JSON(http.StatusOK, updatedComment)
 }
 
+// GetCommentHistory возвращает историю правок комментария
+// Доступно только модераторам и администраторам. Каждая запись содержит
+// текст комментария до правки, старые правки идут первыми.
+// @Security JWT
+// @Summary История правок комментария
+// @Tags comments
+// @Produce json
+// @Param id path int true "ID комментария"
+// @Success 200 {array} CommentRevision
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) GetCommentHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid comment ID",
+			err.Error(),
+		))
+		return
+	}
+
+	revisions, err := h.service.GetCommentHistory(uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to fetch comment history"
+
+		if err == ErrCommentNotFound {
+			status = http.StatusNotFound
+			message = "Comment not found"
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// respondVersionConflict отвечает 412 и сообщает клиенту актуальную версию комментария
+func (h *Handler) respondVersionConflict(c *gin.Context, id uint) {
+	current, err := h.service.GetComment(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to fetch comment",
+			err.Error(),
+		))
+		return
+	}
+
+	c.Header("ETag", httpcache.VersionETag(current.Version))
+	c.JSON(http.StatusPreconditionFailed, VersionConflictResponse{
+		ErrorResponse: ErrorResponse{
+			Code:    http.StatusPreconditionFailed,
+			Message: "Version conflict",
+			Details: ErrVersionConflict.Error(),
+		},
+		CurrentVersion: current.Version,
+	})
+}
+
 // DeleteComment удаляет комментарий
 // Выполняет мягкое удаление, сохраняя комментарий в базе со статусом "deleted"
 // Также рекурсивно помечает удаленными все ответы на этот комментарий
@@ -245,7 +717,7 @@ func (h *Handler) DeleteComment(c *gin.Context) {
 
 	// 2. Получаем данные пользователя из JWT токена
 	userID := c.GetUint("userID")
-	userRole := c.GetString("userRole")
+	userRole := users.RoleFromContext(c)
 
 	// 3. Пытаемся удалить комментарий
 	// Сервис проверит права доступа и выполнит мягкое удаление
@@ -268,3 +740,371 @@ func (h *Handler) DeleteComment(c *gin.Context) {
 	// 4. Возвращаем 204 No Content при успешном удалении
 	c.Status(http.StatusNoContent)
 }
+
+// StatusRequest содержит новый статус комментария
+type StatusRequest struct {
+	Status Status `json:"status" binding:"required" example:"active" enums:"active,hidden,pending"`
+}
+
+// SetCommentStatus меняет статус комментария
+// Доступно только модераторам и администраторам
+// @Security JWT
+// @Summary Изменить статус комментария
+// @Description Публикует ожидающий модерации комментарий или скрывает его
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID комментария"
+// @Param status body StatusRequest true "Новый статус"
+// @Success 200 {object} Comment
+// @Failure 400,403,404,409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) SetCommentStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid comment ID",
+			err.Error(),
+		))
+		return
+	}
+
+	var req StatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid status data",
+			err.Error(),
+		))
+		return
+	}
+
+	moderatorID := c.GetUint("userID")
+	comment, err := h.service.SetCommentStatus(uint(id), req.Status, &moderatorID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to update comment status"
+
+		switch err {
+		case ErrInvalidStatus:
+			status = http.StatusBadRequest
+			message = "Invalid comment status"
+		case ErrCommentNotFound:
+			status = http.StatusNotFound
+			message = "Comment not found"
+		case ErrVersionConflict:
+			status = http.StatusConflict
+			message = "Comment was modified concurrently"
+		case ErrCommentDeleted, ErrNotConfirmed, ErrModerationOnly:
+			status = http.StatusConflict
+			message = "Comment status cannot be changed"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.Header("ETag", httpcache.VersionETag(comment.Version))
+	c.JSON(http.StatusOK, comment)
+}
+
+// GetModerationQueue возвращает очередь модерации комментариев
+// Доступно только модераторам и администраторам.
+// Общее количество комментариев в очереди передается в заголовке X-Total-Count.
+// @Security JWT
+// @Summary Очередь модерации комментариев
+// @Tags comments
+// @Produce json
+// @Param status query string false "Статус (по умолчанию pending)" Enums(pending,hidden,rejected,spam)
+// @Param postId query int false "ID поста (по умолчанию - все посты)"
+// @Param offset query int false "Смещение"
+// @Param limit query int false "Количество записей"
+// @Success 200 {array} QueuedComment
+// @Header 200 {integer} X-Total-Count "Общее количество комментариев в очереди"
+// @Failure 400,401,403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) GetModerationQueue(c *gin.Context) {
+	var postID uint64
+	if value := c.Query("postId"); value != "" {
+		var err error
+		if postID, err = strconv.ParseUint(value, 10, 32); err != nil {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(
+				http.StatusBadRequest,
+				"Invalid post ID format in query parameter",
+				err.Error(),
+			))
+			return
+		}
+	}
+
+	status := Status(c.DefaultQuery("status", string(StatusPending)))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	queue, total, err := h.service.GetModerationQueue(status, uint(postID), offset, limit)
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := "Failed to fetch moderation queue"
+
+		if err == ErrInvalidStatus {
+			code = http.StatusBadRequest
+			message = "Invalid comment status"
+		}
+		c.JSON(code, NewErrorResponse(
+			code,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	// Оценка спама скрыта в Comment и отдается только в очереди модерации
+	items := make([]QueuedComment, len(queue))
+	for i, comment := range queue {
+		items[i] = QueuedComment{Comment: comment, SpamScore: comment.SpamScore, SpamReasons: comment.SpamReasons}
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, items)
+}
+
+// ModerateComments применяет решение модератора к нескольким комментариям
+// Доступно только модераторам и администраторам. Ошибки по отдельным
+// комментариям возвращаются в результате и не отменяют остальные решения.
+// @Security JWT
+// @Summary Массовая модерация комментариев
+// @Description Одобряет, отклоняет, скрывает или помечает спамом комментарии. Авторы отклоненных комментариев получают уведомление с причиной.
+// @Description Одобрение и пометка спамом обучают классификатор спама.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param request body ModerationRequest true "Решение модератора"
+// @Success 200 {object} ModerationResult
+// @Failure 400,401,403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) ModerateComments(c *gin.Context) {
+	var req ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid moderation data",
+			err.Error(),
+		))
+		return
+	}
+
+	result, err := h.service.ModerateComments(req, c.GetUint("userID"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to moderate comments"
+
+		switch err {
+		case ErrInvalidAction, ErrNoTargets, ErrTooManyTargets:
+			status = http.StatusBadRequest
+			message = "Invalid moderation data"
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// LikeComment ставит лайк комментарию от имени текущего пользователя
+// Повторный лайк не увеличивает счетчик; лайк заменяет дизлайк пользователя.
+// @Security JWT
+// @Summary Лайкнуть комментарий
+// @Tags comments
+// @Param id path int true "ID комментария"
+// @Success 200 {object} VoteResult
+// @Failure 400,404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) LikeComment(c *gin.Context) {
+	h.vote(c, h.service.Vote, 1)
+}
+
+// UnlikeComment снимает лайк текущего пользователя
+// @Security JWT
+// @Summary Снять лайк с комментария
+// @Tags comments
+// @Param id path int true "ID комментария"
+// @Success 200 {object} VoteResult
+// @Failure 400,404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) UnlikeComment(c *gin.Context) {
+	h.vote(c, h.service.Unvote, 1)
+}
+
+// DislikeComment ставит дизлайк комментарию от имени текущего пользователя
+// Доступно, только если дизлайки включены в конфигурации (comments.downvotes).
+// @Security JWT
+// @Summary Дизлайкнуть комментарий
+// @Tags comments
+// @Param id path int true "ID комментария"
+// @Success 200 {object} VoteResult
+// @Failure 400,403,404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) DislikeComment(c *gin.Context) {
+	h.vote(c, h.service.Vote, -1)
+}
+
+// UndislikeComment снимает дизлайк текущего пользователя
+// @Security JWT
+// @Summary Снять дизлайк с комментария
+// @Tags comments
+// @Param id path int true "ID комментария"
+// @Success 200 {object} VoteResult
+// @Failure 400,404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) UndislikeComment(c *gin.Context) {
+	h.vote(c, h.service.Unvote, -1)
+}
+
+// vote выполняет операцию голосования и отвечает новыми счетчиками комментария
+func (h *Handler) vote(c *gin.Context, op func(commentID, userID uint, value int) (*VoteResult, error), value int) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid comment ID",
+			err.Error(),
+		))
+		return
+	}
+
+	result, err := op(uint(id), c.GetUint("userID"), value)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to save vote"
+
+		switch err {
+		case ErrCommentNotFound:
+			status = http.StatusNotFound
+			message = "Comment not found"
+		case ErrInvalidVote:
+			status = http.StatusBadRequest
+			message = "Invalid vote"
+		case ErrDownvotesOff:
+			status = http.StatusForbidden
+			message = "Dislikes are disabled"
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// FollowThread подписывает текущего пользователя на новые комментарии к посту
+// Подписчики получают уведомления thread_comment; повторная подписка ничего не меняет.
+// @Security JWT
+// @Summary Подписаться на комментарии поста
+// @Tags comments
+// @Param postId query int true "ID поста"
+// @Success 204 "No Content"
+// @Failure 400,404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) FollowThread(c *gin.Context) {
+	h.follow(c, h.service.FollowThread)
+}
+
+// UnfollowThread отменяет подписку текущего пользователя на комментарии к посту
+// @Security JWT
+// @Summary Отписаться от комментариев поста
+// @Tags comments
+// @Param postId query int true "ID поста"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) UnfollowThread(c *gin.Context) {
+	h.follow(c, h.service.UnfollowThread)
+}
+
+// follow выполняет операцию с подпиской на комментарии к посту из query-параметра postId
+func (h *Handler) follow(c *gin.Context, op func(postID, userID uint) error) {
+	postID, err := strconv.ParseUint(c.Query("postId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid post ID format in query parameter",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := op(uint(postID), c.GetUint("userID")); err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to update subscription"
+
+		if err == ErrPostNotFound {
+			status = http.StatusNotFound
+			message = "Post not found"
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// StreamComments передает события о комментариях поста в формате Server-Sent Events
+// События comment.created, comment.updated и comment.deleted приходят по мере изменений;
+// при переподключении с Last-Event-ID клиент получает пропущенные события, а если они
+// утеряны - событие reset, после которого ветку нужно загрузить заново.
+// @Summary Поток событий комментариев поста
+// @Tags comments
+// @Produce text/event-stream
+// @Param postId query int true "ID поста"
+// @Param Last-Event-ID header string false "ID последнего полученного события"
+// @Param lastEventId query string false "ID последнего полученного события (если нельзя передать заголовок)"
+// @Success 200 {string} string "Поток событий"
+// @Failure 400,404 {object} ErrorResponse
+// @Failure 429 "Слишком много открытых потоков"
+// @Failure 500 {object} ErrorResponse
+func (h *Handler) StreamComments(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Query("postId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid post ID format in query parameter",
+			err.Error(),
+		))
+		return
+	}
+
+	topic, err := h.service.PostStreamTopic(uint(postID))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to subscribe to comments"
+
+		if err == ErrPostNotFound {
+			status = http.StatusNotFound
+			message = "Post not found"
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	h.stream.Serve(c, topic)
+}
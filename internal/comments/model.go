@@ -1,6 +1,12 @@
 package comments
 
-import "time"
+import (
+	"strconv"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/pow"
+)
 
 // Status определяет текущее состояние комментария
 // @Description Статус комментария
@@ -13,8 +19,192 @@ const (
 	StatusDeleted Status = "deleted"
 	// StatusHidden - комментарий скрыт модератором
 	StatusHidden Status = "hidden"
+	// StatusPending - комментарий ожидает модерации и не показывается под постом
+	StatusPending Status = "pending"
+	// StatusRejected - комментарий отклонен модератором и не показывается под постом
+	StatusRejected Status = "rejected"
+	// StatusSpam - комментарий признан спамом (проверкой или модератором) и не показывается под постом
+	StatusSpam Status = "spam"
+	// StatusUnconfirmed - комментарий гостя ожидает подтверждения email и не показывается под постом
+	StatusUnconfirmed Status = "unconfirmed"
+)
+
+// ModerationPolicy определяет, какие новые комментарии попадают в очередь модерации
+type ModerationPolicy string
+
+const (
+	// ModerationAll - все новые комментарии ожидают модерации
+	ModerationAll ModerationPolicy = "all"
+	// ModerationFirstTime - модерации ожидают комментарии пользователей без одобренных комментариев
+	ModerationFirstTime ModerationPolicy = "first_time"
+	// ModerationNone - комментарии публикуются сразу
+	ModerationNone ModerationPolicy = "none"
+)
+
+// Valid сообщает, допустима ли политика. Пустая политика означает политику сайта.
+func (p ModerationPolicy) Valid() bool {
+	switch p {
+	case "", ModerationAll, ModerationFirstTime, ModerationNone:
+		return true
+	}
+	return false
+}
+
+// CommentMode определяет, кто может комментировать пост
+type CommentMode string
+
+const (
+	// CommentsOpen - комментировать могут все
+	CommentsOpen CommentMode = "open"
+	// CommentsClosed - новые комментарии не принимаются
+	CommentsClosed CommentMode = "closed"
+	// CommentsMembers - комментировать могут только зарегистрированные пользователи
+	CommentsMembers CommentMode = "members"
+	// CommentsModerated - все новые комментарии ожидают модерации
+	CommentsModerated CommentMode = "moderated"
 )
 
+// Valid сообщает, допустим ли режим. Пустой режим равнозначен open.
+func (m CommentMode) Valid() bool {
+	switch m {
+	case "", CommentsOpen, CommentsClosed, CommentsMembers, CommentsModerated:
+		return true
+	}
+	return false
+}
+
+// SortMode определяет порядок комментариев в ветке
+type SortMode string
+
+const (
+	// SortNewest - новые первыми
+	SortNewest SortMode = "newest"
+	// SortOldest - в порядке написания
+	SortOldest SortMode = "oldest"
+	// SortTop - больше лайков первыми
+	SortTop SortMode = "top"
+	// SortControversial - первыми комментарии с большим числом голосов,
+	// разделенных поровну между лайками и дизлайками
+	SortControversial SortMode = "controversial"
+	// SortHot - по рейтингу с поправкой на время: рейтинг, больший в 10 раз,
+	// весит как 12,5 часа новизны
+	SortHot SortMode = "hot"
+)
+
+// Valid сообщает, допустим ли режим. Пустой режим - порядок по умолчанию:
+// корневые комментарии новые первыми, ответы в порядке написания.
+func (m SortMode) Valid() bool {
+	switch m {
+	case "", SortNewest, SortOldest, SortTop, SortControversial, SortHot:
+		return true
+	}
+	return false
+}
+
+// ByTime сообщает, что ключ сортировки режима - время создания комментария
+func (m SortMode) ByTime() bool {
+	return m == SortNewest || m == SortOldest
+}
+
+// PostSettings описывает настройки поста, от которых зависит прием комментариев
+type PostSettings struct {
+	AuthorID          uint             // Автор поста получает уведомления о новых комментариях
+	Title             string           // Заголовок поста для текста уведомлений
+	Status            string           // Статус поста: комментарии принимают только опубликованные посты
+	CommentMode       CommentMode      // Кто может комментировать пост
+	CommentModeration ModerationPolicy // Политика премодерации поста (пустая - политика сайта)
+	PublishedAt       *time.Time
+}
+
+// ClosesAt возвращает время автоматического закрытия комментариев
+// через autoClose после публикации (nil - комментарии не закрываются автоматически)
+func (p PostSettings) ClosesAt(autoClose time.Duration) *time.Time {
+	if autoClose <= 0 || p.PublishedAt == nil {
+		return nil
+	}
+	closesAt := p.PublishedAt.Add(autoClose)
+	return &closesAt
+}
+
+// Open сообщает, принимает ли пост комментарии в момент now
+func (p PostSettings) Open(now time.Time, autoClose time.Duration) bool {
+	if p.Status != "published" || p.CommentMode == CommentsClosed {
+		return false
+	}
+	closesAt := p.ClosesAt(autoClose)
+	return closesAt == nil || now.Before(*closesAt)
+}
+
+// ModerationAction определяет решение модератора
+type ModerationAction string
+
+const (
+	// ActionApprove публикует комментарий
+	ActionApprove ModerationAction = "approve"
+	// ActionReject отклоняет комментарий и уведомляет автора
+	ActionReject ModerationAction = "reject"
+	// ActionHide скрывает опубликованный комментарий
+	ActionHide ModerationAction = "hide"
+	// ActionSpam помечает комментарий как спам и обучает на нем классификатор
+	ActionSpam ModerationAction = "spam"
+)
+
+// Типы уведомлений, которые создает пакет
+const (
+	// NotificationCommentRejected - автору об отклонении комментария
+	NotificationCommentRejected = "comment_rejected"
+	// NotificationCommentOnPost - автору поста о новом комментарии
+	NotificationCommentOnPost = "comment_on_post"
+	// NotificationCommentReply - автору комментария об ответе на него
+	NotificationCommentReply = "comment_reply"
+	// NotificationThreadComment - подписчикам обсуждения поста о новом комментарии
+	NotificationThreadComment = "thread_comment"
+)
+
+// Типы событий потока комментариев поста
+const (
+	// EventCommentCreated - комментарий опубликован (данные - Comment)
+	EventCommentCreated = "comment.created"
+	// EventCommentUpdated - текст или статус комментария в ветке изменен (данные - Comment,
+	// для скрытого или удаленного комментария - HiddenComment без текста)
+	EventCommentUpdated = "comment.updated"
+	// EventCommentDeleted - комментарий удален или скрыт вместе с ответами (данные - DeletedComment)
+	EventCommentDeleted = "comment.deleted"
+)
+
+// StreamTopic возвращает тему событий о комментариях поста
+func StreamTopic(postID uint) string {
+	return "post:" + strconv.FormatUint(uint64(postID), 10)
+}
+
+// DeletedComment - данные события об удалении комментария
+// @Description Удаленный или скрытый комментарий
+type DeletedComment struct {
+	ID     uint `json:"id" example:"12"`
+	PostID uint `json:"post_id" example:"5"`
+}
+
+// HiddenComment - данные события об изменении скрытого или удаленного комментария.
+// Такой комментарий остается в ветке без текста, поэтому текст читателям не передается.
+// @Description Скрытый комментарий
+type HiddenComment struct {
+	ID     uint   `json:"id" example:"12"`
+	PostID uint   `json:"post_id" example:"5"`
+	Status Status `json:"status" example:"hidden"`
+}
+
+// Follow - подписка пользователя на новые комментарии к посту
+type Follow struct {
+	UserID    uint `gorm:"primaryKey"`
+	PostID    uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+// TableName задает имя таблицы подписок на обсуждения
+func (Follow) TableName() string {
+	return "comment_follows"
+}
+
 // CommentRef представляет ссылку на комментарий (используется для предотвращения рекурсии)
 // @Description Ссылка на комментарий
 type CommentRef struct {
@@ -26,11 +216,46 @@ type CommentRef struct {
 // @Description Комментарий к посту
 type Comment struct {
 	ID       uint   `json:"id" gorm:"primaryKey" example:"1"`
-	Content  string `json:"content" gorm:"type:text;not null" example:"Это очень интересный пост!"`
+	Content  string `json:"content" gorm:"type:text;not null" example:"Это очень *интересный* пост!"` // Markdown
 	PostID   uint   `json:"post_id" gorm:"index" example:"5"`
-	AuthorID uint   `json:"author_id" example:"42"`
-	ParentID *uint  `json:"parent_id,omitempty" gorm:"index"` // Для древовидной структуры
-	Status   Status `json:"status" gorm:"type:varchar(20);default:'active'" example:"active" enums:"active,deleted,hidden"`
+	AuthorID uint   `json:"author_id" gorm:"default:null" example:"42"` // 0 - гость (в БД NULL)
+	ParentID *uint  `json:"parent_id,omitempty" gorm:"index"`           // Для древовидной структуры
+	Status   Status `json:"status" gorm:"type:varchar(20);default:'active'" example:"active" enums:"active,deleted,hidden,pending,rejected,spam,unconfirmed"`
+
+	// Комментарий гостя: автор указал имя и email без регистрации, author_id равен 0
+	Guest      bool   `json:"guest" gorm:"not null;default:false" example:"false"`
+	GuestName  string `json:"guest_name,omitempty" gorm:"size:100;not null;default:''" example:"Иван"`
+	GuestEmail string `json:"-" gorm:"size:254;not null;default:''"` // Не публикуется
+	// Подтверждение email гостя: SHA-256 токена из письма и срок действия ссылки
+	ConfirmTokenHash string     `json:"-" gorm:"size:64;not null;default:''"`
+	ConfirmExpiresAt *time.Time `json:"-"`
+
+	// Отрендеренный и санитизированный HTML; заполняется сервером при сохранении
+	HTMLContent string `json:"html_content" gorm:"type:text;not null;default:''" example:"<p>Это очень <em>интересный</em> пост!</p>"`
+
+	// Материализованный путь: ID предков и самого комментария, дополненные нулями
+	// до 10 цифр и разделенные "/". Поддерево выбирается по префиксу пути.
+	Path  string `json:"-" gorm:"type:text;not null;default:''"`
+	Depth int    `json:"depth" gorm:"not null;default:0" example:"1"` // 0 - корневой комментарий
+	// Комментарий, на который отвечал автор. Отличается от ParentID, если ответ
+	// превысил максимальную глубину и был поднят на последний уровень.
+	ReplyToID *uint `json:"reply_to_id,omitempty" example:"7"`
+	// Количество видимых прямых ответов; в Replies может быть загружена только их часть
+	ReplyCount int64 `json:"reply_count" gorm:"-" example:"12"`
+	// Курсор следующей страницы ответов, если загружены не все; передается в /comments/{id}/replies
+	RepliesCursor string `json:"replies_cursor,omitempty" gorm:"-" example:"dG9wfDV8MTI"`
+	// Ключ сортировки режимов top, controversial и hot; вычисляется в запросе ветки
+	SortKey float64 `json:"-" gorm:"->;-:migration"`
+
+	// Оценка проверки на спам при создании и сработавшие признаки.
+	// Видны только модераторам, в ответе очереди модерации (QueuedComment).
+	SpamScore   float64 `json:"-" gorm:"not null;default:0"`
+	SpamReasons string  `json:"-" gorm:"size:500"`
+
+	// Последнее решение модератора
+	ModerationReason string     `json:"moderation_reason,omitempty" gorm:"size:500" example:"Реклама"`
+	ModeratedBy      *uint      `json:"moderated_by,omitempty" example:"3"`
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty" example:"2025-01-02T10:00:00Z"`
 
 	// Древовидная структура
 	// swaggerignore: true
@@ -39,23 +264,226 @@ type Comment struct {
 	Replies []Comment `json:"replies,omitempty" gorm:"foreignKey:ParentID" swaggerignore:"true"`
 
 	// Метаданные
-	Likes int `json:"likes" gorm:"default:0" example:"15"`
+	Likes    int `json:"likes" gorm:"default:0" example:"15"`
+	Dislikes int `json:"dislikes" gorm:"not null;default:0" example:"2"`
+	Score    int `json:"score" gorm:"not null;default:0" example:"13"` // likes - dislikes, используется для сортировки
+	// Голос текущего пользователя; заполняется в ветках комментариев
+	LikedByMe    bool `json:"liked_by_me" gorm:"-" example:"true"`
+	DislikedByMe bool `json:"disliked_by_me" gorm:"-" example:"false"`
+
+	Version uint `json:"version" gorm:"not null;default:1" example:"2"` // Версия для оптимистичной блокировки
+
+	// Правки текста: время последней и их количество; прежние тексты хранятся в CommentRevision
+	EditedAt  *time.Time `json:"edited_at,omitempty" example:"2025-01-01T00:10:00Z"`
+	EditCount int        `json:"edit_count" gorm:"not null;default:0" example:"1"`
 
 	CreatedAt time.Time  `json:"created_at" example:"2025-01-01T00:00:00Z"`
 	UpdatedAt time.Time  `json:"updated_at" example:"2025-01-02T00:00:00Z"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty" gorm:"index"`
 }
 
+// ThreadOptions задает пагинацию ветки комментариев
+type ThreadOptions struct {
+	Sort     SortMode // Порядок на всех уровнях ветки, пустой - порядок по умолчанию
+	Cursor   string   // Курсор из предыдущей страницы; если задан, Offset не используется
+	Offset   int      // Смещение в списке запрошенного уровня
+	Limit    int      // Количество комментариев запрошенного уровня
+	Replies  int      // Сколько первых ответов загружать у каждого комментария
+	Depth    int      // Сколько уровней ответов загружать под запрошенным уровнем
+	ViewerID uint     // Текущий пользователь для заполнения LikedByMe, 0 - анонимный
+}
+
+// Page задает страницу выборки комментариев одного уровня
+type Page struct {
+	Sort   SortMode // Режим сортировки (не пустой)
+	After  *Cursor  // Страница начинается после курсора; если задан, Offset не используется
+	Offset int
+	Limit  int
+}
+
+// Thread содержит страницу комментариев одного уровня с загруженными ответами
+type Thread struct {
+	Comments   []Comment
+	Total      int64  // Количество видимых комментариев уровня
+	NextCursor string // Курсор следующей страницы, пустой - страница последняя
+}
+
+// CommentRevision хранит текст комментария до правки.
+// Создается при каждом изменении текста, поэтому история правок полная.
+// @Description Прежняя версия текста комментария
+type CommentRevision struct {
+	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
+	CommentID uint      `json:"comment_id" gorm:"not null;index" example:"17"`
+	Content   string    `json:"content" gorm:"type:text;not null" example:"Первоначальный текст"` // Текст до правки
+	EditedBy  uint      `json:"edited_by" gorm:"not null" example:"42"`                           // Кто внес правку
+	CreatedAt time.Time `json:"created_at" example:"2025-01-01T00:10:00Z"`                        // Время правки
+}
+
+// TableName задает имя таблицы истории правок
+func (CommentRevision) TableName() string {
+	return "comment_revisions"
+}
+
+// CommentLike хранит голос пользователя за комментарий.
+// Пара (CommentID, UserID) уникальна, поэтому пользователь голосует один раз.
+type CommentLike struct {
+	CommentID uint `gorm:"primaryKey"`
+	UserID    uint `gorm:"primaryKey"`
+	Value     int  `gorm:"not null"` // 1 - лайк, -1 - дизлайк
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName задает имя таблицы голосов
+func (CommentLike) TableName() string {
+	return "comment_likes"
+}
+
+// VoteResult содержит счетчики комментария после голосования
+// @Description Результат голосования за комментарий
+type VoteResult struct {
+	CommentID    uint `json:"comment_id" example:"1"`
+	Likes        int  `json:"likes" example:"15"`
+	Dislikes     int  `json:"dislikes" example:"2"`
+	Score        int  `json:"score" example:"13"`
+	LikedByMe    bool `json:"liked_by_me" example:"true"`
+	DislikedByMe bool `json:"disliked_by_me" example:"false"`
+}
+
+// GuestCommentRequest - тело запроса комментария гостя
+// @Description Комментарий гостя с решением задачи proof-of-work
+type GuestCommentRequest struct {
+	Content   string `json:"content" binding:"required" example:"Спасибо за статью!"`
+	PostID    uint   `json:"post_id" binding:"required" example:"5"`
+	ParentID  *uint  `json:"parent_id,omitempty" example:"7"`
+	Name      string `json:"name" binding:"required" example:"Иван"`
+	Email     string `json:"email" binding:"required" example:"ivan@example.com"` // Для ссылки подтверждения, не публикуется
+	Challenge string `json:"challenge" binding:"required"`                        // Задача из GET /api/v1/comments/challenge
+	Nonce     string `json:"nonce" binding:"required" example:"1f3k"`             // Решение задачи
+}
+
+// ConfirmRequest - тело запроса подтверждения комментария гостя
+// @Description Токен из ссылки в письме
+type ConfirmRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// QueuedComment - комментарий в очереди модерации вместе с оценкой проверки на спам
+// @Description Комментарий в очереди модерации
+type QueuedComment struct {
+	Comment
+	SpamScore   float64 `json:"spam_score" example:"0.65"`
+	SpamReasons string  `json:"spam_reasons,omitempty" example:"too many links: 5; new account"`
+}
+
+// ModerationRequest описывает решение модератора по нескольким комментариям
+// @Description Массовая модерация комментариев
+type ModerationRequest struct {
+	IDs    []uint           `json:"ids" binding:"required" example:"4,8,15"`
+	Action ModerationAction `json:"action" binding:"required" example:"reject" enums:"approve,reject,hide,spam"`
+	Reason string           `json:"reason,omitempty" example:"Реклама"` // Сохраняется в комментарии и передается автору при отклонении
+}
+
+// ModerationItemResult содержит результат модерации одного комментария
+type ModerationItemResult struct {
+	ID     uint   `json:"id" example:"4"`
+	Status Status `json:"status,omitempty" example:"rejected"` // Новый статус, если комментарий изменен
+	Error  string `json:"error,omitempty" example:"comment not found"`
+}
+
+// ModerationResult содержит итоги массовой модерации
+// @Description Результат массовой модерации комментариев
+type ModerationResult struct {
+	Moderated int                    `json:"moderated" example:"2"`
+	Failed    int                    `json:"failed" example:"1"`
+	Items     []ModerationItemResult `json:"items"`
+}
+
+// SpamVerdict содержит оценку комментария на спам
+type SpamVerdict struct {
+	Score   float64  // От 0 (точно не спам) до 1 (точно спам)
+	Reasons []string // Сработавшие признаки
+}
+
+// SpamChecker оценивает новые комментарии на спам и обучается на решениях модераторов
+type SpamChecker interface {
+	// Check оценивает новый, еще не сохраненный комментарий
+	Check(comment *Comment) (*SpamVerdict, error)
+	// Learn учитывает решение модератора: spam - комментарий признан спамом
+	Learn(comment *Comment, spam bool) error
+}
+
+// Notifier уведомляет пользователей о решениях по их комментариям.
+// Реализуется сервисом уведомлений.
+type Notifier interface {
+	// Notify создает уведомление для пользователя
+	Notify(userID uint, kind string, subjectID uint, message string) error
+}
+
+// Mentioner находит упоминания @username в HTML комментария и уведомляет
+// упомянутых пользователей. Реализуется сервисом упоминаний.
+type Mentioner interface {
+	// Link заменяет упоминания ссылками на профили и возвращает ID упомянутых пользователей
+	Link(html string) (string, []uint)
+	// Record сохраняет упоминания и уведомляет впервые упомянутых пользователей
+	Record(subjectType string, subjectID, authorID uint, userIDs []uint) error
+}
+
+// Publisher рассылает события читателям в реальном времени.
+// Реализуется events.Hub.
+type Publisher interface {
+	// Publish рассылает событие kind с данными data подписчикам темы
+	Publish(topic, kind string, data interface{})
+}
+
 // Repository описывает методы для работы с хранилищем комментариев
 type Repository interface {
 	// Create создает новый комментарий
 	Create(comment *Comment) error
 	// GetByID возвращает комментарий по его ID
 	GetByID(id uint) (*Comment, error)
-	// GetByPostID возвращает все комментарии к посту
-	GetByPostID(postID uint) ([]Comment, error)
-	// Update обновляет существующий комментарий
+	// GetRoots возвращает страницу корневых комментариев поста, кроме ожидающих модерации, отклоненных и спама
+	GetRoots(postID uint, page Page) ([]Comment, error)
+	// CountRoots возвращает количество видимых корневых комментариев поста
+	CountRoots(postID uint) (int64, error)
+	// GetReplies возвращает страницу видимых прямых ответов на комментарий
+	GetReplies(parentID uint, page Page) ([]Comment, error)
+	// GetDescendants возвращает видимых потомков комментариев с путями paths
+	// глубиной не больше maxDepth, не более perParent первых ответов у каждого родителя
+	// в порядке sort
+	GetDescendants(paths []string, maxDepth, perParent int, sort SortMode) ([]Comment, error)
+	// CountReplies возвращает количество видимых прямых ответов для каждого из родителей
+	CountReplies(parentIDs []uint) (map[uint]int64, error)
+	// GetByStatus возвращает комментарии с указанным статусом, старые первыми (postID 0 - всех постов)
+	GetByStatus(status Status, postID uint, offset, limit int) ([]Comment, error)
+	// CountByStatus возвращает количество комментариев с указанным статусом (postID 0 - всех постов)
+	CountByStatus(status Status, postID uint) (int64, error)
+	// CountApprovedByAuthor возвращает количество опубликованных комментариев пользователя
+	CountApprovedByAuthor(authorID uint) (int64, error)
+	// GetPostSettings возвращает настройки комментариев поста (nil - пост не найден или в корзине)
+	GetPostSettings(postID uint) (*PostSettings, error)
+	// GetByConfirmToken возвращает комментарий гостя по хешу токена подтверждения (nil - не найден)
+	GetByConfirmToken(tokenHash string) (*Comment, error)
+	// DeleteUnconfirmed удаляет неподтвержденные комментарии гостей, ссылка которых истекла до before
+	DeleteUnconfirmed(before time.Time) (int64, error)
+	// Follow подписывает пользователя на новые комментарии к посту; повторная подписка ничего не меняет
+	Follow(userID, postID uint) error
+	// Unfollow отменяет подписку пользователя на комментарии к посту
+	Unfollow(userID, postID uint) error
+	// GetFollowers возвращает пользователей, подписанных на комментарии к посту
+	GetFollowers(postID uint) ([]uint, error)
+	// SetVote устанавливает голос пользователя (1, -1 или 0 - снять голос) и атомарно
+	// обновляет счетчики комментария. Возвращает комментарий с новыми счетчиками.
+	SetVote(commentID, userID uint, value int) (*Comment, error)
+	// GetVotes возвращает голоса пользователя за комментарии из списка
+	GetVotes(userID uint, commentIDs []uint) (map[uint]int, error)
+	// Update обновляет существующий комментарий, если его версия не изменилась
 	Update(comment *Comment) error
+	// UpdateWithRevision обновляет комментарий, как Update, и в той же транзакции
+	// сохраняет прежний текст в истории правок
+	UpdateWithRevision(comment *Comment, revision *CommentRevision) error
+	// GetRevisions возвращает историю правок комментария, старые первыми
+	GetRevisions(commentID uint) ([]CommentRevision, error)
 	// Delete удаляет комментарий
 	Delete(id uint) error
 }
@@ -66,10 +494,45 @@ type Service interface {
 	CreateComment(comment *Comment) error
 	// GetComment получает комментарий по ID
 	GetComment(id uint) (*Comment, error)
+	// ViewComment получает комментарий по ID для показа пользователю: неопубликованный
+	// комментарий видят только автор и модераторы, текст скрытых и удаленных убирается
+	ViewComment(id uint, userID uint, userRole users.Role) (*Comment, error)
 	// Обновляем сигнатуру метода, добавляя userID и userRole
-	UpdateComment(comment *Comment, userID uint, userRole string) error
+	UpdateComment(comment *Comment, userID uint, userRole users.Role) error
 	// Обновляем сигнатуру метода, добавляя userID и userRole
-	DeleteComment(id uint, userID uint, userRole string) error
-	// GetPostComments получает все комментарии к посту
-	GetPostComments(postID uint) ([]Comment, error)
+	DeleteComment(id uint, userID uint, userRole users.Role) error
+	// GetPostComments получает страницу корневых комментариев поста с первыми ответами,
+	// общее количество корневых комментариев и курсор следующей страницы
+	GetPostComments(postID uint, opts ThreadOptions) (*Thread, error)
+	// GetCommentReplies получает страницу прямых ответов на комментарий с их первыми ответами,
+	// общее количество прямых ответов и курсор следующей страницы
+	GetCommentReplies(id uint, opts ThreadOptions) (*Thread, error)
+	// SetCommentStatus меняет статус комментария по решению модератора (moderatorID == nil - автоматически)
+	SetCommentStatus(id uint, status Status, moderatorID *uint) (*Comment, error)
+	// GetModerationQueue возвращает комментарии с указанным статусом для модерации
+	// и их общее количество (postID 0 - всех постов)
+	GetModerationQueue(status Status, postID uint, offset, limit int) ([]Comment, int64, error)
+	// ModerateComments применяет решение модератора к нескольким комментариям
+	ModerateComments(req ModerationRequest, moderatorID uint) (*ModerationResult, error)
+	// Vote ставит лайк (1) или дизлайк (-1) от имени пользователя
+	Vote(commentID, userID uint, value int) (*VoteResult, error)
+	// Unvote снимает голос пользователя, если он совпадает с value
+	Unvote(commentID, userID uint, value int) (*VoteResult, error)
+	// GetCommentHistory возвращает историю правок комментария (для модераторов)
+	GetCommentHistory(id uint) ([]CommentRevision, error)
+	// GuestChallenge выдает задачу proof-of-work для комментария гостя
+	GuestChallenge() (*pow.Challenge, error)
+	// CreateGuestComment сохраняет комментарий гостя и отправляет ссылку подтверждения на его email
+	CreateGuestComment(req GuestCommentRequest) (*Comment, error)
+	// ConfirmGuestComment подтверждает email гостя по токену из письма и публикует комментарий
+	// (или отправляет на модерацию)
+	ConfirmGuestComment(token string) (*Comment, error)
+	// PurgeUnconfirmed удаляет комментарии гостей, не подтвержденные вовремя
+	PurgeUnconfirmed() (int64, error)
+	// FollowThread подписывает пользователя на новые комментарии к посту
+	FollowThread(postID, userID uint) error
+	// UnfollowThread отменяет подписку пользователя на комментарии к посту
+	UnfollowThread(postID, userID uint) error
+	// PostStreamTopic возвращает тему событий о комментариях опубликованного поста
+	PostStreamTopic(postID uint) (string, error)
 }
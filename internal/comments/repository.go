@@ -1,11 +1,33 @@
 package comments
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/database"
 )
 
+// unlisted содержит статусы комментариев, которые не показываются в ветках под постом
+var unlisted = []Status{StatusPending, StatusRejected, StatusSpam, StatusUnconfirmed}
+
+// sortKeys содержит SQL-выражения ключей сортировки. Равные ключи упорядочиваются по id.
+var sortKeys = map[SortMode]string{
+	SortNewest: "created_at",
+	SortOldest: "created_at",
+	SortTop:    "(likes::float8)",
+	// Много голосов, разделенных примерно поровну; без лайков или дизлайков - 0
+	SortControversial: "(CASE WHEN likes > 0 AND dislikes > 0 " +
+		"THEN POWER((likes + dislikes)::float8, LEAST(likes, dislikes)::float8 / GREATEST(likes, dislikes)) " +
+		"ELSE 0 END)",
+	// Порядок рейтинга плюс время создания: 45000 секунд (12,5 часа) новизны равны десятикратному рейтингу
+	SortHot: "(SIGN(score) * LOG(GREATEST(ABS(score), 1)::float8) + EXTRACT(EPOCH FROM created_at)::float8 / 45000)",
+}
+
 // CommentRepository реализует интерфейс Repository для работы с БД
 type CommentRepository struct {
 	database.BaseRepository
@@ -18,9 +40,25 @@ func NewCommentRepository(db *gorm.DB) Repository {
 	}
 }
 
-// Create сохраняет новый комментарий в базу данных
+// Create сохраняет новый комментарий в базу данных и заполняет его материализованный путь
 func (r *CommentRepository) Create(comment *Comment) error {
-	return r.DB.Create(comment).Error
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Path").Create(comment).Error; err != nil {
+			return err
+		}
+
+		// Путь известен только после получения ID: путь родителя + собственный сегмент
+		return tx.Raw(
+			"UPDATE comments SET path = COALESCE((SELECT p.path FROM comments p WHERE p.id = ?), '') || ? WHERE id = ? RETURNING path",
+			comment.ParentID, pathSegment(comment.ID), comment.ID,
+		).Row().Scan(&comment.Path)
+	})
+}
+
+// pathSegment возвращает сегмент материализованного пути для комментария.
+// Дополнение нулями сохраняет порядок сортировки путей как строк.
+func pathSegment(id uint) string {
+	return fmt.Sprintf("%010d/", id)
 }
 
 // GetByID получает комментарий по ID вместе с вложенными ответами
@@ -33,72 +71,363 @@ func (r *CommentRepository) GetByID(id uint) (*Comment, error) {
 	return &comment, nil
 }
 
-// GetByPostID получает все корневые комментарии для поста с полной загрузкой вложенности
-func (r *CommentRepository) GetByPostID(postID uint) ([]Comment, error) {
+// GetRoots получает страницу корневых комментариев поста в порядке page.Sort.
+// Комментарии, ожидающие модерации, отклоненные и спам не попадают в выборку.
+func (r *CommentRepository) GetRoots(postID uint, page Page) ([]Comment, error) {
 	var comments []Comment
+	query := r.DB.Where("post_id = ? AND parent_id IS NULL AND status NOT IN ?", postID, unlisted)
+	if err := ordered(query, page).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
 
-	// Выбираем только корневые комментарии (parent_id IS NULL)
-	err := r.DB.Where("post_id = ? AND parent_id IS NULL", postID).
-		Preload("Replies.Replies.Replies"). // Загружаем до 3 уровней вложенности
-		Order("created_at DESC").
-		Find(&comments).Error
+// CountRoots возвращает количество видимых корневых комментариев поста
+func (r *CommentRepository) CountRoots(postID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&Comment{}).
+		Where("post_id = ? AND parent_id IS NULL AND status NOT IN ?", postID, unlisted).
+		Count(&count).Error
+	return count, err
+}
+
+// GetReplies получает страницу прямых ответов на комментарий в порядке page.Sort
+func (r *CommentRepository) GetReplies(parentID uint, page Page) ([]Comment, error) {
+	var comments []Comment
+	query := r.DB.Where("parent_id = ? AND status NOT IN ?", parentID, unlisted)
+	if err := ordered(query, page).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// ordered упорядочивает запрос по ключу режима сортировки и ограничивает его страницей.
+// Для режимов по рейтингу ключ выбирается в SortKey, чтобы по нему можно было построить курсор.
+func ordered(query *gorm.DB, page Page) *gorm.DB {
+	key := sortKeys[page.Sort]
+	if !page.Sort.ByTime() {
+		query = query.Select("*, " + key + " AS sort_key")
+	}
+
+	dir, cmp := "DESC", "<"
+	if page.Sort == SortOldest {
+		dir, cmp = "ASC", ">"
+	}
+	if page.After != nil {
+		var value interface{} = page.After.Key
+		if page.Sort.ByTime() {
+			value = page.After.Time
+		}
+		query = query.Where("("+key+", id) "+cmp+" (?, ?)", value, page.After.ID)
+	} else {
+		query = query.Offset(page.Offset)
+	}
+	return query.Order(key + " " + dir + ", id " + dir).Limit(page.Limit)
+}
 
+// GetDescendants получает потомков комментариев с путями paths до глубины maxDepth включительно.
+// У каждого родителя берется не более perParent первых ответов в порядке sort; результат
+// упорядочен сначала по глубине, поэтому родители всегда идут раньше ответов.
+func (r *CommentRepository) GetDescendants(paths []string, maxDepth, perParent int, sort SortMode) ([]Comment, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	// Шаблон "путь_%" отсекает сам комментарий и оставляет только его поддерево
+	conditions := make([]string, len(paths))
+	args := make([]interface{}, 0, len(paths)+3)
+	for i, path := range paths {
+		conditions[i] = "c.path LIKE ?"
+		args = append(args, path+"_%")
+	}
+	args = append(args, maxDepth, unlisted, perParent)
+
+	// Ключ вычисляется во внутреннем запросе, где имена колонок однозначны
+	key, order := "0::float8", "created_at DESC, id DESC"
+	switch {
+	case sort == SortOldest:
+		order = "created_at, id"
+	case !sort.ByTime():
+		key, order = sortKeys[sort], "sort_key DESC, id DESC"
+	}
+
+	query := `SELECT * FROM (
+		SELECT *, ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY ` + order + `) AS reply_rank
+		FROM (
+			SELECT c.*, ` + key + ` AS sort_key
+			FROM comments c
+			WHERE (` + strings.Join(conditions, " OR ") + `) AND c.depth <= ? AND c.status NOT IN ?
+		) keyed
+	) ranked
+	WHERE reply_rank <= ?
+	ORDER BY depth, ` + order
+
+	var comments []Comment
+	if err := r.DB.Raw(query, args...).Scan(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// CountReplies возвращает количество видимых прямых ответов для каждого из родителей.
+// Родители без ответов в результат не попадают.
+func (r *CommentRepository) CountReplies(parentIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ParentID uint
+		Count    int64
+	}
+	err := r.DB.Model(&Comment{}).
+		Select("parent_id, COUNT(*) AS count").
+		Where("parent_id IN ? AND status NOT IN ?", parentIDs, unlisted).
+		Group("parent_id").
+		Scan(&rows).Error
 	if err != nil {
-		return nil, err // Added error check
+		return nil, err
 	}
 
+	for _, row := range rows {
+		counts[row.ParentID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetByStatus получает комментарии с указанным статусом, старые первыми,
+// чтобы очередь модерации разбиралась по порядку поступления
+func (r *CommentRepository) GetByStatus(status Status, postID uint, offset, limit int) ([]Comment, error) {
+	var comments []Comment
+	err := r.byStatus(status, postID).
+		Order("created_at, id").
+		Offset(offset).
+		Limit(limit).
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
 	return comments, nil
 }
 
-// Update обновляет существующий комментарий
-func (r *CommentRepository) Update(comment *Comment) error {
-	return r.DB.Save(comment).Error
+// CountByStatus возвращает количество комментариев с указанным статусом
+func (r *CommentRepository) CountByStatus(status Status, postID uint) (int64, error) {
+	var count int64
+	err := r.byStatus(status, postID).Model(&Comment{}).Count(&count).Error
+	return count, err
 }
 
-// Delete выполняет мягкое удаление комментария и всех его ответов
-func (r *CommentRepository) Delete(id uint) error {
-	// Начинаем транзакцию
-	tx := r.DB.Begin()
+// byStatus возвращает запрос комментариев с указанным статусом; postID 0 - всех постов
+func (r *CommentRepository) byStatus(status Status, postID uint) *gorm.DB {
+	query := r.DB.Where("status = ?", status)
+	if postID != 0 {
+		query = query.Where("post_id = ?", postID)
+	}
+	return query
+}
+
+// CountApprovedByAuthor возвращает количество опубликованных комментариев пользователя
+func (r *CommentRepository) CountApprovedByAuthor(authorID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&Comment{}).
+		Where("author_id = ? AND status = ?", authorID, StatusActive).
+		Count(&count).Error
+	return count, err
+}
 
-	// Удаляем основной комментарий
-	if err := tx.Model(&Comment{}).
-		Where("id = ?", id).
-		Update("status", StatusDeleted).Error; err != nil {
-		tx.Rollback()
-		return err
+// GetPostSettings возвращает настройки комментариев поста.
+// Для несуществующего поста и поста в корзине возвращается (nil, nil).
+func (r *CommentRepository) GetPostSettings(postID uint) (*PostSettings, error) {
+	var settings []PostSettings
+	err := r.DB.Table("posts").
+		Select("COALESCE(author_id, 0) AS author_id, title, status, comment_mode, comment_moderation, published_at").
+		Where("id = ? AND deleted_at IS NULL", postID).
+		Limit(1).
+		Scan(&settings).Error
+	if err != nil || len(settings) == 0 {
+		return nil, err
 	}
+	return &settings[0], nil
+}
 
-	// Рекурсивно удаляем все ответы
-	if err := r.recursiveDeleteReplies(tx, id); err != nil {
-		tx.Rollback()
-		return err
+// GetByConfirmToken возвращает комментарий гостя по хешу токена подтверждения.
+// Если комментарий не найден, возвращает (nil, nil).
+func (r *CommentRepository) GetByConfirmToken(tokenHash string) (*Comment, error) {
+	var comment Comment
+	err := r.DB.Where("confirm_token_hash = ?", tokenHash).First(&comment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	return &comment, nil
+}
 
-	return tx.Commit().Error
+// DeleteUnconfirmed окончательно удаляет неподтвержденные комментарии гостей,
+// ссылка подтверждения которых истекла до before. Такие комментарии никогда
+// не показывались, поэтому мягкое удаление не нужно.
+func (r *CommentRepository) DeleteUnconfirmed(before time.Time) (int64, error) {
+	result := r.DB.Where("status = ? AND confirm_expires_at < ?", StatusUnconfirmed, before).
+		Delete(&Comment{})
+	return result.RowsAffected, result.Error
 }
 
-// recursiveDeleteReplies рекурсивно удаляет все ответы на комментарий
-func (r *CommentRepository) recursiveDeleteReplies(tx *gorm.DB, parentID uint) error {
-	// Находим все прямые ответы
-	var replies []Comment
-	if err := tx.Where("parent_id = ?", parentID).Find(&replies).Error; err != nil {
-		return err
-	}
+// Follow подписывает пользователя на новые комментарии к посту; повторная подписка ничего не меняет
+func (r *CommentRepository) Follow(userID, postID uint) error {
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Follow{UserID: userID, PostID: postID}).Error
+}
+
+// Unfollow отменяет подписку пользователя на комментарии к посту
+func (r *CommentRepository) Unfollow(userID, postID uint) error {
+	return r.DB.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&Follow{}).Error
+}
+
+// GetFollowers возвращает пользователей, подписанных на комментарии к посту, в порядке подписки
+func (r *CommentRepository) GetFollowers(postID uint) ([]uint, error) {
+	var userIDs []uint
+	err := r.DB.Model(&Follow{}).
+		Where("post_id = ?", postID).
+		Order("created_at").
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// SetVote устанавливает голос пользователя и обновляет счетчики комментария в одной транзакции.
+// Строка комментария блокируется, поэтому одновременные голоса не теряются.
+// Если комментарий не найден, возвращает (nil, nil).
+func (r *CommentRepository) SetVote(commentID, userID uint, value int) (*Comment, error) {
+	var comment Comment
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&comment, commentID).Error; err != nil {
+			return err
+		}
 
-	// Для каждого ответа
-	for _, reply := range replies {
-		// Удаляем его
-		if err := tx.Model(&Comment{}).
-			Where("id = ?", reply.ID).
-			Update("status", StatusDeleted).Error; err != nil {
+		var previous CommentLike
+		err := tx.Where("comment_id = ? AND user_id = ?", commentID, userID).Take(&previous).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 			return err
 		}
+		if previous.Value == value {
+			return nil
+		}
+
+		switch {
+		case value == 0:
+			err = tx.Delete(&previous).Error
+		case previous.Value == 0:
+			err = tx.Create(&CommentLike{CommentID: commentID, UserID: userID, Value: value}).Error
+		default:
+			err = tx.Model(&previous).Update("value", value).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		likes := boolToInt(value == 1) - boolToInt(previous.Value == 1)
+		dislikes := boolToInt(value == -1) - boolToInt(previous.Value == -1)
+		return tx.Model(&comment).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "likes"}, {Name: "dislikes"}, {Name: "score"}}}).
+			UpdateColumns(map[string]interface{}{
+				"likes":    gorm.Expr("likes + ?", likes),
+				"dislikes": gorm.Expr("dislikes + ?", dislikes),
+				"score":    gorm.Expr("score + ?", likes-dislikes),
+			}).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// boolToInt возвращает 1 для true и 0 для false
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// GetVotes возвращает голоса пользователя за комментарии из списка
+func (r *CommentRepository) GetVotes(userID uint, commentIDs []uint) (map[uint]int, error) {
+	votes := make(map[uint]int, len(commentIDs))
+	if userID == 0 || len(commentIDs) == 0 {
+		return votes, nil
+	}
+
+	var likes []CommentLike
+	if err := r.DB.Where("user_id = ? AND comment_id IN ?", userID, commentIDs).Find(&likes).Error; err != nil {
+		return nil, err
+	}
+	for _, like := range likes {
+		votes[like.CommentID] = like.Value
+	}
+	return votes, nil
+}
+
+// Update обновляет существующий комментарий.
+// Запись изменяется только если версия в БД совпадает с comment.Version,
+// иначе возвращается ErrVersionConflict. При успехе версия увеличивается.
+func (r *CommentRepository) Update(comment *Comment) error {
+	return update(r.DB, comment)
+}
 
-		// Рекурсивно удаляем его ответы
-		if err := r.recursiveDeleteReplies(tx, reply.ID); err != nil {
+// UpdateWithRevision обновляет комментарий и сохраняет прежний текст в истории правок.
+// При конфликте версий правка в историю не попадает.
+func (r *CommentRepository) UpdateWithRevision(comment *Comment, revision *CommentRevision) error {
+	expected := comment.Version
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := update(tx, comment); err != nil {
 			return err
 		}
+		return tx.Create(revision).Error
+	})
+	if err != nil {
+		comment.Version = expected
 	}
+	return err
+}
+
+// GetRevisions возвращает историю правок комментария, старые первыми
+func (r *CommentRepository) GetRevisions(commentID uint) ([]CommentRevision, error) {
+	var revisions []CommentRevision
+	err := r.DB.Where("comment_id = ?", commentID).
+		Order("created_at ASC, id ASC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// update обновляет комментарий с проверкой версии в переданном соединении или транзакции.
+// Автор комментария не меняется (у гостя author_id в БД - NULL).
+func update(db *gorm.DB, comment *Comment) error {
+	expected := comment.Version
+	comment.Version = expected + 1
 
+	result := db.Model(comment).
+		Where("version = ?", expected).
+		Select("*").
+		Omit("id", "author_id", "created_at", "path", "depth", "likes", "dislikes", "score", "Parent", "Replies").
+		Updates(comment)
+	if result.Error != nil {
+		comment.Version = expected
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		comment.Version = expected
+		return ErrVersionConflict
+	}
 	return nil
 }
+
+// Delete выполняет мягкое удаление комментария и всех его ответов.
+// Поддерево любой глубины выбирается одним запросом по префиксу пути.
+func (r *CommentRepository) Delete(id uint) error {
+	return r.DB.Model(&Comment{}).
+		Where("id = ? OR path LIKE (SELECT p.path FROM comments p WHERE p.id = ? AND p.path <> '') || '%'", id, id).
+		Update("status", StatusDeleted).Error
+}
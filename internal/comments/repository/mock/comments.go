@@ -1,6 +1,8 @@
 package mock
 
 import (
+	"time"
+
 	"github.com/stretchr/testify/mock"
 	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
 )
@@ -22,14 +24,43 @@ func (r *CommentsRepositoryMock) GetByID(id uint) (*comments.Comment, error) {
 	return args.Get(0).(*comments.Comment), args.Error(1)
 }
 
-func (r *CommentsRepositoryMock) GetByPostID(postID uint) ([]comments.Comment, error) {
+func (r *CommentsRepositoryMock) GetRoots(postID uint, page comments.Page) ([]comments.Comment, error) {
+	args := r.Called(postID, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]comments.Comment), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) CountRoots(postID uint) (int64, error) {
 	args := r.Called(postID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) GetReplies(parentID uint, page comments.Page) ([]comments.Comment, error) {
+	args := r.Called(parentID, page)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]comments.Comment), args.Error(1)
 }
 
+func (r *CommentsRepositoryMock) GetDescendants(paths []string, maxDepth, perParent int, sort comments.SortMode) ([]comments.Comment, error) {
+	args := r.Called(paths, maxDepth, perParent, sort)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]comments.Comment), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) CountReplies(parentIDs []uint) (map[uint]int64, error) {
+	args := r.Called(parentIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint]int64), args.Error(1)
+}
+
 func (r *CommentsRepositoryMock) Update(comment *comments.Comment) error {
 	args := r.Called(comment)
 	return args.Error(0)
@@ -39,3 +70,89 @@ func (r *CommentsRepositoryMock) Delete(id uint) error {
 	args := r.Called(id)
 	return args.Error(0)
 }
+
+func (r *CommentsRepositoryMock) GetByStatus(status comments.Status, postID uint, offset, limit int) ([]comments.Comment, error) {
+	args := r.Called(status, postID, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]comments.Comment), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) CountByStatus(status comments.Status, postID uint) (int64, error) {
+	args := r.Called(status, postID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) CountApprovedByAuthor(authorID uint) (int64, error) {
+	args := r.Called(authorID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) GetPostSettings(postID uint) (*comments.PostSettings, error) {
+	args := r.Called(postID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*comments.PostSettings), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) GetByConfirmToken(tokenHash string) (*comments.Comment, error) {
+	args := r.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*comments.Comment), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) DeleteUnconfirmed(before time.Time) (int64, error) {
+	args := r.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) Follow(userID, postID uint) error {
+	args := r.Called(userID, postID)
+	return args.Error(0)
+}
+
+func (r *CommentsRepositoryMock) Unfollow(userID, postID uint) error {
+	args := r.Called(userID, postID)
+	return args.Error(0)
+}
+
+func (r *CommentsRepositoryMock) GetFollowers(postID uint) ([]uint, error) {
+	args := r.Called(postID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) SetVote(commentID, userID uint, value int) (*comments.Comment, error) {
+	args := r.Called(commentID, userID, value)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*comments.Comment), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) GetVotes(userID uint, commentIDs []uint) (map[uint]int, error) {
+	args := r.Called(userID, commentIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint]int), args.Error(1)
+}
+
+func (r *CommentsRepositoryMock) UpdateWithRevision(comment *comments.Comment, revision *comments.CommentRevision) error {
+	args := r.Called(comment, revision)
+	return args.Error(0)
+}
+
+func (r *CommentsRepositoryMock) GetRevisions(commentID uint) ([]comments.CommentRevision, error) {
+	args := r.Called(commentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]comments.CommentRevision), args.Error(1)
+}
@@ -1,36 +1,466 @@
 package comments
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/mail"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/markdown"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/pow"
+)
+
+// maxModerationTargets ограничивает количество комментариев в одном решении модератора
+const maxModerationTargets = 100
+
+// mentionSubject - тип объекта для упоминаний в комментариях
+const mentionSubject = "comment"
+
+// Ограничения комментариев гостей и значения по умолчанию
+const (
+	maxGuestNameLength  = 100
+	maxGuestEmailLength = 254
+	defaultConfirmTTL   = 48 * time.Hour
+	defaultChallengeTTL = 10 * time.Minute
 )
 
 // CommentSvc реализует бизнес-логику работы с комментариями
 type CommentSvc struct {
-	repo Repository
+	repo            Repository
+	notifier        Notifier         // Может быть nil - уведомления не отправляются
+	spam            SpamChecker      // Может быть nil - проверка на спам не выполняется
+	mentions        Mentioner        // Может быть nil - упоминания не обрабатываются
+	maxDepth        int              // Максимальная глубина ответа, 0 - без ограничения
+	moderation      ModerationPolicy // Политика модерации сайта
+	reviewThreshold float64          // Оценка спама, с которой комментарий ожидает модерации
+	spamThreshold   float64          // Оценка спама, с которой комментарий попадает в спам
+	downvotes       bool             // Разрешены ли дизлайки
+	editWindow      time.Duration    // Сколько автор может править комментарий, 0 - без ограничения
+	autoClose       time.Duration    // Через сколько после публикации поста закрываются комментарии, 0 - никогда
+	markdown        *markdown.Renderer
+
+	guests     config.GuestsConfig // Параметры комментариев гостей
+	challenges *pow.Issuer         // Задачи proof-of-work для гостей
+	mailer     mail.Sender         // Может быть nil - комментарии гостей недоступны
+	stream     Publisher           // Может быть nil - события читателям не рассылаются
 }
 
 // NewCommentService создает новый экземпляр сервиса комментариев
-func NewCommentService(repo Repository) Service {
-	return &CommentSvc{repo: repo}
+func NewCommentService(repo Repository, notifier Notifier, spam SpamChecker, mentions Mentioner, cfg config.CommentsConfig) *CommentSvc {
+	guests := cfg.Guests
+	if guests.ConfirmTTL <= 0 {
+		guests.ConfirmTTL = defaultConfirmTTL
+	}
+	if guests.ChallengeTTL <= 0 {
+		guests.ChallengeTTL = defaultChallengeTTL
+	}
+
+	return &CommentSvc{
+		repo:            repo,
+		notifier:        notifier,
+		spam:            spam,
+		mentions:        mentions,
+		maxDepth:        cfg.MaxDepth,
+		moderation:      ModerationPolicy(cfg.Moderation),
+		reviewThreshold: cfg.ReviewThreshold,
+		spamThreshold:   cfg.SpamThreshold,
+		downvotes:       cfg.Downvotes,
+		editWindow:      cfg.EditWindow,
+		autoClose:       cfg.AutoClose(),
+		markdown:        markdown.NewCommentRenderer(),
+		guests:          guests,
+		challenges:      pow.NewIssuer(guests.Difficulty, guests.ChallengeTTL),
+	}
+}
+
+// UseMailer задает отправку писем со ссылками подтверждения гостям.
+// Без нее комментарии гостей недоступны, даже если включены в конфигурации.
+func (s *CommentSvc) UseMailer(mailer mail.Sender) {
+	s.mailer = mailer
+}
+
+// UsePublisher включает рассылку событий о комментариях читателям поста в реальном времени
+func (s *CommentSvc) UsePublisher(publisher Publisher) {
+	s.stream = publisher
 }
 
 // CreateComment создает новый комментарий
-// Проверяет наличие контента перед созданием
+// Проверяет наличие контента и родительский комментарий перед созданием.
+// Ответ глубже максимальной глубины становится ответом на родителя родителя,
+// а исходный адресат сохраняется в ReplyToID.
+// Статус определяется оценкой на спам, а затем политикой модерации поста или сайта;
+// комментарий, переданный со статусом pending (например, из федерации), остается в очереди.
 func (s *CommentSvc) CreateComment(comment *Comment) error {
+	post, err := s.prepare(comment)
+	if err != nil {
+		return err
+	}
+
+	if comment.Status != StatusPending {
+		comment.Status = ""
+	}
+	if err := s.assignStatus(comment, post); err != nil {
+		return err
+	}
+
+	var mentioned []uint
+	comment.HTMLContent, mentioned = s.render(comment.Content)
+	comment.Version = 1
+	if err := s.repo.Create(comment); err != nil {
+		return err
+	}
+
+	s.recordMentions(comment, mentioned)
+	s.notifyPublished(comment, post)
+	if listed(comment.Status) {
+		s.publish(EventCommentCreated, comment)
+	}
+	return nil
+}
+
+// prepare проверяет текст, пост и родителя нового комментария и определяет его место в ветке
+func (s *CommentSvc) prepare(comment *Comment) (*PostSettings, error) {
 	// Базовая валидация
 	if comment.Content == "" {
-		return ErrEmptyContent
+		return nil, ErrEmptyContent
+	}
+
+	post, err := s.postSettings(comment)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Depth = 0
+	comment.ReplyToID = nil
+	if comment.ParentID != nil {
+		parent, err := s.repo.GetByID(*comment.ParentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrParentNotFound
+			}
+			return nil, fmt.Errorf("failed to fetch parent comment: %w", err)
+		}
+		if parent.PostID != comment.PostID {
+			return nil, ErrInvalidParent
+		}
+
+		replyTo := parent.ID
+		comment.ReplyToID = &replyTo
+		comment.Depth = parent.Depth + 1
+		if s.maxDepth > 0 && comment.Depth > s.maxDepth {
+			comment.ParentID = parent.ParentID
+			comment.Depth = parent.Depth
+		}
+	}
+	return post, nil
+}
+
+// assignStatus определяет статус комментария оценкой на спам, а затем политикой модерации.
+// Комментарий со статусом pending остается в очереди.
+func (s *CommentSvc) assignStatus(comment *Comment, post *PostSettings) error {
+	if err := s.checkSpam(comment); err != nil {
+		return err
+	}
+	if comment.Status == "" {
+		status, err := s.initialStatus(comment, post)
+		if err != nil {
+			return err
+		}
+		comment.Status = status
+	}
+	comment.ModerationReason, comment.ModeratedBy, comment.ModeratedAt = "", nil, nil
+	return nil
+}
+
+// GuestChallenge выдает задачу proof-of-work для комментария гостя
+func (s *CommentSvc) GuestChallenge() (*pow.Challenge, error) {
+	if !s.guestsEnabled() {
+		return nil, ErrGuestsDisabled
+	}
+	challenge := s.challenges.Issue()
+	return &challenge, nil
+}
+
+// guestsEnabled сообщает, что комментарии гостей включены и письма можно отправить
+func (s *CommentSvc) guestsEnabled() bool {
+	return s.guests.Enabled && s.mailer != nil
+}
+
+// CreateGuestComment сохраняет комментарий гостя со статусом unconfirmed и отправляет
+// на email гостя ссылку подтверждения. Гость должен решить выданную задачу proof-of-work.
+// Проверка на спам и премодерация выполняются после подтверждения email.
+func (s *CommentSvc) CreateGuestComment(req GuestCommentRequest) (*Comment, error) {
+	if !s.guestsEnabled() {
+		return nil, ErrGuestsDisabled
+	}
+
+	name := strings.TrimSpace(req.Name)
+	email := strings.TrimSpace(req.Email)
+	if name == "" || utf8.RuneCountInString(name) > maxGuestNameLength ||
+		len(email) > maxGuestEmailLength || !mail.ValidAddress(email) {
+		return nil, ErrInvalidGuest
+	}
+	if req.Content == "" {
+		return nil, ErrEmptyContent
+	}
+	if err := s.challenges.Verify(req.Challenge, req.Nonce); err != nil {
+		return nil, ErrInvalidProof
+	}
+
+	comment := &Comment{
+		Content:    req.Content,
+		PostID:     req.PostID,
+		ParentID:   req.ParentID,
+		Guest:      true,
+		GuestName:  name,
+		GuestEmail: email,
+	}
+	if _, err := s.prepare(comment); err != nil {
+		return nil, err
+	}
+
+	token, err := newConfirmToken()
+	if err != nil {
+		return nil, err
+	}
+	expires := time.Now().Add(s.guests.ConfirmTTL)
+	comment.Status = StatusUnconfirmed
+	comment.ConfirmTokenHash = hashToken(token)
+	comment.ConfirmExpiresAt = &expires
+	// Упоминания в комментариях гостей выводятся ссылками, но не уведомляют пользователей
+	comment.HTMLContent, _ = s.render(comment.Content)
+	comment.Version = 1
+	if err := s.repo.Create(comment); err != nil {
+		return nil, err
+	}
+
+	// Неподтвержденный комментарий без письма будет удален по истечении срока ссылки
+	if err := s.mailer.Send(confirmationEmail(comment, s.guests.ConfirmLink(token))); err != nil {
+		return nil, fmt.Errorf("failed to send confirmation email: %w", err)
+	}
+	return comment, nil
+}
+
+// confirmationEmail формирует письмо гостю со ссылкой подтверждения комментария
+func confirmationEmail(comment *Comment, link string) mail.Message {
+	return mail.Message{
+		To:      comment.GuestEmail,
+		Subject: "Подтвердите ваш комментарий",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\n"+
+			"Чтобы опубликовать ваш комментарий, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует до %s. Если вы не оставляли комментарий, просто проигнорируйте это письмо.\n",
+			comment.GuestName, link, comment.ConfirmExpiresAt.UTC().Format("02.01.2006 15:04 MST")),
+	}
+}
+
+// ConfirmGuestComment подтверждает email гостя по токену из письма.
+// Комментарий проверяется на спам и получает статус по политике модерации,
+// как новый комментарий; гости всегда считаются новыми комментаторами.
+// Токен одноразовый: после подтверждения он удаляется.
+func (s *CommentSvc) ConfirmGuestComment(token string) (*Comment, error) {
+	comment, err := s.repo.GetByConfirmToken(hashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comment: %w", err)
+	}
+	if comment == nil || comment.Status != StatusUnconfirmed {
+		return nil, ErrInvalidToken
+	}
+	if comment.ConfirmExpiresAt != nil && time.Now().After(*comment.ConfirmExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+
+	post, err := s.postSettings(comment)
+	if err != nil {
+		return nil, err
+	}
+	comment.Status = ""
+	if err := s.assignStatus(comment, post); err != nil {
+		return nil, err
+	}
+	comment.ConfirmTokenHash, comment.ConfirmExpiresAt = "", nil
+	if err := s.repo.Update(comment); err != nil {
+		return nil, err
+	}
+	s.notifyPublished(comment, post)
+	if listed(comment.Status) {
+		s.publish(EventCommentCreated, comment)
+	}
+	return comment, nil
+}
+
+// PurgeUnconfirmed удаляет комментарии гостей, ссылка подтверждения которых истекла
+func (s *CommentSvc) PurgeUnconfirmed() (int64, error) {
+	return s.repo.DeleteUnconfirmed(time.Now())
+}
+
+// newConfirmToken генерирует токен ссылки подтверждения
+func newConfirmToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken возвращает SHA-256 токена: в БД хранится только хеш
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// render конвертирует Markdown комментария в HTML со ссылками на упомянутых пользователей
+func (s *CommentSvc) render(content string) (string, []uint) {
+	html := s.markdown.Render(content)
+	if s.mentions == nil {
+		return html, nil
+	}
+	return s.mentions.Link(html)
+}
+
+// recordMentions сохраняет упоминания опубликованного комментария и уведомляет
+// упомянутых пользователей. Упоминания в комментариях, ожидающих модерации,
+// обрабатываются после одобрения, в комментариях гостей - не обрабатываются.
+// Ошибка не отменяет сохранение комментария.
+func (s *CommentSvc) recordMentions(comment *Comment, userIDs []uint) {
+	if s.mentions == nil || comment.Status != StatusActive || comment.Guest {
+		return
+	}
+	if err := s.mentions.Record(mentionSubject, comment.ID, comment.AuthorID, userIDs); err != nil {
+		log.Printf("Failed to record mentions in comment %d: %v", comment.ID, err)
+	}
+}
+
+// checkSpam оценивает комментарий на спам и, если оценка превышает порог,
+// отправляет его в спам или на модерацию
+func (s *CommentSvc) checkSpam(comment *Comment) error {
+	comment.SpamScore, comment.SpamReasons = 0, ""
+	if s.spam == nil {
+		return nil
+	}
+
+	verdict, err := s.spam.Check(comment)
+	if err != nil {
+		return fmt.Errorf("failed to check comment for spam: %w", err)
+	}
+	comment.SpamScore = verdict.Score
+	comment.SpamReasons = strings.Join(verdict.Reasons, "; ")
+
+	switch {
+	case s.spamThreshold > 0 && verdict.Score >= s.spamThreshold:
+		comment.Status = StatusSpam
+	case s.reviewThreshold > 0 && verdict.Score >= s.reviewThreshold:
+		comment.Status = StatusPending
+	}
+	return nil
+}
+
+// postSettings проверяет, что пост существует и принимает комментарий
+func (s *CommentSvc) postSettings(comment *Comment) (*PostSettings, error) {
+	post, err := s.repo.GetPostSettings(comment.PostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch post: %w", err)
+	}
+	if post == nil {
+		return nil, ErrPostNotFound
+	}
+	if !post.Open(time.Now(), s.autoClose) {
+		return nil, ErrCommentsClosed
+	}
+	if post.CommentMode == CommentsMembers && comment.AuthorID == 0 {
+		return nil, ErrMembersOnly
 	}
-	return s.repo.Create(comment)
+	return post, nil
+}
+
+// initialStatus определяет статус нового комментария по политике модерации.
+// В режиме moderated все комментарии к посту ожидают модерации.
+func (s *CommentSvc) initialStatus(comment *Comment, post *PostSettings) (Status, error) {
+	policy := post.CommentModeration
+	if post.CommentMode == CommentsModerated {
+		policy = ModerationAll
+	}
+	if policy == "" {
+		policy = s.moderation
+	}
+
+	switch policy {
+	case ModerationAll:
+		return StatusPending, nil
+	case ModerationFirstTime:
+		// Гостя нельзя узнать по прошлым комментариям
+		if comment.Guest {
+			return StatusPending, nil
+		}
+		approved, err := s.repo.CountApprovedByAuthor(comment.AuthorID)
+		if err != nil {
+			return "", fmt.Errorf("failed to count approved comments: %w", err)
+		}
+		if approved == 0 {
+			return StatusPending, nil
+		}
+	}
+	return StatusActive, nil
 }
 
 // GetComment получает комментарий по ID
 func (s *CommentSvc) GetComment(id uint) (*Comment, error) {
-	return s.repo.GetByID(id)
+	comment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.renderLegacy(comment)
+	return comment, nil
+}
+
+// ViewComment получает комментарий по ID для показа пользователю.
+// Комментарий, не попавший в публикацию (ожидает модерации, отклонен, спам,
+// не подтвержден), видят только его автор и модераторы, остальным возвращается
+// ErrCommentNotFound. Для всех, кроме модераторов, текст скрытых и удаленных
+// комментариев убирается, а из ответов остаются только опубликованные.
+func (s *CommentSvc) ViewComment(id uint, userID uint, userRole users.Role) (*Comment, error) {
+	comment, err := s.GetComment(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	if isModerator(userRole) {
+		return comment, nil
+	}
+	if !listed(comment.Status) && (comment.Guest || comment.AuthorID != userID) {
+		return nil, ErrCommentNotFound
+	}
+
+	replies := make([]Comment, 0, len(comment.Replies))
+	for _, reply := range comment.Replies {
+		if listed(reply.Status) {
+			replies = append(replies, reply)
+		}
+	}
+	comment.Replies = replies
+	redact(comment)
+	redactRemoved(comment.Replies)
+	return comment, nil
 }
 
-// UpdateComment обновляет существующий комментарий
-func (s *CommentSvc) UpdateComment(comment *Comment, userID uint, userRole string) error {
+// UpdateComment обновляет существующий комментарий.
+// Прежний текст сохраняется в истории правок. Автор может править комментарий
+// только в течение окна редактирования и пока он опубликован или ожидает модерации
+// (скрытый, отклоненный, спам или удаленный комментарий не меняется после решения
+// модератора), модераторы - в любое время.
+func (s *CommentSvc) UpdateComment(comment *Comment, userID uint, userRole users.Role) error {
 	// Базовая валидация
 	if comment.Content == "" {
 		return ErrEmptyContent
@@ -46,12 +476,77 @@ func (s *CommentSvc) UpdateComment(comment *Comment, userID uint, userRole strin
 		return ErrUnauthorized
 	}
 
+	now := time.Now()
+	if !isModerator(userRole) {
+		if existing.Status != StatusActive && existing.Status != StatusPending {
+			return ErrCommentLocked
+		}
+		if s.editWindow > 0 && now.After(existing.CreatedAt.Add(s.editWindow)) {
+			return ErrEditWindowOver
+		}
+	}
+
+	// Клиент редактировал устаревшую версию комментария
+	if existing.Version != comment.Version {
+		return ErrVersionConflict
+	}
+	if existing.Content == comment.Content {
+		return nil
+	}
+
+	revision := &CommentRevision{
+		CommentID: existing.ID,
+		Content:   existing.Content,
+		EditedBy:  userID,
+		CreatedAt: now,
+	}
+
+	previous := existing.Status
 	existing.Content = comment.Content
-	return s.repo.Update(existing)
+	if !isModerator(userRole) {
+		if err := s.recheck(existing); err != nil {
+			return err
+		}
+	}
+
+	var mentioned []uint
+	existing.HTMLContent, mentioned = s.render(existing.Content)
+	existing.EditedAt = &now
+	existing.EditCount++
+	if err := s.repo.UpdateWithRevision(existing, revision); err != nil {
+		return err
+	}
+
+	s.recordMentions(existing, mentioned)
+	if existing.Status != previous {
+		s.publishStatus(existing, previous)
+	} else if listed(existing.Status) {
+		s.publish(EventCommentUpdated, existing)
+	}
+	return nil
+}
+
+// recheck заново оценивает отредактированный текст комментария на спам и применяет
+// политику модерации, как при создании: иначе в одобренный комментарий можно было бы
+// дописать то, что не прошло бы проверку. Комментарий может вернуться на модерацию
+// или попасть в спам.
+func (s *CommentSvc) recheck(comment *Comment) error {
+	post, err := s.repo.GetPostSettings(comment.PostID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch post: %w", err)
+	}
+	if post == nil {
+		return ErrPostNotFound
+	}
+
+	if comment.Status != StatusPending {
+		comment.Status = ""
+	}
+	return s.assignStatus(comment, post)
 }
 
 // DeleteComment удаляет комментарий
-func (s *CommentSvc) DeleteComment(id uint, userID uint, userRole string) error {
+func (s *CommentSvc) DeleteComment(id uint, userID uint, userRole users.Role) error {
 	existing, err := s.repo.GetByID(id)
 	if err != nil {
 		return fmt.Errorf("failed to fetch comment: %w", err)
@@ -61,25 +556,668 @@ func (s *CommentSvc) DeleteComment(id uint, userID uint, userRole string) error
 		return ErrUnauthorized
 	}
 
-	return s.repo.Delete(id)
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	if listed(existing.Status) && existing.Status != StatusDeleted {
+		s.publish(EventCommentDeleted, existing)
+	}
+	return nil
 }
 
 // canModifyComment проверяет права на модификацию комментария
-func (s *CommentSvc) canModifyComment(authorID, userID uint, userRole string) bool {
+func (s *CommentSvc) canModifyComment(authorID, userID uint, userRole users.Role) bool {
 	// Автор может модифицировать свой комментарий
 	if authorID == userID {
 		return true
 	}
 
 	// Администратор или модератор может модифицировать любой комментарий
-	if userRole == "admin" || userRole == "moderator" {
-		return true
+	return isModerator(userRole)
+}
+
+// isModerator сообщает, что роль позволяет модерировать комментарии
+func isModerator(userRole users.Role) bool {
+	return userRole == users.RoleAdmin || userRole == users.RoleModerator
+}
+
+// GetCommentHistory возвращает историю правок комментария, старые первыми.
+// Каждая запись содержит текст до правки; текущий текст - в самом комментарии.
+func (s *CommentSvc) GetCommentHistory(id uint) ([]CommentRevision, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch comment: %w", err)
+	}
+
+	revisions, err := s.repo.GetRevisions(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comment history: %w", err)
+	}
+	if revisions == nil {
+		revisions = []CommentRevision{}
+	}
+	return revisions, nil
+}
+
+// SetCommentStatus меняет статус комментария и запоминает решение модератора
+// (moderatorID == nil - автоматически, например при скрытии по жалобам).
+// Используется для публикации ожидающих комментариев и их скрытия;
+// удаление выполняется через DeleteComment. Спам и отклоненные комментарии
+// одобряются только через ModerateComments, чтобы решение учел классификатор спама.
+func (s *CommentSvc) SetCommentStatus(id uint, status Status, moderatorID *uint) (*Comment, error) {
+	if status != StatusActive && status != StatusHidden && status != StatusPending {
+		return nil, ErrInvalidStatus
+	}
+
+	existing, err := s.fetchForModeration(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Status == StatusSpam || existing.Status == StatusRejected {
+		return nil, ErrModerationOnly
 	}
 
-	return false
+	previous, err := s.applyModeration(existing, status, "", moderatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == StatusActive && previous != StatusActive {
+		_, mentioned := s.render(existing.Content)
+		s.recordMentions(existing, mentioned)
+		if neverPublished(previous) {
+			s.notifyPublished(existing, nil)
+		}
+	}
+	s.publishStatus(existing, previous)
+	return existing, nil
 }
 
-// GetPostComments получает все комментарии для конкретного поста
-func (s *CommentSvc) GetPostComments(postID uint) ([]Comment, error) {
-	return s.repo.GetByPostID(postID)
+// GetModerationQueue получает комментарии с указанным статусом для модерации.
+// По умолчанию возвращаются ожидающие модерации комментарии.
+func (s *CommentSvc) GetModerationQueue(status Status, postID uint, offset, limit int) ([]Comment, int64, error) {
+	if status == "" {
+		status = StatusPending
+	}
+	if status != StatusPending && status != StatusHidden && status != StatusRejected && status != StatusSpam {
+		return nil, 0, ErrInvalidStatus
+	}
+
+	queue, err := s.repo.GetByStatus(status, postID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch moderation queue: %w", err)
+	}
+
+	total, err := s.repo.CountByStatus(status, postID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count moderation queue: %w", err)
+	}
+
+	if queue == nil {
+		queue = []Comment{}
+	}
+	for i := range queue {
+		s.renderLegacy(&queue[i])
+	}
+	return queue, total, nil
+}
+
+// ModerateComments применяет решение модератора к нескольким комментариям.
+// Комментарии обрабатываются независимо: ошибка по одному из них попадает
+// в результат и не отменяет решения по остальным. При отклонении автор
+// получает уведомление с причиной. Одобрение и пометка спамом обучают
+// классификатор спама, поэтому ошибочную пометку можно отменить одобрением.
+func (s *CommentSvc) ModerateComments(req ModerationRequest, moderatorID uint) (*ModerationResult, error) {
+	var status Status
+	switch req.Action {
+	case ActionApprove:
+		status = StatusActive
+	case ActionReject:
+		status = StatusRejected
+	case ActionHide:
+		status = StatusHidden
+	case ActionSpam:
+		status = StatusSpam
+	default:
+		return nil, ErrInvalidAction
+	}
+	if len(req.IDs) == 0 {
+		return nil, ErrNoTargets
+	}
+	if len(req.IDs) > maxModerationTargets {
+		return nil, ErrTooManyTargets
+	}
+
+	result := &ModerationResult{Items: make([]ModerationItemResult, 0, len(req.IDs))}
+	for _, id := range req.IDs {
+		item := ModerationItemResult{ID: id}
+
+		comment, previous, err := s.moderateComment(id, status, req.Reason, &moderatorID)
+		if err != nil {
+			item.Error = err.Error()
+			result.Failed++
+		} else {
+			item.Status = comment.Status
+			result.Moderated++
+			if status == StatusRejected {
+				s.notifyRejected(comment)
+			}
+			if req.Action == ActionApprove || req.Action == ActionSpam {
+				s.learnSpam(comment, req.Action == ActionSpam)
+			}
+			if req.Action == ActionApprove {
+				_, mentioned := s.render(comment.Content)
+				s.recordMentions(comment, mentioned)
+				if neverPublished(previous) {
+					s.notifyPublished(comment, nil)
+				}
+			}
+			s.publishStatus(comment, previous)
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+// moderateComment меняет статус одного комментария и запоминает решение модератора.
+// Возвращает комментарий и его прежний статус.
+func (s *CommentSvc) moderateComment(id uint, status Status, reason string, moderatorID *uint) (*Comment, Status, error) {
+	comment, err := s.fetchForModeration(id)
+	if err != nil {
+		return nil, "", err
+	}
+	previous, err := s.applyModeration(comment, status, reason, moderatorID)
+	if err != nil {
+		return nil, "", err
+	}
+	return comment, previous, nil
+}
+
+// fetchForModeration загружает комментарий для смены статуса модератором.
+// Удаленный комментарий не модерируется, а неподтвержденный комментарий гостя
+// публикуется только после подтверждения email.
+func (s *CommentSvc) fetchForModeration(id uint) (*Comment, error) {
+	comment, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch comment: %w", err)
+	}
+	switch comment.Status {
+	case StatusDeleted:
+		return nil, ErrCommentDeleted
+	case StatusUnconfirmed:
+		return nil, ErrNotConfirmed
+	}
+	return comment, nil
+}
+
+// applyModeration сохраняет новый статус комментария вместе с причиной,
+// модератором и временем решения. Возвращает прежний статус.
+func (s *CommentSvc) applyModeration(comment *Comment, status Status, reason string, moderatorID *uint) (Status, error) {
+	now := time.Now()
+	previous := comment.Status
+	comment.Status = status
+	comment.ModerationReason = reason
+	comment.ModeratedBy = moderatorID
+	comment.ModeratedAt = &now
+	if err := s.repo.Update(comment); err != nil {
+		return "", err
+	}
+	return previous, nil
+}
+
+// learnSpam обучает классификатор спама на решении модератора.
+// Ошибка обучения не отменяет решение модератора.
+func (s *CommentSvc) learnSpam(comment *Comment, spam bool) {
+	if s.spam == nil {
+		return
+	}
+	if err := s.spam.Learn(comment, spam); err != nil {
+		log.Printf("Failed to train spam classifier on comment %d: %v", comment.ID, err)
+	}
+}
+
+// notifyRejected сообщает автору об отклонении комментария.
+// Ошибка уведомления не отменяет решение модератора.
+func (s *CommentSvc) notifyRejected(comment *Comment) {
+	// У гостя нет учетной записи для уведомлений
+	if s.notifier == nil || comment.Guest {
+		return
+	}
+
+	message := "Ваш комментарий отклонен модератором"
+	if comment.ModerationReason != "" {
+		message += ": " + comment.ModerationReason
+	}
+	if err := s.notifier.Notify(comment.AuthorID, NotificationCommentRejected, comment.ID, message); err != nil {
+		log.Printf("Failed to notify author of rejected comment %d: %v", comment.ID, err)
+	}
+}
+
+// neverPublished сообщает, что комментарий с таким статусом еще не показывался читателям.
+// Скрытый комментарий мог быть опубликован раньше, поэтому его возврат не считается новым.
+func neverPublished(status Status) bool {
+	return status == StatusPending || status == StatusSpam || status == StatusRejected || status == StatusUnconfirmed
+}
+
+// notifyPublished уведомляет о первой публикации комментария автора комментария,
+// на который ответили, автора поста и подписчиков обсуждения. Каждый получает
+// не больше одного уведомления, автор нового комментария - ни одного.
+// post может быть nil - тогда настройки поста загружаются.
+// Ошибка уведомления не отменяет публикацию.
+func (s *CommentSvc) notifyPublished(comment *Comment, post *PostSettings) {
+	if s.notifier == nil || comment.Status != StatusActive {
+		return
+	}
+	if post == nil {
+		var err error
+		post, err = s.repo.GetPostSettings(comment.PostID)
+		if err != nil || post == nil {
+			if err != nil {
+				log.Printf("Failed to fetch post %d for comment notifications: %v", comment.PostID, err)
+			}
+			return
+		}
+	}
+
+	// У гостей (AuthorID 0) нет учетной записи для уведомлений
+	notified := map[uint]bool{0: true, comment.AuthorID: true}
+	notify := func(userID uint, kind, message string) {
+		if notified[userID] {
+			return
+		}
+		notified[userID] = true
+		if err := s.notifier.Notify(userID, kind, comment.ID, message); err != nil {
+			log.Printf("Failed to notify user %d of comment %d: %v", userID, comment.ID, err)
+		}
+	}
+
+	// Ответ глубже максимальной глубины адресован ReplyToID, а не родителю
+	to := comment.ReplyToID
+	if to == nil {
+		to = comment.ParentID
+	}
+	if to != nil {
+		parent, err := s.repo.GetByID(*to)
+		if err != nil {
+			log.Printf("Failed to fetch parent of comment %d: %v", comment.ID, err)
+		} else {
+			notify(parent.AuthorID, NotificationCommentReply,
+				fmt.Sprintf("Новый ответ на ваш комментарий к посту «%s»", post.Title))
+		}
+	}
+	notify(post.AuthorID, NotificationCommentOnPost, fmt.Sprintf("Новый комментарий к вашему посту «%s»", post.Title))
+
+	followers, err := s.repo.GetFollowers(comment.PostID)
+	if err != nil {
+		log.Printf("Failed to fetch followers of post %d: %v", comment.PostID, err)
+		return
+	}
+	for _, userID := range followers {
+		notify(userID, NotificationThreadComment, fmt.Sprintf("Новый комментарий в обсуждении «%s»", post.Title))
+	}
+}
+
+// listed сообщает, что комментарий с таким статусом показывается в ветке комментариев поста
+func listed(status Status) bool {
+	for _, hidden := range unlisted {
+		if status == hidden {
+			return false
+		}
+	}
+	return true
+}
+
+// publish рассылает читателям поста событие о комментарии
+func (s *CommentSvc) publish(kind string, comment *Comment) {
+	if s.stream == nil {
+		return
+	}
+	var data interface{} = comment
+	switch {
+	case kind == EventCommentDeleted:
+		data = DeletedComment{ID: comment.ID, PostID: comment.PostID}
+	case comment.Status == StatusHidden || comment.Status == StatusDeleted:
+		// В ветке скрытый комментарий показывается без текста (redactRemoved)
+		data = HiddenComment{ID: comment.ID, PostID: comment.PostID, Status: comment.Status}
+	}
+	s.stream.Publish(StreamTopic(comment.PostID), kind, data)
+}
+
+// publishStatus сообщает читателям поста о смене статуса комментария так,
+// как ее видно в ветке: комментарий появляется в ветке, меняется в ней
+// (например, скрывается модератором) или исчезает из нее.
+func (s *CommentSvc) publishStatus(comment *Comment, previous Status) {
+	was, is := listed(previous), listed(comment.Status)
+	switch {
+	case is && !was:
+		s.publish(EventCommentCreated, comment)
+	case is && was && comment.Status != previous:
+		s.publish(EventCommentUpdated, comment)
+	case was && !is:
+		s.publish(EventCommentDeleted, comment)
+	}
+}
+
+// PostStreamTopic возвращает тему событий о комментариях поста.
+// Подписаться на события можно только у опубликованного поста.
+func (s *CommentSvc) PostStreamTopic(postID uint) (string, error) {
+	post, err := s.repo.GetPostSettings(postID)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch post: %w", err)
+	}
+	if post == nil || post.Status != "published" {
+		return "", ErrPostNotFound
+	}
+	return StreamTopic(postID), nil
+}
+
+// FollowThread подписывает пользователя на новые комментарии к опубликованному посту
+func (s *CommentSvc) FollowThread(postID, userID uint) error {
+	post, err := s.repo.GetPostSettings(postID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch post: %w", err)
+	}
+	if post == nil || post.Status != "published" {
+		return ErrPostNotFound
+	}
+	if err := s.repo.Follow(userID, postID); err != nil {
+		return fmt.Errorf("failed to follow comments: %w", err)
+	}
+	return nil
+}
+
+// UnfollowThread отменяет подписку пользователя на комментарии к посту
+func (s *CommentSvc) UnfollowThread(postID, userID uint) error {
+	if err := s.repo.Unfollow(userID, postID); err != nil {
+		return fmt.Errorf("failed to unfollow comments: %w", err)
+	}
+	return nil
+}
+
+// Vote ставит лайк (1) или дизлайк (-1) от имени пользователя.
+// Повторный голос того же знака ничего не меняет, голос другого знака заменяет прежний.
+// Голосовать можно только за опубликованные комментарии.
+func (s *CommentSvc) Vote(commentID, userID uint, value int) (*VoteResult, error) {
+	if value != 1 && value != -1 {
+		return nil, ErrInvalidVote
+	}
+	if value == -1 && !s.downvotes {
+		return nil, ErrDownvotesOff
+	}
+
+	existing, err := s.repo.GetByID(commentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch comment: %w", err)
+	}
+	if existing.Status != StatusActive {
+		return nil, ErrCommentNotFound
+	}
+
+	return s.setVote(commentID, userID, value)
+}
+
+// Unvote снимает голос пользователя, если он совпадает с value.
+// Снятие отсутствующего голоса не считается ошибкой.
+func (s *CommentSvc) Unvote(commentID, userID uint, value int) (*VoteResult, error) {
+	if value != 1 && value != -1 {
+		return nil, ErrInvalidVote
+	}
+
+	votes, err := s.repo.GetVotes(userID, []uint{commentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch votes: %w", err)
+	}
+	if votes[commentID] != value {
+		existing, err := s.repo.GetByID(commentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrCommentNotFound
+			}
+			return nil, fmt.Errorf("failed to fetch comment: %w", err)
+		}
+		return voteResult(existing, votes[commentID]), nil
+	}
+
+	return s.setVote(commentID, userID, 0)
+}
+
+// setVote сохраняет голос и возвращает новые счетчики комментария
+func (s *CommentSvc) setVote(commentID, userID uint, value int) (*VoteResult, error) {
+	comment, err := s.repo.SetVote(commentID, userID, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save vote: %w", err)
+	}
+	if comment == nil {
+		return nil, ErrCommentNotFound
+	}
+	return voteResult(comment, value), nil
+}
+
+// voteResult формирует ответ на голосование по комментарию и голосу пользователя
+func voteResult(comment *Comment, value int) *VoteResult {
+	return &VoteResult{
+		CommentID:    comment.ID,
+		Likes:        comment.Likes,
+		Dislikes:     comment.Dislikes,
+		Score:        comment.Score,
+		LikedByMe:    value == 1,
+		DislikedByMe: value == -1,
+	}
+}
+
+// GetPostComments получает страницу корневых комментариев поста
+// вместе с первыми ответами до глубины opts.Depth
+func (s *CommentSvc) GetPostComments(postID uint, opts ThreadOptions) (*Thread, error) {
+	page, err := threadPage(opts, SortNewest)
+	if err != nil {
+		return nil, err
+	}
+
+	roots, err := s.repo.GetRoots(postID, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+	roots, next := nextPage(roots, page)
+
+	total, err := s.repo.CountRoots(postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	if err := s.loadReplies(roots, opts); err != nil {
+		return nil, err
+	}
+	return &Thread{Comments: roots, Total: total, NextCursor: next}, nil
+}
+
+// GetCommentReplies получает страницу прямых ответов на комментарий
+// вместе с их первыми ответами до глубины opts.Depth ("показать еще ответы")
+func (s *CommentSvc) GetCommentReplies(id uint, opts ThreadOptions) (*Thread, error) {
+	page, err := threadPage(opts, replySort(opts.Sort))
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch comment: %w", err)
+	}
+	if parent.Status == StatusPending || parent.Status == StatusRejected || parent.Status == StatusSpam || parent.Status == StatusUnconfirmed {
+		return nil, ErrCommentNotFound
+	}
+
+	replies, err := s.repo.GetReplies(id, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch replies: %w", err)
+	}
+	replies, next := nextPage(replies, page)
+
+	counts, err := s.repo.CountReplies([]uint{id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count replies: %w", err)
+	}
+
+	if err := s.loadReplies(replies, opts); err != nil {
+		return nil, err
+	}
+	return &Thread{Comments: replies, Total: counts[id], NextCursor: next}, nil
+}
+
+// replySort возвращает порядок ответов: без явного режима ответы идут в порядке написания
+func replySort(sort SortMode) SortMode {
+	if sort == "" {
+		return SortOldest
+	}
+	return sort
+}
+
+// threadPage проверяет режим сортировки и курсор и возвращает страницу уровня.
+// Запрашивается на один комментарий больше, чтобы узнать, есть ли следующая страница.
+func threadPage(opts ThreadOptions, def SortMode) (Page, error) {
+	if !opts.Sort.Valid() {
+		return Page{}, ErrInvalidSort
+	}
+	page := Page{Sort: opts.Sort, Offset: opts.Offset, Limit: opts.Limit + 1}
+	if page.Sort == "" {
+		page.Sort = def
+	}
+	if opts.Cursor != "" {
+		cursor, err := ParseCursor(opts.Cursor, page.Sort)
+		if err != nil {
+			return Page{}, err
+		}
+		page.After = cursor
+		page.Offset = 0
+	}
+	return page, nil
+}
+
+// nextPage отбрасывает лишний комментарий страницы и возвращает курсор следующей страницы,
+// если она есть
+func nextPage(list []Comment, page Page) ([]Comment, string) {
+	if len(list) < page.Limit {
+		return list, ""
+	}
+	list = list[:page.Limit-1]
+	if len(list) == 0 {
+		return list, ""
+	}
+	return list, CursorAfter(list[len(list)-1], page.Sort).String()
+}
+
+// loadReplies загружает первые opts.Replies ответов каждого комментария списка
+// на opts.Depth уровней вниз и заполняет у всех комментариев ReplyCount.
+// Все комментарии списка находятся на одном уровне.
+func (s *CommentSvc) loadReplies(list []Comment, opts ThreadOptions) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	var descendants []Comment
+	if opts.Depth > 0 && opts.Replies > 0 {
+		paths := make([]string, len(list))
+		for i, comment := range list {
+			paths[i] = comment.Path
+		}
+
+		var err error
+		descendants, err = s.repo.GetDescendants(paths, list[0].Depth+opts.Depth, opts.Replies, replySort(opts.Sort))
+		if err != nil {
+			return fmt.Errorf("failed to fetch replies: %w", err)
+		}
+	}
+
+	ids := make([]uint, 0, len(list)+len(descendants))
+	for _, comment := range list {
+		ids = append(ids, comment.ID)
+	}
+	for _, comment := range descendants {
+		ids = append(ids, comment.ID)
+	}
+	counts, err := s.repo.CountReplies(ids)
+	if err != nil {
+		return fmt.Errorf("failed to count replies: %w", err)
+	}
+
+	if opts.ViewerID != 0 {
+		votes, err := s.repo.GetVotes(opts.ViewerID, ids)
+		if err != nil {
+			return fmt.Errorf("failed to fetch votes: %w", err)
+		}
+		markVotes(list, votes)
+		markVotes(descendants, votes)
+	}
+
+	redactRemoved(list)
+	redactRemoved(descendants)
+
+	children := make(map[uint][]Comment)
+	for _, comment := range descendants {
+		children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+	}
+	attachReplies(list, children, counts, replySort(opts.Sort))
+	for i := range list {
+		s.renderLegacy(&list[i])
+	}
+	return nil
+}
+
+// renderLegacy рендерит HTML комментария, сохраненного до поддержки Markdown,
+// и его загруженных ответов
+func (s *CommentSvc) renderLegacy(comment *Comment) {
+	if comment.HTMLContent == "" && comment.Content != "" {
+		comment.HTMLContent, _ = s.render(comment.Content)
+	}
+	for i := range comment.Replies {
+		s.renderLegacy(&comment.Replies[i])
+	}
+}
+
+// markVotes отмечает комментарии, за которые голосовал текущий пользователь
+func markVotes(list []Comment, votes map[uint]int) {
+	for i := range list {
+		list[i].LikedByMe = votes[list[i].ID] == 1
+		list[i].DislikedByMe = votes[list[i].ID] == -1
+	}
+}
+
+// redactRemoved убирает текст скрытых и удаленных комментариев. Такие комментарии
+// остаются в ветке, чтобы не разрывать обсуждение ответами на них, но их текст
+// (например, скрытый по жалобам читателей) не публикуется.
+func redactRemoved(list []Comment) {
+	for i := range list {
+		redact(&list[i])
+	}
+}
+
+// redact убирает текст комментария, если он скрыт или удален
+func redact(comment *Comment) {
+	if comment.Status == StatusHidden || comment.Status == StatusDeleted {
+		comment.Content, comment.HTMLContent = "", ""
+	}
+}
+
+// attachReplies собирает дерево из ответов, сгруппированных по родителю.
+// Ответы, чей родитель не попал в выборку (например, ожидает модерации), отбрасываются.
+// Если загружена только часть ответов, RepliesCursor указывает на продолжение.
+func attachReplies(list []Comment, children map[uint][]Comment, counts map[uint]int64, sort SortMode) {
+	for i := range list {
+		list[i].ReplyCount = counts[list[i].ID]
+		list[i].Replies = children[list[i].ID]
+		if n := len(list[i].Replies); n > 0 && list[i].ReplyCount > int64(n) {
+			list[i].RepliesCursor = CursorAfter(list[i].Replies[n-1], sort).String()
+		}
+		attachReplies(list[i].Replies, children, counts, sort)
+	}
 }
@@ -1,15 +1,31 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
 	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
 	mockRepo "gitlab.com/Nikolay-Yakunin/blog-service/internal/comments/repository/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	jwtlib "gitlab.com/Nikolay-Yakunin/blog-service/pkg/auth/jwt"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/httpcache"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/mail"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/pow"
 )
 
 func TestCommentsService_CreateComment(t *testing.T) {
 	repo := new(mockRepo.CommentsRepositoryMock)
-	service := comments.NewCommentService(repo)
+	service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
 
 	tests := []struct {
 		name    string
@@ -41,6 +57,7 @@ func TestCommentsService_CreateComment(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published"}, nil).Maybe()
 			repo.On("Create", tt.comment).Return(tt.mockErr).Maybe()
 			err := service.CreateComment(tt.comment)
 			if tt.wantErr {
@@ -52,63 +69,1509 @@ func TestCommentsService_CreateComment(t *testing.T) {
 	}
 }
 
-func TestCommentsService_GetThreadByPostID(t *testing.T) {
+func TestCommentsService_CreateCommentRendersMarkdown(t *testing.T) {
 	repo := new(mockRepo.CommentsRepositoryMock)
-	service := comments.NewCommentService(repo)
+	service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+
+	comment := &comments.Comment{
+		AuthorID: 1,
+		PostID:   1,
+		Content:  "Смотрите `go vet` и [документацию](https://go.dev)<script>alert(1)</script>",
+	}
+	repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published"}, nil)
+	repo.On("Create", comment).Return(nil)
+
+	assert.NoError(t, service.CreateComment(comment))
+	assert.Contains(t, comment.HTMLContent, "<code>go vet</code>")
+	assert.Contains(t, comment.HTMLContent, `<a href="https://go.dev" rel="nofollow ugc">документацию</a>`)
+	assert.NotContains(t, comment.HTMLContent, "<script>")
+	assert.Contains(t, comment.Content, "<script>")
+}
+
+func TestCommentsService_CreateReplyFlattening(t *testing.T) {
+	parentOf := func(id uint) *uint { return &id }
 
-	mockComments := []comments.Comment{
+	tests := []struct {
+		name         string
+		parent       *comments.Comment
+		postID       uint
+		wantParentID *uint
+		wantDepth    int
+		wantErr      error
+	}{
+		{
+			name:         "Reply within depth limit",
+			parent:       &comments.Comment{ID: 5, PostID: 1, ParentID: parentOf(4), Depth: 1},
+			postID:       1,
+			wantParentID: parentOf(5),
+			wantDepth:    2,
+		},
 		{
-			ID:       1,
-			AuthorID: 1,
-			PostID:   1,
-			Content:  "Parent comment",
+			name:         "Reply beyond depth limit is flattened",
+			parent:       &comments.Comment{ID: 5, PostID: 1, ParentID: parentOf(4), Depth: 2},
+			postID:       1,
+			wantParentID: parentOf(4),
+			wantDepth:    2,
 		},
 		{
-			ID:       2,
-			AuthorID: 2,
-			PostID:   1,
-			Content:  "Child comment",
-			ParentID: new(uint),
+			name:    "Parent from another post",
+			parent:  &comments.Comment{ID: 5, PostID: 2},
+			postID:  1,
+			wantErr: comments.ErrInvalidParent,
 		},
 	}
-	*mockComments[1].ParentID = 1
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{MaxDepth: 2})
+
+			comment := &comments.Comment{
+				AuthorID: 1,
+				PostID:   tt.postID,
+				Content:  "Reply",
+				ParentID: parentOf(5),
+			}
+			repo.On("GetByID", uint(5)).Return(tt.parent, nil)
+			repo.On("GetPostSettings", tt.postID).Return(&comments.PostSettings{Status: "published"}, nil).Maybe()
+			repo.On("Create", comment).Return(nil).Maybe()
+
+			err := service.CreateComment(comment)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantParentID, comment.ParentID)
+			assert.Equal(t, tt.wantDepth, comment.Depth)
+			assert.Equal(t, parentOf(5), comment.ReplyToID)
+		})
+	}
+}
+
+func TestCommentsService_GetThreadByPostID(t *testing.T) {
+	parentOf := func(id uint) *uint { return &id }
+
+	roots := []comments.Comment{
+		{ID: 1, PostID: 1, Content: "First root", Path: "0000000001/"},
+		{ID: 4, PostID: 1, Content: "Second root", Path: "0000000004/"},
+	}
+	descendants := []comments.Comment{
+		{ID: 2, PostID: 1, ParentID: parentOf(1), Depth: 1, Content: "Reply"},
+		{ID: 3, PostID: 1, ParentID: parentOf(2), Depth: 2, Content: "Nested reply"},
+		// Родитель ожидает модерации и не попал в выборку
+		{ID: 6, PostID: 1, ParentID: parentOf(5), Depth: 2, Content: "Orphan"},
+	}
+	opts := comments.ThreadOptions{Offset: 0, Limit: 2, Replies: 1, Depth: 2}
 
 	tests := []struct {
-		name         string
-		postID      uint
-		mockComments []comments.Comment
-		mockErr     error
-		wantErr     bool
+		name    string
+		postID  uint
+		mockErr error
+		wantErr bool
 	}{
 		{
-			name:         "Success get thread",
-			postID:      1,
-			mockComments: mockComments,
-			mockErr:     nil,
-			wantErr:     false,
+			name:   "Success get thread",
+			postID: 1,
 		},
 		{
-			name:         "Post not found",
-			postID:      999,
-			mockComments: nil,
-			mockErr:     comments.ErrPostNotFound,
-			wantErr:     true,
+			name:    "Storage failure",
+			postID:  999,
+			mockErr: comments.ErrPostNotFound,
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo.On("GetByPostID", tt.postID).
-				Return(tt.mockComments, tt.mockErr)
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
 
-			comments, err := service.GetPostComments(tt.postID)
+			page := make([]comments.Comment, len(roots))
+			copy(page, roots)
+			repo.On("GetRoots", tt.postID, comments.Page{Sort: comments.SortNewest, Limit: 3}).Return(page, tt.mockErr)
+			repo.On("CountRoots", tt.postID).Return(int64(7), nil).Maybe()
+			repo.On("GetDescendants", []string{"0000000001/", "0000000004/"}, 2, 1, comments.SortOldest).
+				Return(descendants, nil).Maybe()
+			repo.On("CountReplies", []uint{1, 4, 2, 3, 6}).
+				Return(map[uint]int64{1: 3, 2: 1}, nil).Maybe()
+
+			result, err := service.GetPostComments(tt.postID, opts)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
 			}
 			assert.NoError(t, err)
-			assert.Equal(t, tt.mockComments, comments)
+			assert.Equal(t, int64(7), result.Total)
+			assert.Empty(t, result.NextCursor)
+			thread := result.Comments
+			assert.Len(t, thread, 2)
+
+			assert.Equal(t, int64(3), thread[0].ReplyCount)
+			assert.Len(t, thread[0].Replies, 1)
+			assert.Equal(t, uint(2), thread[0].Replies[0].ID)
+			assert.Equal(t, comments.CursorAfter(descendants[0], comments.SortOldest).String(), thread[0].RepliesCursor)
+			assert.Equal(t, int64(1), thread[0].Replies[0].ReplyCount)
+			assert.Empty(t, thread[0].Replies[0].RepliesCursor)
+			assert.Len(t, thread[0].Replies[0].Replies, 1)
+			assert.Equal(t, uint(3), thread[0].Replies[0].Replies[0].ID)
+			assert.Empty(t, thread[1].Replies)
+		})
+	}
+}
+
+func TestCommentsService_GetPostCommentsRedactsHidden(t *testing.T) {
+	parentID := uint(1)
+	repo := new(mockRepo.CommentsRepositoryMock)
+	service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+
+	roots := []comments.Comment{
+		{ID: 1, PostID: 1, Content: "Скрыт по жалобам", HTMLContent: "<p>Скрыт по жалобам</p>", Status: comments.StatusHidden, Path: "0000000001/"},
+	}
+	descendants := []comments.Comment{
+		{ID: 2, PostID: 1, ParentID: &parentID, Depth: 1, Content: "Ответ", HTMLContent: "<p>Ответ</p>", Status: comments.StatusActive},
+		{ID: 3, PostID: 1, ParentID: &parentID, Depth: 1, Content: "Удален автором", HTMLContent: "<p>Удален автором</p>", Status: comments.StatusDeleted},
+	}
+	repo.On("GetRoots", uint(1), comments.Page{Sort: comments.SortNewest, Limit: 11}).Return(roots, nil)
+	repo.On("CountRoots", uint(1)).Return(int64(1), nil)
+	repo.On("GetDescendants", []string{"0000000001/"}, 1, 5, comments.SortOldest).Return(descendants, nil)
+	repo.On("CountReplies", []uint{1, 2, 3}).Return(map[uint]int64{1: 2}, nil)
+
+	result, err := service.GetPostComments(1, comments.ThreadOptions{Limit: 10, Replies: 5, Depth: 1})
+	assert.NoError(t, err)
+
+	// Скрытый и удаленный комментарии остаются в ветке без текста
+	hidden := result.Comments[0]
+	assert.Equal(t, comments.StatusHidden, hidden.Status)
+	assert.Empty(t, hidden.Content)
+	assert.Empty(t, hidden.HTMLContent)
+	assert.Len(t, hidden.Replies, 2)
+	assert.Equal(t, "Ответ", hidden.Replies[0].Content)
+	assert.Empty(t, hidden.Replies[1].Content)
+	assert.Empty(t, hidden.Replies[1].HTMLContent)
+}
+
+func TestCommentsService_GetCommentReplies(t *testing.T) {
+	tests := []struct {
+		name    string
+		parent  *comments.Comment
+		wantErr error
+	}{
+		{
+			name:   "Success load more replies",
+			parent: &comments.Comment{ID: 1, PostID: 1, Status: comments.StatusActive},
+		},
+		{
+			name:    "Pending comment is hidden",
+			parent:  &comments.Comment{ID: 1, PostID: 1, Status: comments.StatusPending},
+			wantErr: comments.ErrCommentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+
+			replies := []comments.Comment{{ID: 2, PostID: 1, Depth: 1, Content: "Reply"}}
+			repo.On("GetByID", uint(1)).Return(tt.parent, nil)
+			repo.On("GetReplies", uint(1), comments.Page{Sort: comments.SortOldest, Offset: 3, Limit: 4}).Return(replies, nil).Maybe()
+			repo.On("CountReplies", []uint{1}).Return(map[uint]int64{1: 5}, nil).Maybe()
+			repo.On("CountReplies", []uint{2}).Return(map[uint]int64{}, nil).Maybe()
+
+			page, err := service.GetCommentReplies(1, comments.ThreadOptions{Offset: 3, Limit: 3})
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int64(5), page.Total)
+			assert.Equal(t, replies, page.Comments)
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func TestCommentsService_UpdateComment(t *testing.T) {
+	tests := []struct {
+		name    string
+		version uint
+		wantErr error
+	}{
+		{
+			name:    "Success update",
+			version: 2,
+			wantErr: nil,
+		},
+		{
+			name:    "Stale version",
+			version: 1,
+			wantErr: comments.ErrVersionConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+
+			existing := &comments.Comment{
+				ID:       1,
+				AuthorID: 1,
+				PostID:   1,
+				Content:  "Old content",
+				Status:   comments.StatusActive,
+				Version:  2,
+			}
+			repo.On("GetByID", uint(1)).Return(existing, nil)
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published"}, nil).Maybe()
+			repo.On("UpdateWithRevision", existing, mock.Anything).Return(nil).Maybe()
+
+			err := service.UpdateComment(&comments.Comment{
+				ID:      1,
+				Content: "New content",
+				Version: tt.version,
+			}, 1, users.RoleUser)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestCommentsService_UpdateCommentEditWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     users.Role
+		age      time.Duration
+		wantErr  error
+		revision bool
+	}{
+		{
+			name:     "Author edits within window",
+			role:     users.RoleUser,
+			age:      5 * time.Minute,
+			revision: true,
+		},
+		{
+			name:    "Author edits after window",
+			role:    users.RoleUser,
+			age:     time.Hour,
+			wantErr: comments.ErrEditWindowOver,
+		},
+		{
+			name:     "Moderator edits after window",
+			role:     users.RoleModerator,
+			age:      time.Hour,
+			revision: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{EditWindow: 15 * time.Minute})
+
+			existing := &comments.Comment{
+				ID:        1,
+				AuthorID:  1,
+				PostID:    1,
+				Content:   "Old content",
+				Status:    comments.StatusActive,
+				Version:   2,
+				CreatedAt: time.Now().Add(-tt.age),
+			}
+			var saved *comments.CommentRevision
+			repo.On("GetByID", uint(1)).Return(existing, nil)
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published"}, nil).Maybe()
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published"}, nil).Maybe()
+			repo.On("UpdateWithRevision", existing, mock.Anything).Run(func(args mock.Arguments) {
+				saved = args.Get(1).(*comments.CommentRevision)
+			}).Return(nil).Maybe()
+
+			err := service.UpdateComment(&comments.Comment{
+				ID:      1,
+				Content: "New content",
+				Version: 2,
+			}, 1, tt.role)
+			assert.Equal(t, tt.wantErr, err)
+
+			if !tt.revision {
+				repo.AssertNotCalled(t, "UpdateWithRevision", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Equal(t, "Old content", saved.Content)
+			assert.Equal(t, uint(1), saved.EditedBy)
+			assert.Equal(t, "New content", existing.Content)
+			assert.Equal(t, 1, existing.EditCount)
+			assert.NotNil(t, existing.EditedAt)
+		})
+	}
+}
+
+func TestCommentsService_UpdateCommentAfterModeration(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  comments.Status
+		role    users.Role
+		wantErr error
+	}{
+		{name: "Author edits published comment", status: comments.StatusActive, role: users.RoleUser},
+		{name: "Author edits comment awaiting moderation", status: comments.StatusPending, role: users.RoleUser},
+		{name: "Author cannot edit hidden comment", status: comments.StatusHidden, role: users.RoleUser, wantErr: comments.ErrCommentLocked},
+		{name: "Author cannot edit rejected comment", status: comments.StatusRejected, role: users.RoleUser, wantErr: comments.ErrCommentLocked},
+		{name: "Author cannot edit spam", status: comments.StatusSpam, role: users.RoleUser, wantErr: comments.ErrCommentLocked},
+		{name: "Author cannot edit deleted comment", status: comments.StatusDeleted, role: users.RoleUser, wantErr: comments.ErrCommentLocked},
+		{name: "Moderator edits hidden comment", status: comments.StatusHidden, role: users.RoleModerator},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+
+			existing := &comments.Comment{ID: 1, AuthorID: 1, PostID: 1, Content: "Old content", Status: tt.status, Version: 2}
+			repo.On("GetByID", uint(1)).Return(existing, nil)
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published"}, nil).Maybe()
+			repo.On("UpdateWithRevision", existing, mock.Anything).Return(nil).Maybe()
+
+			err := service.UpdateComment(&comments.Comment{ID: 1, Content: "New content", Version: 2}, 1, tt.role)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				repo.AssertNotCalled(t, "UpdateWithRevision", mock.Anything, mock.Anything)
+				assert.Equal(t, "Old content", existing.Content)
+			}
+		})
+	}
+}
+
+func TestCommentsService_SetCommentStatus(t *testing.T) {
+	moderatorID := uint(2)
+
+	tests := []struct {
+		name     string
+		previous comments.Status
+		status   comments.Status
+		wantErr  error
+	}{
+		{
+			name:     "Approve pending comment",
+			previous: comments.StatusPending,
+			status:   comments.StatusActive,
+			wantErr:  nil,
+		},
+		{
+			name:     "Deleted is not a moderation status",
+			previous: comments.StatusPending,
+			status:   comments.StatusDeleted,
+			wantErr:  comments.ErrInvalidStatus,
+		},
+		{
+			name:     "Deleted comment cannot be restored",
+			previous: comments.StatusDeleted,
+			status:   comments.StatusActive,
+			wantErr:  comments.ErrCommentDeleted,
+		},
+		{
+			name:     "Guest must confirm email first",
+			previous: comments.StatusUnconfirmed,
+			status:   comments.StatusActive,
+			wantErr:  comments.ErrNotConfirmed,
+		},
+		{
+			name:     "Spam is approved only through moderation",
+			previous: comments.StatusSpam,
+			status:   comments.StatusActive,
+			wantErr:  comments.ErrModerationOnly,
+		},
+		{
+			name:     "Rejected is approved only through moderation",
+			previous: comments.StatusRejected,
+			status:   comments.StatusActive,
+			wantErr:  comments.ErrModerationOnly,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+
+			existing := &comments.Comment{
+				ID:      1,
+				PostID:  1,
+				Content: "Reply from the fediverse",
+				Status:  tt.previous,
+				Version: 1,
+			}
+			repo.On("GetByID", uint(1)).Return(existing, nil).Maybe()
+			repo.On("Update", existing).Return(nil).Maybe()
+
+			comment, err := service.SetCommentStatus(1, tt.status, &moderatorID)
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				repo.AssertNotCalled(t, "Update", mock.Anything)
+				return
+			}
+			assert.Equal(t, tt.status, comment.Status)
+			assert.Equal(t, &moderatorID, comment.ModeratedBy)
+			assert.NotNil(t, comment.ModeratedAt)
+		})
+	}
+}
+
+// Роль из JWT сохраняется в контексте как users.Role, поэтому проверяем
+// права модераторов через маршруты, а не только через сервис
+func TestCommentsHandler_ModeratorModifiesComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	tests := []struct {
+		name       string
+		role       users.Role
+		method     string
+		wantStatus int
+	}{
+		{name: "Moderator edits another user's comment after window", role: users.RoleModerator, method: http.MethodPut, wantStatus: http.StatusOK},
+		{name: "Admin deletes another user's comment", role: users.RoleAdmin, method: http.MethodDelete, wantStatus: http.StatusNoContent},
+		{name: "User cannot edit another user's comment", role: users.RoleUser, method: http.MethodPut, wantStatus: http.StatusForbidden},
+		{name: "User cannot delete another user's comment", role: users.RoleUser, method: http.MethodDelete, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{EditWindow: 15 * time.Minute})
+
+			existing := &comments.Comment{
+				ID:        1,
+				AuthorID:  1,
+				PostID:    1,
+				Content:   "Old content",
+				Status:    comments.StatusActive,
+				Version:   2,
+				CreatedAt: time.Now().Add(-time.Hour),
+			}
+			repo.On("GetByID", uint(1)).Return(existing, nil)
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published"}, nil).Maybe()
+			repo.On("UpdateWithRevision", existing, mock.Anything).Return(nil).Maybe()
+			repo.On("Delete", uint(1)).Return(nil).Maybe()
+
+			router := gin.New()
+			comments.NewHandler(service, &config.Config{}).Register(router)
+
+			token, err := jwtlib.GenerateToken(&jwtlib.TokenUser{ID: 9, Role: tt.role})
+			assert.NoError(t, err)
+			req := httptest.NewRequest(tt.method, "/api/v1/comments/1", strings.NewReader(`{"content":"New content"}`))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", httpcache.VersionETag(2))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestCommentsHandler_GetCommentVisibility(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	tests := []struct {
+		name        string
+		status      comments.Status
+		userID      uint
+		role        users.Role
+		wantStatus  int
+		wantContent string
+		wantReplies int
+	}{
+		{name: "Published comment with published replies", status: comments.StatusActive, userID: 9, role: users.RoleUser, wantStatus: http.StatusOK, wantContent: "Текст", wantReplies: 2},
+		{name: "Pending comment is hidden from others", status: comments.StatusPending, userID: 9, role: users.RoleUser, wantStatus: http.StatusNotFound},
+		{name: "Spam is hidden from others", status: comments.StatusSpam, userID: 9, role: users.RoleUser, wantStatus: http.StatusNotFound},
+		{name: "Author sees own pending comment", status: comments.StatusPending, userID: 1, role: users.RoleUser, wantStatus: http.StatusOK, wantContent: "Текст", wantReplies: 2},
+		{name: "Moderator sees rejected comment and all replies", status: comments.StatusRejected, userID: 9, role: users.RoleModerator, wantStatus: http.StatusOK, wantContent: "Текст", wantReplies: 3},
+		{name: "Hidden comment text is redacted", status: comments.StatusHidden, userID: 1, role: users.RoleUser, wantStatus: http.StatusOK, wantContent: "", wantReplies: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+
+			repo.On("GetByID", uint(1)).Return(&comments.Comment{
+				ID: 1, AuthorID: 1, PostID: 1, Content: "Текст", HTMLContent: "<p>Текст</p>", Status: tt.status,
+				Replies: []comments.Comment{
+					{ID: 2, Content: "Ответ", HTMLContent: "<p>Ответ</p>", Status: comments.StatusActive},
+					{ID: 3, Content: "Скрыт", HTMLContent: "<p>Скрыт</p>", Status: comments.StatusHidden},
+					{ID: 4, Content: "В очереди", HTMLContent: "<p>В очереди</p>", Status: comments.StatusPending},
+				},
+			}, nil)
+
+			router := gin.New()
+			comments.NewHandler(service, &config.Config{}).Register(router)
+
+			token, err := jwtlib.GenerateToken(&jwtlib.TokenUser{ID: tt.userID, Role: tt.role})
+			assert.NoError(t, err)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/comments/1", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantStatus != http.StatusOK {
+				assert.NotContains(t, w.Body.String(), "Текст")
+				return
+			}
+			var got comments.Comment
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, tt.wantContent, got.Content)
+			assert.Len(t, got.Replies, tt.wantReplies)
+			if tt.role != users.RoleModerator {
+				assert.NotContains(t, w.Body.String(), "Скрыт")
+				assert.NotContains(t, w.Body.String(), "В очереди")
+			}
+		})
+	}
+}
+
+func TestCommentsHandler_SpamScoreOnlyInQueue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	repo := new(mockRepo.CommentsRepositoryMock)
+	service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+	comment := comments.Comment{
+		ID:          1,
+		AuthorID:    1,
+		PostID:      1,
+		Content:     "Buy now",
+		Status:      comments.StatusPending,
+		SpamScore:   0.65,
+		SpamReasons: "too many links: 5",
+	}
+	repo.On("GetByID", uint(1)).Return(&comment, nil)
+	repo.On("GetByStatus", comments.StatusPending, uint(0), 0, 20).Return([]comments.Comment{comment}, nil)
+	repo.On("CountByStatus", comments.StatusPending, uint(0)).Return(int64(1), nil)
+
+	router := gin.New()
+	comments.NewHandler(service, &config.Config{}).Register(router)
+
+	get := func(path string, role users.Role) string {
+		token, err := jwtlib.GenerateToken(&jwtlib.TokenUser{ID: 1, Role: role})
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return w.Body.String()
+	}
+
+	body := get("/api/v1/comments/1", users.RoleUser)
+	assert.NotContains(t, body, "spam_score")
+	assert.NotContains(t, body, "too many links")
+
+	body = get("/api/v1/comments/queue", users.RoleModerator)
+	assert.Contains(t, body, `"spam_score":0.65`)
+	assert.Contains(t, body, `"spam_reasons":"too many links: 5"`)
+}
+
+// notifierStub запоминает отправленные уведомления
+type notifierStub struct {
+	sent  []string
+	kinds map[uint]string // Тип уведомления по получателю
+}
+
+func (n *notifierStub) Notify(userID uint, kind string, subjectID uint, message string) error {
+	n.sent = append(n.sent, message)
+	if n.kinds == nil {
+		n.kinds = make(map[uint]string)
+	}
+	n.kinds[userID] = kind
+	return nil
+}
+
+func TestCommentsService_CreateCommentNotifiesThread(t *testing.T) {
+	parentID := uint(10)
+	post := &comments.PostSettings{AuthorID: 5, Title: "Go и Swagger", Status: "published"}
+	parent := &comments.Comment{ID: parentID, AuthorID: 7, PostID: 1, Status: comments.StatusActive}
+
+	tests := []struct {
+		name      string
+		authorID  uint
+		previous  comments.Status // Статус до одобрения модератором, пустой - новый комментарий
+		wantKinds map[uint]string
+	}{
+		{
+			name:     "Reply notifies parent author, post author and followers once",
+			authorID: 3,
+			wantKinds: map[uint]string{
+				7: comments.NotificationCommentReply,
+				5: comments.NotificationCommentOnPost,
+				9: comments.NotificationThreadComment,
+			},
+		},
+		{
+			name:     "Post author replying is not notified of own comment",
+			authorID: 5,
+			wantKinds: map[uint]string{
+				7: comments.NotificationCommentReply,
+				9: comments.NotificationThreadComment,
+			},
+		},
+		{
+			name:     "Approved pending comment notifies",
+			authorID: 3,
+			previous: comments.StatusPending,
+			wantKinds: map[uint]string{
+				7: comments.NotificationCommentReply,
+				5: comments.NotificationCommentOnPost,
+				9: comments.NotificationThreadComment,
+			},
+		},
+		{
+			name:      "Restored hidden comment does not notify again",
+			authorID:  3,
+			previous:  comments.StatusHidden,
+			wantKinds: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			notifier := &notifierStub{}
+			service := comments.NewCommentService(repo, notifier, nil, nil, config.CommentsConfig{Moderation: "none"})
+
+			repo.On("GetPostSettings", uint(1)).Return(post, nil)
+			repo.On("GetByID", parentID).Return(parent, nil)
+			repo.On("GetFollowers", uint(1)).Return([]uint{9, 7, tt.authorID}, nil)
+
+			if tt.previous == "" {
+				comment := &comments.Comment{AuthorID: tt.authorID, PostID: 1, ParentID: &parentID, Content: "Ответ"}
+				repo.On("Create", comment).Return(nil)
+				assert.NoError(t, service.CreateComment(comment))
+			} else {
+				comment := &comments.Comment{ID: 11, AuthorID: tt.authorID, PostID: 1, ParentID: &parentID,
+					ReplyToID: &parentID, Content: "Ответ", Status: tt.previous}
+				repo.On("GetByID", uint(11)).Return(comment, nil)
+				repo.On("Update", comment).Return(nil)
+				_, err := service.SetCommentStatus(11, comments.StatusActive, nil)
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantKinds, notifier.kinds)
+		})
+	}
+}
+
+func TestCommentsService_FollowThread(t *testing.T) {
+	tests := []struct {
+		name    string
+		post    *comments.PostSettings
+		wantErr error
+	}{
+		{name: "Published post", post: &comments.PostSettings{Status: "published"}},
+		{name: "Draft", post: &comments.PostSettings{Status: "draft"}, wantErr: comments.ErrPostNotFound},
+		{name: "Missing post", wantErr: comments.ErrPostNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+
+			repo.On("GetPostSettings", uint(1)).Return(tt.post, nil)
+			repo.On("Follow", uint(3), uint(1)).Return(nil).Maybe()
+
+			err := service.FollowThread(1, 3)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				repo.AssertCalled(t, "Follow", uint(3), uint(1))
+			} else {
+				repo.AssertNotCalled(t, "Follow", mock.Anything, mock.Anything)
+			}
 		})
 	}
 }
 
+// publisherStub запоминает разосланные события
+type publisherStub struct {
+	topics []string
+	kinds  []string
+	data   []interface{}
+}
+
+func (p *publisherStub) Publish(topic, kind string, data interface{}) {
+	p.topics = append(p.topics, topic)
+	p.kinds = append(p.kinds, kind)
+	p.data = append(p.data, data)
+}
+
+func TestCommentsService_PublishesEvents(t *testing.T) {
+	post := &comments.PostSettings{Status: "published"}
+
+	tests := []struct {
+		name       string
+		moderation string
+		run        func(service *comments.CommentSvc, repo *mockRepo.CommentsRepositoryMock) error
+		wantKinds  []string
+		wantData   interface{} // Данные последнего события, если проверяются
+	}{
+		{
+			name:       "Published comment",
+			moderation: "none",
+			run: func(service *comments.CommentSvc, repo *mockRepo.CommentsRepositoryMock) error {
+				comment := &comments.Comment{AuthorID: 3, PostID: 1, Content: "Комментарий"}
+				repo.On("Create", comment).Return(nil)
+				return service.CreateComment(comment)
+			},
+			wantKinds: []string{comments.EventCommentCreated},
+		},
+		{
+			name:       "Comment awaiting moderation",
+			moderation: "all",
+			run: func(service *comments.CommentSvc, repo *mockRepo.CommentsRepositoryMock) error {
+				comment := &comments.Comment{AuthorID: 3, PostID: 1, Content: "Комментарий"}
+				repo.On("Create", comment).Return(nil)
+				return service.CreateComment(comment)
+			},
+		},
+		{
+			name: "Approved pending comment",
+			run: func(service *comments.CommentSvc, repo *mockRepo.CommentsRepositoryMock) error {
+				comment := &comments.Comment{ID: 11, PostID: 1, Content: "Комментарий", Status: comments.StatusPending}
+				repo.On("GetByID", uint(11)).Return(comment, nil)
+				repo.On("Update", comment).Return(nil)
+				_, err := service.SetCommentStatus(11, comments.StatusActive, nil)
+				return err
+			},
+			wantKinds: []string{comments.EventCommentCreated},
+		},
+		{
+			name: "Hidden comment stays in the thread",
+			run: func(service *comments.CommentSvc, repo *mockRepo.CommentsRepositoryMock) error {
+				comment := &comments.Comment{ID: 11, PostID: 1, Content: "Комментарий", Status: comments.StatusActive}
+				repo.On("GetByID", uint(11)).Return(comment, nil)
+				repo.On("Update", comment).Return(nil)
+				_, err := service.SetCommentStatus(11, comments.StatusHidden, nil)
+				return err
+			},
+			wantKinds: []string{comments.EventCommentUpdated},
+			wantData:  comments.HiddenComment{ID: 11, PostID: 1, Status: comments.StatusHidden},
+		},
+		{
+			name: "Published comment marked as spam",
+			run: func(service *comments.CommentSvc, repo *mockRepo.CommentsRepositoryMock) error {
+				comment := &comments.Comment{ID: 11, PostID: 1, Content: "Комментарий", Status: comments.StatusActive}
+				repo.On("GetByID", uint(11)).Return(comment, nil)
+				repo.On("Update", comment).Return(nil)
+				_, err := service.ModerateComments(comments.ModerationRequest{IDs: []uint{11}, Action: comments.ActionSpam}, 2)
+				return err
+			},
+			wantKinds: []string{comments.EventCommentDeleted},
+		},
+		{
+			name: "Deleted comment",
+			run: func(service *comments.CommentSvc, repo *mockRepo.CommentsRepositoryMock) error {
+				comment := &comments.Comment{ID: 11, AuthorID: 3, PostID: 1, Content: "Комментарий", Status: comments.StatusActive}
+				repo.On("GetByID", uint(11)).Return(comment, nil)
+				repo.On("Delete", uint(11)).Return(nil)
+				return service.DeleteComment(11, 3, users.RoleUser)
+			},
+			wantKinds: []string{comments.EventCommentDeleted},
+		},
+		{
+			name: "Deleted pending comment was never shown",
+			run: func(service *comments.CommentSvc, repo *mockRepo.CommentsRepositoryMock) error {
+				comment := &comments.Comment{ID: 11, AuthorID: 3, PostID: 1, Content: "Комментарий", Status: comments.StatusPending}
+				repo.On("GetByID", uint(11)).Return(comment, nil)
+				repo.On("Delete", uint(11)).Return(nil)
+				return service.DeleteComment(11, 3, users.RoleUser)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			publisher := &publisherStub{}
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{Moderation: tt.moderation})
+			service.UsePublisher(publisher)
+
+			repo.On("GetPostSettings", uint(1)).Return(post, nil)
+
+			assert.NoError(t, tt.run(service, repo))
+			assert.Equal(t, tt.wantKinds, publisher.kinds)
+			if tt.wantData != nil {
+				assert.Equal(t, tt.wantData, publisher.data[len(publisher.data)-1])
+			}
+			for _, topic := range publisher.topics {
+				assert.Equal(t, "post:1", topic)
+			}
+		})
+	}
+}
+
+func TestCommentsService_CreateCommentModerationPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		site       comments.ModerationPolicy
+		post       comments.ModerationPolicy
+		approved   int64
+		incoming   comments.Status
+		wantStatus comments.Status
+	}{
+		{name: "Site policy none", site: comments.ModerationNone, wantStatus: comments.StatusActive},
+		{name: "Site policy all", site: comments.ModerationAll, wantStatus: comments.StatusPending},
+		{name: "Post overrides site", site: comments.ModerationAll, post: comments.ModerationNone, wantStatus: comments.StatusActive},
+		{name: "First-time commenter", site: comments.ModerationFirstTime, approved: 0, wantStatus: comments.StatusPending},
+		{name: "Returning commenter", site: comments.ModerationFirstTime, approved: 3, wantStatus: comments.StatusActive},
+		{name: "Pending from federation stays pending", site: comments.ModerationNone, incoming: comments.StatusPending, wantStatus: comments.StatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{Moderation: string(tt.site)})
+
+			comment := &comments.Comment{AuthorID: 1, PostID: 1, Content: "Comment", Status: tt.incoming}
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published", CommentModeration: tt.post}, nil).Maybe()
+			repo.On("CountApprovedByAuthor", uint(1)).Return(tt.approved, nil).Maybe()
+			repo.On("Create", comment).Return(nil)
+
+			assert.NoError(t, service.CreateComment(comment))
+			assert.Equal(t, tt.wantStatus, comment.Status)
+		})
+	}
+}
+
+func TestCommentsService_CreateCommentPostSettings(t *testing.T) {
+	daysAgo := func(days int) *time.Time {
+		at := time.Now().AddDate(0, 0, -days)
+		return &at
+	}
+
+	tests := []struct {
+		name       string
+		post       *comments.PostSettings
+		authorID   uint
+		wantErr    error
+		wantStatus comments.Status
+	}{
+		{
+			name:    "Missing post",
+			wantErr: comments.ErrPostNotFound,
+		},
+		{
+			name:     "Draft post",
+			post:     &comments.PostSettings{Status: "draft"},
+			authorID: 1,
+			wantErr:  comments.ErrCommentsClosed,
+		},
+		{
+			name:     "Closed post",
+			post:     &comments.PostSettings{Status: "published", CommentMode: comments.CommentsClosed},
+			authorID: 1,
+			wantErr:  comments.ErrCommentsClosed,
+		},
+		{
+			name:     "Auto-closed after period",
+			post:     &comments.PostSettings{Status: "published", PublishedAt: daysAgo(31)},
+			authorID: 1,
+			wantErr:  comments.ErrCommentsClosed,
+		},
+		{
+			name:       "Within auto-close period",
+			post:       &comments.PostSettings{Status: "published", PublishedAt: daysAgo(29)},
+			authorID:   1,
+			wantStatus: comments.StatusActive,
+		},
+		{
+			name:    "Members only rejects guests",
+			post:    &comments.PostSettings{Status: "published", CommentMode: comments.CommentsMembers},
+			wantErr: comments.ErrMembersOnly,
+		},
+		{
+			name:       "Members only accepts users",
+			post:       &comments.PostSettings{Status: "published", CommentMode: comments.CommentsMembers},
+			authorID:   1,
+			wantStatus: comments.StatusActive,
+		},
+		{
+			name:       "Moderated post holds comments",
+			post:       &comments.PostSettings{Status: "published", CommentMode: comments.CommentsModerated, CommentModeration: comments.ModerationNone},
+			authorID:   1,
+			wantStatus: comments.StatusPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{
+				Moderation:    string(comments.ModerationNone),
+				AutoCloseDays: 30,
+			})
+
+			comment := &comments.Comment{AuthorID: tt.authorID, PostID: 1, Content: "Comment"}
+			repo.On("GetPostSettings", uint(1)).Return(tt.post, nil)
+			repo.On("Create", comment).Return(nil).Maybe()
+
+			err := service.CreateComment(comment)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				repo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, comment.Status)
+		})
+	}
+}
+
+func TestCommentsService_ModerateComments(t *testing.T) {
+	repo := new(mockRepo.CommentsRepositoryMock)
+	notifier := &notifierStub{}
+	service := comments.NewCommentService(repo, notifier, nil, nil, config.CommentsConfig{})
+
+	pending := &comments.Comment{ID: 1, AuthorID: 7, PostID: 1, Content: "Spam", Status: comments.StatusPending, Version: 1}
+	deleted := &comments.Comment{ID: 2, AuthorID: 8, PostID: 1, Content: "Gone", Status: comments.StatusDeleted, Version: 1}
+	repo.On("GetByID", uint(1)).Return(pending, nil)
+	repo.On("GetByID", uint(2)).Return(deleted, nil)
+	repo.On("Update", pending).Return(nil)
+
+	result, err := service.ModerateComments(comments.ModerationRequest{
+		IDs:    []uint{1, 2},
+		Action: comments.ActionReject,
+		Reason: "Реклама",
+	}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Moderated)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, comments.StatusRejected, result.Items[0].Status)
+	assert.Equal(t, comments.ErrCommentDeleted.Error(), result.Items[1].Error)
+
+	assert.Equal(t, "Реклама", pending.ModerationReason)
+	assert.Equal(t, uint(3), *pending.ModeratedBy)
+	assert.Equal(t, []string{"Ваш комментарий отклонен модератором: Реклама"}, notifier.sent)
+	repo.AssertNotCalled(t, "Update", deleted)
+
+	_, err = service.ModerateComments(comments.ModerationRequest{IDs: []uint{1}, Action: "delete"}, 3)
+	assert.Equal(t, comments.ErrInvalidAction, err)
+}
+
+// spamStub возвращает заданную оценку и запоминает обучение
+type spamStub struct {
+	score   float64
+	learned map[uint]bool
+}
+
+func (s *spamStub) Check(comment *comments.Comment) (*comments.SpamVerdict, error) {
+	return &comments.SpamVerdict{Score: s.score, Reasons: []string{"stub"}}, nil
+}
+
+func (s *spamStub) Learn(comment *comments.Comment, spam bool) error {
+	s.learned[comment.ID] = spam
+	return nil
+}
+
+func TestCommentsService_CreateCommentSpamRouting(t *testing.T) {
+	tests := []struct {
+		name       string
+		score      float64
+		wantStatus comments.Status
+	}{
+		{name: "Published", score: 0.2, wantStatus: comments.StatusActive},
+		{name: "Held for review", score: 0.6, wantStatus: comments.StatusPending},
+		{name: "Spam", score: 0.95, wantStatus: comments.StatusSpam},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			checker := &spamStub{score: tt.score}
+			service := comments.NewCommentService(repo, nil, checker, nil, config.CommentsConfig{
+				Moderation:      string(comments.ModerationNone),
+				ReviewThreshold: 0.5,
+				SpamThreshold:   0.9,
+			})
+
+			comment := &comments.Comment{AuthorID: 1, PostID: 1, Content: "Comment"}
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published"}, nil).Maybe()
+			repo.On("Create", comment).Return(nil)
+
+			assert.NoError(t, service.CreateComment(comment))
+			assert.Equal(t, tt.wantStatus, comment.Status)
+			assert.Equal(t, tt.score, comment.SpamScore)
+			assert.Equal(t, "stub", comment.SpamReasons)
+		})
+	}
+}
+
+func TestCommentsService_UpdateCommentRechecksSpam(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       users.Role
+		moderation comments.ModerationPolicy
+		score      float64
+		wantStatus comments.Status
+		wantEvent  string
+	}{
+		{name: "Clean edit stays published", role: users.RoleUser, moderation: comments.ModerationNone, score: 0.2, wantStatus: comments.StatusActive, wantEvent: comments.EventCommentUpdated},
+		{name: "Suspicious edit goes back to review", role: users.RoleUser, moderation: comments.ModerationNone, score: 0.6, wantStatus: comments.StatusPending, wantEvent: comments.EventCommentDeleted},
+		{name: "Spam edit is marked as spam", role: users.RoleUser, moderation: comments.ModerationNone, score: 0.95, wantStatus: comments.StatusSpam, wantEvent: comments.EventCommentDeleted},
+		{name: "Premoderated post reviews every edit", role: users.RoleUser, moderation: comments.ModerationAll, score: 0.2, wantStatus: comments.StatusPending, wantEvent: comments.EventCommentDeleted},
+		{name: "Moderator edit is not rechecked", role: users.RoleModerator, moderation: comments.ModerationNone, score: 0.95, wantStatus: comments.StatusActive, wantEvent: comments.EventCommentUpdated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			publisher := &publisherStub{}
+			service := comments.NewCommentService(repo, nil, &spamStub{score: tt.score}, nil, config.CommentsConfig{
+				Moderation:      string(comments.ModerationNone),
+				ReviewThreshold: 0.5,
+				SpamThreshold:   0.9,
+			})
+			service.UsePublisher(publisher)
+
+			existing := &comments.Comment{ID: 1, AuthorID: 1, PostID: 1, Content: "Спасибо", Status: comments.StatusActive, Version: 2}
+			repo.On("GetByID", uint(1)).Return(existing, nil)
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published", CommentModeration: tt.moderation}, nil).Maybe()
+			repo.On("UpdateWithRevision", existing, mock.Anything).Return(nil)
+
+			err := service.UpdateComment(&comments.Comment{ID: 1, Content: "Купите http://spam.example", Version: 2}, 1, tt.role)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, existing.Status)
+			assert.Equal(t, []string{tt.wantEvent}, publisher.kinds)
+		})
+	}
+}
+
+func TestCommentsService_ModerateSpamTrainsClassifier(t *testing.T) {
+	repo := new(mockRepo.CommentsRepositoryMock)
+	checker := &spamStub{learned: make(map[uint]bool)}
+	service := comments.NewCommentService(repo, nil, checker, nil, config.CommentsConfig{})
+
+	spam := &comments.Comment{ID: 1, PostID: 1, Content: "Buy now", Status: comments.StatusSpam, Version: 1}
+	repo.On("GetByID", uint(1)).Return(spam, nil)
+	repo.On("Update", spam).Return(nil)
+
+	// Ошибочно помеченный комментарий восстанавливается одобрением
+	result, err := service.ModerateComments(comments.ModerationRequest{IDs: []uint{1}, Action: comments.ActionApprove}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Moderated)
+	assert.Equal(t, comments.StatusActive, spam.Status)
+	assert.Equal(t, map[uint]bool{1: false}, checker.learned)
+
+	_, err = service.ModerateComments(comments.ModerationRequest{IDs: []uint{1}, Action: comments.ActionSpam}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, comments.StatusSpam, spam.Status)
+	assert.Equal(t, map[uint]bool{1: true}, checker.learned)
+}
+
+func TestCommentsService_Vote(t *testing.T) {
+	tests := []struct {
+		name      string
+		downvotes bool
+		status    comments.Status
+		value     int
+		wantErr   error
+	}{
+		{
+			name:   "Like active comment",
+			status: comments.StatusActive,
+			value:  1,
+		},
+		{
+			name:      "Dislike when enabled",
+			downvotes: true,
+			status:    comments.StatusActive,
+			value:     -1,
+		},
+		{
+			name:    "Dislike when disabled",
+			status:  comments.StatusActive,
+			value:   -1,
+			wantErr: comments.ErrDownvotesOff,
+		},
+		{
+			name:    "Invalid vote value",
+			status:  comments.StatusActive,
+			value:   2,
+			wantErr: comments.ErrInvalidVote,
+		},
+		{
+			name:    "Pending comment cannot be liked",
+			status:  comments.StatusPending,
+			value:   1,
+			wantErr: comments.ErrCommentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{Downvotes: tt.downvotes})
+
+			repo.On("GetByID", uint(1)).Return(&comments.Comment{ID: 1, Status: tt.status}, nil).Maybe()
+			repo.On("SetVote", uint(1), uint(2), tt.value).
+				Return(&comments.Comment{ID: 1, Likes: 3, Dislikes: 1, Score: 2}, nil).Maybe()
+
+			result, err := service.Vote(1, 2, tt.value)
+			assert.Equal(t, tt.wantErr, err)
+			if err == nil {
+				assert.Equal(t, 2, result.Score)
+				assert.Equal(t, tt.value == 1, result.LikedByMe)
+				assert.Equal(t, tt.value == -1, result.DislikedByMe)
+			} else {
+				repo.AssertNotCalled(t, "SetVote", uint(1), uint(2), tt.value)
+			}
+		})
+	}
+}
+
+func TestCommentsService_Unvote(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		value    int
+		wantCall bool
+	}{
+		{
+			name:     "Remove own like",
+			current:  1,
+			value:    1,
+			wantCall: true,
+		},
+		{
+			name:    "Removing a like keeps the dislike",
+			current: -1,
+			value:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{Downvotes: true})
+
+			comment := &comments.Comment{ID: 1, Status: comments.StatusActive, Likes: 1, Dislikes: 1}
+			repo.On("GetVotes", uint(2), []uint{1}).Return(map[uint]int{1: tt.current}, nil)
+			repo.On("GetByID", uint(1)).Return(comment, nil).Maybe()
+			repo.On("SetVote", uint(1), uint(2), 0).Return(comment, nil).Maybe()
+
+			result, err := service.Unvote(1, 2, tt.value)
+			assert.NoError(t, err)
+			if tt.wantCall {
+				repo.AssertCalled(t, "SetVote", uint(1), uint(2), 0)
+				assert.False(t, result.LikedByMe)
+			} else {
+				repo.AssertNotCalled(t, "SetVote", uint(1), uint(2), 0)
+				assert.True(t, result.DislikedByMe)
+			}
+		})
+	}
+}
+
+func TestCommentsService_GetPostCommentsMarksViewerVotes(t *testing.T) {
+	parentOf := func(id uint) *uint { return &id }
+	repo := new(mockRepo.CommentsRepositoryMock)
+	service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+
+	roots := []comments.Comment{
+		{ID: 1, PostID: 1, Path: "0000000001/"},
+		{ID: 4, PostID: 1, Path: "0000000004/"},
+	}
+	descendants := []comments.Comment{
+		{ID: 2, PostID: 1, ParentID: parentOf(1), Depth: 1},
+	}
+	repo.On("GetRoots", uint(1), comments.Page{Sort: comments.SortNewest, Limit: 21}).Return(roots, nil)
+	repo.On("CountRoots", uint(1)).Return(int64(2), nil)
+	repo.On("GetDescendants", []string{"0000000001/", "0000000004/"}, 1, 3, comments.SortOldest).Return(descendants, nil)
+	repo.On("CountReplies", []uint{1, 4, 2}).Return(map[uint]int64{1: 1}, nil)
+	repo.On("GetVotes", uint(9), []uint{1, 4, 2}).Return(map[uint]int{2: 1, 4: -1}, nil)
+
+	opts := comments.ThreadOptions{Limit: 20, Replies: 3, Depth: 1, ViewerID: 9}
+	result, err := service.GetPostComments(1, opts)
+	assert.NoError(t, err)
+	thread := result.Comments
+	assert.False(t, thread[0].LikedByMe)
+	assert.True(t, thread[0].Replies[0].LikedByMe)
+	assert.True(t, thread[1].DislikedByMe)
+}
+
+func TestCommentsService_GetPostCommentsSortedPages(t *testing.T) {
+	repo := new(mockRepo.CommentsRepositoryMock)
+	service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+
+	// Третий комментарий - признак следующей страницы, в ответ он не попадает
+	first := []comments.Comment{
+		{ID: 5, PostID: 1, Path: "0000000005/", SortKey: 12},
+		{ID: 3, PostID: 1, Path: "0000000003/", SortKey: 7},
+		{ID: 9, PostID: 1, Path: "0000000009/", SortKey: 7},
+	}
+	repo.On("GetRoots", uint(1), comments.Page{Sort: comments.SortTop, Limit: 3}).Return(first, nil)
+	repo.On("CountRoots", uint(1)).Return(int64(3), nil)
+	repo.On("GetDescendants", []string{"0000000005/", "0000000003/"}, 1, 2, comments.SortTop).Return(nil, nil)
+	repo.On("CountReplies", []uint{5, 3}).Return(map[uint]int64{}, nil)
+
+	opts := comments.ThreadOptions{Sort: comments.SortTop, Limit: 2, Replies: 2, Depth: 1}
+	result, err := service.GetPostComments(1, opts)
+	assert.NoError(t, err)
+	assert.Len(t, result.Comments, 2)
+	assert.NotEmpty(t, result.NextCursor)
+
+	// Следующая страница продолжается после последнего комментария, смещение не используется
+	after := &comments.Cursor{Sort: comments.SortTop, Key: 7, ID: 3}
+	repo.On("GetRoots", uint(1), comments.Page{Sort: comments.SortTop, After: after, Limit: 3}).
+		Return([]comments.Comment{first[2]}, nil)
+	repo.On("GetDescendants", []string{"0000000009/"}, 1, 2, comments.SortTop).Return(nil, nil)
+	repo.On("CountReplies", []uint{9}).Return(map[uint]int64{}, nil)
+
+	opts.Cursor = result.NextCursor
+	opts.Offset = 10
+	result, err = service.GetPostComments(1, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(9), result.Comments[0].ID)
+	assert.Empty(t, result.NextCursor)
+	repo.AssertExpectations(t)
+}
+
+func TestCommentsService_GetPostCommentsInvalidSort(t *testing.T) {
+	cursor := comments.Cursor{Sort: comments.SortHot, Key: 1.5, ID: 4}.String()
+
+	tests := []struct {
+		name    string
+		opts    comments.ThreadOptions
+		wantErr error
+	}{
+		{name: "Unknown sort", opts: comments.ThreadOptions{Sort: "random", Limit: 20}, wantErr: comments.ErrInvalidSort},
+		{name: "Cursor of another sort", opts: comments.ThreadOptions{Sort: comments.SortTop, Cursor: cursor, Limit: 20}, wantErr: comments.ErrInvalidCursor},
+		{name: "Damaged cursor", opts: comments.ThreadOptions{Cursor: "not a cursor", Limit: 20}, wantErr: comments.ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, config.CommentsConfig{})
+
+			_, err := service.GetPostComments(1, tt.opts)
+			assert.Equal(t, tt.wantErr, err)
+			repo.AssertNotCalled(t, "GetRoots", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 678901000, time.UTC)
+	tests := []comments.Cursor{
+		{Sort: comments.SortNewest, Time: created, ID: 17},
+		{Sort: comments.SortOldest, Time: created, ID: 1},
+		{Sort: comments.SortHot, Key: 38586.70123456789, ID: 42},
+		{Sort: comments.SortControversial, Key: 0, ID: 3},
+	}
+
+	for _, want := range tests {
+		t.Run(string(want.Sort), func(t *testing.T) {
+			got, err := comments.ParseCursor(want.String(), want.Sort)
+			assert.NoError(t, err)
+			assert.Equal(t, want, *got)
+		})
+	}
+}
+
+// mentionerStub отмечает упоминания и запоминает сохраненные
+type mentionerStub struct {
+	recorded []uint
+}
+
+func (m *mentionerStub) Link(html string) (string, []uint) {
+	return strings.Replace(html, "@alice", `<a href="/users/alice" class="mention">@alice</a>`, 1), []uint{3}
+}
+
+func (m *mentionerStub) Record(subjectType string, subjectID, authorID uint, userIDs []uint) error {
+	m.recorded = append(m.recorded, userIDs...)
+	return nil
+}
+
+func TestCommentsService_CreateCommentMentions(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       comments.ModerationPolicy
+		wantRecorded []uint
+	}{
+		{name: "Published comment notifies mentioned users", policy: comments.ModerationNone, wantRecorded: []uint{3}},
+		{name: "Pending comment waits for approval", policy: comments.ModerationAll},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			mentioner := &mentionerStub{}
+			service := comments.NewCommentService(repo, nil, nil, mentioner, config.CommentsConfig{})
+
+			comment := &comments.Comment{AuthorID: 1, PostID: 1, Content: "Согласен с @alice"}
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published", CommentModeration: tt.policy}, nil)
+			repo.On("Create", comment).Return(nil)
+
+			assert.NoError(t, service.CreateComment(comment))
+			assert.Contains(t, comment.HTMLContent, `class="mention">@alice</a>`)
+			assert.Equal(t, tt.wantRecorded, mentioner.recorded)
+		})
+	}
+}
+
+// mailerStub запоминает отправленные письма
+type mailerStub struct {
+	sent []mail.Message
+}
+
+func (m *mailerStub) Send(msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func guestConfig() config.CommentsConfig {
+	return config.CommentsConfig{
+		Moderation: string(comments.ModerationFirstTime),
+		Guests: config.GuestsConfig{
+			Enabled:    true,
+			ConfirmURL: "https://example.com/comments/confirm?token={token}",
+			ConfirmTTL: time.Hour,
+			Difficulty: 4,
+		},
+	}
+}
+
+func TestCommentsService_CreateGuestComment(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    comments.CommentMode
+		email   string
+		solve   bool
+		mailer  bool
+		wantErr error
+	}{
+		{name: "Guest comment awaits confirmation", email: "guest@example.com", solve: true, mailer: true},
+		{name: "Disabled without mailer", email: "guest@example.com", solve: true, wantErr: comments.ErrGuestsDisabled},
+		{name: "Invalid email", email: "guest@", solve: true, mailer: true, wantErr: comments.ErrInvalidGuest},
+		{name: "Unsolved challenge", email: "guest@example.com", mailer: true, wantErr: comments.ErrInvalidProof},
+		{name: "Members only post", mode: comments.CommentsMembers, email: "guest@example.com", solve: true, mailer: true, wantErr: comments.ErrMembersOnly},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, guestConfig())
+			mailer := &mailerStub{}
+			if tt.mailer {
+				service.UseMailer(mailer)
+			}
+
+			req := comments.GuestCommentRequest{
+				Content: "Спасибо за статью!",
+				PostID:  1,
+				Name:    " Иван ",
+				Email:   tt.email,
+				Nonce:   "0",
+			}
+			if challenge, err := service.GuestChallenge(); err == nil {
+				req.Challenge = challenge.Token
+				if tt.solve {
+					req.Nonce = pow.Solve(*challenge)
+				} else {
+					// При малой сложности случайный nonce может оказаться решением
+					for n := 1; pow.Check(challenge.Token, req.Nonce, challenge.Difficulty); n++ {
+						req.Nonce = strconv.Itoa(n)
+					}
+				}
+			}
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published", CommentMode: tt.mode}, nil).Maybe()
+			repo.On("Create", mock.Anything).Return(nil).Maybe()
+
+			comment, err := service.CreateGuestComment(req)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				repo.AssertNotCalled(t, "Create", mock.Anything)
+				assert.Empty(t, mailer.sent)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, comments.StatusUnconfirmed, comment.Status)
+			assert.True(t, comment.Guest)
+			assert.Equal(t, uint(0), comment.AuthorID)
+			assert.Equal(t, "Иван", comment.GuestName)
+
+			// В письме ссылка с токеном, в комментарии - только его хеш
+			if assert.Len(t, mailer.sent, 1) {
+				assert.Equal(t, "guest@example.com", mailer.sent[0].To)
+				_, token, found := strings.Cut(mailer.sent[0].Body, "?token=")
+				assert.True(t, found)
+				token, _, _ = strings.Cut(token, "\n")
+				sum := sha256.Sum256([]byte(token))
+				assert.Equal(t, hex.EncodeToString(sum[:]), comment.ConfirmTokenHash)
+			}
+
+			// Решенную задачу нельзя использовать повторно
+			_, err = service.CreateGuestComment(req)
+			assert.Equal(t, comments.ErrInvalidProof, err)
+		})
+	}
+}
+
+func TestCommentsService_ConfirmGuestComment(t *testing.T) {
+	token := "confirm-token"
+	sum := sha256.Sum256([]byte(token))
+	tokenHash := hex.EncodeToString(sum[:])
+	inHour := time.Now().Add(time.Hour)
+	hourAgo := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		stored     *comments.Comment
+		wantErr    error
+		wantStatus comments.Status
+	}{
+		{
+			name:       "Confirmed guest comment awaits moderation",
+			stored:     &comments.Comment{ID: 3, PostID: 1, Guest: true, Status: comments.StatusUnconfirmed, ConfirmTokenHash: tokenHash, ConfirmExpiresAt: &inHour},
+			wantStatus: comments.StatusPending,
+		},
+		{
+			name:    "Expired link",
+			stored:  &comments.Comment{ID: 3, PostID: 1, Guest: true, Status: comments.StatusUnconfirmed, ConfirmTokenHash: tokenHash, ConfirmExpiresAt: &hourAgo},
+			wantErr: comments.ErrTokenExpired,
+		},
+		{
+			name:    "Unknown token",
+			wantErr: comments.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.CommentsRepositoryMock)
+			service := comments.NewCommentService(repo, nil, nil, nil, guestConfig())
+			service.UseMailer(&mailerStub{})
+
+			repo.On("GetByConfirmToken", tokenHash).Return(tt.stored, nil)
+			repo.On("GetPostSettings", uint(1)).Return(&comments.PostSettings{Status: "published"}, nil).Maybe()
+			repo.On("Update", mock.Anything).Return(nil).Maybe()
+
+			comment, err := service.ConfirmGuestComment(token)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				repo.AssertNotCalled(t, "Update", mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, comment.Status)
+			assert.Empty(t, comment.ConfirmTokenHash)
+			assert.Nil(t, comment.ConfirmExpiresAt)
+			repo.AssertNotCalled(t, "CountApprovedByAuthor", mock.Anything)
+		})
+	}
+}
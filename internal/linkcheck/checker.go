@@ -0,0 +1,56 @@
+package linkcheck
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/safehttp"
+)
+
+// HTTPChecker проверяет ссылки HTTP-запросами
+type HTTPChecker struct {
+	client *http.Client
+}
+
+// NewHTTPChecker создает проверяющего с указанным таймаутом запроса.
+// Ссылки в постах пишут авторы, поэтому проверка выполняется только
+// по публичным адресам: ссылки во внутреннюю сеть считаются недоступными.
+func NewHTTPChecker(timeout time.Duration) *HTTPChecker {
+	return &HTTPChecker{
+		client: safehttp.NewClient(timeout),
+	}
+}
+
+// Check запрашивает URL методом HEAD, а если сервер его не поддерживает - методом GET.
+// Переходы по редиректам выполняются, в результат попадает итоговый код ответа.
+func (c *HTTPChecker) Check(ctx context.Context, url string) Result {
+	code, err := c.do(ctx, http.MethodHead, url)
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented || code == http.StatusForbidden) {
+		code, err = c.do(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	return Result{StatusCode: code}
+}
+
+// do выполняет запрос и возвращает код ответа, не читая тело целиком
+func (c *HTTPChecker) do(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "blog-service-linkcheck/1.0")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Дочитываем небольшой остаток, чтобы соединение можно было переиспользовать
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,23 @@
+package linkcheck
+
+import "errors"
+
+var (
+	ErrPostNotFound = errors.New("post not found")
+)
+
+// ErrorResponse представляет структуру ответа с ошибкой
+type ErrorResponse struct {
+	Code    int    `json:"code" example:"400" swagger:"description=HTTP код ошибки"`
+	Message string `json:"message" example:"Неверный формат данных" swagger:"description=Описание ошибки"`
+	Details string `json:"details,omitempty" example:"post not found" swagger:"description=Дополнительные детали ошибки"`
+}
+
+// NewErrorResponse создает новый экземпляр ErrorResponse
+func NewErrorResponse(code int, message string, details string) *ErrorResponse {
+	return &ErrorResponse{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
@@ -0,0 +1,113 @@
+package linkcheck
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/middleware"
+)
+
+// Handler обрабатывает HTTP-запросы отчетов о битых ссылках
+type Handler struct {
+	service Service
+	config  *config.Config
+}
+
+// NewHandler создает новый обработчик HTTP-запросов отчетов о битых ссылках
+func NewHandler(service Service, cfg *config.Config) *Handler {
+	return &Handler{
+		service: service,
+		config:  cfg,
+	}
+}
+
+// Register регистрирует все пути обработки HTTP-запросов
+func (h *Handler) Register(router *gin.Engine) {
+	// Битые ссылки поста (автор или админ)
+	router.GET("/api/v1/posts/:id/links/broken", middleware.AuthMiddleware(), h.GetPostBrokenLinks)
+
+	// Отчет по всем постам (только админ)
+	router.GET("/api/v1/links/broken",
+		middleware.AuthMiddleware(), users.RequireRoles(users.RoleAdmin), h.GetBrokenLinks)
+}
+
+// GetPostBrokenLinks возвращает битые ссылки поста по результатам последней проверки
+// @Security JWT
+// @Summary Битые ссылки поста
+// @Tags links
+// @Produce json
+// @Param id path int true "ID поста"
+// @Success 200 {object} PostReport
+// @Failure 400,401,403,404,500 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/links/broken [get]
+func (h *Handler) GetPostBrokenLinks(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid post ID",
+			err.Error(),
+		))
+		return
+	}
+
+	report, err := h.service.GetPostBrokenLinks(uint(postID))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to fetch links"
+
+		if err == ErrPostNotFound {
+			status = http.StatusNotFound
+			message = "Post not found"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	if c.GetUint("userID") != report.AuthorID && users.RoleFromContext(c) != users.RoleAdmin {
+		c.JSON(http.StatusForbidden, NewErrorResponse(
+			http.StatusForbidden,
+			"Unauthorized",
+			"only the author or an admin can view the link report",
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetBrokenLinks возвращает битые ссылки всех постов
+// @Security JWT
+// @Summary Отчет по битым ссылкам
+// @Tags links
+// @Produce json
+// @Param offset query int false "Смещение"
+// @Param limit query int false "Количество записей"
+// @Success 200 {array} Link
+// @Failure 401,403,500 {object} ErrorResponse
+// @Router /api/v1/links/broken [get]
+func (h *Handler) GetBrokenLinks(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	links, err := h.service.GetBrokenLinks(offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to fetch links",
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
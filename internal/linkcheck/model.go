@@ -0,0 +1,112 @@
+// Package linkcheck находит битые исходящие ссылки в постах.
+//
+// Основные компоненты:
+//   - Link: исходящая ссылка поста и результат ее последней проверки
+//   - Checker: проверка доступности URL (подменяется в тестах)
+//   - Repository: интерфейс хранилища
+//   - Service: извлечение ссылок из HTML постов и их проверка
+//     с кэшированием результатов и ограничением частоты запросов
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+)
+
+// Link представляет исходящую ссылку из поста и результат ее проверки
+// @Description Исходящая ссылка поста
+type Link struct {
+	ID         uint       `json:"id" gorm:"primaryKey" example:"1"`
+	PostID     uint       `json:"post_id" gorm:"not null" example:"5"`
+	URL        string     `json:"url" gorm:"size:2048;not null" example:"https://example.org/old-page"`
+	StatusCode int        `json:"status_code" example:"404"`                  // 0 - запрос не удался
+	Error      string     `json:"error,omitempty" gorm:"size:500" example:""` // Ошибка соединения, если была
+	Broken     bool       `json:"broken" gorm:"not null;default:false" example:"true"`
+	CheckedAt  *time.Time `json:"checked_at,omitempty" example:"2025-01-03T12:00:00Z"` // nil - еще не проверялась
+
+	// Заголовок поста, заполняется только в отчете по битым ссылкам
+	PostTitle string `json:"post_title,omitempty" gorm:"->;-:migration" example:"Как настроить Swagger в Go"`
+
+	CreatedAt time.Time `json:"created_at" example:"2025-01-03T12:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2025-01-03T12:00:00Z"`
+}
+
+// TableName задает имя таблицы ссылок
+func (Link) TableName() string {
+	return "post_links"
+}
+
+// Result содержит результат проверки одного URL
+type Result struct {
+	StatusCode int
+	Error      string
+}
+
+// Broken сообщает, считается ли ссылка битой.
+// 429 Too Many Requests говорит об ограничении частоты, а не о недоступности страницы.
+func (r Result) Broken() bool {
+	if r.Error != "" {
+		return true
+	}
+	return r.StatusCode >= http.StatusBadRequest && r.StatusCode != http.StatusTooManyRequests
+}
+
+// Checker проверяет доступность URL.
+// Выделен в интерфейс, чтобы проверку можно было тестировать без сети.
+type Checker interface {
+	// Check запрашивает URL; ошибки соединения возвращаются в Result.Error
+	Check(ctx context.Context, url string) Result
+}
+
+// PostSource предоставляет посты для проверки
+type PostSource interface {
+	// GetPost получает пост по ID
+	GetPost(id uint) (*posts.Post, error)
+	// ListPosts получает список постов с пагинацией
+	ListPosts(opts posts.ListOptions) ([]posts.Post, error)
+}
+
+// Summary содержит итоги прохода проверки
+// @Description Итоги проверки ссылок
+type Summary struct {
+	Posts   int `json:"posts" example:"42"`    // Обработано постов
+	Links   int `json:"links" example:"310"`   // Найдено ссылок
+	Checked int `json:"checked" example:"120"` // Выполнено запросов (без учета кэша)
+	Broken  int `json:"broken" example:"7"`    // Найдено битых ссылок
+}
+
+// PostReport содержит ссылки поста вместе с данными для проверки прав
+// @Description Битые ссылки поста
+type PostReport struct {
+	PostID   uint   `json:"post_id" example:"5"`
+	Title    string `json:"title" example:"Как настроить Swagger в Go"`
+	AuthorID uint   `json:"author_id" example:"3"`
+	Links    []Link `json:"links"`
+}
+
+// Repository описывает методы для работы с хранилищем ссылок
+type Repository interface {
+	// SyncPostLinks приводит набор ссылок поста к urls: добавляет новые и удаляет исчезнувшие
+	SyncPostLinks(postID uint, urls []string) error
+	// SaveResult сохраняет результат проверки ссылки поста
+	SaveResult(postID uint, url string, result Result, checkedAt time.Time) error
+	// ListByPost возвращает ссылки поста (brokenOnly - только битые)
+	ListByPost(postID uint, brokenOnly bool) ([]Link, error)
+	// ListBroken возвращает битые ссылки всех неудаленных постов
+	ListBroken(offset, limit int) ([]Link, error)
+}
+
+// Service описывает бизнес-логику проверки ссылок
+type Service interface {
+	// CheckAll проверяет ссылки всех постов
+	CheckAll(ctx context.Context) (*Summary, error)
+	// CheckPost проверяет ссылки одного поста
+	CheckPost(ctx context.Context, postID uint) (*Summary, error)
+	// GetPostBrokenLinks возвращает битые ссылки поста
+	GetPostBrokenLinks(postID uint) (*PostReport, error)
+	// GetBrokenLinks возвращает отчет по битым ссылкам всех постов
+	GetBrokenLinks(offset, limit int) ([]Link, error)
+}
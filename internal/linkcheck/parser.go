@@ -0,0 +1,59 @@
+package linkcheck
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// extractLinks возвращает уникальные исходящие http(s)-ссылки из HTML поста
+// в порядке появления. Ссылки на хосты из ownHosts (сам блог и API) пропускаются.
+func extractLinks(content string, ownHosts map[string]bool) []string {
+	root, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var links []string
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			if link, ok := outboundURL(attr(n, "href"), ownHosts); ok && !seen[link] {
+				seen[link] = true
+				links = append(links, link)
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return links
+}
+
+// outboundURL нормализует абсолютную внешнюю ссылку, отбрасывая фрагмент
+func outboundURL(raw string, ownHosts map[string]bool) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", false
+	}
+	if ownHosts[strings.ToLower(parsed.Host)] {
+		return "", false
+	}
+	parsed.Fragment = ""
+	return parsed.String(), true
+}
+
+// attr возвращает значение атрибута элемента
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
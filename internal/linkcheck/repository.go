@@ -0,0 +1,83 @@
+package linkcheck
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/database"
+)
+
+// LinkRepository реализует интерфейс Repository для работы с БД
+type LinkRepository struct {
+	database.BaseRepository
+}
+
+// NewLinkRepository создает новый экземпляр репозитория ссылок
+func NewLinkRepository(db *gorm.DB) Repository {
+	return &LinkRepository{
+		BaseRepository: database.NewBaseRepository(db),
+	}
+}
+
+// SyncPostLinks приводит набор ссылок поста к urls.
+// Результаты проверки ссылок, оставшихся в посте, сохраняются.
+func (r *LinkRepository) SyncPostLinks(postID uint, urls []string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Where("post_id = ?", postID)
+		if len(urls) > 0 {
+			stale = stale.Where("url NOT IN ?", urls)
+		}
+		if err := stale.Delete(&Link{}).Error; err != nil {
+			return err
+		}
+		if len(urls) == 0 {
+			return nil
+		}
+
+		links := make([]Link, 0, len(urls))
+		for _, url := range urls {
+			links = append(links, Link{PostID: postID, URL: url})
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+	})
+}
+
+// SaveResult сохраняет результат проверки ссылки поста
+func (r *LinkRepository) SaveResult(postID uint, url string, result Result, checkedAt time.Time) error {
+	return r.DB.Model(&Link{}).
+		Where("post_id = ? AND url = ?", postID, url).
+		Updates(map[string]interface{}{
+			"status_code": result.StatusCode,
+			"error":       result.Error,
+			"broken":      result.Broken(),
+			"checked_at":  checkedAt,
+			"updated_at":  time.Now(),
+		}).Error
+}
+
+// ListByPost возвращает ссылки поста в порядке добавления
+func (r *LinkRepository) ListByPost(postID uint, brokenOnly bool) ([]Link, error) {
+	var links []Link
+	query := r.DB.Where("post_id = ?", postID)
+	if brokenOnly {
+		query = query.Where("broken")
+	}
+	err := query.Order("id ASC").Find(&links).Error
+	return links, err
+}
+
+// ListBroken возвращает битые ссылки с заголовками постов, недавно проверенные первыми.
+// Ссылки постов из корзины в отчет не попадают.
+func (r *LinkRepository) ListBroken(offset, limit int) ([]Link, error) {
+	var links []Link
+	err := r.DB.Select("post_links.*, posts.title AS post_title").
+		Joins("JOIN posts ON posts.id = post_links.post_id AND posts.deleted_at IS NULL").
+		Where("post_links.broken").
+		Order("post_links.checked_at DESC, post_links.id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&links).Error
+	return links, err
+}
@@ -0,0 +1,38 @@
+package mock
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/linkcheck"
+)
+
+type LinkcheckRepositoryMock struct {
+	mock.Mock
+}
+
+func (r *LinkcheckRepositoryMock) SyncPostLinks(postID uint, urls []string) error {
+	args := r.Called(postID, urls)
+	return args.Error(0)
+}
+
+func (r *LinkcheckRepositoryMock) SaveResult(postID uint, url string, result linkcheck.Result, checkedAt time.Time) error {
+	args := r.Called(postID, url, result, checkedAt)
+	return args.Error(0)
+}
+
+func (r *LinkcheckRepositoryMock) ListByPost(postID uint, brokenOnly bool) ([]linkcheck.Link, error) {
+	args := r.Called(postID, brokenOnly)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]linkcheck.Link), args.Error(1)
+}
+
+func (r *LinkcheckRepositoryMock) ListBroken(offset, limit int) ([]linkcheck.Link, error) {
+	args := r.Called(offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]linkcheck.Link), args.Error(1)
+}
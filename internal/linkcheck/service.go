@@ -0,0 +1,234 @@
+package linkcheck
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+)
+
+const (
+	// batchSize - количество постов, загружаемых за один запрос при полном проходе
+	batchSize = 50
+	// maxURLLength совпадает с размером колонки url
+	maxURLLength = 2048
+)
+
+// cachedResult - результат проверки URL с временем проверки
+type cachedResult struct {
+	result    Result
+	checkedAt time.Time
+}
+
+// CheckService реализует проверку исходящих ссылок постов.
+// Один и тот же URL за время CacheTTL запрашивается не больше одного раза,
+// даже если встречается в нескольких постах, а запросы выполняются не чаще
+// RequestsPerSecond. Методы проверки не предназначены для параллельного вызова.
+type CheckService struct {
+	repo     Repository
+	posts    PostSource
+	checker  Checker
+	cfg      config.LinkCheckConfig
+	ownHosts map[string]bool
+	cache    map[string]cachedResult
+	lastCall time.Time
+}
+
+// NewCheckService создает новый экземпляр сервиса проверки ссылок
+func NewCheckService(repo Repository, posts PostSource, checker Checker, site config.SiteConfig, cfg config.LinkCheckConfig) *CheckService {
+	ownHosts := make(map[string]bool)
+	for _, raw := range []string{site.APIURL, site.PostURL} {
+		if parsed, err := url.Parse(raw); err == nil && parsed.Host != "" {
+			ownHosts[strings.ToLower(parsed.Host)] = true
+		}
+	}
+
+	return &CheckService{
+		repo:     repo,
+		posts:    posts,
+		checker:  checker,
+		cfg:      cfg,
+		ownHosts: ownHosts,
+		cache:    make(map[string]cachedResult),
+	}
+}
+
+// CheckAll проверяет ссылки всех постов.
+// При отмене ctx возвращает итоги уже выполненной части вместе с ошибкой.
+func (s *CheckService) CheckAll(ctx context.Context) (*Summary, error) {
+	summary := &Summary{}
+	for offset := 0; ; offset += batchSize {
+		batch, err := s.posts.ListPosts(posts.ListOptions{Offset: offset, Limit: batchSize})
+		if err != nil {
+			return summary, err
+		}
+
+		for i := range batch {
+			if err := s.checkPost(ctx, &batch[i], summary); err != nil {
+				return summary, err
+			}
+		}
+
+		if len(batch) < batchSize {
+			return summary, nil
+		}
+	}
+}
+
+// CheckPost проверяет ссылки одного поста
+func (s *CheckService) CheckPost(ctx context.Context, postID uint) (*Summary, error) {
+	post, err := s.getPost(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+	if err := s.checkPost(ctx, post, summary); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// checkPost синхронизирует набор ссылок поста с его HTML и проверяет их
+func (s *CheckService) checkPost(ctx context.Context, post *posts.Post, summary *Summary) error {
+	var links []string
+	for _, link := range extractLinks(post.HTMLContent, s.ownHosts) {
+		if len(link) <= maxURLLength {
+			links = append(links, link)
+		}
+	}
+
+	if err := s.repo.SyncPostLinks(post.ID, links); err != nil {
+		return err
+	}
+	summary.Posts++
+
+	for _, link := range links {
+		cached, fresh := s.cached(link)
+		if !fresh {
+			if err := s.throttle(ctx); err != nil {
+				return err
+			}
+			cached = cachedResult{result: s.checker.Check(ctx, link), checkedAt: time.Now()}
+			// Отмена посреди запроса - не повод считать ссылку битой
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.cache[link] = cached
+			summary.Checked++
+		}
+
+		if err := s.repo.SaveResult(post.ID, link, cached.result, cached.checkedAt); err != nil {
+			return err
+		}
+		summary.Links++
+		if cached.result.Broken() {
+			summary.Broken++
+		}
+	}
+	return nil
+}
+
+// cached возвращает результат проверки URL, если он еще актуален
+func (s *CheckService) cached(link string) (cachedResult, bool) {
+	cached, ok := s.cache[link]
+	if !ok || time.Since(cached.checkedAt) >= s.cfg.CacheTTL {
+		return cachedResult{}, false
+	}
+	return cached, true
+}
+
+// throttle выдерживает паузу между запросами согласно RequestsPerSecond
+func (s *CheckService) throttle(ctx context.Context) error {
+	if s.cfg.RequestsPerSecond > 0 {
+		interval := time.Duration(float64(time.Second) / s.cfg.RequestsPerSecond)
+		if wait := time.Until(s.lastCall.Add(interval)); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	s.lastCall = time.Now()
+	return nil
+}
+
+// Run периодически проверяет ссылки всех постов до отмены ctx.
+// При Interval <= 0 фоновая проверка отключена.
+func (s *CheckService) Run(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			summary, err := s.CheckAll(ctx)
+			if err != nil {
+				log.Printf("linkcheck: check failed: %v", err)
+				continue
+			}
+			log.Printf("linkcheck: %d posts, %d links, %d requests, %d broken",
+				summary.Posts, summary.Links, summary.Checked, summary.Broken)
+		}
+	}
+}
+
+// GetPostBrokenLinks возвращает битые ссылки поста
+func (s *CheckService) GetPostBrokenLinks(postID uint) (*PostReport, error) {
+	post, err := s.getPost(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.repo.ListByPost(postID, true)
+	if err != nil {
+		return nil, err
+	}
+	if links == nil {
+		links = []Link{}
+	}
+
+	return &PostReport{
+		PostID:   post.ID,
+		Title:    post.Title,
+		AuthorID: post.AuthorID,
+		Links:    links,
+	}, nil
+}
+
+// GetBrokenLinks возвращает отчет по битым ссылкам всех постов
+func (s *CheckService) GetBrokenLinks(offset, limit int) ([]Link, error) {
+	links, err := s.repo.ListBroken(offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if links == nil {
+		links = []Link{}
+	}
+	return links, nil
+}
+
+// getPost получает пост, приводя ошибку отсутствия к ErrPostNotFound
+func (s *CheckService) getPost(postID uint) (*posts.Post, error) {
+	post, err := s.posts.GetPost(postID)
+	if err != nil {
+		if err == posts.ErrPostNotFound {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+	return post, nil
+}
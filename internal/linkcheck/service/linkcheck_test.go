@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/linkcheck"
+	mockRepo "gitlab.com/Nikolay-Yakunin/blog-service/internal/linkcheck/repository/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+)
+
+var testSite = config.SiteConfig{
+	APIURL:  "https://api.example.com",
+	PostURL: "https://blog.example.com/posts/{slug}",
+}
+
+var testConfig = config.LinkCheckConfig{CacheTTL: time.Hour}
+
+// stubChecker отвечает заранее заданными результатами и считает запросы
+type stubChecker struct {
+	results map[string]linkcheck.Result
+	calls   map[string]int
+}
+
+func newStubChecker(results map[string]linkcheck.Result) *stubChecker {
+	return &stubChecker{results: results, calls: make(map[string]int)}
+}
+
+func (c *stubChecker) Check(ctx context.Context, url string) linkcheck.Result {
+	c.calls[url]++
+	return c.results[url]
+}
+
+// stubPosts хранит посты в памяти
+type stubPosts []posts.Post
+
+func (p stubPosts) GetPost(id uint) (*posts.Post, error) {
+	for i := range p {
+		if p[i].ID == id {
+			return &p[i], nil
+		}
+	}
+	return nil, posts.ErrPostNotFound
+}
+
+func (p stubPosts) ListPosts(opts posts.ListOptions) ([]posts.Post, error) {
+	if opts.Offset >= len(p) {
+		return nil, nil
+	}
+	end := opts.Offset + opts.Limit
+	if end > len(p) {
+		end = len(p)
+	}
+	return p[opts.Offset:end], nil
+}
+
+func TestCheckService_CheckPost(t *testing.T) {
+	post := posts.Post{ID: 1, HTMLContent: `<p>
+<a href="https://example.org/ok">ok</a>
+<a href="https://example.org/gone#section">gone</a>
+<a href="https://example.org/gone">gone again</a>
+<a href="https://blog.example.com/posts/other">свой пост</a>
+<a href="/relative">относительная</a>
+<a href="mailto:me@example.org">почта</a>
+<a href="https://down.example.net/">down</a>
+</p>`}
+
+	checker := newStubChecker(map[string]linkcheck.Result{
+		"https://example.org/ok":    {StatusCode: http.StatusOK},
+		"https://example.org/gone":  {StatusCode: http.StatusNotFound},
+		"https://down.example.net/": {Error: "connection refused"},
+	})
+	repo := new(mockRepo.LinkcheckRepositoryMock)
+	service := linkcheck.NewCheckService(repo, stubPosts{post}, checker, testSite, testConfig)
+
+	wantURLs := []string{"https://example.org/ok", "https://example.org/gone", "https://down.example.net/"}
+	repo.On("SyncPostLinks", uint(1), wantURLs).Return(nil)
+	repo.On("SaveResult", uint(1), mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+
+	summary, err := service.CheckPost(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, &linkcheck.Summary{Posts: 1, Links: 3, Checked: 3, Broken: 2}, summary)
+	repo.AssertCalled(t, "SaveResult", uint(1), "https://example.org/gone", linkcheck.Result{StatusCode: http.StatusNotFound}, mock.Anything)
+	repo.AssertNumberOfCalls(t, "SaveResult", 3)
+}
+
+func TestCheckService_CheckAllUsesCache(t *testing.T) {
+	shared := `<a href="https://example.org/shared">ссылка</a>`
+	source := stubPosts{
+		{ID: 1, HTMLContent: shared},
+		{ID: 2, HTMLContent: shared},
+		{ID: 3, HTMLContent: `<p>без ссылок</p>`},
+	}
+
+	checker := newStubChecker(map[string]linkcheck.Result{
+		"https://example.org/shared": {StatusCode: http.StatusTooManyRequests},
+	})
+	repo := new(mockRepo.LinkcheckRepositoryMock)
+	service := linkcheck.NewCheckService(repo, source, checker, testSite, testConfig)
+
+	repo.On("SyncPostLinks", mock.Anything, mock.Anything).Return(nil)
+	repo.On("SaveResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	summary, err := service.CheckAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &linkcheck.Summary{Posts: 3, Links: 2, Checked: 1, Broken: 0}, summary)
+	assert.Equal(t, 1, checker.calls["https://example.org/shared"])
+
+	// Ссылки из поста без ссылок удаляются при синхронизации
+	repo.AssertCalled(t, "SyncPostLinks", uint(3), []string(nil))
+}
+
+func TestCheckService_CheckAllCancelled(t *testing.T) {
+	source := stubPosts{{ID: 1, HTMLContent: `<a href="https://example.org/a">a</a><a href="https://example.org/b">b</a>`}}
+
+	checker := newStubChecker(nil)
+	repo := new(mockRepo.LinkcheckRepositoryMock)
+	service := linkcheck.NewCheckService(repo, source, checker, testSite,
+		config.LinkCheckConfig{RequestsPerSecond: 0.001, CacheTTL: time.Hour})
+
+	repo.On("SyncPostLinks", mock.Anything, mock.Anything).Return(nil)
+	repo.On("SaveResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// Первый запрос выполняется сразу, второй ждет ограничения частоты и прерывается
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	summary, err := service.CheckAll(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, summary.Checked)
+	assert.Equal(t, 0, checker.calls["https://example.org/b"])
+}
+
+func TestCheckService_GetPostBrokenLinks(t *testing.T) {
+	source := stubPosts{{ID: 1, Title: "Пост", AuthorID: 5}}
+
+	tests := []struct {
+		name    string
+		postID  uint
+		wantErr error
+	}{
+		{name: "Success", postID: 1},
+		{name: "Post not found", postID: 2, wantErr: linkcheck.ErrPostNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.LinkcheckRepositoryMock)
+			service := linkcheck.NewCheckService(repo, source, newStubChecker(nil), testSite, testConfig)
+
+			repo.On("ListByPost", tt.postID, true).Return(nil, nil)
+
+			report, err := service.GetPostBrokenLinks(tt.postID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, uint(5), report.AuthorID)
+			assert.Equal(t, []linkcheck.Link{}, report.Links)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package mentions обрабатывает упоминания пользователей вида @username
+// в комментариях и постах.
+//
+// Упоминания ищутся в уже санитизированном HTML: имена активных пользователей
+// заменяются ссылками на профили, упоминания сохраняются, а впервые
+// упомянутые пользователи получают уведомление. Упоминания удаленных
+// и деактивированных пользователей остаются обычным текстом, а при деактивации
+// пользователя ссылки на его профиль убираются из уже сохраненного HTML.
+//
+// Основные компоненты:
+//   - Mention: упоминание пользователя в комментарии или посте
+//   - Repository: интерфейс хранилища
+//   - Service: поиск упоминаний и уведомление упомянутых пользователей
+package mentions
+
+import (
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+)
+
+// Типы объектов, в которых встречаются упоминания
+const (
+	SubjectComment = "comment"
+	SubjectPost    = "post"
+)
+
+// Типы уведомлений об упоминании: subject_id уведомления - ID комментария или поста
+const (
+	NotificationCommentMention = "comment_mention"
+	NotificationPostMention    = "post_mention"
+)
+
+// Mention представляет упоминание пользователя в комментарии или посте
+// @Description Упоминание пользователя
+type Mention struct {
+	ID          uint      `json:"id" gorm:"primaryKey" example:"1"`
+	UserID      uint      `json:"user_id" gorm:"not null;index" example:"42"`             // Упомянутый пользователь
+	AuthorID    uint      `json:"author_id" gorm:"not null" example:"7"`                  // Автор комментария или поста
+	SubjectType string    `json:"subject_type" gorm:"size:20;not null" example:"comment"` // comment или post
+	SubjectID   uint      `json:"subject_id" gorm:"not null" example:"17"`
+	CreatedAt   time.Time `json:"created_at" example:"2025-01-03T11:00:00Z"`
+}
+
+// Notifier создает уведомления пользователей (реализуется notifications.Service)
+type Notifier interface {
+	Notify(userID uint, kind string, subjectID uint, message string) error
+}
+
+// Repository описывает методы для работы с хранилищем упоминаний
+type Repository interface {
+	// GetUserIDs возвращает ID пользователей, упомянутых в объекте
+	GetUserIDs(subjectType string, subjectID uint) ([]uint, error)
+	// Replace заменяет упоминания объекта указанным списком пользователей
+	Replace(subjectType string, subjectID, authorID uint, userIDs []uint) error
+	// UnlinkUser заменяет ссылки link на профиль пользователя в сохраненном HTML
+	// упомянувших его объектов текстом упоминания и возвращает количество измененных объектов
+	UnlinkUser(userID uint, link string) (int64, error)
+}
+
+// Service описывает бизнес-логику работы с упоминаниями
+type Service interface {
+	// Link заменяет упоминания в санитизированном HTML ссылками на профили
+	// и возвращает ID упомянутых пользователей
+	Link(html string) (string, []uint)
+	// Record сохраняет упоминания объекта и уведомляет впервые упомянутых пользователей
+	Record(subjectType string, subjectID, authorID uint, userIDs []uint) error
+	// Unlink убирает ссылки на профиль деактивированного пользователя из упомянувших его объектов
+	Unlink(user users.User)
+}
@@ -0,0 +1,100 @@
+package mentions
+
+import (
+	"regexp"
+
+	"gorm.io/gorm"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/database"
+)
+
+// MentionRepository реализует интерфейс Repository для работы с БД
+type MentionRepository struct {
+	database.BaseRepository
+}
+
+// NewMentionRepository создает новый экземпляр репозитория упоминаний
+func NewMentionRepository(db *gorm.DB) Repository {
+	return &MentionRepository{
+		BaseRepository: database.NewBaseRepository(db),
+	}
+}
+
+// GetUserIDs возвращает ID пользователей, упомянутых в объекте
+func (r *MentionRepository) GetUserIDs(subjectType string, subjectID uint) ([]uint, error) {
+	var ids []uint
+	err := r.DB.Model(&Mention{}).
+		Where("subject_type = ? AND subject_id = ?", subjectType, subjectID).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// mentionTables - таблицы объектов с упоминаниями по типу объекта
+var mentionTables = map[string]string{
+	SubjectComment: "comments",
+	SubjectPost:    "posts",
+}
+
+// UnlinkUser заменяет ссылки на профиль пользователя в html_content упомянувших его
+// комментариев и постов текстом упоминания. Версия измененных объектов увеличивается,
+// чтобы сменились их ETag.
+func (r *MentionRepository) UnlinkUser(userID uint, link string) (int64, error) {
+	pattern := `<a href="` + regexp.QuoteMeta(link) + `" class="mention">(@[^<]*)</a>`
+
+	var affected int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		for subjectType, table := range mentionTables {
+			mentioned := tx.Model(&Mention{}).Select("subject_id").
+				Where("user_id = ? AND subject_type = ?", userID, subjectType)
+			result := tx.Table(table).
+				Where("id IN (?) AND html_content ~ ?", mentioned, pattern).
+				Updates(map[string]interface{}{
+					"html_content": gorm.Expr("regexp_replace(html_content, ?, ?, 'g')", pattern, `\1`),
+					"version":      gorm.Expr("version + 1"),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			affected += result.RowsAffected
+		}
+		return nil
+	})
+	return affected, err
+}
+
+// Replace заменяет упоминания объекта указанным списком пользователей.
+// Сохраненные упоминания тех же пользователей не пересоздаются.
+func (r *MentionRepository) Replace(subjectType string, subjectID, authorID uint, userIDs []uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Where("subject_type = ? AND subject_id = ?", subjectType, subjectID)
+		if len(userIDs) > 0 {
+			stale = stale.Where("user_id NOT IN ?", userIDs)
+		}
+		if err := stale.Delete(&Mention{}).Error; err != nil {
+			return err
+		}
+
+		var existing []uint
+		if err := tx.Model(&Mention{}).
+			Where("subject_type = ? AND subject_id = ?", subjectType, subjectID).
+			Pluck("user_id", &existing).Error; err != nil {
+			return err
+		}
+		saved := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			saved[id] = true
+		}
+
+		var added []Mention
+		for _, id := range userIDs {
+			if !saved[id] {
+				saved[id] = true
+				added = append(added, Mention{UserID: id, AuthorID: authorID, SubjectType: subjectType, SubjectID: subjectID})
+			}
+		}
+		if len(added) == 0 {
+			return nil
+		}
+		return tx.Create(&added).Error
+	})
+}
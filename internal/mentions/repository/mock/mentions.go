@@ -0,0 +1,27 @@
+package mock
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+type MentionsRepositoryMock struct {
+	mock.Mock
+}
+
+func (r *MentionsRepositoryMock) GetUserIDs(subjectType string, subjectID uint) ([]uint, error) {
+	args := r.Called(subjectType, subjectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (r *MentionsRepositoryMock) Replace(subjectType string, subjectID, authorID uint, userIDs []uint) error {
+	args := r.Called(subjectType, subjectID, authorID, userIDs)
+	return args.Error(0)
+}
+
+func (r *MentionsRepositoryMock) UnlinkUser(userID uint, link string) (int64, error) {
+	args := r.Called(userID, link)
+	return args.Get(0).(int64), args.Error(1)
+}
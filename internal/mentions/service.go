@@ -0,0 +1,226 @@
+package mentions
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+)
+
+// maxMentions ограничивает количество разных имен, проверяемых в одном тексте.
+// Остальные упоминания остаются текстом и не создают уведомлений.
+const maxMentions = 10
+
+// mentionPattern находит @username; имя начинается и заканчивается буквой, цифрой или "_"
+var mentionPattern = regexp.MustCompile(`@([A-Za-z0-9_](?:[A-Za-z0-9_.-]{0,62}[A-Za-z0-9_])?)`)
+
+// subjects задает тип и текст уведомления для типов объектов
+var subjects = map[string]struct{ kind, where string }{
+	SubjectComment: {NotificationCommentMention, "в комментарии"},
+	SubjectPost:    {NotificationPostMention, "в посте"},
+}
+
+// MentionService реализует бизнес-логику работы с упоминаниями
+type MentionService struct {
+	repo     Repository
+	users    users.Repository
+	notifier Notifier // Может быть nil - уведомления не отправляются
+	site     config.SiteConfig
+}
+
+// NewMentionService создает новый экземпляр сервиса упоминаний
+func NewMentionService(repo Repository, users users.Repository, notifier Notifier, site config.SiteConfig) Service {
+	return &MentionService{
+		repo:     repo,
+		users:    users,
+		notifier: notifier,
+		site:     site,
+	}
+}
+
+// Link заменяет упоминания в санитизированном HTML ссылками на профили.
+// Упоминания внутри ссылок и кода не обрабатываются. Упоминания неизвестных,
+// деактивированных и удаленных (федерации) пользователей остаются текстом.
+func (s *MentionService) Link(content string) (string, []uint) {
+	if !strings.Contains(content, "@") {
+		return content, nil
+	}
+
+	var (
+		b        strings.Builder
+		ids      []uint
+		resolved = make(map[string]*users.User)
+		skip     = 0
+	)
+	z := nethtml.NewTokenizer(strings.NewReader(content))
+	for {
+		switch z.Next() {
+		case nethtml.ErrorToken:
+			if z.Err() != io.EOF {
+				return content, nil
+			}
+			return b.String(), ids
+		case nethtml.StartTagToken:
+			if name, _ := z.TagName(); isOpaque(string(name)) {
+				skip++
+			}
+		case nethtml.EndTagToken:
+			if name, _ := z.TagName(); isOpaque(string(name)) && skip > 0 {
+				skip--
+			}
+		case nethtml.TextToken:
+			if skip == 0 {
+				b.WriteString(s.linkText(string(z.Raw()), resolved, &ids))
+				continue
+			}
+		}
+		b.Write(z.Raw())
+	}
+}
+
+// isOpaque сообщает, что внутри элемента упоминания не ищутся
+func isOpaque(tag string) bool {
+	return tag == "a" || tag == "code" || tag == "pre"
+}
+
+// linkText заменяет упоминания в текстовом фрагменте HTML.
+// resolved кэширует найденных пользователей (nil - упоминание остается текстом).
+func (s *MentionService) linkText(text string, resolved map[string]*users.User, ids *[]uint) string {
+	matches := mentionPattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		// Часть адреса почты или ссылки, либо адрес пользователя федерации @name@host
+		if start > 0 && isNameBoundary(text[start-1]) || end < len(text) && text[end] == '@' {
+			continue
+		}
+
+		name := text[m[2]:m[3]]
+		user, ok := resolved[name]
+		if !ok {
+			if len(resolved) < maxMentions {
+				user = s.resolve(name)
+			}
+			resolved[name] = user
+			if user != nil {
+				*ids = append(*ids, user.ID)
+			}
+		}
+		if user == nil {
+			continue
+		}
+
+		b.WriteString(text[last:start])
+		fmt.Fprintf(&b, `<a href="%s" class="mention">@%s</a>`, html.EscapeString(s.site.ProfileLink(user.Username)), name)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isNameBoundary сообщает, что символ перед "@" продолжает слово, адрес почты или путь
+func isNameBoundary(c byte) bool {
+	return c == '@' || c == '/' || c == '.' || c == '_' || c == '-' ||
+		c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+// resolve находит пользователя, которого можно упомянуть
+func (s *MentionService) resolve(username string) *users.User {
+	user, err := s.users.GetByUsername(username)
+	if err != nil {
+		log.Printf("Failed to resolve mention @%s: %v", username, err)
+		return nil
+	}
+	if user == nil || !user.IsActive || user.IsRemote() {
+		return nil
+	}
+	return user
+}
+
+// Record сохраняет упоминания объекта и уведомляет впервые упомянутых пользователей.
+// Повторное сохранение после редактирования не уведомляет пользователей повторно.
+// Автор не получает уведомление об упоминании себя, а пользователь, заблокировавший автора,
+// не получает уведомлений от него; отключенные типы уведомлений учитывает Notifier.
+func (s *MentionService) Record(subjectType string, subjectID, authorID uint, userIDs []uint) error {
+	subject, ok := subjects[subjectType]
+	if !ok {
+		return fmt.Errorf("unknown mention subject %q", subjectType)
+	}
+
+	previous, err := s.repo.GetUserIDs(subjectType, subjectID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch mentions: %w", err)
+	}
+	if err := s.repo.Replace(subjectType, subjectID, authorID, userIDs); err != nil {
+		return fmt.Errorf("failed to save mentions: %w", err)
+	}
+	if s.notifier == nil {
+		return nil
+	}
+
+	notified := make(map[uint]bool, len(previous)+1)
+	for _, id := range previous {
+		notified[id] = true
+	}
+	notified[authorID] = true
+
+	message := ""
+	for _, id := range userIDs {
+		if notified[id] {
+			continue
+		}
+		notified[id] = true
+
+		blocked, err := s.users.IsBlocked(id, authorID)
+		if err != nil {
+			log.Printf("Failed to check block of user %d by %d: %v", authorID, id, err)
+			continue
+		}
+		if blocked {
+			continue
+		}
+
+		if message == "" {
+			message = s.message(subject.where, authorID)
+		}
+		if err := s.notifier.Notify(id, subject.kind, subjectID, message); err != nil {
+			log.Printf("Failed to notify user %d of mention in %s %d: %v", id, subjectType, subjectID, err)
+		}
+	}
+	return nil
+}
+
+// message формирует текст уведомления об упоминании
+func (s *MentionService) message(where string, authorID uint) string {
+	author, err := s.users.GetByID(authorID)
+	if err != nil || author == nil {
+		return "Вас упомянули " + where
+	}
+	return fmt.Sprintf("@%s упоминает вас %s", author.Username, where)
+}
+
+// Unlink убирает ссылки на профиль деактивированного пользователя из сохраненного
+// HTML упомянувших его комментариев и постов: упоминания остаются текстом, как
+// в новых текстах. Вызывается после деактивации (users.DeactivateListener),
+// поэтому ошибка записывается в журнал.
+func (s *MentionService) Unlink(user users.User) {
+	if user.Username == "" {
+		return
+	}
+	link := html.EscapeString(s.site.ProfileLink(user.Username))
+	if _, err := s.repo.UnlinkUser(user.ID, link); err != nil {
+		log.Printf("Failed to unlink mentions of user %d: %v", user.ID, err)
+	}
+}
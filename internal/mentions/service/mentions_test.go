@@ -0,0 +1,144 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/mentions"
+	mockRepo "gitlab.com/Nikolay-Yakunin/blog-service/internal/mentions/repository/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	mockUsers "gitlab.com/Nikolay-Yakunin/blog-service/internal/users/repository/mock"
+)
+
+var site = config.SiteConfig{ProfileURL: "https://example.com/users/{username}"}
+
+// notifierStub запоминает получателей уведомлений
+type notifierStub struct {
+	recipients []uint
+	messages   []string
+}
+
+func (n *notifierStub) Notify(userID uint, kind string, subjectID uint, message string) error {
+	n.recipients = append(n.recipients, userID)
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+func TestMentionService_Link(t *testing.T) {
+	tests := []struct {
+		name    string
+		html    string
+		want    string
+		wantIDs []uint
+	}{
+		{
+			name:    "Active user becomes a profile link",
+			html:    "<p>Спасибо, @alice!</p>",
+			want:    `<p>Спасибо, <a href="https://example.com/users/alice" class="mention">@alice</a>!</p>`,
+			wantIDs: []uint{1},
+		},
+		{
+			name: "Deactivated user stays plain text",
+			html: "<p>@bob, привет</p>",
+			want: "<p>@bob, привет</p>",
+		},
+		{
+			name: "Unknown user stays plain text",
+			html: "<p>@nobody</p>",
+			want: "<p>@nobody</p>",
+		},
+		{
+			name: "Code, links, emails and remote handles are ignored",
+			html: `<p><code>@alice</code> <a href="https://example.com">@alice</a> alice@example.com @alice@mastodon.social</p>`,
+			want: `<p><code>@alice</code> <a href="https://example.com">@alice</a> alice@example.com @alice@mastodon.social</p>`,
+		},
+		{
+			name:    "Repeated mention is resolved once",
+			html:    "<p>@alice @alice</p>",
+			want:    `<p><a href="https://example.com/users/alice" class="mention">@alice</a> <a href="https://example.com/users/alice" class="mention">@alice</a></p>`,
+			wantIDs: []uint{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(mockUsers.UsersRepositoryMock)
+			service := mentions.NewMentionService(new(mockRepo.MentionsRepositoryMock), userRepo, nil, site)
+
+			userRepo.On("GetByUsername", "alice").Return(&users.User{ID: 1, Username: "alice", IsActive: true}, nil).Maybe()
+			userRepo.On("GetByUsername", "bob").Return(&users.User{ID: 2, Username: "bob", IsActive: false}, nil).Maybe()
+			userRepo.On("GetByUsername", "nobody").Return(nil, nil).Maybe()
+
+			got, ids := service.Link(tt.html)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantIDs, ids)
+			if tt.name == "Repeated mention is resolved once" {
+				userRepo.AssertNumberOfCalls(t, "GetByUsername", 1)
+			}
+		})
+	}
+}
+
+func TestMentionService_Record(t *testing.T) {
+	tests := []struct {
+		name           string
+		previous       []uint
+		mentioned      []uint
+		blocked        bool
+		wantRecipients []uint
+	}{
+		{
+			name:           "New mention is notified",
+			mentioned:      []uint{3},
+			wantRecipients: []uint{3},
+		},
+		{
+			name:      "Edit does not notify again",
+			previous:  []uint{3},
+			mentioned: []uint{3},
+		},
+		{
+			name:      "Self mention is not notified",
+			mentioned: []uint{7},
+		},
+		{
+			name:      "User who blocked the author is not notified",
+			mentioned: []uint{3},
+			blocked:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.MentionsRepositoryMock)
+			userRepo := new(mockUsers.UsersRepositoryMock)
+			notifier := &notifierStub{}
+			service := mentions.NewMentionService(repo, userRepo, notifier, site)
+
+			repo.On("GetUserIDs", mentions.SubjectComment, uint(17)).Return(tt.previous, nil)
+			repo.On("Replace", mentions.SubjectComment, uint(17), uint(7), tt.mentioned).Return(nil)
+			userRepo.On("IsBlocked", mock.Anything, uint(7)).Return(tt.blocked, nil).Maybe()
+			userRepo.On("GetByID", uint(7)).Return(&users.User{ID: 7, Username: "carol"}, nil).Maybe()
+
+			err := service.Record(mentions.SubjectComment, 17, 7, tt.mentioned)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRecipients, notifier.recipients)
+			if len(tt.wantRecipients) > 0 {
+				assert.Equal(t, "@carol упоминает вас в комментарии", notifier.messages[0])
+			}
+		})
+	}
+}
+
+func TestMentionService_Unlink(t *testing.T) {
+	repo := new(mockRepo.MentionsRepositoryMock)
+	service := mentions.NewMentionService(repo, new(mockUsers.UsersRepositoryMock), nil, site)
+
+	// Ссылка совпадает с той, что Link вставил в HTML
+	repo.On("UnlinkUser", uint(3), "https://example.com/users/bob").Return(int64(2), nil)
+	service.Unlink(users.User{ID: 3, Username: "bob"})
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,238 @@
+package notifications
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/mail"
+)
+
+// maxDigestItems ограничивает количество уведомлений, перечисленных в дайджесте
+const maxDigestItems = 50
+
+// emailEnabled сообщает, приходят ли уведомления типа kind на email.
+// Без настройки пользователя действуют типы из настроек сервиса.
+func (s *NotificationService) emailEnabled(preference *Preference, kind string) bool {
+	if s.mailer == nil {
+		return false
+	}
+	if preference != nil && preference.Email != nil {
+		return *preference.Email
+	}
+	for _, t := range s.email.Types {
+		if t == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliverEmails отправляет на email уведомления, ожидающие дольше задержки.
+// Пользователь получает не больше одного письма за интервал: уведомления,
+// накопившиеся с прошлого письма, приходят одним дайджестом.
+// Ошибка отправки одному пользователю не мешает остальным: его уведомления
+// остаются в очереди до следующего вызова.
+func (s *NotificationService) DeliverEmails() (int, error) {
+	if s.mailer == nil {
+		return 0, nil
+	}
+
+	now := time.Now()
+	userIDs, err := s.repo.PendingEmailUsers(now.Add(-s.email.Delay))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch pending emails: %w", err)
+	}
+
+	sent := 0
+	for _, userID := range userIDs {
+		ok, err := s.deliver(userID, now)
+		if err != nil {
+			log.Printf("Failed to email notifications to user %d: %v", userID, err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// deliver отправляет пользователю одно письмо с ожидающими уведомлениями.
+// Уведомления, которые не должны приходить на email (пользователь отписался,
+// учетная запись отключена или принадлежит удаленному актору федерации),
+// снимаются с очереди без отправки.
+func (s *NotificationService) deliver(userID uint, now time.Time) (bool, error) {
+	last, err := s.repo.LastEmailedAt(userID)
+	if err != nil {
+		return false, err
+	}
+	if last != nil && now.Sub(*last) < s.email.Interval {
+		return false, nil
+	}
+
+	pending, err := s.repo.GetPendingEmails(userID)
+	if err != nil {
+		return false, err
+	}
+	preferences, err := s.repo.GetPreferences(userID)
+	if err != nil {
+		return false, err
+	}
+	byType := make(map[string]*Preference, len(preferences))
+	for i := range preferences {
+		byType[preferences[i].Type] = &preferences[i]
+	}
+	user, err := s.recipients.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	deliverable := user != nil && user.IsActive && !user.IsRemote() && mail.ValidAddress(user.Email)
+
+	var items []Notification
+	var dropped []uint
+	for _, notification := range pending {
+		if deliverable && s.emailEnabled(byType[notification.Type], notification.Type) {
+			items = append(items, notification)
+		} else {
+			dropped = append(dropped, notification.ID)
+		}
+	}
+	if len(dropped) > 0 {
+		if err := s.repo.MarkEmailed(dropped, nil); err != nil {
+			return false, err
+		}
+	}
+	if len(items) == 0 {
+		return false, nil
+	}
+
+	if err := s.mailer.Send(s.emailMessage(user.Email, userID, items)); err != nil {
+		return false, err
+	}
+	ids := make([]uint, len(items))
+	for i, notification := range items {
+		ids[i] = notification.ID
+	}
+	return true, s.repo.MarkEmailed(ids, &now)
+}
+
+// emailMessage формирует письмо с одним уведомлением или дайджест из нескольких.
+// Ссылка отписки отключает письма всех типов, вошедших в письмо.
+func (s *NotificationService) emailMessage(to string, userID uint, items []Notification) mail.Message {
+	var kinds []string
+	seen := make(map[string]bool)
+	for _, notification := range items {
+		if !seen[notification.Type] {
+			seen[notification.Type] = true
+			kinds = append(kinds, notification.Type)
+		}
+	}
+	link := s.unsubscribeLink(userID, kinds)
+
+	var subject string
+	var body strings.Builder
+	if len(items) == 1 {
+		subject = items[0].Message
+		body.WriteString(items[0].Message + "\n")
+	} else {
+		subject = fmt.Sprintf("Новые уведомления: %d", len(items))
+		for i, notification := range items {
+			if i == maxDigestItems {
+				fmt.Fprintf(&body, "...и еще %d\n", len(items)-maxDigestItems)
+				break
+			}
+			fmt.Fprintf(&body, "- %s\n", notification.Message)
+		}
+	}
+	fmt.Fprintf(&body, "\nОтписаться от таких писем: %s\n", link)
+
+	return mail.Message{
+		To:      to,
+		Subject: subject,
+		Body:    body.String(),
+		Headers: map[string]string{
+			// Отписка в один клик из почтового клиента (RFC 8058)
+			"List-Unsubscribe":      "<" + link + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}
+}
+
+// unsubscribeLink возвращает ссылку отписки пользователя от писем типов kinds
+func (s *NotificationService) unsubscribeLink(userID uint, kinds []string) string {
+	return s.apiURL + "/api/v1/notifications/unsubscribe?token=" + url.QueryEscape(s.unsubscribeToken(userID, kinds))
+}
+
+// unsubscribeToken подписывает пользователя и типы уведомлений.
+// У токена нет срока действия: ссылка из старого письма тоже должна работать.
+func (s *NotificationService) unsubscribeToken(userID uint, kinds []string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(userID), 10) + ":" + strings.Join(kinds, ",")))
+	return payload + "." + s.sign(payload)
+}
+
+// sign возвращает подпись ссылки отписки
+func (s *NotificationService) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("unsubscribe:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseUnsubscribeToken проверяет подпись токена и возвращает пользователя и типы уведомлений
+func (s *NotificationService) parseUnsubscribeToken(token string) (uint, []string, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || len(s.secret) == 0 || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return 0, nil, ErrInvalidUnsubscribe
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, nil, ErrInvalidUnsubscribe
+	}
+	id, list, ok := strings.Cut(string(raw), ":")
+	userID, err := strconv.ParseUint(id, 10, 64)
+	if !ok || err != nil || userID == 0 || list == "" {
+		return 0, nil, ErrInvalidUnsubscribe
+	}
+	return uint(userID), strings.Split(list, ","), nil
+}
+
+// CheckUnsubscribe проверяет подпись ссылки отписки, не меняя настроек.
+// Используется страницей подтверждения, которую открывает переход по ссылке.
+func (s *NotificationService) CheckUnsubscribe(token string) error {
+	_, _, err := s.parseUnsubscribeToken(token)
+	return err
+}
+
+// Unsubscribe отключает отправку на email типов уведомлений из подписанной ссылки.
+// Уведомления внутри сервиса остаются включенными или отключенными, как были.
+func (s *NotificationService) Unsubscribe(token string) error {
+	userID, kinds, err := s.parseUnsubscribeToken(token)
+	if err != nil {
+		return err
+	}
+
+	off := false
+	preferences := make([]Preference, 0, len(kinds))
+	for _, kind := range kinds {
+		current, err := s.repo.GetPreference(userID, kind)
+		if err != nil {
+			return fmt.Errorf("failed to fetch notification preferences: %w", err)
+		}
+		preferences = append(preferences, Preference{
+			UserID:  userID,
+			Type:    kind,
+			Enabled: current == nil || current.Enabled,
+			Email:   &off,
+		})
+	}
+	if err := s.repo.SavePreferences(preferences); err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return nil
+}
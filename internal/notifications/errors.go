@@ -0,0 +1,26 @@
+package notifications
+
+import "errors"
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrInvalidNotification  = errors.New("notification must have a recipient, a type and a message")
+	ErrInvalidPreference    = errors.New("notification preference must have a type of at most 50 characters")
+	ErrInvalidUnsubscribe   = errors.New("invalid unsubscribe link")
+)
+
+// ErrorResponse представляет структуру ответа с ошибкой
+type ErrorResponse struct {
+	Code    int    `json:"code" example:"404" swagger:"description=HTTP код ошибки"`
+	Message string `json:"message" example:"Notification not found" swagger:"description=Описание ошибки"`
+	Details string `json:"details,omitempty" example:"notification not found" swagger:"description=Дополнительные детали ошибки"`
+}
+
+// NewErrorResponse создает новый экземпляр ErrorResponse
+func NewErrorResponse(code int, message string, details string) *ErrorResponse {
+	return &ErrorResponse{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
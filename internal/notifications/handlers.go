@@ -0,0 +1,276 @@
+package notifications
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/middleware"
+)
+
+// Handler обрабатывает HTTP-запросы уведомлений текущего пользователя
+type Handler struct {
+	service Service
+	config  *config.Config
+}
+
+// NewHandler создает новый обработчик HTTP-запросов уведомлений
+func NewHandler(service Service, cfg *config.Config) *Handler {
+	return &Handler{
+		service: service,
+		config:  cfg,
+	}
+}
+
+// Register регистрирует все пути обработки HTTP-запросов
+func (h *Handler) Register(router *gin.Engine) {
+	notificationsAPI := router.Group("/api/v1/notifications")
+
+	// Ссылка отписки из письма работает без авторизации: ее подлинность подтверждает подпись.
+	// GET только показывает страницу подтверждения, т.к. ссылки из писем открывают
+	// и почтовые сервисы при проверке. Отписывает POST: кнопка на странице
+	// или отписка в один клик из почтового клиента (List-Unsubscribe-Post)
+	notificationsAPI.GET("/unsubscribe", h.UnsubscribePage)
+	notificationsAPI.POST("/unsubscribe", h.Unsubscribe)
+
+	notificationsAPI.Use(middleware.AuthMiddleware())
+	{
+		notificationsAPI.GET("", h.ListNotifications)
+		notificationsAPI.PUT("/read", h.MarkAllRead)
+		notificationsAPI.PUT("/:id/read", h.MarkRead)
+		notificationsAPI.GET("/preferences", h.GetPreferences)
+		notificationsAPI.PUT("/preferences", h.UpdatePreferences)
+	}
+}
+
+// ListNotifications возвращает уведомления текущего пользователя
+// Количество непрочитанных уведомлений передается в заголовке X-Unread-Count.
+// @Security JWT
+// @Summary Уведомления пользователя
+// @Tags notifications
+// @Produce json
+// @Param unread query bool false "Только непрочитанные"
+// @Param offset query int false "Смещение"
+// @Param limit query int false "Количество записей"
+// @Success 200 {array} Notification
+// @Header 200 {integer} X-Unread-Count "Количество непрочитанных уведомлений"
+// @Failure 401,500 {object} ErrorResponse
+// @Router /api/v1/notifications [get]
+func (h *Handler) ListNotifications(c *gin.Context) {
+	unreadOnly, _ := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	list, unread, err := h.service.ListNotifications(c.GetUint("userID"), unreadOnly, offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to fetch notifications",
+			err.Error(),
+		))
+		return
+	}
+
+	c.Header("X-Unread-Count", strconv.FormatInt(unread, 10))
+	c.JSON(http.StatusOK, list)
+}
+
+// MarkRead отмечает уведомление прочитанным
+// @Security JWT
+// @Summary Отметить уведомление прочитанным
+// @Tags notifications
+// @Param id path int true "ID уведомления"
+// @Success 204 "No Content"
+// @Failure 400,401,404,500 {object} ErrorResponse
+// @Router /api/v1/notifications/{id}/read [put]
+func (h *Handler) MarkRead(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid notification ID",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := h.service.MarkRead(c.GetUint("userID"), uint(id)); err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to update notification"
+
+		if err == ErrNotificationNotFound {
+			status = http.StatusNotFound
+			message = "Notification not found"
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MarkAllRead отмечает прочитанными все уведомления текущего пользователя
+// @Security JWT
+// @Summary Отметить все уведомления прочитанными
+// @Tags notifications
+// @Success 204 "No Content"
+// @Failure 401,500 {object} ErrorResponse
+// @Router /api/v1/notifications/read [put]
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	if err := h.service.MarkAllRead(c.GetUint("userID")); err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to update notifications",
+			err.Error(),
+		))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetPreferences возвращает настройки уведомлений текущего пользователя
+// Типы уведомлений без настройки включены.
+// @Security JWT
+// @Summary Настройки уведомлений
+// @Tags notifications
+// @Produce json
+// @Success 200 {array} Preference
+// @Failure 401,500 {object} ErrorResponse
+// @Router /api/v1/notifications/preferences [get]
+func (h *Handler) GetPreferences(c *gin.Context) {
+	preferences, err := h.service.GetPreferences(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to fetch notification preferences",
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences включает или отключает типы уведомлений текущего пользователя
+// @Security JWT
+// @Summary Изменить настройки уведомлений
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param preferences body []Preference true "Настройки по типам уведомлений"
+// @Success 200 {array} Preference
+// @Failure 400,401,500 {object} ErrorResponse
+// @Router /api/v1/notifications/preferences [put]
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	var preferences []Preference
+	if err := c.ShouldBindJSON(&preferences); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid preferences data",
+			err.Error(),
+		))
+		return
+	}
+
+	updated, err := h.service.UpdatePreferences(c.GetUint("userID"), preferences)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to update notification preferences"
+
+		if err == ErrInvalidPreference {
+			status = http.StatusBadRequest
+			message = "Invalid preferences data"
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// unsubscribePage - страница подтверждения отписки, форма отправляет POST на тот же адрес
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+  <head><meta charset="utf-8"><title>Отписка от писем</title></head>
+  <body>
+    {{if .Valid}}
+    <form method="post" action="?token={{.Token}}">
+      <p>Отключить письма об этих уведомлениях? Уведомления на сайте останутся.</p>
+      <button type="submit">Отписаться</button>
+    </form>
+    {{else}}
+    <p>Ссылка отписки недействительна.</p>
+    {{end}}
+  </body>
+</html>
+`))
+
+// UnsubscribePage показывает страницу подтверждения отписки по ссылке из письма.
+// Настройки не меняются: отписывает только POST с той же ссылкой.
+// @Summary Страница отписки от писем
+// @Tags notifications
+// @Produce html
+// @Param token query string true "Токен из ссылки отписки"
+// @Success 200 "Страница с кнопкой подтверждения"
+// @Failure 400 "Ссылка недействительна"
+// @Router /api/v1/notifications/unsubscribe [get]
+func (h *Handler) UnsubscribePage(c *gin.Context) {
+	token := c.Query("token")
+	status := http.StatusOK
+	valid := h.service.CheckUnsubscribe(token) == nil
+	if !valid {
+		status = http.StatusBadRequest
+	}
+
+	var page bytes.Buffer
+	if err := unsubscribePage.Execute(&page, struct {
+		Valid bool
+		Token string
+	}{valid, token}); err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
+
+// Unsubscribe отключает письма по подписанной ссылке из письма
+// @Summary Отписаться от писем
+// @Description Отключает отправку на email типов уведомлений, вошедших в письмо.
+// @Description Уведомления внутри сервиса не отключаются.
+// @Tags notifications
+// @Param token query string true "Токен из ссылки отписки"
+// @Success 204 "No Content"
+// @Failure 400,500 {object} ErrorResponse
+// @Router /api/v1/notifications/unsubscribe [post]
+func (h *Handler) Unsubscribe(c *gin.Context) {
+	if err := h.service.Unsubscribe(c.Query("token")); err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to unsubscribe"
+
+		if err == ErrInvalidUnsubscribe {
+			status = http.StatusBadRequest
+			message = "Invalid unsubscribe link"
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
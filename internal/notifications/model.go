@@ -0,0 +1,116 @@
+// Package notifications хранит уведомления пользователей внутри сервиса.
+//
+// Другие пакеты создают уведомления через Service.Notify, не завися от этого
+// пакета: каждый из них объявляет собственный интерфейс уведомителя
+// с такой же сигнатурой.
+//
+// Уведомления выбранных типов дополнительно приходят на email. Письма
+// отправляются в фоне с задержкой: если за это время набралось несколько
+// уведомлений, они приходят одним письмом-дайджестом. В каждом письме есть
+// подписанная ссылка отписки, работающая без входа в учетную запись.
+//
+// Основные компоненты:
+//   - Notification: уведомление пользователя
+//   - Preference: отключение уведомлений выбранного типа и их отправки на email
+//   - Recipients: адреса получателей писем
+//   - Repository: интерфейс хранилища
+//   - Service: создание уведомлений и работа со списком уведомлений пользователя
+package notifications
+
+import (
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+)
+
+// Notification представляет уведомление пользователя
+// @Description Уведомление пользователя
+type Notification struct {
+	ID     uint   `json:"id" gorm:"primaryKey" example:"1"`
+	UserID uint   `json:"user_id" gorm:"not null;index" example:"42"`
+	Type   string `json:"type" gorm:"size:50;not null" example:"comment_rejected"` // Задается пакетом-источником
+	// ID объекта, к которому относится уведомление (комментария, поста); тип объекта определяется Type
+	SubjectID uint       `json:"subject_id" example:"17"`
+	Message   string     `json:"message" gorm:"type:text;not null" example:"Ваш комментарий отклонен модератором: реклама"`
+	ReadAt    *time.Time `json:"read_at,omitempty" example:"2025-01-03T12:00:00Z"` // nil - не прочитано
+	CreatedAt time.Time  `json:"created_at" example:"2025-01-03T11:00:00Z"`
+
+	EmailPending bool       `json:"-" gorm:"not null;default:false"` // Ожидает отправки на email
+	EmailedAt    *time.Time `json:"-"`                               // Когда ушло письмо с уведомлением
+}
+
+// Preference хранит настройку пользователя для одного типа уведомлений.
+// Отсутствие записи означает, что уведомления этого типа включены,
+// а на email приходят типы из настроек сервиса.
+// @Description Настройка уведомлений
+type Preference struct {
+	UserID  uint   `json:"-" gorm:"primaryKey"`
+	Type    string `json:"type" gorm:"primaryKey;size:50" example:"mention"`
+	Enabled bool   `json:"enabled" gorm:"not null" example:"false"`
+	// Отправлять уведомления этого типа на email; null - как задано в настройках сервиса
+	Email     *bool     `json:"email" example:"false"`
+	UpdatedAt time.Time `json:"updated_at" example:"2025-01-03T11:00:00Z"`
+}
+
+// TableName задает имя таблицы настроек уведомлений
+func (Preference) TableName() string {
+	return "notification_preferences"
+}
+
+// Recipients находит получателей писем (реализуется users.Repository)
+type Recipients interface {
+	// GetByID возвращает пользователя или nil, если его нет
+	GetByID(id uint) (*users.User, error)
+}
+
+// Repository описывает методы для работы с хранилищем уведомлений
+type Repository interface {
+	// Create сохраняет новое уведомление
+	Create(notification *Notification) error
+	// ListByUser возвращает уведомления пользователя, новые первыми
+	ListByUser(userID uint, unreadOnly bool, offset, limit int) ([]Notification, error)
+	// CountUnread возвращает количество непрочитанных уведомлений пользователя
+	CountUnread(userID uint) (int64, error)
+	// MarkRead отмечает уведомление пользователя прочитанным.
+	// Возвращает false, если у пользователя нет такого уведомления.
+	MarkRead(userID, id uint, at time.Time) (bool, error)
+	// MarkAllRead отмечает прочитанными все уведомления пользователя
+	MarkAllRead(userID uint, at time.Time) error
+	// GetPreferences возвращает сохраненные настройки уведомлений пользователя
+	GetPreferences(userID uint) ([]Preference, error)
+	// SavePreferences создает или обновляет настройки уведомлений
+	SavePreferences(preferences []Preference) error
+	// GetPreference возвращает настройку пользователя для типа уведомлений или nil, если ее нет
+	GetPreference(userID uint, kind string) (*Preference, error)
+	// PendingEmailUsers возвращает пользователей с уведомлениями, созданными до before
+	// и ожидающими отправки на email
+	PendingEmailUsers(before time.Time) ([]uint, error)
+	// GetPendingEmails возвращает уведомления пользователя, ожидающие отправки на email, старые первыми
+	GetPendingEmails(userID uint) ([]Notification, error)
+	// LastEmailedAt возвращает время последнего письма пользователю или nil, если писем не было
+	LastEmailedAt(userID uint) (*time.Time, error)
+	// MarkEmailed снимает с уведомлений ожидание отправки; at nil - письмо не отправлялось
+	MarkEmailed(ids []uint, at *time.Time) error
+}
+
+// Service описывает бизнес-логику работы с уведомлениями
+type Service interface {
+	// Notify создает уведомление для пользователя, если он не отключил уведомления этого типа
+	Notify(userID uint, kind string, subjectID uint, message string) error
+	// ListNotifications возвращает уведомления пользователя и количество непрочитанных
+	ListNotifications(userID uint, unreadOnly bool, offset, limit int) ([]Notification, int64, error)
+	// MarkRead отмечает уведомление прочитанным
+	MarkRead(userID, id uint) error
+	// MarkAllRead отмечает прочитанными все уведомления пользователя
+	MarkAllRead(userID uint) error
+	// GetPreferences возвращает настройки уведомлений пользователя
+	GetPreferences(userID uint) ([]Preference, error)
+	// UpdatePreferences сохраняет настройки уведомлений пользователя
+	UpdatePreferences(userID uint, preferences []Preference) ([]Preference, error)
+	// DeliverEmails отправляет накопившиеся уведомления на email и возвращает количество писем
+	DeliverEmails() (int, error)
+	// CheckUnsubscribe проверяет подпись ссылки отписки, не меняя настроек
+	CheckUnsubscribe(token string) error
+	// Unsubscribe отключает отправку на email типов уведомлений из подписанной ссылки
+	Unsubscribe(token string) error
+}
@@ -0,0 +1,147 @@
+package notifications
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/database"
+)
+
+// NotificationRepository реализует интерфейс Repository для работы с БД
+type NotificationRepository struct {
+	database.BaseRepository
+}
+
+// NewNotificationRepository создает новый экземпляр репозитория уведомлений
+func NewNotificationRepository(db *gorm.DB) Repository {
+	return &NotificationRepository{
+		BaseRepository: database.NewBaseRepository(db),
+	}
+}
+
+// Create сохраняет новое уведомление
+func (r *NotificationRepository) Create(notification *Notification) error {
+	return r.DB.Create(notification).Error
+}
+
+// ListByUser возвращает уведомления пользователя, новые первыми
+func (r *NotificationRepository) ListByUser(userID uint, unreadOnly bool, offset, limit int) ([]Notification, error) {
+	query := r.DB.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []Notification
+	err := query.Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// CountUnread возвращает количество непрочитанных уведомлений пользователя
+func (r *NotificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead отмечает уведомление пользователя прочитанным.
+// Повторная отметка не меняет время прочтения.
+func (r *NotificationRepository) MarkRead(userID, id uint, at time.Time) (bool, error) {
+	var count int64
+	if err := r.DB.Model(&Notification{}).Where("id = ? AND user_id = ?", id, userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, nil
+	}
+
+	err := r.DB.Model(&Notification{}).
+		Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", at).Error
+	return err == nil, err
+}
+
+// MarkAllRead отмечает прочитанными все уведомления пользователя
+func (r *NotificationRepository) MarkAllRead(userID uint, at time.Time) error {
+	return r.DB.Model(&Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", at).Error
+}
+
+// GetPreferences возвращает сохраненные настройки уведомлений пользователя
+func (r *NotificationRepository) GetPreferences(userID uint) ([]Preference, error) {
+	var preferences []Preference
+	err := r.DB.Where("user_id = ?", userID).Order("type").Find(&preferences).Error
+	return preferences, err
+}
+
+// SavePreferences создает или обновляет настройки уведомлений
+func (r *NotificationRepository) SavePreferences(preferences []Preference) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "email", "updated_at"}),
+	}).Create(&preferences).Error
+}
+
+// GetPreference возвращает настройку пользователя для типа уведомлений или nil, если ее нет
+func (r *NotificationRepository) GetPreference(userID uint, kind string) (*Preference, error) {
+	var preferences []Preference
+	if err := r.DB.Where("user_id = ? AND type = ?", userID, kind).Limit(1).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+	if len(preferences) == 0 {
+		return nil, nil
+	}
+	return &preferences[0], nil
+}
+
+// PendingEmailUsers возвращает пользователей с уведомлениями, созданными до before
+// и ожидающими отправки на email
+func (r *NotificationRepository) PendingEmailUsers(before time.Time) ([]uint, error) {
+	var userIDs []uint
+	err := r.DB.Model(&Notification{}).
+		Where("email_pending AND created_at < ?", before).
+		Distinct().
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// GetPendingEmails возвращает уведомления пользователя, ожидающие отправки на email, старые первыми
+func (r *NotificationRepository) GetPendingEmails(userID uint) ([]Notification, error) {
+	var notifications []Notification
+	err := r.DB.Where("user_id = ? AND email_pending", userID).
+		Order("created_at, id").
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// LastEmailedAt возвращает время последнего письма пользователю или nil, если писем не было
+func (r *NotificationRepository) LastEmailedAt(userID uint) (*time.Time, error) {
+	var last sql.NullTime
+	err := r.DB.Model(&Notification{}).
+		Where("user_id = ?", userID).
+		Select("MAX(emailed_at)").
+		Row().
+		Scan(&last)
+	if err != nil || !last.Valid {
+		return nil, err
+	}
+	return &last.Time, nil
+}
+
+// MarkEmailed снимает с уведомлений ожидание отправки; at nil - письмо не отправлялось
+func (r *NotificationRepository) MarkEmailed(ids []uint, at *time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB.Model(&Notification{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{"email_pending": false, "emailed_at": at}).Error
+}
@@ -0,0 +1,90 @@
+package mock
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/notifications"
+)
+
+type NotificationsRepositoryMock struct {
+	mock.Mock
+}
+
+func (r *NotificationsRepositoryMock) Create(notification *notifications.Notification) error {
+	args := r.Called(notification)
+	return args.Error(0)
+}
+
+func (r *NotificationsRepositoryMock) ListByUser(userID uint, unreadOnly bool, offset, limit int) ([]notifications.Notification, error) {
+	args := r.Called(userID, unreadOnly, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]notifications.Notification), args.Error(1)
+}
+
+func (r *NotificationsRepositoryMock) CountUnread(userID uint) (int64, error) {
+	args := r.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *NotificationsRepositoryMock) MarkRead(userID, id uint, at time.Time) (bool, error) {
+	args := r.Called(userID, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (r *NotificationsRepositoryMock) MarkAllRead(userID uint, at time.Time) error {
+	args := r.Called(userID, at)
+	return args.Error(0)
+}
+
+func (r *NotificationsRepositoryMock) GetPreferences(userID uint) ([]notifications.Preference, error) {
+	args := r.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]notifications.Preference), args.Error(1)
+}
+
+func (r *NotificationsRepositoryMock) SavePreferences(preferences []notifications.Preference) error {
+	args := r.Called(preferences)
+	return args.Error(0)
+}
+
+func (r *NotificationsRepositoryMock) GetPreference(userID uint, kind string) (*notifications.Preference, error) {
+	args := r.Called(userID, kind)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*notifications.Preference), args.Error(1)
+}
+
+func (r *NotificationsRepositoryMock) PendingEmailUsers(before time.Time) ([]uint, error) {
+	args := r.Called(before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (r *NotificationsRepositoryMock) GetPendingEmails(userID uint) ([]notifications.Notification, error) {
+	args := r.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]notifications.Notification), args.Error(1)
+}
+
+func (r *NotificationsRepositoryMock) LastEmailedAt(userID uint) (*time.Time, error) {
+	args := r.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (r *NotificationsRepositoryMock) MarkEmailed(ids []uint, at *time.Time) error {
+	args := r.Called(ids, at)
+	return args.Error(0)
+}
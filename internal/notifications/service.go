@@ -0,0 +1,129 @@
+package notifications
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/mail"
+)
+
+// NotificationService реализует бизнес-логику работы с уведомлениями
+type NotificationService struct {
+	repo Repository
+
+	// Отправка уведомлений на email; mailer nil - письма не отправляются
+	mailer     mail.Sender
+	recipients Recipients
+	email      config.NotificationEmailConfig
+	apiURL     string
+	secret     []byte // Ключ подписи ссылок отписки
+}
+
+// NewNotificationService создает новый экземпляр сервиса уведомлений
+func NewNotificationService(repo Repository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// UseEmail включает отправку уведомлений на email.
+// Ссылки отписки ведут на API сайта и подписываются ключом secret.
+func (s *NotificationService) UseEmail(mailer mail.Sender, recipients Recipients, cfg config.NotificationEmailConfig, site config.SiteConfig, secret string) {
+	s.mailer = mailer
+	s.recipients = recipients
+	s.email = cfg
+	s.apiURL = site.APIURL
+	s.secret = []byte(secret)
+}
+
+// maxTypeLength - максимальная длина типа уведомления
+const maxTypeLength = 50
+
+// Notify создает уведомление для пользователя.
+// Если пользователь отключил уведомления этого типа, уведомление не создается.
+// Уведомление ставится в очередь писем, если тип приходит пользователю на email.
+func (s *NotificationService) Notify(userID uint, kind string, subjectID uint, message string) error {
+	if userID == 0 || kind == "" || message == "" {
+		return ErrInvalidNotification
+	}
+
+	preference, err := s.repo.GetPreference(userID, kind)
+	if err != nil {
+		return fmt.Errorf("failed to check notification preferences: %w", err)
+	}
+	if preference != nil && !preference.Enabled {
+		return nil
+	}
+
+	return s.repo.Create(&Notification{
+		UserID:       userID,
+		Type:         kind,
+		SubjectID:    subjectID,
+		Message:      message,
+		EmailPending: s.emailEnabled(preference, kind),
+	})
+}
+
+// ListNotifications возвращает уведомления пользователя и количество непрочитанных
+func (s *NotificationService) ListNotifications(userID uint, unreadOnly bool, offset, limit int) ([]Notification, int64, error) {
+	list, err := s.repo.ListByUser(userID, unreadOnly, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	if list == nil {
+		list = []Notification{}
+	}
+	return list, unread, nil
+}
+
+// MarkRead отмечает уведомление прочитанным
+func (s *NotificationService) MarkRead(userID, id uint) error {
+	found, err := s.repo.MarkRead(userID, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead отмечает прочитанными все уведомления пользователя
+func (s *NotificationService) MarkAllRead(userID uint) error {
+	return s.repo.MarkAllRead(userID, time.Now())
+}
+
+// GetPreferences возвращает настройки уведомлений пользователя.
+// Типы без настройки не возвращаются: уведомления этих типов включены.
+func (s *NotificationService) GetPreferences(userID uint) ([]Preference, error) {
+	preferences, err := s.repo.GetPreferences(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notification preferences: %w", err)
+	}
+	if preferences == nil {
+		preferences = []Preference{}
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences сохраняет настройки уведомлений пользователя и возвращает все его настройки
+func (s *NotificationService) UpdatePreferences(userID uint, preferences []Preference) ([]Preference, error) {
+	for i := range preferences {
+		if preferences[i].Type == "" || len(preferences[i].Type) > maxTypeLength {
+			return nil, ErrInvalidPreference
+		}
+		preferences[i].UserID = userID
+	}
+
+	if len(preferences) > 0 {
+		if err := s.repo.SavePreferences(preferences); err != nil {
+			return nil, fmt.Errorf("failed to save notification preferences: %w", err)
+		}
+	}
+	return s.GetPreferences(userID)
+}
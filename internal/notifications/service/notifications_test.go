@@ -0,0 +1,282 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/notifications"
+	mockRepo "gitlab.com/Nikolay-Yakunin/blog-service/internal/notifications/repository/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/mail"
+)
+
+func TestNotificationService_Notify(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   uint
+		message  string
+		disabled bool
+		wantErr  error
+	}{
+		{name: "Success", userID: 1, message: "Ваш комментарий отклонен"},
+		{name: "Type disabled by user", userID: 1, message: "Ваш комментарий отклонен", disabled: true},
+		{name: "No recipient", userID: 0, message: "Ваш комментарий отклонен", wantErr: notifications.ErrInvalidNotification},
+		{name: "Empty message", userID: 1, wantErr: notifications.ErrInvalidNotification},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.NotificationsRepositoryMock)
+			service := notifications.NewNotificationService(repo)
+
+			var preference *notifications.Preference
+			if tt.disabled {
+				preference = &notifications.Preference{Type: "comment_rejected", Enabled: false}
+			}
+			repo.On("GetPreference", tt.userID, "comment_rejected").Return(preference, nil).Maybe()
+			repo.On("Create", mock.MatchedBy(func(n *notifications.Notification) bool {
+				return n.UserID == tt.userID && n.Type == "comment_rejected" && n.SubjectID == 7 && !n.EmailPending
+			})).Return(nil).Maybe()
+
+			err := service.Notify(tt.userID, "comment_rejected", 7, tt.message)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.disabled {
+				repo.AssertNotCalled(t, "Create", mock.Anything)
+			} else if tt.wantErr == nil {
+				repo.AssertNumberOfCalls(t, "Create", 1)
+			}
+		})
+	}
+}
+
+func TestNotificationService_MarkRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		found   bool
+		wantErr error
+	}{
+		{name: "Success", found: true},
+		{name: "Someone else's notification", found: false, wantErr: notifications.ErrNotificationNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.NotificationsRepositoryMock)
+			service := notifications.NewNotificationService(repo)
+
+			repo.On("MarkRead", uint(1), uint(5), mock.AnythingOfType("time.Time")).Return(tt.found, nil)
+
+			assert.Equal(t, tt.wantErr, service.MarkRead(1, 5))
+		})
+	}
+}
+
+func TestNotificationService_UpdatePreferences(t *testing.T) {
+	tests := []struct {
+		name        string
+		preferences []notifications.Preference
+		wantErr     error
+	}{
+		{
+			name:        "Disable mentions",
+			preferences: []notifications.Preference{{Type: "mention", Enabled: false}},
+		},
+		{
+			name:        "Empty type",
+			preferences: []notifications.Preference{{Enabled: false}},
+			wantErr:     notifications.ErrInvalidPreference,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.NotificationsRepositoryMock)
+			service := notifications.NewNotificationService(repo)
+
+			repo.On("SavePreferences", mock.MatchedBy(func(p []notifications.Preference) bool {
+				return len(p) == 1 && p[0].UserID == 3
+			})).Return(nil).Maybe()
+			repo.On("GetPreferences", uint(3)).Return(tt.preferences, nil).Maybe()
+
+			updated, err := service.UpdatePreferences(3, tt.preferences)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				repo.AssertNumberOfCalls(t, "SavePreferences", 1)
+				assert.Len(t, updated, 1)
+			} else {
+				repo.AssertNotCalled(t, "SavePreferences", mock.Anything)
+			}
+		})
+	}
+}
+
+// mailerStub запоминает отправленные письма
+type mailerStub struct {
+	sent []mail.Message
+}
+
+func (m *mailerStub) Send(msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// recipientsStub возвращает пользователей по ID
+type recipientsStub map[uint]*users.User
+
+func (r recipientsStub) GetByID(id uint) (*users.User, error) {
+	return r[id], nil
+}
+
+func newEmailService(repo *mockRepo.NotificationsRepositoryMock, recipients recipientsStub) (*notifications.NotificationService, *mailerStub) {
+	mailer := &mailerStub{}
+	service := notifications.NewNotificationService(repo)
+	service.UseEmail(mailer, recipients, config.NotificationEmailConfig{
+		Enabled:  true,
+		Types:    []string{"comment_reply", "comment_on_post"},
+		Delay:    5 * time.Minute,
+		Interval: time.Hour,
+	}, config.SiteConfig{APIURL: "https://api.example.com"}, "secret")
+	return service, mailer
+}
+
+func TestNotificationService_NotifyQueuesEmail(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		name        string
+		kind        string
+		preference  *notifications.Preference
+		wantPending bool
+	}{
+		{name: "Default email type", kind: "comment_reply", wantPending: true},
+		{name: "Not an email type by default", kind: "comment_mention"},
+		{name: "User turned email off", kind: "comment_reply", preference: &notifications.Preference{Enabled: true, Email: &off}},
+		{name: "User turned email on", kind: "comment_mention", preference: &notifications.Preference{Enabled: true, Email: &on}, wantPending: true},
+		{name: "Email setting without preference for the type", kind: "comment_reply", preference: &notifications.Preference{Enabled: true}, wantPending: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.NotificationsRepositoryMock)
+			service, _ := newEmailService(repo, nil)
+
+			repo.On("GetPreference", uint(1), tt.kind).Return(tt.preference, nil)
+			repo.On("Create", mock.MatchedBy(func(n *notifications.Notification) bool {
+				return n.EmailPending == tt.wantPending
+			})).Return(nil)
+
+			assert.NoError(t, service.Notify(1, tt.kind, 7, "Новый ответ на ваш комментарий"))
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNotificationService_DeliverEmails(t *testing.T) {
+	repo := new(mockRepo.NotificationsRepositoryMock)
+	service, mailer := newEmailService(repo, recipientsStub{
+		1: {ID: 1, Email: "alice@example.com", IsActive: true},
+		2: {ID: 2, Email: "bob@example.com", IsActive: true},
+		3: {ID: 3, Email: "carol@remote.example", IsActive: true, Provider: users.ProviderActivityPub},
+	})
+
+	off := false
+	recent := time.Now().Add(-10 * time.Minute)
+	repo.On("PendingEmailUsers", mock.AnythingOfType("time.Time")).Return([]uint{1, 2, 3}, nil)
+
+	// Два уведомления приходят одним дайджестом, отключенный на email тип снимается с очереди
+	repo.On("LastEmailedAt", uint(1)).Return(nil, nil)
+	repo.On("GetPendingEmails", uint(1)).Return([]notifications.Notification{
+		{ID: 1, UserID: 1, Type: "comment_reply", Message: "Новый ответ на ваш комментарий"},
+		{ID: 2, UserID: 1, Type: "comment_on_post", Message: "Новый комментарий к вашему посту"},
+		{ID: 3, UserID: 1, Type: "thread_comment", Message: "Новый комментарий в обсуждении"},
+	}, nil)
+	repo.On("GetPreferences", uint(1)).Return([]notifications.Preference{{Type: "thread_comment", Enabled: true, Email: &off}}, nil)
+	repo.On("MarkEmailed", []uint{3}, (*time.Time)(nil)).Return(nil)
+	repo.On("MarkEmailed", []uint{1, 2}, mock.AnythingOfType("*time.Time")).Return(nil)
+
+	// Письмо уже было в пределах интервала: уведомления ждут следующего дайджеста
+	repo.On("LastEmailedAt", uint(2)).Return(&recent, nil)
+
+	// Удаленному актору федерации письма не отправляются
+	repo.On("LastEmailedAt", uint(3)).Return(nil, nil)
+	repo.On("GetPendingEmails", uint(3)).Return([]notifications.Notification{{ID: 5, UserID: 3, Type: "comment_reply", Message: "Ответ"}}, nil)
+	repo.On("GetPreferences", uint(3)).Return(nil, nil)
+	repo.On("MarkEmailed", []uint{5}, (*time.Time)(nil)).Return(nil)
+
+	sent, err := service.DeliverEmails()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "GetPendingEmails", uint(2))
+
+	assert.Len(t, mailer.sent, 1)
+	msg := mailer.sent[0]
+	assert.Equal(t, "alice@example.com", msg.To)
+	assert.Equal(t, "Новые уведомления: 2", msg.Subject)
+	assert.Contains(t, msg.Body, "- Новый ответ на ваш комментарий\n- Новый комментарий к вашему посту\n")
+	assert.NotContains(t, msg.Body, "обсуждении")
+	assert.True(t, strings.HasPrefix(msg.Headers["List-Unsubscribe"], "<https://api.example.com/api/v1/notifications/unsubscribe?token="))
+	assert.Equal(t, "List-Unsubscribe=One-Click", msg.Headers["List-Unsubscribe-Post"])
+}
+
+func TestNotificationService_Unsubscribe(t *testing.T) {
+	repo := new(mockRepo.NotificationsRepositoryMock)
+	service, mailer := newEmailService(repo, recipientsStub{1: {ID: 1, Email: "alice@example.com", IsActive: true}})
+
+	// Получаем ссылку отписки из письма
+	repo.On("PendingEmailUsers", mock.AnythingOfType("time.Time")).Return([]uint{1}, nil)
+	repo.On("LastEmailedAt", uint(1)).Return(nil, nil)
+	repo.On("GetPendingEmails", uint(1)).Return([]notifications.Notification{
+		{ID: 1, UserID: 1, Type: "comment_reply", Message: "Новый ответ"},
+		{ID: 2, UserID: 1, Type: "comment_on_post", Message: "Новый комментарий"},
+	}, nil)
+	repo.On("GetPreferences", uint(1)).Return(nil, nil)
+	repo.On("MarkEmailed", []uint{1, 2}, mock.AnythingOfType("*time.Time")).Return(nil)
+	_, err := service.DeliverEmails()
+	assert.NoError(t, err)
+	link, err := url.Parse(strings.Trim(mailer.sent[0].Headers["List-Unsubscribe"], "<>"))
+	assert.NoError(t, err)
+	token := link.Query().Get("token")
+
+	// Отписка отключает только письма; отключенные в сервисе уведомления остаются отключенными
+	repo.On("GetPreference", uint(1), "comment_reply").Return(nil, nil)
+	repo.On("GetPreference", uint(1), "comment_on_post").Return(&notifications.Preference{Enabled: false}, nil)
+	repo.On("SavePreferences", mock.MatchedBy(func(p []notifications.Preference) bool {
+		return len(p) == 2 &&
+			p[0].UserID == 1 && p[0].Type == "comment_reply" && p[0].Enabled && !*p[0].Email &&
+			p[1].UserID == 1 && p[1].Type == "comment_on_post" && !p[1].Enabled && !*p[1].Email
+	})).Return(nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	notifications.NewHandler(service, &config.Config{}).Register(router)
+	request := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	// Переход по ссылке только показывает страницу подтверждения
+	page := request(http.MethodGet, link.RequestURI())
+	assert.Equal(t, http.StatusOK, page.Code)
+	assert.Contains(t, page.Body.String(), `method="post"`)
+	assert.Contains(t, page.Body.String(), url.QueryEscape(token))
+	repo.AssertNotCalled(t, "SavePreferences", mock.Anything)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/api/v1/notifications/unsubscribe?token=garbage").Code)
+
+	// Отписывает POST по той же ссылке
+	assert.Equal(t, http.StatusNoContent, request(http.MethodPost, link.RequestURI()).Code)
+
+	// Подделанный токен не принимается
+	for _, forged := range []string{"", "garbage", token + "x", "Mjpjb21tZW50X3JlcGx5" + token[strings.Index(token, "."):]} {
+		assert.Equal(t, notifications.ErrInvalidUnsubscribe, service.Unsubscribe(forged), forged)
+	}
+	repo.AssertNumberOfCalls(t, "SavePreferences", 1)
+}
@@ -0,0 +1,52 @@
+package posts
+
+import (
+	"fmt"
+	"html"
+)
+
+// Размеры карточки поста в ответе oEmbed по умолчанию
+const (
+	defaultEmbedWidth  = 600
+	defaultEmbedHeight = 200
+)
+
+// postShortcode встраивает карточку другого поста блога: {{< post SLUG >}}
+// Карточка фиксируется при рендеринге, поэтому после переименования
+// встроенного поста ее обновит только повторное сохранение.
+func (s *PostService) postShortcode(args []string) (string, bool) {
+	if len(args) != 1 {
+		return "", false
+	}
+	post, err := s.publishedBySlug(args[0])
+	if err != nil {
+		return "", false
+	}
+	return s.postEmbed(post), true
+}
+
+// postEmbed формирует карточку поста со ссылкой на его публичную страницу
+func (s *PostService) postEmbed(post *Post) string {
+	caption := ""
+	if post.Description != "" {
+		caption = "<figcaption>" + html.EscapeString(post.Description) + "</figcaption>"
+	}
+	return fmt.Sprintf(
+		`<figure class="embed embed-post"><a href="%s">%s</a>%s</figure>`,
+		html.EscapeString(s.site.PostLink(post.Slug)),
+		html.EscapeString(post.Title),
+		caption,
+	)
+}
+
+// embedSize ограничивает размеры карточки значениями maxwidth/maxheight запроса
+func embedSize(maxWidth, maxHeight int) (int, int) {
+	width, height := defaultEmbedWidth, defaultEmbedHeight
+	if maxWidth > 0 && maxWidth < width {
+		width = maxWidth
+	}
+	if maxHeight > 0 && maxHeight < height {
+		height = maxHeight
+	}
+	return width, height
+}
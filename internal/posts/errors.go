@@ -16,8 +16,44 @@ var (
 	// ErrInvalidStatus возвращается при попытке установить недопустимый статус поста
 	ErrInvalidStatus = errors.New("недопустимый статус поста")
 
+	// ErrInvalidModeration возвращается при недопустимой политике модерации комментариев
+	ErrInvalidModeration = errors.New("недопустимая политика модерации комментариев")
+
+	// ErrInvalidCommentMode возвращается при недопустимом режиме комментариев
+	ErrInvalidCommentMode = errors.New("недопустимый режим комментариев")
+
+	// ErrInvalidArchivePeriod возвращается при некорректном годе или месяце архива
+	ErrInvalidArchivePeriod = errors.New("некорректный период архива")
+
+	// ErrInvalidExpiry возвращается, если срок закрепления или избранного уже прошел
+	ErrInvalidExpiry = errors.New("срок действия должен быть в будущем")
+
+	// ErrVersionConflict возвращается, если пост был изменен после того, как клиент его получил
+	ErrVersionConflict = errors.New("пост был изменен другим пользователем")
+
+	// ErrSlugConflict возвращается при восстановлении поста, слаг которого уже занят
+	ErrSlugConflict = errors.New("слаг уже занят другим постом")
+
+	// ErrUnsupportedURL возвращается, если адрес для oEmbed не является ссылкой на пост
+	ErrUnsupportedURL = errors.New("адрес не является ссылкой на пост")
+
+	// ErrBulkTargets возвращается, если в массовой операции не указаны ровно одно из: IDs или фильтр
+	ErrBulkTargets = errors.New("укажите либо список ID постов, либо непустой фильтр")
+
+	// ErrBulkOperation возвращается при неизвестной операции или некорректных ее параметрах
+	ErrBulkOperation = errors.New("некорректная массовая операция")
+
+	// ErrBulkTooLarge возвращается, если массовая операция затрагивает слишком много постов
+	ErrBulkTooLarge = errors.New("массовая операция затрагивает слишком много постов")
+
+	// ErrDraftNotFound возвращается, когда у пользователя нет автосохраненного черновика
+	ErrDraftNotFound = errors.New("черновик не найден")
+
 	// ErrUnauthorized возвращается при попытке выполнить операцию без необходимых прав
 	ErrUnauthorized = errors.New("недостаточно прав для выполнения операции")
+
+	// ErrHiddenByModeration возвращается при попытке опубликовать пост, скрытый модерацией по жалобам
+	ErrHiddenByModeration = errors.New("пост скрыт модерацией и не может быть опубликован")
 )
 
 // ErrorResponse представляет структуру ответа с ошибкой
@@ -35,3 +71,9 @@ func NewErrorResponse(code int, message string, details string) *ErrorResponse {
 		Details: details,
 	}
 }
+
+// VersionConflictResponse возвращается с кодом 412, когда If-Match не совпадает с текущей версией
+type VersionConflictResponse struct {
+	ErrorResponse
+	CurrentVersion uint `json:"current_version" example:"4" swagger:"description=Актуальная версия ресурса"`
+}
@@ -1,19 +1,38 @@
 package posts
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/events"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/httpcache"
 	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/middleware"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/query"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/ratelimit"
+)
+
+// Параметры пагинации архива и избранного по умолчанию и их предельные значения
+const (
+	defaultArchiveLimit  = 10
+	maxArchiveLimit      = 100
+	defaultFeaturedLimit = 5
+	maxFeaturedLimit     = 50
 )
 
 // Handler обрабатывает HTTP-запросы для работы с постами
 type Handler struct {
-	service Service
-	config  *config.Config
+	service    Service
+	config     *config.Config
+	rateLimits ratelimit.Store // Хранилище ограничений частоты запросов, nil - без ограничений
+	stream     *events.Hub     // Поток новых публикаций, nil - поток недоступен
 }
 
 // NewHandler создает новый обработчик HTTP-запросов для постов
@@ -24,38 +43,92 @@ func NewHandler(service Service, cfg *config.Config) *Handler {
 	}
 }
 
+// UseRateLimit включает ограничение частоты создания постов (config.RateLimit.Posts).
+// Вызывать нужно до Register.
+func (h *Handler) UseRateLimit(store ratelimit.Store) {
+	h.rateLimits = store
+}
+
+// UseStream включает поток событий о новых публикациях (GET /posts/stream).
+// Вызывать нужно до Register.
+func (h *Handler) UseStream(hub *events.Hub) {
+	h.stream = hub
+}
+
 // Register регистрирует все пути обработки HTTP-запросов
 func (h *Handler) Register(router *gin.Engine) {
+	// Провайдер oEmbed для встраивания постов на другие сайты
+	router.GET("/oembed", h.OEmbed)
+
 	posts := router.Group("/api/v1/posts")
 	{
 		// Публичные эндпоинты
 		posts.GET("", h.ListPosts)
 		posts.GET("/:id", h.GetPost)
 		posts.GET("/slug/:slug", h.GetPostBySlug)
+		posts.GET("/archive", h.GetArchive)
+		posts.GET("/archive/:year/:month", h.GetArchiveMonth)
+		posts.GET("/featured", h.GetFeaturedPosts)
+		if h.stream != nil {
+			posts.GET("/stream", middleware.OptionalAuthMiddleware(), h.StreamPosts)
+		}
 
 		// Защищенные эндпоинты
 		authorized := posts.Use(middleware.AuthMiddleware())
 		{
-			authorized.POST("", h.CreatePost)
+			authorized.POST("", middleware.RateLimit(h.rateLimits, "posts", h.config.RateLimit.Posts), h.CreatePost)
 			authorized.PUT("/:id", h.UpdatePost)
 			authorized.DELETE("/:id", h.DeletePost)
+
+			// Корзина: просмотр, восстановление и окончательное удаление
+			authorized.GET("/trash", h.GetTrash)
+			authorized.POST("/:id/restore", h.RestorePost)
+			authorized.DELETE("/:id/purge", h.PurgePost)
+
+			// Предпросмотр и автосохранение черновиков
+			authorized.POST("/preview", h.PreviewPost)
+			authorized.GET("/autosave", h.GetDraft)
+			authorized.PUT("/autosave", h.AutosaveDraft)
+			authorized.DELETE("/autosave", h.DiscardDraft)
+			authorized.GET("/:id/autosave", h.GetDraft)
+			authorized.PUT("/:id/autosave", h.AutosaveDraft)
+			authorized.DELETE("/:id/autosave", h.DiscardDraft)
+
+			// Закрепление и избранное (только администраторы)
+			editor := users.RequireRoles(users.RoleAdmin)
+			authorized.PUT("/:id/pin", editor, h.PinPost)
+			authorized.DELETE("/:id/pin", editor, h.UnpinPost)
+			authorized.PUT("/:id/feature", editor, h.FeaturePost)
+			authorized.DELETE("/:id/feature", editor, h.UnfeaturePost)
+
+			// Массовые операции (только администраторы)
+			authorized.POST("/bulk", editor, h.BulkUpdate)
 		}
 	}
 }
 
 // ListPosts возвращает список постов с пагинацией
+// Поддерживает условные запросы (If-None-Match / If-Modified-Since)
 // @Summary Получить список постов
 // @Tags posts
 // @Param offset query int false "Смещение"
 // @Param limit query int false "Количество записей"
+// @Param pinned_first query bool false "Закрепленные посты первыми"
 // @Success 200 {array} Post
+// @Success 304 "Not Modified"
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/posts [get]
 func (h *Handler) ListPosts(c *gin.Context) {
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
-	posts, err := h.service.ListPosts(offset, limit)
+	pinnedFirst, _ := strconv.ParseBool(c.DefaultQuery("pinned_first", "false"))
+
+	posts, err := h.service.ListPosts(ListOptions{
+		Offset:      offset,
+		Limit:       limit,
+		PinnedFirst: pinnedFirst,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, NewErrorResponse(
 			http.StatusInternalServerError,
@@ -65,6 +138,11 @@ func (h *Handler) ListPosts(c *gin.Context) {
 		return
 	}
 
+	h.withCommentSettingsList(posts)
+	if httpcache.Respond(c, h.config.Cache.PostList, listValidators(posts)) {
+		return
+	}
+
 	c.JSON(http.StatusOK, posts)
 }
 
@@ -73,6 +151,7 @@ func (h *Handler) ListPosts(c *gin.Context) {
 // @Tags posts
 // @Param id path int true "ID поста"
 // @Success 200 {object} Post
+// @Success 304 "Not Modified"
 // @Failure 404,500 {object} ErrorResponse
 // @Router /api/v1/posts/{id} [get]
 func (h *Handler) GetPost(c *gin.Context) {
@@ -107,6 +186,12 @@ func (h *Handler) GetPost(c *gin.Context) {
 	// Увеличиваем счетчик просмотров
 	go h.service.IncrementViewCount(uint(id))
 
+	h.advertiseLinks(c, post)
+	h.withCommentSettings(post)
+	if httpcache.Respond(c, h.config.Cache.Post, postValidators(post)) {
+		return
+	}
+
 	c.JSON(http.StatusOK, post)
 }
 
@@ -118,7 +203,7 @@ func (h *Handler) GetPost(c *gin.Context) {
 // @Produce json
 // @Param post body Post true "Данные поста"
 // @Success 201 {object} Post
-// @Failure 400,401 {object} ErrorResponse
+// @Failure 400,401,429 {object} ErrorResponse
 // @Router /api/v1/posts [post]
 func (h *Handler) CreatePost(c *gin.Context) {
 	var post Post
@@ -143,19 +228,23 @@ func (h *Handler) CreatePost(c *gin.Context) {
 		return
 	}
 
+	h.withCommentSettings(&post)
 	c.JSON(http.StatusCreated, post)
 }
 
-// UpdatePost обновляет существующий пост
+// UpdatePost обновляет существующий пост.
+// Требует заголовок If-Match с ETag, полученным при чтении поста.
 // @Security JWT
 // @Summary Обновить пост
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param id path int true "ID поста"
+// @Param If-Match header string true "ETag редактируемой версии поста"
 // @Param post body Post true "Данные поста"
 // @Success 200 {object} Post
-// @Failure 400,401,404 {object} ErrorResponse
+// @Failure 400,401,403,404,428 {object} ErrorResponse
+// @Failure 412 {object} VersionConflictResponse
 // @Router /api/v1/posts/{id} [put]
 func (h *Handler) UpdatePost(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -178,7 +267,25 @@ func (h *Handler) UpdatePost(c *gin.Context) {
 		return
 	}
 
+	version, err := httpcache.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err {
+		case httpcache.ErrMissingIfMatch:
+			status = http.StatusPreconditionRequired
+		case httpcache.ErrWeakIfMatch:
+			status = http.StatusPreconditionFailed
+		}
+		c.JSON(status, NewErrorResponse(
+			status,
+			"Invalid If-Match header",
+			err.Error(),
+		))
+		return
+	}
+
 	post.ID = uint(id)
+	post.Version = version
 	// Проверка прав (автор или админ)
 	if !h.canModifyPost(c, post.AuthorID) {
 		c.JSON(http.StatusForbidden, NewErrorResponse(
@@ -190,12 +297,21 @@ func (h *Handler) UpdatePost(c *gin.Context) {
 	}
 
 	if err := h.service.UpdatePost(&post); err != nil {
+		if err == ErrVersionConflict {
+			h.respondVersionConflict(c, post.ID)
+			return
+		}
+
 		status := http.StatusInternalServerError
 		message := "Failed to update post"
 
-		if err == ErrPostNotFound {
+		switch err {
+		case ErrPostNotFound:
 			status = http.StatusNotFound
 			message = "Post not found"
+		case ErrHiddenByModeration:
+			status = http.StatusForbidden
+			message = "Post is hidden by moderation"
 		}
 
 		c.JSON(status, NewErrorResponse(
@@ -206,9 +322,34 @@ func (h *Handler) UpdatePost(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", httpcache.VersionETag(post.Version))
+	h.withCommentSettings(&post)
 	c.JSON(http.StatusOK, post)
 }
 
+// respondVersionConflict отвечает 412 и сообщает клиенту актуальную версию поста
+func (h *Handler) respondVersionConflict(c *gin.Context, id uint) {
+	current, err := h.service.GetPost(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to fetch post",
+			err.Error(),
+		))
+		return
+	}
+
+	c.Header("ETag", httpcache.VersionETag(current.Version))
+	c.JSON(http.StatusPreconditionFailed, VersionConflictResponse{
+		ErrorResponse: ErrorResponse{
+			Code:    http.StatusPreconditionFailed,
+			Message: "Version conflict",
+			Details: ErrVersionConflict.Error(),
+		},
+		CurrentVersion: current.Version,
+	})
+}
+
 // DeletePost удаляет пост
 // @Security JWT
 // @Summary Удалить пост
@@ -268,6 +409,266 @@ func (h *Handler) DeletePost(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// OEmbed возвращает описание встраивания опубликованного поста по его публичной ссылке
+// @Summary Провайдер oEmbed
+// @Tags posts
+// @Produce json
+// @Param url query string true "Публичная ссылка на пост"
+// @Param maxwidth query int false "Максимальная ширина"
+// @Param maxheight query int false "Максимальная высота"
+// @Param format query string false "Формат ответа (поддерживается только json)"
+// @Success 200 {object} OEmbed
+// @Failure 404,500,501 {object} ErrorResponse
+// @Router /oembed [get]
+func (h *Handler) OEmbed(c *gin.Context) {
+	if format := c.Query("format"); format != "" && format != "json" {
+		c.JSON(http.StatusNotImplemented, NewErrorResponse(
+			http.StatusNotImplemented,
+			"Unsupported format",
+			"поддерживается только формат json",
+		))
+		return
+	}
+
+	maxWidth, _ := strconv.Atoi(c.Query("maxwidth"))
+	maxHeight, _ := strconv.Atoi(c.Query("maxheight"))
+
+	embed, err := h.service.GetOEmbed(c.Query("url"), maxWidth, maxHeight)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to build embed"
+
+		if errors.Is(err, ErrPostNotFound) || errors.Is(err, ErrUnsupportedURL) {
+			status = http.StatusNotFound
+			message = "Post not found"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	if site, err := url.Parse(h.config.Site.PostURL); err == nil && site.Host != "" {
+		embed.ProviderURL = site.Scheme + "://" + site.Host
+	}
+	c.JSON(http.StatusOK, embed)
+}
+
+// BulkUpdate выполняет массовую операцию над постами в одной транзакции
+// @Security JWT
+// @Summary Массовая операция над постами
+// @Description Посты задаются списком ids или фильтром. Если хотя бы один пост не удалось изменить
+// @Description или указан dry_run, изменения откатываются; результат по каждому посту возвращается всегда.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param request body BulkRequest true "Операция"
+// @Success 200 {object} BulkResult
+// @Failure 400,401,403,500 {object} ErrorResponse
+// @Router /api/v1/posts/bulk [post]
+func (h *Handler) BulkUpdate(c *gin.Context) {
+	var req BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid request format",
+			err.Error(),
+		))
+		return
+	}
+
+	result, err := h.service.BulkUpdate(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to apply bulk operation"
+
+		switch {
+		case errors.Is(err, ErrBulkTargets), errors.Is(err, ErrBulkOperation),
+			errors.Is(err, ErrBulkTooLarge), errors.Is(err, ErrInvalidStatus):
+			status = http.StatusBadRequest
+			message = "Invalid bulk operation"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetTrash возвращает посты из корзины.
+// Администратор видит все посты (или посты автора из author_id), остальные - только свои.
+// @Security JWT
+// @Summary Получить корзину
+// @Tags posts
+// @Param author_id query int false "ID автора (только для администратора)"
+// @Param offset query int false "Смещение"
+// @Param limit query int false "Количество записей"
+// @Success 200 {array} Post
+// @Failure 400,401,500 {object} ErrorResponse
+// @Router /api/v1/posts/trash [get]
+func (h *Handler) GetTrash(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	userID := c.GetUint("userID")
+	authorID := &userID
+	if users.RoleFromContext(c) == users.RoleAdmin {
+		authorID = nil
+		if raw := c.Query("author_id"); raw != "" {
+			id, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, NewErrorResponse(
+					http.StatusBadRequest,
+					"Invalid author ID",
+					err.Error(),
+				))
+				return
+			}
+			filter := uint(id)
+			authorID = &filter
+		}
+	}
+
+	posts, err := h.service.GetTrash(authorID, offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to fetch trash",
+			err.Error(),
+		))
+		return
+	}
+
+	h.withCommentSettingsList(posts)
+	c.JSON(http.StatusOK, posts)
+}
+
+// RestorePost возвращает пост из корзины
+// @Security JWT
+// @Summary Восстановить пост из корзины
+// @Tags posts
+// @Param id path int true "ID поста"
+// @Success 200 {object} Post
+// @Failure 400,401,403,404,409,500 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/restore [post]
+func (h *Handler) RestorePost(c *gin.Context) {
+	id, ok := h.resolveTrashedPost(c)
+	if !ok {
+		return
+	}
+
+	post, err := h.service.RestorePost(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to restore post"
+
+		switch {
+		case errors.Is(err, ErrPostNotFound):
+			status = http.StatusNotFound
+			message = "Post not found in trash"
+		case errors.Is(err, ErrSlugConflict):
+			status = http.StatusConflict
+			message = "Slug is already taken"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	h.withCommentSettings(post)
+	c.JSON(http.StatusOK, post)
+}
+
+// PurgePost окончательно удаляет пост из корзины
+// @Security JWT
+// @Summary Окончательно удалить пост
+// @Tags posts
+// @Param id path int true "ID поста"
+// @Success 204 "No Content"
+// @Failure 400,401,403,404,500 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/purge [delete]
+func (h *Handler) PurgePost(c *gin.Context) {
+	id, ok := h.resolveTrashedPost(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.PurgePost(id); err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to purge post"
+
+		if errors.Is(err, ErrPostNotFound) {
+			status = http.StatusNotFound
+			message = "Post not found in trash"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// resolveTrashedPost разбирает ID поста из пути и проверяет, что пост
+// находится в корзине и текущий пользователь вправе им распоряжаться.
+// При ошибке отправляет ответ сам и возвращает false.
+func (h *Handler) resolveTrashedPost(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid post ID",
+			err.Error(),
+		))
+		return 0, false
+	}
+
+	post, err := h.service.GetTrashedPost(uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to fetch post"
+
+		if errors.Is(err, ErrPostNotFound) {
+			status = http.StatusNotFound
+			message = "Post not found in trash"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return 0, false
+	}
+
+	if !h.canModifyPost(c, post.AuthorID) {
+		c.JSON(http.StatusForbidden, NewErrorResponse(
+			http.StatusForbidden,
+			"Unauthorized",
+			ErrUnauthorized.Error(),
+		))
+		return 0, false
+	}
+
+	return post.ID, true
+}
+
 // GetPostByTitle возвращает пост по его заголовку
 func (h *Handler) GetPostByTitle(c *gin.Context) {
 	title := c.Param("title")
@@ -277,11 +678,15 @@ func (h *Handler) GetPostByTitle(c *gin.Context) {
 		return
 	}
 
+	h.withCommentSettings(post)
 	c.JSON(http.StatusOK, post)
 }
 
 // GetPostsByPublishedAt возвращает посты, опубликованные в указанный период
 func (h *Handler) GetPostsByPublishedAt(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
 	from, err := time.Parse(time.RFC3339, c.Query("from"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'from' date"})
@@ -294,15 +699,267 @@ func (h *Handler) GetPostsByPublishedAt(c *gin.Context) {
 		return
 	}
 
-	posts, err := h.service.GetPostsByPublishedAt(from, to)
+	posts, err := h.service.GetPostsByPublishedAt(from, to, offset, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	h.withCommentSettingsList(posts)
+	c.JSON(http.StatusOK, posts)
+}
+
+// GetArchive возвращает количество опубликованных постов по годам и месяцам
+// @Summary Архив постов по месяцам
+// @Tags posts
+// @Produce json
+// @Success 200 {array} ArchiveMonth
+// @Success 304 "Not Modified"
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/posts/archive [get]
+func (h *Handler) GetArchive(c *gin.Context) {
+	months, err := h.service.GetArchive()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to fetch archive",
+			err.Error(),
+		))
+		return
+	}
+
+	parts := make([]interface{}, 0, len(months))
+	for _, month := range months {
+		parts = append(parts, month.Year, month.Month, month.Count)
+	}
+	if httpcache.Respond(c, h.config.Cache.PostList, httpcache.Validators{
+		ETag: httpcache.WeakETag(parts...),
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, months)
+}
+
+// GetArchiveMonth возвращает опубликованные посты за месяц с пагинацией
+// @Summary Посты архива за месяц
+// @Tags posts
+// @Produce json
+// @Param year path int true "Год"
+// @Param month path int true "Месяц (1-12)"
+// @Param offset query int false "Смещение" default(0)
+// @Param limit query int false "Количество записей (до 100)" default(10)
+// @Success 200 {array} Post
+// @Success 304 "Not Modified"
+// @Failure 400,500 {object} ErrorResponse
+// @Router /api/v1/posts/archive/{year}/{month} [get]
+func (h *Handler) GetArchiveMonth(c *gin.Context) {
+	year, errYear := strconv.Atoi(c.Param("year"))
+	month, errMonth := strconv.Atoi(c.Param("month"))
+	if errYear != nil || errMonth != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid archive period",
+			ErrInvalidArchivePeriod.Error(),
+		))
+		return
+	}
+
+	offset := query.Int(c, "offset", 0, 0, -1)
+	limit := query.Int(c, "limit", defaultArchiveLimit, 1, maxArchiveLimit)
+
+	posts, err := h.service.GetArchiveMonth(year, month, offset, limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to fetch archive"
+
+		if err == ErrInvalidArchivePeriod {
+			status = http.StatusBadRequest
+			message = "Invalid archive period"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	h.withCommentSettingsList(posts)
+	if httpcache.Respond(c, h.config.Cache.PostList, listValidators(posts)) {
+		return
+	}
+
 	c.JSON(http.StatusOK, posts)
 }
 
+// GetFeaturedPosts возвращает действующие избранные посты
+// @Summary Избранные посты
+// @Tags posts
+// @Produce json
+// @Param limit query int false "Количество записей (до 50)" default(5)
+// @Success 200 {array} Post
+// @Success 304 "Not Modified"
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/posts/featured [get]
+func (h *Handler) GetFeaturedPosts(c *gin.Context) {
+	limit := query.Int(c, "limit", defaultFeaturedLimit, 1, maxFeaturedLimit)
+
+	posts, err := h.service.GetFeaturedPosts(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to fetch featured posts",
+			err.Error(),
+		))
+		return
+	}
+
+	h.withCommentSettingsList(posts)
+	if httpcache.Respond(c, h.config.Cache.PostList, listValidators(posts)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, posts)
+}
+
+// PinPost закрепляет пост на главной странице
+// @Security JWT
+// @Summary Закрепить пост
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "ID поста"
+// @Param pin body HighlightRequest false "Срок и порядок закрепления"
+// @Success 200 {object} Post
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/pin [put]
+func (h *Handler) PinPost(c *gin.Context) {
+	h.setHighlight(c, h.service.PinPost)
+}
+
+// UnpinPost снимает закрепление поста
+// @Security JWT
+// @Summary Открепить пост
+// @Tags posts
+// @Param id path int true "ID поста"
+// @Success 204 "No Content"
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/pin [delete]
+func (h *Handler) UnpinPost(c *gin.Context) {
+	h.clearHighlight(c, h.service.UnpinPost)
+}
+
+// FeaturePost добавляет пост в избранное
+// @Security JWT
+// @Summary Добавить пост в избранное
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "ID поста"
+// @Param feature body HighlightRequest false "Срок и порядок в избранном"
+// @Success 200 {object} Post
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/feature [put]
+func (h *Handler) FeaturePost(c *gin.Context) {
+	h.setHighlight(c, h.service.FeaturePost)
+}
+
+// UnfeaturePost убирает пост из избранного
+// @Security JWT
+// @Summary Убрать пост из избранного
+// @Tags posts
+// @Param id path int true "ID поста"
+// @Success 204 "No Content"
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/feature [delete]
+func (h *Handler) UnfeaturePost(c *gin.Context) {
+	h.clearHighlight(c, h.service.UnfeaturePost)
+}
+
+// setHighlight разбирает запрос закрепления/избранного и применяет его
+func (h *Handler) setHighlight(c *gin.Context, apply func(id uint, req HighlightRequest) (*Post, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid post ID",
+			err.Error(),
+		))
+		return
+	}
+
+	var req HighlightRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(
+				http.StatusBadRequest,
+				"Invalid request data",
+				err.Error(),
+			))
+			return
+		}
+	}
+
+	post, err := apply(uint(id), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to update post"
+
+		switch err {
+		case ErrPostNotFound:
+			status = http.StatusNotFound
+			message = "Post not found"
+		case ErrInvalidExpiry:
+			status = http.StatusBadRequest
+			message = "Invalid expiry"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	h.withCommentSettings(post)
+	c.JSON(http.StatusOK, post)
+}
+
+// clearHighlight снимает закрепление/избранное
+func (h *Handler) clearHighlight(c *gin.Context, clear func(id uint) error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid post ID",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := clear(uint(id)); err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to update post"
+
+		if err == ErrPostNotFound {
+			status = http.StatusNotFound
+			message = "Post not found"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // GetPostsByTag возвращает посты по тегу
 func (h *Handler) GetPostsByTag(c *gin.Context) {
 	tag := c.Param("tag")
@@ -312,6 +969,7 @@ func (h *Handler) GetPostsByTag(c *gin.Context) {
 		return
 	}
 
+	h.withCommentSettingsList(posts)
 	c.JSON(http.StatusOK, posts)
 }
 
@@ -320,6 +978,7 @@ func (h *Handler) GetPostsByTag(c *gin.Context) {
 // @Tags posts
 // @Param slug path string true "Slug поста"
 // @Success 200 {object} Post
+// @Success 304 "Not Modified"
 // @Failure 404,500 {object} ErrorResponse
 // @Router /api/v1/posts/slug/{slug} [get]
 func (h *Handler) GetPostBySlug(c *gin.Context) {
@@ -337,6 +996,12 @@ func (h *Handler) GetPostBySlug(c *gin.Context) {
 	// Увеличиваем счетчик просмотров
 	go h.service.IncrementViewCount(post.ID)
 
+	h.advertiseLinks(c, post)
+	h.withCommentSettings(post)
+	if httpcache.Respond(c, h.config.Cache.Post, postValidators(post)) {
+		return
+	}
+
 	c.JSON(http.StatusOK, post)
 }
 
@@ -349,12 +1014,298 @@ func (h *Handler) GetPostsByAuthor(c *gin.Context) {
 		return
 	}
 
+	h.withCommentSettingsList(posts)
 	c.JSON(http.StatusOK, posts)
 }
 
+// PreviewRequest содержит Markdown для предпросмотра
+type PreviewRequest struct {
+	RawContent string `json:"raw_content" binding:"required" example:"# Заголовок\n\nТекст"`
+}
+
+// PreviewResponse содержит отрендеренный HTML
+type PreviewResponse struct {
+	HTMLContent string `json:"html_content" example:"<h1>Заголовок</h1><p>Текст</p>"`
+}
+
+// PreviewPost рендерит Markdown без сохранения поста
+// @Security JWT
+// @Summary Предпросмотр Markdown
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param preview body PreviewRequest true "Markdown контент"
+// @Success 200 {object} PreviewResponse
+// @Failure 400,401 {object} ErrorResponse
+// @Router /api/v1/posts/preview [post]
+func (h *Handler) PreviewPost(c *gin.Context) {
+	var req PreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid preview data",
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, PreviewResponse{
+		HTMLContent: h.service.PreviewPost(req.RawContent),
+	})
+}
+
+// AutosaveRequest содержит снимок редактируемого поста
+type AutosaveRequest struct {
+	Title       string   `json:"title" example:"Как настроить Swagger в Go"`
+	Description string   `json:"description" example:"Черновик описания"`
+	RawContent  string   `json:"raw_content" example:"# Заголовок\n\nНедописанный текст..."`
+	Tags        []string `json:"tags" example:"golang,swagger"`
+}
+
+// GetDraft возвращает автосохраненный черновик текущего пользователя
+// @Security JWT
+// @Summary Получить автосохраненный черновик
+// @Tags posts
+// @Produce json
+// @Param id path int false "ID поста (без него - черновик нового поста)"
+// @Success 200 {object} Draft
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/autosave [get]
+func (h *Handler) GetDraft(c *gin.Context) {
+	postID, ok := h.resolveDraftPost(c)
+	if !ok {
+		return
+	}
+
+	draft, err := h.service.GetDraft(postID, c.GetUint("userID"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to fetch draft"
+
+		if err == ErrDraftNotFound {
+			status = http.StatusNotFound
+			message = "Draft not found"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
+
+// AutosaveDraft сохраняет снимок черновика текущего пользователя.
+// Опубликованный пост не изменяется.
+// @Security JWT
+// @Summary Автосохранение черновика
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int false "ID поста (без него - черновик нового поста)"
+// @Param draft body AutosaveRequest true "Снимок черновика"
+// @Success 200 {object} Draft
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/autosave [put]
+func (h *Handler) AutosaveDraft(c *gin.Context) {
+	postID, ok := h.resolveDraftPost(c)
+	if !ok {
+		return
+	}
+
+	var req AutosaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid draft data",
+			err.Error(),
+		))
+		return
+	}
+
+	draft := &Draft{
+		PostID:      postID,
+		AuthorID:    c.GetUint("userID"),
+		Title:       req.Title,
+		Description: req.Description,
+		RawContent:  req.RawContent,
+		Tags:        req.Tags,
+	}
+
+	if err := h.service.AutosaveDraft(draft); err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to save draft",
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
+
+// DiscardDraft удаляет автосохраненный черновик текущего пользователя
+// @Security JWT
+// @Summary Удалить автосохраненный черновик
+// @Tags posts
+// @Param id path int false "ID поста (без него - черновик нового поста)"
+// @Success 204 "No Content"
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/autosave [delete]
+func (h *Handler) DiscardDraft(c *gin.Context) {
+	postID, ok := h.resolveDraftPost(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DiscardDraft(postID, c.GetUint("userID")); err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to discard draft",
+			err.Error(),
+		))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// resolveDraftPost определяет пост, к которому относится черновик.
+// Для маршрутов без :id возвращает nil - черновик нового поста.
+// При ошибке сам записывает ответ и возвращает false.
+func (h *Handler) resolveDraftPost(c *gin.Context) (*uint, bool) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		return nil, true
+	}
+
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid post ID",
+			err.Error(),
+		))
+		return nil, false
+	}
+
+	post, err := h.service.GetPost(uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to fetch post"
+
+		if err == ErrPostNotFound {
+			status = http.StatusNotFound
+			message = "Post not found"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return nil, false
+	}
+
+	if !h.canModifyPost(c, post.AuthorID) {
+		c.JSON(http.StatusForbidden, NewErrorResponse(
+			http.StatusForbidden,
+			"Unauthorized",
+			ErrUnauthorized.Error(),
+		))
+		return nil, false
+	}
+
+	postID := post.ID
+	return &postID, true
+}
+
+// advertiseLinks объявляет через заголовки Link эндпоинт приема Webmention
+// и, для опубликованных постов, описание встраивания oEmbed
+func (h *Handler) advertiseLinks(c *gin.Context, post *Post) {
+	site := h.config.Site
+	if site.APIURL == "" {
+		return
+	}
+	c.Writer.Header().Add("Link", fmt.Sprintf(`<%s/webmention>; rel="webmention"`, site.APIURL))
+
+	if post.Status == StatusPublished && site.PostURL != "" {
+		oembed := site.APIURL + "/oembed?url=" + url.QueryEscape(site.PostLink(post.Slug))
+		c.Writer.Header().Add("Link", fmt.Sprintf(`<%s>; rel="alternate"; type="application/json+oembed"; title="%s"`, oembed, strings.ReplaceAll(post.Title, `"`, `'`)))
+	}
+}
+
+// withCommentSettings заполняет вычисляемые поля приема комментариев поста
+func (h *Handler) withCommentSettings(post *Post) {
+	settings := post.CommentSettings()
+	autoClose := h.config.Comments.AutoClose()
+	post.CommentsCloseAt = settings.ClosesAt(autoClose)
+	post.CommentsOpen = settings.Open(time.Now(), autoClose)
+}
+
+// withCommentSettingsList заполняет вычисляемые поля приема комментариев списка постов
+func (h *Handler) withCommentSettingsList(posts []Post) {
+	for i := range posts {
+		h.withCommentSettings(&posts[i])
+	}
+}
+
+// postValidators возвращает валидаторы кэша для одного поста.
+// ETag строится из версии и вычисляемых полей приема комментариев, которые
+// меняются без изменения версии. Счетчик просмотров растет при каждом чтении
+// и в ETag не входит, иначе условный запрос никогда не получил бы 304.
+// Версия в ETag позволяет передать его в If-Match при обновлении.
+// Вызывается после заполнения вычисляемых полей.
+func postValidators(post *Post) httpcache.Validators {
+	return httpcache.Validators{
+		ETag:         httpcache.RepresentationETag(post.Version, post.CommentsOpen, commentsCloseAt(post)),
+		LastModified: post.UpdatedAt,
+	}
+}
+
+// listValidators возвращает валидаторы кэша для списка постов.
+// Слабый ETag строится из состава списка, версий постов и вычисляемых полей
+// приема комментариев; счетчики просмотров в него не входят.
+// Last-Modified не передается: список меняется и без изменения постов
+// (удаление, снятие с публикации). Вызывается после заполнения вычисляемых полей.
+func listValidators(posts []Post) httpcache.Validators {
+	parts := make([]interface{}, 0, len(posts)*4)
+	for i := range posts {
+		parts = append(parts, posts[i].ID, posts[i].Version, posts[i].CommentsOpen, commentsCloseAt(&posts[i]))
+	}
+	return httpcache.Validators{
+		ETag: httpcache.WeakETag(parts...),
+	}
+}
+
+// commentsCloseAt возвращает время автоматического закрытия комментариев для ETag
+func commentsCloseAt(post *Post) string {
+	if post.CommentsCloseAt == nil {
+		return ""
+	}
+	return post.CommentsCloseAt.UTC().Format(time.RFC3339)
+}
+
 // canModifyPost проверяет, может ли текущий пользователь изменять пост
 func (h *Handler) canModifyPost(c *gin.Context, authorID uint) bool {
 	userID := c.GetUint("userID")
-	userRole := c.GetString("userRole")
-	return userID == authorID || userRole == "admin"
+	return userID == authorID || users.RoleFromContext(c) == users.RoleAdmin
+}
+
+// StreamPosts передает события о новых публикациях в формате Server-Sent Events
+// Событие post.published приходит при первой публикации поста; при переподключении
+// с Last-Event-ID клиент получает пропущенные события или reset, если они утеряны.
+// @Summary Поток новых публикаций
+// @Tags posts
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "ID последнего полученного события"
+// @Param lastEventId query string false "ID последнего полученного события (если нельзя передать заголовок)"
+// @Success 200 {string} string "Поток событий"
+// @Failure 429 "Слишком много открытых потоков"
+// @Router /api/v1/posts/stream [get]
+func (h *Handler) StreamPosts(c *gin.Context) {
+	h.stream.Serve(c, StreamTopic)
 }
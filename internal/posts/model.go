@@ -3,6 +3,8 @@ package posts
 import (
 	"time"
 
+	"gorm.io/gorm"
+
 	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
 )
 
@@ -24,7 +26,7 @@ const (
 type Post struct {
 	ID          uint   `json:"id" gorm:"primaryKey" example:"1"`
 	Title       string `json:"title" gorm:"size:255;not null" example:"Как настроить Swagger в Go"`
-	Slug        string `json:"slug" gorm:"uniqueIndex;size:255" example:"how-to-setup-swagger-in-go"`
+	Slug        string `json:"slug" gorm:"index;size:255" example:"how-to-setup-swagger-in-go"` // Уникален среди неудаленных постов
 	Description string `json:"description" gorm:"size:500" example:"Подробное руководство по настройке документации API с помощью Swagger в Go-приложениях"`
 
 	// Контент
@@ -35,11 +37,34 @@ type Post struct {
 	Status    Status   `json:"status" gorm:"type:varchar(20);default:'draft'" example:"published" enums:"draft,published,archived"`
 	Tags      []string `json:"tags" gorm:"type:text[]" example:"golang,swagger,api"`
 	ViewCount int64    `json:"view_count" gorm:"default:0" example:"42"`
+	Version   uint     `json:"version" gorm:"not null;default:1" example:"3"` // Версия для оптимистичной блокировки
+	// Пост снят с публикации модерацией по жалобам; автор не может опубликовать его снова,
+	// пока модератор не отклонит жалобы
+	HiddenByModeration bool `json:"hidden_by_moderation" gorm:"not null;default:false" example:"false"`
+
+	// Закрепление и избранное (управляются только администраторами)
+	IsPinned      bool       `json:"is_pinned" gorm:"not null;default:false" example:"false"`
+	PinnedUntil   *time.Time `json:"pinned_until,omitempty" example:"2025-02-01T00:00:00Z"` // nil - без срока
+	PinOrder      int        `json:"pin_order" gorm:"not null;default:0" example:"0"`       // Меньше - выше
+	IsFeatured    bool       `json:"is_featured" gorm:"not null;default:false" example:"true"`
+	FeaturedUntil *time.Time `json:"featured_until,omitempty" example:"2025-02-01T00:00:00Z"` // nil - без срока
+	FeaturedOrder int        `json:"featured_order" gorm:"not null;default:0" example:"1"`    // Меньше - выше
+
+	// Политика премодерации комментариев к посту; пустая - политика сайта
+	CommentModeration comments.ModerationPolicy `json:"comment_moderation" gorm:"type:varchar(20);not null;default:''" example:"first_time" enums:",all,first_time,none"`
+	// Кто может комментировать пост
+	CommentMode comments.CommentMode `json:"comment_mode" gorm:"type:varchar(20);not null;default:'open'" example:"open" enums:"open,closed,members,moderated"`
+	// Принимает ли пост комментарии сейчас и когда комментарии закроются автоматически
+	// (вычисляются при ответе с учетом comments.auto_close_days)
+	CommentsOpen    bool       `json:"comments_open" gorm:"-" example:"true"`
+	CommentsCloseAt *time.Time `json:"comments_close_at,omitempty" gorm:"-" example:"2025-02-02T12:00:00Z"`
 
 	// Временные метки
 	CreatedAt   time.Time  `json:"created_at" example:"2025-01-01T00:00:00Z"`
 	UpdatedAt   time.Time  `json:"updated_at" example:"2025-01-02T00:00:00Z"`
 	PublishedAt *time.Time `json:"published_at" example:"2025-01-03T12:00:00Z"`
+	// Время перемещения в корзину; удаленные посты исключаются из всех запросов GORM
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggertype:"string" format:"date-time" example:"2025-02-01T00:00:00Z"`
 
 	// Связи
 	AuthorID uint `json:"author_id" example:"5"`
@@ -48,6 +73,16 @@ type Post struct {
 	Comments []comments.Comment `json:"comments,omitempty" gorm:"foreignKey:PostID" swaggerignore:"true"`
 }
 
+// CommentSettings возвращает настройки поста, от которых зависит прием комментариев
+func (p *Post) CommentSettings() comments.PostSettings {
+	return comments.PostSettings{
+		Status:            string(p.Status),
+		CommentMode:       p.CommentMode,
+		CommentModeration: p.CommentModeration,
+		PublishedAt:       p.PublishedAt,
+	}
+}
+
 // PostResponse используется для ответа API с упрощенной структурой комментариев
 // @Description Ответ API с постом
 type PostResponse struct {
@@ -67,6 +102,146 @@ type PostResponse struct {
 	CommentIDs []uint `json:"comment_ids,omitempty" example:"1,2,3"`
 }
 
+// StreamTopic - тема событий о новых публикациях сайта
+const StreamTopic = "posts"
+
+// EventPostPublished - пост опубликован впервые (данные - PostEvent)
+const EventPostPublished = "post.published"
+
+// PostEvent - данные события о публикации поста; текст поста загружается отдельно
+// @Description Опубликованный пост
+type PostEvent struct {
+	ID          uint       `json:"id" example:"1"`
+	Title       string     `json:"title" example:"Как настроить Swagger в Go"`
+	Slug        string     `json:"slug" example:"how-to-setup-swagger-in-go"`
+	Description string     `json:"description" example:"Подробное руководство по настройке документации API с помощью Swagger в Go-приложениях"`
+	Tags        []string   `json:"tags" example:"golang,swagger,api"`
+	AuthorID    uint       `json:"author_id" example:"5"`
+	PublishedAt *time.Time `json:"published_at" example:"2025-01-03T12:00:00Z"`
+}
+
+// ListOptions задает параметры выборки списка постов
+type ListOptions struct {
+	Offset      int
+	Limit       int
+	PinnedFirst bool // Действующие закрепленные посты идут первыми
+}
+
+// HighlightRequest задает параметры закрепления или избранного
+type HighlightRequest struct {
+	Until *time.Time `json:"until,omitempty" example:"2025-02-01T00:00:00Z"` // Срок действия, nil - бессрочно
+	Order int        `json:"order" example:"0"`                              // Порядок среди закрепленных/избранных
+}
+
+// ArchiveMonth содержит количество опубликованных постов за месяц
+// @Description Элемент архива постов
+type ArchiveMonth struct {
+	Year  int   `json:"year" example:"2025"`
+	Month int   `json:"month" example:"3"`
+	Count int64 `json:"count" example:"7"`
+}
+
+// BulkOperation определяет массовую операцию над постами
+type BulkOperation string
+
+const (
+	// BulkSetStatus меняет статус постов
+	BulkSetStatus BulkOperation = "set_status"
+	// BulkAddTags добавляет теги к постам
+	BulkAddTags BulkOperation = "add_tags"
+	// BulkRemoveTags удаляет теги из постов
+	BulkRemoveTags BulkOperation = "remove_tags"
+	// BulkSetAuthor меняет автора постов
+	BulkSetAuthor BulkOperation = "set_author"
+)
+
+// BulkFilter выбирает посты для массовой операции; условия объединяются через AND
+type BulkFilter struct {
+	Status   Status `json:"status,omitempty" example:"draft"`
+	Tag      string `json:"tag,omitempty" example:"golang"`
+	AuthorID *uint  `json:"author_id,omitempty" example:"5"`
+}
+
+// IsEmpty сообщает, что в фильтре не задано ни одного условия
+func (f BulkFilter) IsEmpty() bool {
+	return f.Status == "" && f.Tag == "" && f.AuthorID == nil
+}
+
+// BulkRequest описывает массовую операцию над постами.
+// Посты задаются либо списком IDs, либо фильтром.
+// @Description Массовая операция над постами
+type BulkRequest struct {
+	IDs       []uint        `json:"ids,omitempty" example:"1,2,3"`
+	Filter    *BulkFilter   `json:"filter,omitempty"`
+	Operation BulkOperation `json:"operation" binding:"required" example:"add_tags" enums:"set_status,add_tags,remove_tags,set_author"`
+	Status    Status        `json:"status,omitempty" example:"archived"` // Для set_status
+	Tags      []string      `json:"tags,omitempty" example:"golang,api"` // Для add_tags и remove_tags
+	AuthorID  uint          `json:"author_id,omitempty" example:"7"`     // Для set_author
+	DryRun    bool          `json:"dry_run" example:"false"`             // Только показать результат, ничего не меняя
+}
+
+// Результаты массовой операции для отдельного поста
+const (
+	BulkItemUpdated   = "updated"
+	BulkItemUnchanged = "unchanged"
+	BulkItemNotFound  = "not_found"
+	BulkItemFailed    = "failed"
+)
+
+// BulkItemResult содержит результат массовой операции для одного поста
+type BulkItemResult struct {
+	ID     uint   `json:"id" example:"1"`
+	Result string `json:"result" example:"updated" enums:"updated,unchanged,not_found,failed"`
+	Error  string `json:"error,omitempty" example:""`
+}
+
+// BulkResult содержит итоги массовой операции.
+// Операция выполняется в одной транзакции: если хотя бы один пост не удалось
+// изменить или включен dry_run, изменения откатываются и Applied = false.
+// @Description Итоги массовой операции
+type BulkResult struct {
+	Applied   bool             `json:"applied" example:"true"`
+	DryRun    bool             `json:"dry_run" example:"false"`
+	Matched   int              `json:"matched" example:"3"`
+	Updated   int              `json:"updated" example:"2"`
+	Unchanged int              `json:"unchanged" example:"1"`
+	Failed    int              `json:"failed" example:"0"` // Включая не найденные посты
+	Items     []BulkItemResult `json:"items"`
+}
+
+// OEmbed - ответ провайдера oEmbed (тип rich) для ссылки на пост
+// @Description Ответ oEmbed
+type OEmbed struct {
+	Version     string `json:"version" example:"1.0"`
+	Type        string `json:"type" example:"rich"`
+	Title       string `json:"title" example:"Как настроить Swagger в Go"`
+	ProviderURL string `json:"provider_url,omitempty" example:"https://blog.example.com"`
+	HTML        string `json:"html" example:"<figure class=\"embed embed-post\">...</figure>"`
+	Width       int    `json:"width" example:"600"`
+	Height      int    `json:"height" example:"200"`
+}
+
+// Draft хранит автосохраненный снимок поста конкретного пользователя.
+// Черновики лежат отдельно от опубликованного контента, поэтому их
+// сохранение не меняет Post.UpdatedAt.
+// @Description Автосохраненный черновик поста
+type Draft struct {
+	ID          uint      `json:"id" gorm:"primaryKey" example:"1"`
+	PostID      *uint     `json:"post_id,omitempty" gorm:"index" example:"1"` // nil для еще не созданного поста
+	AuthorID    uint      `json:"author_id" gorm:"not null;index" example:"5"`
+	Title       string    `json:"title" gorm:"size:255" example:"Как настроить Swagger в Go"`
+	Description string    `json:"description" gorm:"size:500" example:"Черновик описания"`
+	RawContent  string    `json:"raw_content" gorm:"type:text" example:"# Заголовок\n\nНедописанный текст..."`
+	Tags        []string  `json:"tags" gorm:"type:text[]" example:"golang,swagger"`
+	CreatedAt   time.Time `json:"created_at" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2025-01-01T00:05:00Z"`
+}
+
+// TableName задает имя таблицы черновиков
+func (Draft) TableName() string {
+	return "post_drafts"
+}
+
 // Repository описывает методы для работы с хранилищем постов
 type Repository interface {
 	// Create создает новый пост
@@ -81,14 +256,47 @@ type Repository interface {
 	GetByTag(tag string) ([]Post, error)
 	// GetByID возвращает пост по его ID
 	GetByID(id uint) (*Post, error)
-	// GetByPublishedAt возвращает посты, опубликованные в указанный период
-	GetByPublishedAt(from, to time.Time) ([]Post, error)
-	// Update обновляет существующий пост
+	// GetByPublishedAt возвращает опубликованные посты за период [from, to) с пагинацией
+	GetByPublishedAt(from, to time.Time, offset, limit int) ([]Post, error)
+	// CountByMonth возвращает количество опубликованных постов по месяцам
+	CountByMonth() ([]ArchiveMonth, error)
+	// Update обновляет существующий пост, если его версия не изменилась
 	Update(post *Post) error
-	// Delete удаляет пост
+	// IncrementViewCount атомарно увеличивает счетчик просмотров
+	IncrementViewCount(id uint) error
+	// Delete перемещает пост в корзину (мягкое удаление)
 	Delete(id uint) error
+	// GetDeletedByID возвращает пост из корзины
+	GetDeletedByID(id uint) (*Post, error)
+	// ListDeleted возвращает посты из корзины (authorID == nil - всех авторов)
+	ListDeleted(authorID *uint, offset, limit int) ([]Post, error)
+	// Restore возвращает пост из корзины
+	Restore(id uint) error
+	// Purge окончательно удаляет пост из корзины вместе с комментариями
+	Purge(id uint) error
+	// PurgeDeletedBefore окончательно удаляет посты, находящиеся в корзине дольше срока хранения
+	PurgeDeletedBefore(cutoff time.Time) (int64, error)
+	// FindForUpdate возвращает посты по IDs или фильтру, блокируя их до конца транзакции
+	FindForUpdate(ids []uint, filter *BulkFilter, limit int) ([]Post, error)
+	// WithTransaction выполняет fn в транзакции, передавая репозиторий, привязанный к ней.
+	// Вложенный вызов создает точку сохранения. Ошибка fn откатывает транзакцию.
+	WithTransaction(fn func(repo Repository) error) error
 	// List возвращает список постов с пагинацией
-	List(offset, limit int) ([]Post, error)
+	List(opts ListOptions) ([]Post, error)
+	// SetPinned закрепляет или открепляет пост
+	SetPinned(id uint, pinned bool, until *time.Time, order int) error
+	// SetFeatured добавляет пост в избранное или убирает из него
+	SetFeatured(id uint, featured bool, until *time.Time, order int) error
+	// GetFeatured возвращает действующие избранные опубликованные посты
+	GetFeatured(limit int) ([]Post, error)
+	// ClearExpiredHighlights снимает закрепления и избранное с истекшим сроком
+	ClearExpiredHighlights(now time.Time) (int64, error)
+	// GetDraft возвращает черновик пользователя (postID == nil - черновик нового поста)
+	GetDraft(postID *uint, authorID uint) (*Draft, error)
+	// SaveDraft создает или перезаписывает черновик пользователя
+	SaveDraft(draft *Draft) error
+	// DeleteDraft удаляет черновик пользователя
+	DeleteDraft(postID *uint, authorID uint) error
 }
 
 // Service описывает бизнес-логику работы с постами
@@ -105,14 +313,56 @@ type Service interface {
 	GetPostsByAuthor(authorID uint) ([]Post, error)
 	// GetPostsByTag возвращает посты по тегу
 	GetPostsByTag(tag string) ([]Post, error)
-	// GetPostsByPublishedAt возвращает посты, опубликованные в указанный период
-	GetPostsByPublishedAt(from, to time.Time) ([]Post, error)
+	// GetPostsByPublishedAt возвращает опубликованные посты за период [from, to) с пагинацией
+	GetPostsByPublishedAt(from, to time.Time, offset, limit int) ([]Post, error)
+	// GetArchive возвращает количество опубликованных постов по годам и месяцам
+	GetArchive() ([]ArchiveMonth, error)
+	// GetArchiveMonth возвращает опубликованные посты за указанный месяц
+	GetArchiveMonth(year, month, offset, limit int) ([]Post, error)
 	// UpdatePost обновляет существующий пост
 	UpdatePost(post *Post) error
 	// IncrementViewCount увеличивает счетчик просмотров поста
 	IncrementViewCount(id uint) error
-	// DeletePost удаляет пост
+	// DeletePost перемещает пост в корзину
 	DeletePost(id uint) error
+	// GetTrash возвращает посты из корзины (authorID == nil - всех авторов)
+	GetTrash(authorID *uint, offset, limit int) ([]Post, error)
+	// GetTrashedPost возвращает пост из корзины
+	GetTrashedPost(id uint) (*Post, error)
+	// RestorePost возвращает пост из корзины
+	RestorePost(id uint) (*Post, error)
+	// HidePost снимает опубликованный пост с публикации по решению модерации
+	HidePost(id uint) (bool, error)
+	// UnhidePost возвращает в публикацию пост, скрытый HidePost
+	UnhidePost(id uint) error
+	// PurgePost окончательно удаляет пост из корзины
+	PurgePost(id uint) error
+	// PurgeExpiredTrash окончательно удаляет посты, находящиеся в корзине дольше retention
+	PurgeExpiredTrash(retention time.Duration) (int64, error)
+	// BulkUpdate выполняет массовую операцию над постами в одной транзакции
+	BulkUpdate(req BulkRequest) (*BulkResult, error)
 	// ListPosts получает список постов с пагинацией
-	ListPosts(offset, limit int) ([]Post, error)
+	ListPosts(opts ListOptions) ([]Post, error)
+	// PinPost закрепляет пост на главной странице
+	PinPost(id uint, req HighlightRequest) (*Post, error)
+	// UnpinPost снимает закрепление поста
+	UnpinPost(id uint) error
+	// FeaturePost добавляет пост в избранное
+	FeaturePost(id uint, req HighlightRequest) (*Post, error)
+	// UnfeaturePost убирает пост из избранного
+	UnfeaturePost(id uint) error
+	// GetFeaturedPosts возвращает действующие избранные посты
+	GetFeaturedPosts(limit int) ([]Post, error)
+	// ExpireHighlights снимает истекшие закрепления и избранное
+	ExpireHighlights() (int64, error)
+	// PreviewPost рендерит Markdown так же, как при сохранении поста
+	PreviewPost(rawContent string) string
+	// GetOEmbed возвращает описание встраивания опубликованного поста по его публичной ссылке
+	GetOEmbed(link string, maxWidth, maxHeight int) (*OEmbed, error)
+	// GetDraft возвращает автосохраненный черновик пользователя
+	GetDraft(postID *uint, authorID uint) (*Draft, error)
+	// AutosaveDraft сохраняет снимок черновика пользователя
+	AutosaveDraft(draft *Draft) error
+	// DiscardDraft удаляет автосохраненный черновик пользователя
+	DiscardDraft(postID *uint, authorID uint) error
 }
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/database"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PostRepository реализует интерфейс Repository и предоставляет методы
@@ -79,15 +81,34 @@ func (r *PostRepository) GetByTag(tag string) ([]Post, error) {
 	return posts, err
 }
 
-// GetByPublishedAt возвращает посты, опубликованные в указанный период
-func (r *PostRepository) GetByPublishedAt(from, to time.Time) ([]Post, error) {
+// GetByPublishedAt возвращает опубликованные посты за полуинтервал [from, to).
+// Полуинтервал позволяет выбирать смежные периоды (например, месяцы)
+// без пересечения на границе. Посты сортируются от новых к старым.
+func (r *PostRepository) GetByPublishedAt(from, to time.Time, offset, limit int) ([]Post, error) {
 	var posts []Post
-	err := r.DB.Where("published_at BETWEEN ? AND ?", from, to).
+	err := r.DB.Where("status = ? AND published_at >= ? AND published_at < ?", StatusPublished, from, to).
 		Order("published_at DESC").
+		Offset(offset).
+		Limit(limit).
 		Find(&posts).Error
 	return posts, err
 }
 
+// CountByMonth возвращает количество опубликованных постов, сгруппированное
+// по году и месяцу публикации (в UTC). Новые месяцы идут первыми.
+func (r *PostRepository) CountByMonth() ([]ArchiveMonth, error) {
+	var months []ArchiveMonth
+	err := r.DB.Model(&Post{}).
+		Select("EXTRACT(YEAR FROM published_at AT TIME ZONE 'UTC')::int AS year, " +
+			"EXTRACT(MONTH FROM published_at AT TIME ZONE 'UTC')::int AS month, " +
+			"COUNT(*) AS count").
+		Where("status = ? AND published_at IS NOT NULL", StatusPublished).
+		Group("year, month").
+		Order("year DESC, month DESC").
+		Scan(&months).Error
+	return months, err
+}
+
 // Create создает новый пост в базе данных.
 // Принимает указатель на структуру Post, которая должна содержать
 // все необходимые поля. Возвращает error в случае неудачи.
@@ -114,8 +135,46 @@ func (r *PostRepository) GetByID(id uint) (*Post, error) {
 // Принимает указатель на структуру Post с обновленными данными.
 // Пост должен иметь валидный ID. Возвращает error в случае неудачи.
 // Автоматически обновляет временную метку updated_at.
+//
+// Обновление выполняется только если версия в БД совпадает с post.Version,
+// после чего версия увеличивается. Иначе возвращается ErrVersionConflict.
+// Счетчик просмотров, дата создания, закрепление и избранное не перезаписываются:
+// ими управляют отдельные методы.
 func (r *PostRepository) Update(post *Post) error {
-	return r.DB.Save(post).Error
+	expected := post.Version
+	post.Version = expected + 1
+
+	result := r.DB.Model(post).
+		Where("version = ?", expected).
+		Select("*").
+		Omit("id", "created_at", "view_count", "deleted_at",
+			"is_pinned", "pinned_until", "pin_order",
+			"is_featured", "featured_until", "featured_order").
+		Updates(post)
+	if result.Error != nil {
+		post.Version = expected
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		post.Version = expected
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// IncrementViewCount атомарно увеличивает счетчик просмотров поста.
+// Версия поста при этом не меняется.
+func (r *PostRepository) IncrementViewCount(id uint) error {
+	result := r.DB.Model(&Post{}).
+		Where("id = ?", id).
+		UpdateColumn("view_count", gorm.Expr("view_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPostNotFound
+	}
+	return nil
 }
 
 // Delete выполняет мягкое удаление поста по его идентификатору.
@@ -126,13 +185,261 @@ func (r *PostRepository) Delete(id uint) error {
 	return r.DB.Delete(&Post{}, id).Error
 }
 
+// trashScope выбирает посты, находящиеся в корзине
+func (r *PostRepository) trashScope() *gorm.DB {
+	return r.DB.Unscoped().Where("deleted_at IS NOT NULL")
+}
+
+// GetDeletedByID возвращает пост из корзины.
+// Если пост не найден или не удален, возвращает (nil, nil).
+func (r *PostRepository) GetDeletedByID(id uint) (*Post, error) {
+	var post Post
+	if err := r.trashScope().First(&post, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &post, nil
+}
+
+// ListDeleted возвращает посты из корзины, недавно удаленные первыми.
+// Если authorID не nil, возвращаются только посты этого автора.
+func (r *PostRepository) ListDeleted(authorID *uint, offset, limit int) ([]Post, error) {
+	var posts []Post
+	query := r.trashScope()
+	if authorID != nil {
+		query = query.Where("author_id = ?", *authorID)
+	}
+	err := query.Order("deleted_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&posts).Error
+	return posts, err
+}
+
+// Restore возвращает пост из корзины.
+// Возвращает ErrSlugConflict, если слаг поста уже занят неудаленным постом,
+// в том числе если его одновременно заняли восстановлением или созданием другого поста.
+func (r *PostRepository) Restore(id uint) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var post Post
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&post, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPostNotFound
+			}
+			return err
+		}
+
+		var taken int64
+		if err := tx.Model(&Post{}).Where("slug = ?", post.Slug).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrSlugConflict
+		}
+
+		return tx.Unscoped().Model(&Post{}).
+			Where("id = ?", id).
+			Update("deleted_at", nil).Error
+	})
+	if isSlugConflict(err) {
+		return ErrSlugConflict
+	}
+	return err
+}
+
+// isSlugConflict сообщает, что запрос нарушил уникальность слага неудаленных постов
+func isSlugConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) &&
+		pgErr.Code == "23505" &&
+		pgErr.ConstraintName == "idx_posts_slug_live"
+}
+
+// Purge окончательно удаляет пост из корзины.
+// Комментарии и черновики поста удаляются каскадно внешними ключами.
+func (r *PostRepository) Purge(id uint) error {
+	result := r.trashScope().Delete(&Post{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPostNotFound
+	}
+	return nil
+}
+
+// FindForUpdate возвращает посты по IDs или фильтру, блокируя их строки (SELECT ... FOR UPDATE).
+// Вызывать нужно внутри WithTransaction, иначе блокировка снимается сразу.
+func (r *PostRepository) FindForUpdate(ids []uint, filter *BulkFilter, limit int) ([]Post, error) {
+	var posts []Post
+	query := r.DB.Clauses(clause.Locking{Strength: "UPDATE"})
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	if filter != nil {
+		if filter.Status != "" {
+			query = query.Where("status = ?", filter.Status)
+		}
+		if filter.Tag != "" {
+			query = query.Where("? = ANY(tags)", filter.Tag)
+		}
+		if filter.AuthorID != nil {
+			query = query.Where("author_id = ?", *filter.AuthorID)
+		}
+	}
+	err := query.Order("id ASC").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+// WithTransaction выполняет fn в транзакции с репозиторием, привязанным к ней
+func (r *PostRepository) WithTransaction(fn func(repo Repository) error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&PostRepository{BaseRepository: database.NewBaseRepository(tx)})
+	})
+}
+
+// PurgeDeletedBefore окончательно удаляет посты, перемещенные в корзину раньше cutoff
+func (r *PostRepository) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+	result := r.trashScope().Where("deleted_at < ?", cutoff).Delete(&Post{})
+	return result.RowsAffected, result.Error
+}
+
 // List возвращает список постов с пагинацией.
 // Принимает offset (смещение от начала) и limit (максимальное количество записей).
 // Возвращает срез постов и error в случае неудачи.
 // Посты сортируются по дате создания в обратном порядке (новые первые).
+// При opts.PinnedFirst действующие закрепленные посты идут первыми в порядке pin_order.
 // Удаленные записи (soft deleted) не включаются в результат.
-func (r *PostRepository) List(offset, limit int) ([]Post, error) {
+func (r *PostRepository) List(opts ListOptions) ([]Post, error) {
 	var posts []Post
-	err := r.DB.Offset(offset).Limit(limit).Order("created_at DESC").Find(&posts).Error
+	query := r.DB.Offset(opts.Offset).Limit(opts.Limit)
+	if opts.PinnedFirst {
+		// pin_order учитывается только у действующих закреплений: у истекших, еще
+		// не сброшенных фоновой задачей, он не должен влиять на порядок остальных постов
+		now := time.Now()
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL: "CASE WHEN is_pinned AND (pinned_until IS NULL OR pinned_until > ?) THEN 0 ELSE 1 END, " +
+				"CASE WHEN is_pinned AND (pinned_until IS NULL OR pinned_until > ?) THEN pin_order ELSE 0 END",
+			Vars:               []interface{}{now, now},
+			WithoutParentheses: true,
+		}})
+	}
+	err := query.Order("created_at DESC").Find(&posts).Error
+	return posts, err
+}
+
+// SetPinned закрепляет или открепляет пост.
+func (r *PostRepository) SetPinned(id uint, pinned bool, until *time.Time, order int) error {
+	return r.setHighlight(id, map[string]interface{}{
+		"is_pinned":    pinned,
+		"pinned_until": until,
+		"pin_order":    order,
+	})
+}
+
+// SetFeatured добавляет пост в избранное или убирает из него
+func (r *PostRepository) SetFeatured(id uint, featured bool, until *time.Time, order int) error {
+	return r.setHighlight(id, map[string]interface{}{
+		"is_featured":    featured,
+		"featured_until": until,
+		"featured_order": order,
+	})
+}
+
+// setHighlight обновляет поля закрепления/избранного.
+// Поля входят в ответ GET, поэтому версия увеличивается, чтобы сменился ETag.
+func (r *PostRepository) setHighlight(id uint, fields map[string]interface{}) error {
+	fields["version"] = gorm.Expr("version + 1")
+	result := r.DB.Model(&Post{}).Where("id = ?", id).Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPostNotFound
+	}
+	return nil
+}
+
+// GetFeatured возвращает опубликованные избранные посты с неистекшим сроком.
+// Посты сортируются по featured_order, затем по дате публикации.
+func (r *PostRepository) GetFeatured(limit int) ([]Post, error) {
+	var posts []Post
+	err := r.DB.Where("status = ? AND is_featured AND (featured_until IS NULL OR featured_until > ?)",
+		StatusPublished, time.Now()).
+		Order("featured_order ASC").
+		Order("published_at DESC").
+		Limit(limit).
+		Find(&posts).Error
 	return posts, err
 }
+
+// ClearExpiredHighlights снимает закрепления и избранное, срок которых истек к now.
+// Версия затронутых постов увеличивается, как при ручном снятии.
+// Возвращает количество затронутых постов.
+func (r *PostRepository) ClearExpiredHighlights(now time.Time) (int64, error) {
+	var affected int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		pins := tx.Model(&Post{}).
+			Where("is_pinned AND pinned_until IS NOT NULL AND pinned_until <= ?", now).
+			Updates(map[string]interface{}{"is_pinned": false, "pinned_until": nil, "pin_order": 0, "version": gorm.Expr("version + 1")})
+		if pins.Error != nil {
+			return pins.Error
+		}
+
+		featured := tx.Model(&Post{}).
+			Where("is_featured AND featured_until IS NOT NULL AND featured_until <= ?", now).
+			Updates(map[string]interface{}{"is_featured": false, "featured_until": nil, "featured_order": 0, "version": gorm.Expr("version + 1")})
+		if featured.Error != nil {
+			return featured.Error
+		}
+
+		affected = pins.RowsAffected + featured.RowsAffected
+		return nil
+	})
+	return affected, err
+}
+
+// draftScope ограничивает выборку черновиками пользователя для поста.
+// Черновик еще не созданного поста хранится с post_id IS NULL.
+func (r *PostRepository) draftScope(postID *uint, authorID uint) *gorm.DB {
+	query := r.DB.Where("author_id = ?", authorID)
+	if postID == nil {
+		return query.Where("post_id IS NULL")
+	}
+	return query.Where("post_id = ?", *postID)
+}
+
+// GetDraft возвращает черновик пользователя.
+// Если черновик не найден, возвращает (nil, nil).
+func (r *PostRepository) GetDraft(postID *uint, authorID uint) (*Draft, error) {
+	var draft Draft
+	if err := r.draftScope(postID, authorID).First(&draft).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// SaveDraft создает черновик или перезаписывает существующий.
+// У пользователя может быть только один черновик на пост, что
+// дополнительно гарантируется уникальным индексом в БД.
+func (r *PostRepository) SaveDraft(draft *Draft) error {
+	existing, err := r.GetDraft(draft.PostID, draft.AuthorID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		draft.ID = existing.ID
+		draft.CreatedAt = existing.CreatedAt
+	}
+	return r.DB.Save(draft).Error
+}
+
+// DeleteDraft удаляет черновик пользователя
+func (r *PostRepository) DeleteDraft(postID *uint, authorID uint) error {
+	return r.draftScope(postID, authorID).Delete(&Draft{}).Error
+}
@@ -0,0 +1,182 @@
+package mock
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+)
+
+type PostsRepositoryMock struct {
+	mock.Mock
+}
+
+func (r *PostsRepositoryMock) Create(post *posts.Post) error {
+	args := r.Called(post)
+	return args.Error(0)
+}
+
+func (r *PostsRepositoryMock) GetByTitle(title string) (*posts.Post, error) {
+	args := r.Called(title)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*posts.Post), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) GetBySlug(slug string) (*posts.Post, error) {
+	args := r.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*posts.Post), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) GetByAuthor(authorID uint) ([]posts.Post, error) {
+	args := r.Called(authorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]posts.Post), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) GetByTag(tag string) ([]posts.Post, error) {
+	args := r.Called(tag)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]posts.Post), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) GetByID(id uint) (*posts.Post, error) {
+	args := r.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*posts.Post), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) GetByPublishedAt(from, to time.Time, offset, limit int) ([]posts.Post, error) {
+	args := r.Called(from, to, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]posts.Post), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) CountByMonth() ([]posts.ArchiveMonth, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]posts.ArchiveMonth), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) Update(post *posts.Post) error {
+	args := r.Called(post)
+	return args.Error(0)
+}
+
+func (r *PostsRepositoryMock) IncrementViewCount(id uint) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *PostsRepositoryMock) Delete(id uint) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *PostsRepositoryMock) GetDeletedByID(id uint) (*posts.Post, error) {
+	args := r.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*posts.Post), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) ListDeleted(authorID *uint, offset, limit int) ([]posts.Post, error) {
+	args := r.Called(authorID, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]posts.Post), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) Restore(id uint) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *PostsRepositoryMock) Purge(id uint) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *PostsRepositoryMock) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+	args := r.Called(cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) FindForUpdate(ids []uint, filter *posts.BulkFilter, limit int) ([]posts.Post, error) {
+	args := r.Called(ids, filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]posts.Post), args.Error(1)
+}
+
+// WithTransaction выполняет fn с этим же моком вместо репозитория транзакции.
+// Ошибка fn возвращается как есть, откат не моделируется.
+func (r *PostsRepositoryMock) WithTransaction(fn func(repo posts.Repository) error) error {
+	return fn(r)
+}
+
+func (r *PostsRepositoryMock) List(opts posts.ListOptions) ([]posts.Post, error) {
+	args := r.Called(opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]posts.Post), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) SetPinned(id uint, pinned bool, until *time.Time, order int) error {
+	args := r.Called(id, pinned, until, order)
+	return args.Error(0)
+}
+
+func (r *PostsRepositoryMock) SetFeatured(id uint, featured bool, until *time.Time, order int) error {
+	args := r.Called(id, featured, until, order)
+	return args.Error(0)
+}
+
+func (r *PostsRepositoryMock) GetFeatured(limit int) ([]posts.Post, error) {
+	args := r.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]posts.Post), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) ClearExpiredHighlights(now time.Time) (int64, error) {
+	args := r.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) GetDraft(postID *uint, authorID uint) (*posts.Draft, error) {
+	args := r.Called(postID, authorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*posts.Draft), args.Error(1)
+}
+
+func (r *PostsRepositoryMock) SaveDraft(draft *posts.Draft) error {
+	args := r.Called(draft)
+	return args.Error(0)
+}
+
+func (r *PostsRepositoryMock) DeleteDraft(postID *uint, authorID uint) error {
+	args := r.Called(postID, authorID)
+	return args.Error(0)
+}
@@ -1,24 +1,93 @@
 package posts
 
 import (
+	"errors"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/gosimple/slug"
-	"github.com/microcosm-cc/bluemonday"
-	"github.com/russross/blackfriday/v2"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/markdown"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/shortcode"
 )
 
+// PublishListener вызывается после первой публикации поста
+type PublishListener func(post Post)
+
+// Mentioner находит упоминания @username в HTML поста и уведомляет
+// упомянутых пользователей. Реализуется сервисом упоминаний.
+type Mentioner interface {
+	// Link заменяет упоминания ссылками на профили и возвращает ID упомянутых пользователей
+	Link(html string) (string, []uint)
+	// Record сохраняет упоминания и уведомляет впервые упомянутых пользователей
+	Record(subjectType string, subjectID, authorID uint, userIDs []uint) error
+}
+
+// Publisher рассылает события читателям в реальном времени.
+// Реализуется events.Hub.
+type Publisher interface {
+	// Publish рассылает событие kind с данными data подписчикам темы
+	Publish(topic, kind string, data interface{})
+}
+
+// mentionSubject - тип объекта для упоминаний в постах
+const mentionSubject = "post"
+
 // PostService реализует бизнес-логику работы с постами
 type PostService struct {
-	repo Repository
+	repo       Repository
+	site       config.SiteConfig
+	shortcodes *shortcode.Registry
+	markdown   *markdown.Renderer
+	mentions   Mentioner // Может быть nil - упоминания не обрабатываются
+	listeners  []PublishListener
 }
 
 // NewPostService создает новый экземпляр сервиса постов
-func NewPostService(repo Repository) *PostService {
-	return &PostService{
-		repo: repo,
+func NewPostService(repo Repository, site config.SiteConfig) *PostService {
+	s := &PostService{
+		repo:       repo,
+		site:       site,
+		shortcodes: shortcode.NewRegistry(),
+		markdown:   markdown.NewPostRenderer(),
 	}
+	s.shortcodes.Register("post", s.postShortcode)
+	return s
+}
+
+// OnPublish регистрирует обработчик публикации поста.
+// Обработчики вызываются синхронно после сохранения, поэтому долгую
+// работу (например, доставку в федерацию) они должны выполнять в фоне.
+// Регистрировать обработчики нужно до начала обработки запросов.
+func (s *PostService) OnPublish(listener PublishListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// UseMentions включает обработку упоминаний в постах.
+// Упомянутые пользователи получают уведомление при публикации поста
+// или при редактировании опубликованного поста.
+// Вызывать нужно до начала обработки запросов.
+func (s *PostService) UseMentions(mentions Mentioner) {
+	s.mentions = mentions
+}
+
+// UsePublisher включает рассылку событий о новых публикациях читателям сайта.
+// Вызывать нужно до начала обработки запросов.
+func (s *PostService) UsePublisher(publisher Publisher) {
+	s.OnPublish(func(post Post) {
+		publisher.Publish(StreamTopic, EventPostPublished, PostEvent{
+			ID:          post.ID,
+			Title:       post.Title,
+			Slug:        post.Slug,
+			Description: post.Description,
+			Tags:        post.Tags,
+			AuthorID:    post.AuthorID,
+			PublishedAt: post.PublishedAt,
+		})
+	})
 }
 
 // CreatePost создает новый пост
@@ -32,11 +101,17 @@ func (s *PostService) CreatePost(post *Post) error {
 	post.Slug = slug.Make(post.Title)
 
 	// Рендеринг HTML из Markdown
-	post.HTMLContent = s.renderHTML(post.RawContent)
+	post.HTMLContent, _ = s.renderHTML(post.RawContent)
 
 	// Установка начальных значений
 	post.Status = StatusDraft
 	post.ViewCount = 0
+	post.Version = 1
+	post.HiddenByModeration = false
+
+	// Закрепление и избранное назначаются только через отдельные методы
+	post.IsPinned, post.PinnedUntil, post.PinOrder = false, nil, 0
+	post.IsFeatured, post.FeaturedUntil, post.FeaturedOrder = false, nil, 0
 	post.CreatedAt = time.Now()
 	post.UpdatedAt = time.Now()
 
@@ -71,9 +146,22 @@ func (s *PostService) UpdatePost(post *Post) error {
 		return ErrPostNotFound
 	}
 
+	// Клиент редактировал устаревшую версию поста
+	if post.Version != existing.Version {
+		return ErrVersionConflict
+	}
+
+	// Скрытие модерацией снимает только модератор (UnhidePost)
+	post.HiddenByModeration = existing.HiddenByModeration
+	if post.HiddenByModeration && post.Status == StatusPublished {
+		return ErrHiddenByModeration
+	}
+
 	// Обновляем HTML контент если изменился Markdown
-	if post.RawContent != existing.RawContent {
-		post.HTMLContent = s.renderHTML(post.RawContent)
+	var mentioned []uint
+	changed := post.RawContent != existing.RawContent
+	if changed {
+		post.HTMLContent, mentioned = s.renderHTML(post.RawContent)
 	}
 
 	// Обновляем слаг если изменился заголовок
@@ -82,16 +170,30 @@ func (s *PostService) UpdatePost(post *Post) error {
 	}
 
 	// Если пост публикуется впервые
-	if post.Status == StatusPublished && existing.Status != StatusPublished {
+	published := post.Status == StatusPublished && existing.Status != StatusPublished
+	if published {
 		now := time.Now()
 		post.PublishedAt = &now
 	}
 
 	post.UpdatedAt = time.Now()
-	return s.repo.Update(post)
+	if err := s.repo.Update(post); err != nil {
+		return err
+	}
+
+	if changed && !published && post.Status == StatusPublished {
+		s.recordMentions(post, mentioned)
+	}
+	if published {
+		s.publishMentions(*post)
+		for _, listener := range s.listeners {
+			listener(*post)
+		}
+	}
+	return nil
 }
 
-// DeletePost удаляет пост
+// DeletePost перемещает пост в корзину
 func (s *PostService) DeletePost(id uint) error {
 	// Проверяем существование поста
 	if _, err := s.GetPost(id); err != nil {
@@ -100,9 +202,137 @@ func (s *PostService) DeletePost(id uint) error {
 	return s.repo.Delete(id)
 }
 
+// GetTrash получает посты из корзины с пагинацией
+func (s *PostService) GetTrash(authorID *uint, offset, limit int) ([]Post, error) {
+	return s.repo.ListDeleted(authorID, offset, limit)
+}
+
+// GetTrashedPost получает пост из корзины
+func (s *PostService) GetTrashedPost(id uint) (*Post, error) {
+	post, err := s.repo.GetDeletedByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, ErrPostNotFound
+	}
+	return post, nil
+}
+
+// RestorePost возвращает пост из корзины
+func (s *PostService) RestorePost(id uint) (*Post, error) {
+	if err := s.repo.Restore(id); err != nil {
+		return nil, err
+	}
+	return s.GetPost(id)
+}
+
+// HidePost снимает опубликованный пост с публикации по решению модерации
+// (статус archived с отметкой HiddenByModeration, пока она стоит, автор
+// не может опубликовать пост снова). Возвращает false, если пост не опубликован.
+func (s *PostService) HidePost(id uint) (bool, error) {
+	post, err := s.GetPost(id)
+	if err != nil {
+		return false, err
+	}
+	if post.Status != StatusPublished {
+		return false, nil
+	}
+
+	post.Status = StatusArchived
+	post.HiddenByModeration = true
+	if err := s.repo.Update(post); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnhidePost возвращает в публикацию пост, скрытый HidePost.
+// В отличие от UpdatePost, дата публикации сохраняется, а подписчики
+// не уведомляются о повторной публикации. Если автор с тех пор перевел
+// пост в черновики, снимается только отметка скрытия.
+func (s *PostService) UnhidePost(id uint) error {
+	post, err := s.GetPost(id)
+	if err != nil {
+		return err
+	}
+	if !post.HiddenByModeration {
+		return nil
+	}
+
+	post.HiddenByModeration = false
+	if post.Status == StatusArchived {
+		post.Status = StatusPublished
+	}
+	return s.repo.Update(post)
+}
+
+// PurgePost окончательно удаляет пост из корзины
+func (s *PostService) PurgePost(id uint) error {
+	return s.repo.Purge(id)
+}
+
+// PurgeExpiredTrash окончательно удаляет посты, находящиеся в корзине дольше retention
+func (s *PostService) PurgeExpiredTrash(retention time.Duration) (int64, error) {
+	return s.repo.PurgeDeletedBefore(time.Now().Add(-retention))
+}
+
 // ListPosts получает список постов с пагинацией
-func (s *PostService) ListPosts(offset, limit int) ([]Post, error) {
-	return s.repo.List(offset, limit)
+func (s *PostService) ListPosts(opts ListOptions) ([]Post, error) {
+	return s.repo.List(opts)
+}
+
+// PinPost закрепляет пост на главной странице.
+// Если указан req.Until, закрепление снимается автоматически по истечении срока.
+func (s *PostService) PinPost(id uint, req HighlightRequest) (*Post, error) {
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+	if err := s.repo.SetPinned(id, true, req.Until, req.Order); err != nil {
+		return nil, err
+	}
+	return s.GetPost(id)
+}
+
+// UnpinPost снимает закрепление поста
+func (s *PostService) UnpinPost(id uint) error {
+	return s.repo.SetPinned(id, false, nil, 0)
+}
+
+// FeaturePost добавляет пост в избранное.
+// Если указан req.Until, пост убирается из избранного по истечении срока.
+func (s *PostService) FeaturePost(id uint, req HighlightRequest) (*Post, error) {
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+	if err := s.repo.SetFeatured(id, true, req.Until, req.Order); err != nil {
+		return nil, err
+	}
+	return s.GetPost(id)
+}
+
+// UnfeaturePost убирает пост из избранного
+func (s *PostService) UnfeaturePost(id uint) error {
+	return s.repo.SetFeatured(id, false, nil, 0)
+}
+
+// GetFeaturedPosts возвращает действующие избранные посты
+func (s *PostService) GetFeaturedPosts(limit int) ([]Post, error) {
+	posts, err := s.repo.GetFeatured(limit)
+	if err != nil {
+		return nil, err
+	}
+	if posts == nil {
+		posts = []Post{}
+	}
+	return posts, nil
+}
+
+// ExpireHighlights снимает истекшие закрепления и избранное.
+// Выборки и так не учитывают истекшие записи, очистка лишь
+// приводит флаги в БД в соответствие с фактическим состоянием.
+func (s *PostService) ExpireHighlights() (int64, error) {
+	return s.repo.ClearExpiredHighlights(time.Now())
 }
 
 // GetPostByTitle получает пост по его заголовку
@@ -153,26 +383,296 @@ func (s *PostService) GetPostsByTag(tag string) ([]Post, error) {
 	return posts, nil
 }
 
-// GetPostsByPublishedAt возвращает посты, опубликованные в указанный период
-func (s *PostService) GetPostsByPublishedAt(from, to time.Time) ([]Post, error) {
-	posts, err := s.repo.GetByPublishedAt(from, to)
+// GetPostsByPublishedAt возвращает опубликованные посты за период [from, to).
+// Пустой период не является ошибкой.
+func (s *PostService) GetPostsByPublishedAt(from, to time.Time, offset, limit int) ([]Post, error) {
+	posts, err := s.repo.GetByPublishedAt(from, to, offset, limit)
 	if err != nil {
 		return nil, err
 	}
 	if posts == nil {
-		return nil, ErrPostNotFound
+		posts = []Post{}
 	}
 	return posts, nil
 }
 
+// GetArchive возвращает количество опубликованных постов по годам и месяцам
+func (s *PostService) GetArchive() ([]ArchiveMonth, error) {
+	months, err := s.repo.CountByMonth()
+	if err != nil {
+		return nil, err
+	}
+	if months == nil {
+		months = []ArchiveMonth{}
+	}
+	return months, nil
+}
+
+// GetArchiveMonth возвращает опубликованные посты за месяц (в UTC)
+func (s *PostService) GetArchiveMonth(year, month, offset, limit int) ([]Post, error) {
+	if year < 1 || year > 9999 || month < 1 || month > 12 {
+		return nil, ErrInvalidArchivePeriod
+	}
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return s.GetPostsByPublishedAt(from, from.AddDate(0, 1, 0), offset, limit)
+}
+
 // IncrementViewCount увеличивает счетчик просмотров поста
 func (s *PostService) IncrementViewCount(id uint) error {
-	post, err := s.GetPost(id)
+	return s.repo.IncrementViewCount(id)
+}
+
+// PreviewPost рендерит Markdown в санитизированный HTML без сохранения поста
+func (s *PostService) PreviewPost(rawContent string) string {
+	html, _ := s.renderHTML(rawContent)
+	return html
+}
+
+// maxBulkItems ограничивает число постов в одной массовой операции
+const maxBulkItems = 500
+
+// errBulkRollback откатывает транзакцию массовой операции (dry-run или ошибки по постам)
+var errBulkRollback = errors.New("bulk operation rolled back")
+
+// BulkUpdate выполняет массовую операцию над постами в одной транзакции.
+// Каждый пост изменяется в своей точке сохранения, поэтому ошибка одного поста
+// не мешает получить результаты по остальным. Если хотя бы один пост не найден
+// или не изменен из-за ошибки, а также в режиме dry-run транзакция откатывается.
+func (s *PostService) BulkUpdate(req BulkRequest) (*BulkResult, error) {
+	if err := validateBulkRequest(req); err != nil {
+		return nil, err
+	}
+
+	result := &BulkResult{DryRun: req.DryRun, Items: []BulkItemResult{}}
+	var published []Post
+
+	err := s.repo.WithTransaction(func(tx Repository) error {
+		targets, err := tx.FindForUpdate(req.IDs, req.Filter, maxBulkItems+1)
+		if err != nil {
+			return err
+		}
+		if len(targets) > maxBulkItems {
+			return ErrBulkTooLarge
+		}
+		result.Matched = len(targets)
+
+		found := make(map[uint]bool, len(targets))
+		for i := range targets {
+			post := &targets[i]
+			found[post.ID] = true
+
+			wasPublished := post.Status == StatusPublished
+			if !applyBulkOperation(post, req) {
+				result.add(BulkItemResult{ID: post.ID, Result: BulkItemUnchanged})
+				continue
+			}
+			if post.HiddenByModeration && post.Status == StatusPublished {
+				result.add(BulkItemResult{ID: post.ID, Result: BulkItemFailed, Error: ErrHiddenByModeration.Error()})
+				continue
+			}
+
+			// Как и в UpdatePost, переход в published фиксирует дату публикации
+			becamePublished := post.Status == StatusPublished && !wasPublished
+			if becamePublished {
+				now := time.Now()
+				post.PublishedAt = &now
+			}
+			post.UpdatedAt = time.Now()
+
+			if err := tx.WithTransaction(func(item Repository) error {
+				return item.Update(post)
+			}); err != nil {
+				result.add(BulkItemResult{ID: post.ID, Result: BulkItemFailed, Error: err.Error()})
+				continue
+			}
+
+			result.add(BulkItemResult{ID: post.ID, Result: BulkItemUpdated})
+			if becamePublished {
+				published = append(published, *post)
+			}
+		}
+
+		reported := make(map[uint]bool)
+		for _, id := range req.IDs {
+			if !found[id] && !reported[id] {
+				reported[id] = true
+				result.add(BulkItemResult{ID: id, Result: BulkItemNotFound, Error: ErrPostNotFound.Error()})
+			}
+		}
+
+		if req.DryRun || result.Failed > 0 {
+			return errBulkRollback
+		}
+		return nil
+	})
+	if err != nil && err != errBulkRollback {
+		return nil, err
+	}
+
+	result.Applied = err == nil
+	if result.Applied {
+		for _, post := range published {
+			s.publishMentions(post)
+			for _, listener := range s.listeners {
+				listener(post)
+			}
+		}
+	}
+	return result, nil
+}
+
+// add добавляет результат по посту и обновляет счетчики
+func (r *BulkResult) add(item BulkItemResult) {
+	r.Items = append(r.Items, item)
+	switch item.Result {
+	case BulkItemUpdated:
+		r.Updated++
+	case BulkItemUnchanged:
+		r.Unchanged++
+	default:
+		r.Failed++
+	}
+}
+
+// validateBulkRequest проверяет выбор постов и параметры операции
+func validateBulkRequest(req BulkRequest) error {
+	byFilter := req.Filter != nil && !req.Filter.IsEmpty()
+	if (len(req.IDs) > 0) == byFilter {
+		return ErrBulkTargets
+	}
+	if len(req.IDs) > maxBulkItems {
+		return ErrBulkTooLarge
+	}
+
+	switch req.Operation {
+	case BulkSetStatus:
+		if req.Status != StatusDraft && req.Status != StatusPublished && req.Status != StatusArchived {
+			return ErrInvalidStatus
+		}
+	case BulkAddTags, BulkRemoveTags:
+		if len(req.Tags) == 0 {
+			return ErrBulkOperation
+		}
+		for _, tag := range req.Tags {
+			if strings.TrimSpace(tag) == "" {
+				return ErrBulkOperation
+			}
+		}
+	case BulkSetAuthor:
+		if req.AuthorID == 0 {
+			return ErrBulkOperation
+		}
+	default:
+		return ErrBulkOperation
+	}
+	return nil
+}
+
+// applyBulkOperation применяет операцию к посту в памяти.
+// Возвращает false, если пост от этого не изменился.
+func applyBulkOperation(post *Post, req BulkRequest) bool {
+	switch req.Operation {
+	case BulkSetStatus:
+		if post.Status == req.Status {
+			return false
+		}
+		post.Status = req.Status
+	case BulkAddTags:
+		changed := false
+		for _, tag := range req.Tags {
+			tag = strings.TrimSpace(tag)
+			if !containsTag(post.Tags, tag) {
+				post.Tags = append(post.Tags, tag)
+				changed = true
+			}
+		}
+		return changed
+	case BulkRemoveTags:
+		kept := make([]string, 0, len(post.Tags))
+		for _, tag := range post.Tags {
+			if !containsTag(req.Tags, strings.TrimSpace(tag)) {
+				kept = append(kept, tag)
+			}
+		}
+		if len(kept) == len(post.Tags) {
+			return false
+		}
+		post.Tags = kept
+	case BulkSetAuthor:
+		if post.AuthorID == req.AuthorID {
+			return false
+		}
+		post.AuthorID = req.AuthorID
+	}
+	return true
+}
+
+// containsTag проверяет наличие тега в списке без учета пробелов по краям
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.TrimSpace(t) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// GetOEmbed возвращает описание встраивания опубликованного поста по его публичной ссылке
+func (s *PostService) GetOEmbed(link string, maxWidth, maxHeight int) (*OEmbed, error) {
+	postSlug, ok := s.site.SlugFromURL(link)
+	if !ok {
+		return nil, ErrUnsupportedURL
+	}
+
+	post, err := s.publishedBySlug(postSlug)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	post.ViewCount++
-	return s.repo.Update(post)
+
+	width, height := embedSize(maxWidth, maxHeight)
+	return &OEmbed{
+		Version: "1.0",
+		Type:    "rich",
+		Title:   post.Title,
+		HTML:    s.postEmbed(post),
+		Width:   width,
+		Height:  height,
+	}, nil
+}
+
+// publishedBySlug возвращает опубликованный пост по слагу
+func (s *PostService) publishedBySlug(postSlug string) (*Post, error) {
+	post, err := s.repo.GetBySlug(postSlug)
+	if err != nil {
+		return nil, err
+	}
+	if post == nil || post.Status != StatusPublished {
+		return nil, ErrPostNotFound
+	}
+	return post, nil
+}
+
+// GetDraft возвращает автосохраненный черновик пользователя
+func (s *PostService) GetDraft(postID *uint, authorID uint) (*Draft, error) {
+	draft, err := s.repo.GetDraft(postID, authorID)
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil {
+		return nil, ErrDraftNotFound
+	}
+	return draft, nil
+}
+
+// AutosaveDraft сохраняет снимок черновика.
+// Пост при этом не изменяется: ни контент, ни UpdatedAt не трогаются.
+func (s *PostService) AutosaveDraft(draft *Draft) error {
+	return s.repo.SaveDraft(draft)
+}
+
+// DiscardDraft удаляет автосохраненный черновик пользователя
+func (s *PostService) DiscardDraft(postID *uint, authorID uint) error {
+	return s.repo.DeleteDraft(postID, authorID)
 }
 
 // validatePost проверяет корректность данных поста
@@ -189,15 +689,50 @@ func (s *PostService) validatePost(post *Post) error {
 	   post.Status != StatusArchived {
 		return ErrInvalidStatus
 	}
+	if !post.CommentModeration.Valid() {
+		return ErrInvalidModeration
+	}
+	if !post.CommentMode.Valid() {
+		return ErrInvalidCommentMode
+	}
+	if post.CommentMode == "" {
+		post.CommentMode = comments.CommentsOpen
+	}
 	return nil
 }
 
-// renderHTML конвертирует Markdown в HTML с санитизацией
-func (s *PostService) renderHTML(markdown string) string {
-	// Конвертируем Markdown в HTML
-	unsafe := blackfriday.Run([]byte(markdown))
-	
-	// Санитизируем HTML
-	p := bluemonday.UGCPolicy()
-	return string(p.SanitizeBytes(unsafe))
+// renderHTML конвертирует Markdown в HTML с санитизацией.
+// Возвращает также ID пользователей, упомянутых в тексте.
+func (s *PostService) renderHTML(markdown string) (string, []uint) {
+	// Заменяем шорткоды плейсхолдерами, чтобы вставки не прошли через Markdown
+	text, embeds := s.shortcodes.Extract(markdown)
+
+	// Конвертируем Markdown в санитизированный HTML, отмечаем упоминания
+	// и подставляем уже проверенную разметку вставок
+	html := s.markdown.Render(text)
+	var mentioned []uint
+	if s.mentions != nil {
+		html, mentioned = s.mentions.Link(html)
+	}
+	return embeds.Restore(html), mentioned
+}
+
+// publishMentions уведомляет пользователей, упомянутых в только что опубликованном посте
+func (s *PostService) publishMentions(post Post) {
+	if s.mentions == nil {
+		return
+	}
+	_, mentioned := s.renderHTML(post.RawContent)
+	s.recordMentions(&post, mentioned)
+}
+
+// recordMentions сохраняет упоминания поста и уведомляет впервые упомянутых пользователей.
+// Ошибка не отменяет сохранение поста.
+func (s *PostService) recordMentions(post *Post, userIDs []uint) {
+	if s.mentions == nil {
+		return
+	}
+	if err := s.mentions.Record(mentionSubject, post.ID, post.AuthorID, userIDs); err != nil {
+		log.Printf("Failed to record mentions in post %d: %v", post.ID, err)
+	}
 }
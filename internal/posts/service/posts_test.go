@@ -0,0 +1,474 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+	mockRepo "gitlab.com/Nikolay-Yakunin/blog-service/internal/posts/repository/mock"
+	jwtlib "gitlab.com/Nikolay-Yakunin/blog-service/pkg/auth/jwt"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/httpcache"
+)
+
+func TestPostService_HiddenByModeration(t *testing.T) {
+	repo := new(mockRepo.PostsRepositoryMock)
+	service := posts.NewPostService(repo, config.SiteConfig{})
+
+	var notified []uint
+	service.OnPublish(func(post posts.Post) { notified = append(notified, post.ID) })
+
+	stored := &posts.Post{ID: 1, Title: "Пост", RawContent: "текст", Status: posts.StatusPublished, Version: 3}
+	repo.On("GetByID", uint(1)).Return(stored, nil)
+	repo.On("Update", mock.AnythingOfType("*posts.Post")).Return(nil)
+
+	hidden, err := service.HidePost(1)
+	assert.NoError(t, err)
+	assert.True(t, hidden)
+	assert.Equal(t, posts.StatusArchived, stored.Status)
+	assert.True(t, stored.HiddenByModeration)
+
+	// Автор не может вернуть пост в публикацию, даже сбросив флаг в запросе
+	err = service.UpdatePost(&posts.Post{ID: 1, Title: "Пост", RawContent: "текст", Status: posts.StatusPublished, Version: 3})
+	assert.ErrorIs(t, err, posts.ErrHiddenByModeration)
+	assert.Empty(t, notified)
+
+	// Редактировать скрытый пост без публикации можно, флаг сохраняется
+	update := &posts.Post{ID: 1, Title: "Пост", RawContent: "исправлено", Status: posts.StatusArchived, Version: 3}
+	assert.NoError(t, service.UpdatePost(update))
+	assert.True(t, update.HiddenByModeration)
+
+	// Модератор отклонил жалобы: пост снова опубликован без повторного уведомления
+	assert.NoError(t, service.UnhidePost(1))
+	assert.Equal(t, posts.StatusPublished, stored.Status)
+	assert.False(t, stored.HiddenByModeration)
+	assert.Empty(t, notified)
+}
+
+func TestPostService_UnhidePostKeepsAuthorArchive(t *testing.T) {
+	repo := new(mockRepo.PostsRepositoryMock)
+	service := posts.NewPostService(repo, config.SiteConfig{})
+
+	// Пост, который автор сам снял с публикации, модерация не возвращает
+	repo.On("GetByID", uint(2)).Return(&posts.Post{ID: 2, Status: posts.StatusArchived}, nil)
+	assert.NoError(t, service.UnhidePost(2))
+	repo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestPostsHandler_ETagIgnoresViewCount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := new(mockRepo.PostsRepositoryMock)
+	service := posts.NewPostService(repo, config.SiteConfig{})
+
+	stored := &posts.Post{ID: 1, Title: "Пост", Status: posts.StatusPublished, Version: 3, ViewCount: 10}
+	repo.On("GetByID", uint(1)).Return(stored, nil)
+	repo.On("IncrementViewCount", uint(1)).Return(nil).Maybe()
+	repo.On("List", mock.Anything).Return([]posts.Post{*stored}, nil)
+
+	router := gin.New()
+	posts.NewHandler(service, &config.Config{}).Register(router)
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("/api/v1/posts/1", "")
+	etag := first.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusNotModified, get("/api/v1/posts/1", etag).Code)
+
+	// ETag передается в If-Match как версия поста
+	version, err := httpcache.ParseIfMatch(etag)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), version)
+
+	// Каждое чтение увеличивает счетчик просмотров, но кэш остается актуальным
+	stored.ViewCount = 11
+	assert.Equal(t, http.StatusNotModified, get("/api/v1/posts/1", etag).Code)
+
+	// Изменение поста меняет ETag
+	stored.Version = 4
+	changed := get("/api/v1/posts/1", etag)
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+
+	// Списки не передают Last-Modified и тоже не зависят от просмотров
+	list := get("/api/v1/posts", "")
+	listETag := list.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, list.Code)
+	assert.NotEmpty(t, listETag)
+	assert.Empty(t, list.Header().Get("Last-Modified"))
+	stored.ViewCount = 12
+	assert.Equal(t, http.StatusNotModified, get("/api/v1/posts", listETag).Code)
+}
+
+func TestPostsHandler_UpdateRejectsWeakIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	repo := new(mockRepo.PostsRepositoryMock)
+	service := posts.NewPostService(repo, config.SiteConfig{})
+
+	router := gin.New()
+	posts.NewHandler(service, &config.Config{}).Register(router)
+
+	token, err := jwtlib.GenerateToken(&jwtlib.TokenUser{ID: 7, Role: "user"})
+	assert.NoError(t, err)
+	body := `{"title":"Пост","raw_content":"текст","author_id":7}`
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/posts/1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-Match", `W/"3"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	repo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestPostService_Trash(t *testing.T) {
+	repo := new(mockRepo.PostsRepositoryMock)
+	service := posts.NewPostService(repo, config.SiteConfig{})
+	authorID := uint(7)
+
+	// Удаление переносит существующий пост в корзину
+	repo.On("GetByID", uint(1)).Return(&posts.Post{ID: 1, AuthorID: authorID}, nil).Once()
+	repo.On("Delete", uint(1)).Return(nil)
+	assert.NoError(t, service.DeletePost(1))
+
+	repo.On("GetByID", uint(2)).Return(nil, nil)
+	assert.ErrorIs(t, service.DeletePost(2), posts.ErrPostNotFound)
+	repo.AssertNotCalled(t, "Delete", uint(2))
+
+	repo.On("ListDeleted", &authorID, 0, 20).Return([]posts.Post{{ID: 1, AuthorID: authorID}}, nil)
+	trash, err := service.GetTrash(&authorID, 0, 20)
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+
+	repo.On("GetDeletedByID", uint(1)).Return(&posts.Post{ID: 1}, nil)
+	repo.On("GetDeletedByID", uint(3)).Return(nil, nil)
+	post, err := service.GetTrashedPost(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), post.ID)
+	_, err = service.GetTrashedPost(3)
+	assert.ErrorIs(t, err, posts.ErrPostNotFound)
+}
+
+func TestPostService_RestorePost(t *testing.T) {
+	tests := []struct {
+		name       string
+		restoreErr error
+		wantErr    error
+	}{
+		{name: "Restores post", restoreErr: nil},
+		{name: "Post not in trash", restoreErr: posts.ErrPostNotFound, wantErr: posts.ErrPostNotFound},
+		{name: "Slug taken by live post", restoreErr: posts.ErrSlugConflict, wantErr: posts.ErrSlugConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.PostsRepositoryMock)
+			service := posts.NewPostService(repo, config.SiteConfig{})
+
+			repo.On("Restore", uint(1)).Return(tt.restoreErr)
+			repo.On("GetByID", uint(1)).Return(&posts.Post{ID: 1, Slug: "post"}, nil).Maybe()
+
+			post, err := service.RestorePost(1)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, post)
+				repo.AssertNotCalled(t, "GetByID", uint(1))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "post", post.Slug)
+		})
+	}
+}
+
+func TestPostsHandler_RestoreSlugConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	repo := new(mockRepo.PostsRepositoryMock)
+	service := posts.NewPostService(repo, config.SiteConfig{})
+
+	repo.On("GetDeletedByID", uint(1)).Return(&posts.Post{ID: 1, AuthorID: 7}, nil)
+	repo.On("Restore", uint(1)).Return(posts.ErrSlugConflict)
+
+	router := gin.New()
+	posts.NewHandler(service, &config.Config{}).Register(router)
+
+	token, err := jwtlib.GenerateToken(&jwtlib.TokenUser{ID: 7, Role: "user"})
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/posts/1/restore", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestPostService_Purge(t *testing.T) {
+	repo := new(mockRepo.PostsRepositoryMock)
+	service := posts.NewPostService(repo, config.SiteConfig{})
+
+	repo.On("Purge", uint(1)).Return(nil)
+	repo.On("Purge", uint(2)).Return(posts.ErrPostNotFound)
+	assert.NoError(t, service.PurgePost(1))
+	assert.ErrorIs(t, service.PurgePost(2), posts.ErrPostNotFound)
+
+	// Удаляются посты, пролежавшие в корзине дольше срока хранения
+	retention := 30 * 24 * time.Hour
+	before := time.Now().Add(-retention)
+	repo.On("PurgeDeletedBefore", mock.MatchedBy(func(cutoff time.Time) bool {
+		return !cutoff.Before(before) && cutoff.Before(time.Now().Add(-retention+time.Minute))
+	})).Return(int64(3), nil)
+
+	purged, err := service.PurgeExpiredTrash(retention)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+}
+
+func TestPostsHandler_GetArchiveMonth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	march := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantOffset int
+		wantLimit  int
+	}{
+		{name: "Defaults", path: "/api/v1/posts/archive/2024/3", wantStatus: http.StatusOK, wantOffset: 0, wantLimit: 10},
+		{name: "Explicit page", path: "/api/v1/posts/archive/2024/3?offset=20&limit=5", wantStatus: http.StatusOK, wantOffset: 20, wantLimit: 5},
+		{name: "Limit above maximum", path: "/api/v1/posts/archive/2024/3?limit=100000", wantStatus: http.StatusOK, wantOffset: 0, wantLimit: 100},
+		{name: "Negative values", path: "/api/v1/posts/archive/2024/3?offset=-5&limit=-1", wantStatus: http.StatusOK, wantOffset: 0, wantLimit: 1},
+		{name: "Not a number", path: "/api/v1/posts/archive/2024/3?offset=x&limit=y", wantStatus: http.StatusOK, wantOffset: 0, wantLimit: 10},
+		{name: "Invalid month", path: "/api/v1/posts/archive/2024/13", wantStatus: http.StatusBadRequest},
+		{name: "Invalid year", path: "/api/v1/posts/archive/abc/3", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.PostsRepositoryMock)
+			service := posts.NewPostService(repo, config.SiteConfig{})
+			repo.On("GetByPublishedAt", march, march.AddDate(0, 1, 0), tt.wantOffset, tt.wantLimit).Return(nil, nil).Maybe()
+
+			router := gin.New()
+			posts.NewHandler(service, &config.Config{}).Register(router)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				repo.AssertNotCalled(t, "GetByPublishedAt", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			repo.AssertExpectations(t)
+			assert.JSONEq(t, "[]", w.Body.String())
+		})
+	}
+}
+
+func TestPostsHandler_GetFeaturedPostsLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantLimit int
+	}{
+		{name: "Default", query: "", wantLimit: 5},
+		{name: "Explicit", query: "?limit=3", wantLimit: 3},
+		{name: "Negative would disable the limit", query: "?limit=-1", wantLimit: 1},
+		{name: "Above maximum", query: "?limit=100000", wantLimit: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.PostsRepositoryMock)
+			service := posts.NewPostService(repo, config.SiteConfig{})
+			repo.On("GetFeatured", tt.wantLimit).Return(nil, nil)
+
+			router := gin.New()
+			posts.NewHandler(service, &config.Config{}).Register(router)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/posts/featured"+tt.query, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPostsHandler_GetArchive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := new(mockRepo.PostsRepositoryMock)
+	service := posts.NewPostService(repo, config.SiteConfig{})
+
+	months := []posts.ArchiveMonth{{Year: 2024, Month: 3, Count: 7}, {Year: 2024, Month: 1, Count: 2}}
+	repo.On("CountByMonth").Return(months, nil).Once()
+	repo.On("CountByMonth").Return(nil, nil).Once()
+
+	router := gin.New()
+	posts.NewHandler(service, &config.Config{}).Register(router)
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/posts/archive", nil))
+		return w
+	}
+
+	w := get()
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got []posts.ArchiveMonth
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, months, got)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	// Пустой архив возвращается пустым массивом, а не null, и с другим ETag
+	empty := get()
+	assert.Equal(t, http.StatusOK, empty.Code)
+	assert.JSONEq(t, "[]", empty.Body.String())
+	assert.NotEqual(t, etag, empty.Header().Get("ETag"))
+}
+
+func TestPostService_BulkUpdate(t *testing.T) {
+	drafts := func() []posts.Post {
+		return []posts.Post{
+			{ID: 1, Title: "Первый", Status: posts.StatusDraft, Version: 1},
+			{ID: 2, Title: "Второй", Status: posts.StatusDraft, Version: 1},
+			{ID: 3, Title: "Третий", Status: posts.StatusPublished, Version: 1},
+		}
+	}
+	publish := posts.BulkRequest{IDs: []uint{1, 2, 3}, Operation: posts.BulkSetStatus, Status: posts.StatusPublished}
+
+	tests := []struct {
+		name          string
+		req           posts.BulkRequest
+		targets       []posts.Post
+		updateErr     map[uint]error // Ошибка сохранения по ID поста
+		wantErr       error
+		wantApplied   bool
+		wantUpdated   int
+		wantUnchanged int
+		wantFailed    int
+		wantPublished []uint
+	}{
+		{
+			name:          "Publishes drafts",
+			req:           publish,
+			targets:       drafts(),
+			wantApplied:   true,
+			wantUpdated:   2,
+			wantUnchanged: 1,
+			wantPublished: []uint{1, 2},
+		},
+		{
+			name:          "Dry run reports changes without applying them",
+			req:           posts.BulkRequest{IDs: publish.IDs, Operation: publish.Operation, Status: publish.Status, DryRun: true},
+			targets:       drafts(),
+			wantUpdated:   2,
+			wantUnchanged: 1,
+		},
+		{
+			name:        "Partial failure rolls back everything",
+			req:         publish,
+			targets:     drafts(),
+			updateErr:   map[uint]error{2: posts.ErrVersionConflict},
+			wantUpdated: 1, wantUnchanged: 1, wantFailed: 1,
+		},
+		{
+			name:        "Missing post fails the batch",
+			req:         posts.BulkRequest{IDs: []uint{1, 4}, Operation: posts.BulkAddTags, Tags: []string{"go"}},
+			targets:     drafts()[:1],
+			wantUpdated: 1, wantFailed: 1,
+		},
+		{
+			name:       "Post hidden by moderation cannot be published",
+			req:        posts.BulkRequest{IDs: []uint{5}, Operation: posts.BulkSetStatus, Status: posts.StatusPublished},
+			targets:    []posts.Post{{ID: 5, Status: posts.StatusArchived, HiddenByModeration: true}},
+			wantFailed: 1,
+		},
+		{
+			name:    "Filter matching more than 500 posts",
+			req:     posts.BulkRequest{Filter: &posts.BulkFilter{Status: posts.StatusDraft}, Operation: posts.BulkAddTags, Tags: []string{"go"}},
+			targets: make([]posts.Post, 501),
+			wantErr: posts.ErrBulkTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.PostsRepositoryMock)
+			service := posts.NewPostService(repo, config.SiteConfig{})
+			var published []uint
+			service.OnPublish(func(post posts.Post) {
+				assert.NotNil(t, post.PublishedAt)
+				published = append(published, post.ID)
+			})
+
+			repo.On("FindForUpdate", tt.req.IDs, tt.req.Filter, 501).Return(tt.targets, nil)
+			for _, target := range tt.targets {
+				id := target.ID
+				repo.On("Update", mock.MatchedBy(func(post *posts.Post) bool { return post.ID == id })).
+					Return(tt.updateErr[id]).Maybe()
+			}
+
+			result, err := service.BulkUpdate(tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "Update", mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantApplied, result.Applied)
+			assert.Equal(t, tt.req.DryRun, result.DryRun)
+			assert.Equal(t, tt.wantUpdated, result.Updated)
+			assert.Equal(t, tt.wantUnchanged, result.Unchanged)
+			assert.Equal(t, tt.wantFailed, result.Failed)
+			assert.Equal(t, tt.wantPublished, published)
+		})
+	}
+}
+
+func TestPostService_BulkUpdateValidation(t *testing.T) {
+	service := posts.NewPostService(new(mockRepo.PostsRepositoryMock), config.SiteConfig{})
+	tooMany := make([]uint, 501)
+	for i := range tooMany {
+		tooMany[i] = uint(i + 1)
+	}
+
+	tests := []struct {
+		name string
+		req  posts.BulkRequest
+		want error
+	}{
+		{name: "No targets", req: posts.BulkRequest{Operation: posts.BulkAddTags, Tags: []string{"go"}}, want: posts.ErrBulkTargets},
+		{name: "Both IDs and filter", req: posts.BulkRequest{IDs: []uint{1}, Filter: &posts.BulkFilter{Tag: "go"}, Operation: posts.BulkAddTags, Tags: []string{"go"}}, want: posts.ErrBulkTargets},
+		{name: "Too many IDs", req: posts.BulkRequest{IDs: tooMany, Operation: posts.BulkAddTags, Tags: []string{"go"}}, want: posts.ErrBulkTooLarge},
+		{name: "Unknown operation", req: posts.BulkRequest{IDs: []uint{1}, Operation: "rename"}, want: posts.ErrBulkOperation},
+		{name: "Invalid status", req: posts.BulkRequest{IDs: []uint{1}, Operation: posts.BulkSetStatus, Status: "deleted"}, want: posts.ErrInvalidStatus},
+		{name: "Blank tag", req: posts.BulkRequest{IDs: []uint{1}, Operation: posts.BulkRemoveTags, Tags: []string{" "}}, want: posts.ErrBulkOperation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.BulkUpdate(tt.req)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
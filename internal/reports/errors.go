@@ -0,0 +1,30 @@
+package reports
+
+import "errors"
+
+var (
+	ErrInvalidSubject  = errors.New("unknown report subject type")
+	ErrSubjectNotFound = errors.New("reported content not found")
+	ErrInvalidReason   = errors.New("invalid report reason")
+	ErrTextRequired    = errors.New("report text is required for reason other")
+	ErrTextTooLong     = errors.New("report text is too long")
+	ErrOwnContent      = errors.New("cannot report your own content")
+	ErrAlreadyReported = errors.New("content already reported by this user")
+	ErrNoOpenReports   = errors.New("no open reports for this content")
+)
+
+// ErrorResponse представляет структуру ответа с ошибкой
+type ErrorResponse struct {
+	Code    int    `json:"code" example:"404" swagger:"description=HTTP код ошибки"`
+	Message string `json:"message" example:"Content not found" swagger:"description=Описание ошибки"`
+	Details string `json:"details,omitempty" example:"reported content not found" swagger:"description=Дополнительные детали ошибки"`
+}
+
+// NewErrorResponse создает новый экземпляр ErrorResponse
+func NewErrorResponse(code int, message string, details string) *ErrorResponse {
+	return &ErrorResponse{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
@@ -0,0 +1,266 @@
+package reports
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/middleware"
+)
+
+// errorStatuses задает HTTP-статусы ошибок сервиса жалоб
+var errorStatuses = map[error]int{
+	ErrInvalidSubject:  http.StatusBadRequest,
+	ErrInvalidReason:   http.StatusBadRequest,
+	ErrTextRequired:    http.StatusBadRequest,
+	ErrTextTooLong:     http.StatusBadRequest,
+	ErrSubjectNotFound: http.StatusNotFound,
+	ErrNoOpenReports:   http.StatusNotFound,
+	ErrOwnContent:      http.StatusForbidden,
+	ErrAlreadyReported: http.StatusConflict,
+}
+
+// Handler обрабатывает HTTP-запросы жалоб
+type Handler struct {
+	service Service
+	config  *config.Config
+}
+
+// NewHandler создает новый обработчик HTTP-запросов жалоб
+func NewHandler(service Service, cfg *config.Config) *Handler {
+	return &Handler{
+		service: service,
+		config:  cfg,
+	}
+}
+
+// Register регистрирует все пути обработки HTTP-запросов.
+// Жалобы отправляются на маршрутах комментариев и постов, панель модератора - /api/v1/reports.
+func (h *Handler) Register(router *gin.Engine) {
+	router.POST("/api/v1/comments/:id/report", middleware.AuthMiddleware(), h.ReportComment)
+	router.POST("/api/v1/posts/:id/report", middleware.AuthMiddleware(), h.ReportPost)
+
+	reportsAPI := router.Group("/api/v1/reports")
+	reportsAPI.Use(middleware.AuthMiddleware(), users.RequireRoles(users.RoleModerator, users.RoleAdmin))
+	{
+		reportsAPI.GET("", h.ListOpen)
+		reportsAPI.GET("/:type/:id", h.GetSubjectReports)
+		reportsAPI.POST("/:type/:id/resolve", h.Resolve)
+		reportsAPI.POST("/:type/:id/dismiss", h.Dismiss)
+	}
+}
+
+// ReportComment сохраняет жалобу на комментарий
+// @Security JWT
+// @Summary Пожаловаться на комментарий
+// @Description Причина other требует пояснения. Когда на комментарий пожалуются reports.hide_threshold пользователей, он скрывается до решения модератора.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param id path int true "ID комментария"
+// @Param report body ReportRequest true "Жалоба"
+// @Success 201 {object} Report
+// @Failure 400,401,403,404,409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/comments/{id}/report [post]
+func (h *Handler) ReportComment(c *gin.Context) {
+	h.report(c, SubjectComment)
+}
+
+// ReportPost сохраняет жалобу на пост
+// @Security JWT
+// @Summary Пожаловаться на пост
+// @Description Причина other требует пояснения. Когда на пост пожалуются reports.hide_threshold пользователей, он снимается с публикации до решения модератора.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param id path int true "ID поста"
+// @Param report body ReportRequest true "Жалоба"
+// @Success 201 {object} Report
+// @Failure 400,401,403,404,409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/report [post]
+func (h *Handler) ReportPost(c *gin.Context) {
+	h.report(c, SubjectPost)
+}
+
+// report сохраняет жалобу текущего пользователя на объект subjectType
+func (h *Handler) report(c *gin.Context, subjectType string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid ID",
+			err.Error(),
+		))
+		return
+	}
+
+	var req ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid report data",
+			err.Error(),
+		))
+		return
+	}
+
+	report, err := h.service.Report(subjectType, uint(id), c.GetUint("userID"), req)
+	if err != nil {
+		respondError(c, err, "Failed to save report")
+		return
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// ListOpen возвращает объекты с открытыми жалобами для панели модератора
+// Доступно только модераторам и администраторам.
+// Общее количество объектов передается в заголовке X-Total-Count.
+// @Security JWT
+// @Summary Открытые жалобы
+// @Tags reports
+// @Produce json
+// @Param type query string false "Тип объекта (по умолчанию - все)" Enums(comment,post)
+// @Param offset query int false "Смещение"
+// @Param limit query int false "Количество записей"
+// @Success 200 {array} Summary
+// @Header 200 {integer} X-Total-Count "Количество объектов с открытыми жалобами"
+// @Failure 400,401,403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/reports [get]
+func (h *Handler) ListOpen(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	summaries, total, err := h.service.ListOpen(c.Query("type"), offset, limit)
+	if err != nil {
+		respondError(c, err, "Failed to fetch reports")
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, summaries)
+}
+
+// GetSubjectReports возвращает жалобы на объект и журнал действий по ним
+// Доступно только модераторам и администраторам.
+// @Security JWT
+// @Summary Жалобы на объект
+// @Tags reports
+// @Produce json
+// @Param type path string true "Тип объекта" Enums(comment,post)
+// @Param id path int true "ID объекта"
+// @Success 200 {object} SubjectReports
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/reports/{type}/{id} [get]
+func (h *Handler) GetSubjectReports(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid ID",
+			err.Error(),
+		))
+		return
+	}
+
+	reports, err := h.service.GetSubjectReports(c.Param("type"), uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch reports")
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// Resolve подтверждает открытые жалобы на объект
+// Доступно только модераторам и администраторам. Объект скрывается, если он еще опубликован.
+// @Security JWT
+// @Summary Подтвердить жалобы
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param type path string true "Тип объекта" Enums(comment,post)
+// @Param id path int true "ID объекта"
+// @Param decision body DecisionRequest false "Комментарий модератора"
+// @Success 200 {object} Action
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/reports/{type}/{id}/resolve [post]
+func (h *Handler) Resolve(c *gin.Context) {
+	h.decide(c, h.service.Resolve)
+}
+
+// Dismiss отклоняет открытые жалобы на объект
+// Доступно только модераторам и администраторам. Объект, скрытый по жалобам,
+// возвращается в публикацию.
+// @Security JWT
+// @Summary Отклонить жалобы
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param type path string true "Тип объекта" Enums(comment,post)
+// @Param id path int true "ID объекта"
+// @Param decision body DecisionRequest false "Комментарий модератора"
+// @Success 200 {object} Action
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/reports/{type}/{id}/dismiss [post]
+func (h *Handler) Dismiss(c *gin.Context) {
+	h.decide(c, h.service.Dismiss)
+}
+
+// decide применяет решение модератора к открытым жалобам на объект
+func (h *Handler) decide(c *gin.Context, op func(subjectType string, subjectID, moderatorID uint, note string) (*Action, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid ID",
+			err.Error(),
+		))
+		return
+	}
+
+	// Комментарий модератора необязателен, тело запроса может отсутствовать
+	var req DecisionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(
+				http.StatusBadRequest,
+				"Invalid decision data",
+				err.Error(),
+			))
+			return
+		}
+	}
+
+	action, err := op(c.Param("type"), uint(id), c.GetUint("userID"), req.Note)
+	if err != nil {
+		respondError(c, err, "Failed to apply decision")
+		return
+	}
+
+	c.JSON(http.StatusOK, action)
+}
+
+// respondError отвечает статусом, соответствующим ошибке сервиса
+func respondError(c *gin.Context, err error, message string) {
+	status, ok := errorStatuses[err]
+	if !ok {
+		status = http.StatusInternalServerError
+	} else {
+		message = http.StatusText(status)
+	}
+	c.JSON(status, NewErrorResponse(
+		status,
+		message,
+		err.Error(),
+	))
+}
@@ -0,0 +1,198 @@
+// Package reports реализует жалобы читателей на комментарии и посты.
+//
+// Пользователь может один раз пожаловаться на объект, указав причину и пояснение.
+// Когда число открытых жалоб разных пользователей достигает порога, объект
+// автоматически скрывается до решения модератора. Модератор подтверждает жалобы
+// (объект остается скрытым) или отклоняет их (автоматически скрытый объект
+// возвращается в публикацию). Все решения записываются в журнал действий.
+//
+// Основные компоненты:
+//   - Report: жалоба пользователя
+//   - Action: запись журнала действий по жалобам
+//   - Target: скрытие и восстановление объектов жалоб
+//   - Repository: интерфейс хранилища
+//   - Service: бизнес-логика жалоб
+package reports
+
+import (
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+)
+
+// Типы объектов, на которые можно пожаловаться
+const (
+	SubjectComment = "comment"
+	SubjectPost    = "post"
+)
+
+// Reason определяет категорию жалобы
+type Reason string
+
+const (
+	// ReasonSpam - спам или реклама
+	ReasonSpam Reason = "spam"
+	// ReasonAbuse - оскорбления и травля
+	ReasonAbuse Reason = "abuse"
+	// ReasonIllegal - незаконный контент
+	ReasonIllegal Reason = "illegal"
+	// ReasonOffTopic - не по теме
+	ReasonOffTopic Reason = "off_topic"
+	// ReasonOther - другое, пояснение обязательно
+	ReasonOther Reason = "other"
+)
+
+// Status определяет состояние жалобы
+type Status string
+
+const (
+	// StatusOpen - жалоба ожидает решения модератора
+	StatusOpen Status = "open"
+	// StatusResolved - модератор подтвердил жалобу
+	StatusResolved Status = "resolved"
+	// StatusDismissed - модератор отклонил жалобу
+	StatusDismissed Status = "dismissed"
+)
+
+// ActionType определяет тип записи журнала действий
+type ActionType string
+
+const (
+	// ActionHidden - объект скрыт (автоматически или при подтверждении жалоб)
+	ActionHidden ActionType = "hidden"
+	// ActionRestored - скрытый объект возвращен в публикацию
+	ActionRestored ActionType = "restored"
+	// ActionResolved - модератор подтвердил открытые жалобы
+	ActionResolved ActionType = "resolved"
+	// ActionDismissed - модератор отклонил открытые жалобы
+	ActionDismissed ActionType = "dismissed"
+)
+
+// Report представляет жалобу пользователя на комментарий или пост
+// @Description Жалоба на комментарий или пост
+type Report struct {
+	ID          uint       `json:"id" gorm:"primaryKey" example:"1"`
+	SubjectType string     `json:"subject_type" gorm:"size:20;not null" example:"comment"` // comment или post
+	SubjectID   uint       `json:"subject_id" gorm:"not null" example:"17"`
+	ReporterID  uint       `json:"reporter_id" gorm:"not null;index" example:"42"`
+	Reason      Reason     `json:"reason" gorm:"size:20;not null" example:"spam" enums:"spam,abuse,illegal,off_topic,other"`
+	Text        string     `json:"text" gorm:"type:text" example:"Ссылки на казино"`
+	Status      Status     `json:"status" gorm:"size:20;not null;default:'open'" example:"open" enums:"open,resolved,dismissed"`
+	ResolvedBy  *uint      `json:"resolved_by,omitempty" example:"5"` // Модератор, принявший решение
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty" example:"2025-01-03T12:00:00Z"`
+	CreatedAt   time.Time  `json:"created_at" example:"2025-01-03T11:00:00Z"`
+}
+
+// Action представляет запись журнала действий по жалобам на объект
+// @Description Запись журнала действий по жалобам
+type Action struct {
+	ID          uint       `json:"id" gorm:"primaryKey" example:"1"`
+	SubjectType string     `json:"subject_type" gorm:"size:20;not null" example:"comment"`
+	SubjectID   uint       `json:"subject_id" gorm:"not null" example:"17"`
+	Action      ActionType `json:"action" gorm:"size:20;not null" example:"dismissed" enums:"hidden,restored,resolved,dismissed"`
+	ModeratorID *uint      `json:"moderator_id,omitempty" example:"5"`                        // nil - автоматическое действие
+	Reports     int        `json:"reports" gorm:"not null;default:0" example:"3"`             // Сколько жалоб затронуло действие
+	Note        string     `json:"note,omitempty" gorm:"type:text" example:"Обычная критика"` // Комментарий модератора
+	CreatedAt   time.Time  `json:"created_at" example:"2025-01-03T12:00:00Z"`
+}
+
+// TableName задает имя таблицы журнала действий
+func (Action) TableName() string {
+	return "report_actions"
+}
+
+// ReportRequest - тело запроса жалобы
+// @Description Жалоба на комментарий или пост
+type ReportRequest struct {
+	Reason Reason `json:"reason" binding:"required" example:"spam" enums:"spam,abuse,illegal,off_topic,other"`
+	Text   string `json:"text" example:"Ссылки на казино"` // Обязательно для причины other
+}
+
+// DecisionRequest - тело запроса решения модератора
+// @Description Решение модератора по жалобам
+type DecisionRequest struct {
+	Note string `json:"note" example:"Обычная критика"`
+}
+
+// Summary описывает объект с открытыми жалобами для панели модератора
+// @Description Объект с открытыми жалобами
+type Summary struct {
+	SubjectType     string    `json:"subject_type" example:"comment"`
+	SubjectID       uint      `json:"subject_id" example:"17"`
+	Reports         int       `json:"reports" example:"3"`                   // Количество открытых жалоб
+	Reasons         []Reason  `json:"reasons" gorm:"-" example:"spam,abuse"` // Причины открытых жалоб
+	ReasonList      string    `json:"-" gorm:"column:reasons"`
+	FirstReportedAt time.Time `json:"first_reported_at" example:"2025-01-03T11:00:00Z"`
+	LastReportedAt  time.Time `json:"last_reported_at" example:"2025-01-03T11:30:00Z"`
+}
+
+// SubjectReports содержит все жалобы на объект и журнал действий по ним
+// @Description Жалобы на объект и журнал действий
+type SubjectReports struct {
+	SubjectType string   `json:"subject_type" example:"comment"`
+	SubjectID   uint     `json:"subject_id" example:"17"`
+	Hidden      bool     `json:"hidden" example:"true"` // Объект скрыт по жалобам
+	Reports     []Report `json:"reports"`               // Новые первыми
+	Actions     []Action `json:"actions"`               // Новые первыми
+}
+
+// Target скрывает объекты жалоб одного типа и возвращает их в публикацию
+type Target interface {
+	// Author возвращает автора объекта или ErrSubjectNotFound
+	Author(id uint) (uint, error)
+	// Hide скрывает опубликованный объект; false - объект уже не опубликован
+	Hide(id uint) (bool, error)
+	// Unhide возвращает скрытый объект в публикацию
+	Unhide(id uint) error
+}
+
+// CommentModerator скрывает комментарии (реализуется comments.Service)
+type CommentModerator interface {
+	GetComment(id uint) (*comments.Comment, error)
+	SetCommentStatus(id uint, status comments.Status, moderatorID *uint) (*comments.Comment, error)
+}
+
+// PostModerator скрывает посты (реализуется posts.Service)
+type PostModerator interface {
+	GetPost(id uint) (*posts.Post, error)
+	HidePost(id uint) (bool, error)
+	UnhidePost(id uint) error
+}
+
+// Repository описывает методы для работы с хранилищем жалоб
+type Repository interface {
+	// Create сохраняет жалобу; ErrAlreadyReported, если у пользователя есть открытая жалоба на объект
+	Create(report *Report) error
+	// CountOpen возвращает количество открытых жалоб на объект
+	CountOpen(subjectType string, subjectID uint) (int64, error)
+	// ListOpen возвращает объекты с открытыми жалобами, самые обжалованные первыми
+	ListOpen(subjectType string, offset, limit int) ([]Summary, error)
+	// CountOpenSubjects возвращает количество объектов с открытыми жалобами
+	CountOpenSubjects(subjectType string) (int64, error)
+	// GetBySubject возвращает все жалобы на объект, новые первыми
+	GetBySubject(subjectType string, subjectID uint) ([]Report, error)
+	// Close закрывает открытые жалобы на объект и записывает действие в журнал.
+	// Возвращает запись журнала; ErrNoOpenReports, если открытых жалоб нет.
+	Close(subjectType string, subjectID uint, status Status, moderatorID uint, note string) (*Action, error)
+	// AddAction добавляет запись в журнал действий
+	AddAction(action *Action) error
+	// GetActions возвращает журнал действий по объекту, новые первыми
+	GetActions(subjectType string, subjectID uint) ([]Action, error)
+	// IsHidden сообщает, что последнее действие с видимостью объекта - скрытие
+	IsHidden(subjectType string, subjectID uint) (bool, error)
+}
+
+// Service описывает бизнес-логику работы с жалобами
+type Service interface {
+	// Report сохраняет жалобу и скрывает объект при достижении порога
+	Report(subjectType string, subjectID, reporterID uint, req ReportRequest) (*Report, error)
+	// ListOpen возвращает объекты с открытыми жалобами и их общее количество
+	ListOpen(subjectType string, offset, limit int) ([]Summary, int64, error)
+	// GetSubjectReports возвращает жалобы на объект и журнал действий
+	GetSubjectReports(subjectType string, subjectID uint) (*SubjectReports, error)
+	// Resolve подтверждает открытые жалобы и скрывает объект
+	Resolve(subjectType string, subjectID, moderatorID uint, note string) (*Action, error)
+	// Dismiss отклоняет открытые жалобы и возвращает скрытый по ним объект в публикацию
+	Dismiss(subjectType string, subjectID, moderatorID uint, note string) (*Action, error)
+}
@@ -0,0 +1,170 @@
+package reports
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/database"
+)
+
+// closeActions задает запись журнала для решения по жалобам
+var closeActions = map[Status]ActionType{
+	StatusResolved:  ActionResolved,
+	StatusDismissed: ActionDismissed,
+}
+
+// ReportRepository реализует интерфейс Repository для работы с БД
+type ReportRepository struct {
+	database.BaseRepository
+}
+
+// NewReportRepository создает новый экземпляр репозитория жалоб
+func NewReportRepository(db *gorm.DB) Repository {
+	return &ReportRepository{
+		BaseRepository: database.NewBaseRepository(db),
+	}
+}
+
+// Create сохраняет жалобу.
+// Повторная открытая жалоба того же пользователя отсекается уникальным индексом.
+func (r *ReportRepository) Create(report *Report) error {
+	result := r.DB.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "subject_type"}, {Name: "subject_id"}, {Name: "reporter_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status = 'open'"}}},
+		DoNothing:   true,
+	}).Create(report)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAlreadyReported
+	}
+	return nil
+}
+
+// CountOpen возвращает количество открытых жалоб на объект
+func (r *ReportRepository) CountOpen(subjectType string, subjectID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&Report{}).
+		Where("subject_type = ? AND subject_id = ? AND status = ?", subjectType, subjectID, StatusOpen).
+		Count(&count).Error
+	return count, err
+}
+
+// openScope выбирает открытые жалобы (subjectType == "" - на объекты всех типов)
+func (r *ReportRepository) openScope(subjectType string) *gorm.DB {
+	query := r.DB.Model(&Report{}).Where("status = ?", StatusOpen)
+	if subjectType != "" {
+		query = query.Where("subject_type = ?", subjectType)
+	}
+	return query
+}
+
+// ListOpen возвращает объекты с открытыми жалобами, самые обжалованные первыми
+func (r *ReportRepository) ListOpen(subjectType string, offset, limit int) ([]Summary, error) {
+	var summaries []Summary
+	err := r.openScope(subjectType).
+		Select("subject_type, subject_id, COUNT(*) AS reports, " +
+			"string_agg(DISTINCT reason, ',') AS reasons, " +
+			"MIN(created_at) AS first_reported_at, MAX(created_at) AS last_reported_at").
+		Group("subject_type, subject_id").
+		Order("reports DESC, last_reported_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range summaries {
+		for _, reason := range strings.Split(summaries[i].ReasonList, ",") {
+			summaries[i].Reasons = append(summaries[i].Reasons, Reason(reason))
+		}
+	}
+	return summaries, nil
+}
+
+// CountOpenSubjects возвращает количество объектов с открытыми жалобами
+func (r *ReportRepository) CountOpenSubjects(subjectType string) (int64, error) {
+	var count int64
+	subjects := r.openScope(subjectType).Select("subject_type, subject_id").Group("subject_type, subject_id")
+	err := r.DB.Table("(?) AS subjects", subjects).Count(&count).Error
+	return count, err
+}
+
+// GetBySubject возвращает все жалобы на объект, новые первыми
+func (r *ReportRepository) GetBySubject(subjectType string, subjectID uint) ([]Report, error) {
+	var reports []Report
+	err := r.DB.Where("subject_type = ? AND subject_id = ?", subjectType, subjectID).
+		Order("created_at DESC, id DESC").
+		Find(&reports).Error
+	return reports, err
+}
+
+// Close закрывает открытые жалобы на объект и записывает решение в журнал в одной транзакции
+func (r *ReportRepository) Close(subjectType string, subjectID uint, status Status, moderatorID uint, note string) (*Action, error) {
+	var action *Action
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Report{}).
+			Where("subject_type = ? AND subject_id = ? AND status = ?", subjectType, subjectID, StatusOpen).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"resolved_by": moderatorID,
+				"resolved_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNoOpenReports
+		}
+
+		action = &Action{
+			SubjectType: subjectType,
+			SubjectID:   subjectID,
+			Action:      closeActions[status],
+			ModeratorID: &moderatorID,
+			Reports:     int(result.RowsAffected),
+			Note:        note,
+		}
+		return tx.Create(action).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// AddAction добавляет запись в журнал действий
+func (r *ReportRepository) AddAction(action *Action) error {
+	return r.DB.Create(action).Error
+}
+
+// GetActions возвращает журнал действий по объекту, новые первыми
+func (r *ReportRepository) GetActions(subjectType string, subjectID uint) ([]Action, error) {
+	var actions []Action
+	err := r.DB.Where("subject_type = ? AND subject_id = ?", subjectType, subjectID).
+		Order("created_at DESC, id DESC").
+		Find(&actions).Error
+	return actions, err
+}
+
+// IsHidden сообщает, что последнее действие с видимостью объекта - скрытие
+func (r *ReportRepository) IsHidden(subjectType string, subjectID uint) (bool, error) {
+	var action Action
+	err := r.DB.Where("subject_type = ? AND subject_id = ? AND action IN ?",
+		subjectType, subjectID, []ActionType{ActionHidden, ActionRestored}).
+		Order("id DESC").
+		First(&action).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return action.Action == ActionHidden, nil
+}
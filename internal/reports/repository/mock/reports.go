@@ -0,0 +1,67 @@
+package mock
+
+import (
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/reports"
+)
+
+type ReportsRepositoryMock struct {
+	mock.Mock
+}
+
+func (r *ReportsRepositoryMock) Create(report *reports.Report) error {
+	args := r.Called(report)
+	return args.Error(0)
+}
+
+func (r *ReportsRepositoryMock) CountOpen(subjectType string, subjectID uint) (int64, error) {
+	args := r.Called(subjectType, subjectID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *ReportsRepositoryMock) ListOpen(subjectType string, offset, limit int) ([]reports.Summary, error) {
+	args := r.Called(subjectType, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]reports.Summary), args.Error(1)
+}
+
+func (r *ReportsRepositoryMock) CountOpenSubjects(subjectType string) (int64, error) {
+	args := r.Called(subjectType)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *ReportsRepositoryMock) GetBySubject(subjectType string, subjectID uint) ([]reports.Report, error) {
+	args := r.Called(subjectType, subjectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]reports.Report), args.Error(1)
+}
+
+func (r *ReportsRepositoryMock) Close(subjectType string, subjectID uint, status reports.Status, moderatorID uint, note string) (*reports.Action, error) {
+	args := r.Called(subjectType, subjectID, status, moderatorID, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*reports.Action), args.Error(1)
+}
+
+func (r *ReportsRepositoryMock) AddAction(action *reports.Action) error {
+	args := r.Called(action)
+	return args.Error(0)
+}
+
+func (r *ReportsRepositoryMock) GetActions(subjectType string, subjectID uint) ([]reports.Action, error) {
+	args := r.Called(subjectType, subjectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]reports.Action), args.Error(1)
+}
+
+func (r *ReportsRepositoryMock) IsHidden(subjectType string, subjectID uint) (bool, error) {
+	args := r.Called(subjectType, subjectID)
+	return args.Get(0).(bool), args.Error(1)
+}
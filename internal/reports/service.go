@@ -0,0 +1,252 @@
+package reports
+
+import (
+	"fmt"
+	"log"
+	"unicode/utf8"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+)
+
+// maxTextLength - максимальная длина пояснения к жалобе в символах
+const maxTextLength = 1000
+
+// Параметры пагинации панели модератора
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// ReportService реализует бизнес-логику работы с жалобами
+type ReportService struct {
+	repo          Repository
+	targets       map[string]Target
+	hideThreshold int
+}
+
+// NewReportService создает новый экземпляр сервиса жалоб
+func NewReportService(repo Repository, comments, posts Target, cfg config.ReportsConfig) Service {
+	return &ReportService{
+		repo: repo,
+		targets: map[string]Target{
+			SubjectComment: comments,
+			SubjectPost:    posts,
+		},
+		hideThreshold: cfg.HideThreshold,
+	}
+}
+
+// target возвращает Target для типа объекта
+func (s *ReportService) target(subjectType string) (Target, error) {
+	target, ok := s.targets[subjectType]
+	if !ok || target == nil {
+		return nil, ErrInvalidSubject
+	}
+	return target, nil
+}
+
+// validateReport проверяет причину и пояснение жалобы
+func validateReport(req ReportRequest) error {
+	switch req.Reason {
+	case ReasonSpam, ReasonAbuse, ReasonIllegal, ReasonOffTopic:
+	case ReasonOther:
+		if req.Text == "" {
+			return ErrTextRequired
+		}
+	default:
+		return ErrInvalidReason
+	}
+
+	if utf8.RuneCountInString(req.Text) > maxTextLength {
+		return ErrTextTooLong
+	}
+	return nil
+}
+
+// Report сохраняет жалобу пользователя на объект.
+// Пользователь не может жаловаться на свой объект и повторно на тот же объект,
+// пока его жалоба открыта. Когда открытых жалоб становится не меньше порога,
+// объект скрывается до решения модератора.
+func (s *ReportService) Report(subjectType string, subjectID, reporterID uint, req ReportRequest) (*Report, error) {
+	target, err := s.target(subjectType)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateReport(req); err != nil {
+		return nil, err
+	}
+
+	authorID, err := target.Author(subjectID)
+	if err != nil {
+		return nil, err
+	}
+	if authorID == reporterID {
+		return nil, ErrOwnContent
+	}
+
+	report := &Report{
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		ReporterID:  reporterID,
+		Reason:      req.Reason,
+		Text:        req.Text,
+		Status:      StatusOpen,
+	}
+	if err := s.repo.Create(report); err != nil {
+		return nil, err
+	}
+
+	// Жалоба уже сохранена: ошибка скрытия не должна ее отменять
+	if err := s.autoHide(target, subjectType, subjectID); err != nil {
+		log.Printf("Failed to auto-hide reported %s %d: %v", subjectType, subjectID, err)
+	}
+	return report, nil
+}
+
+// autoHide скрывает объект, если открытых жалоб на него не меньше порога
+func (s *ReportService) autoHide(target Target, subjectType string, subjectID uint) error {
+	if s.hideThreshold <= 0 {
+		return nil
+	}
+
+	count, err := s.repo.CountOpen(subjectType, subjectID)
+	if err != nil || count < int64(s.hideThreshold) {
+		return err
+	}
+	return s.hide(target, subjectType, subjectID, nil, int(count))
+}
+
+// hide скрывает объект и записывает это в журнал (moderatorID == nil - автоматически)
+func (s *ReportService) hide(target Target, subjectType string, subjectID uint, moderatorID *uint, reports int) error {
+	hidden, err := s.repo.IsHidden(subjectType, subjectID)
+	if err != nil || hidden {
+		return err
+	}
+
+	changed, err := target.Hide(subjectID)
+	if err != nil || !changed {
+		return err
+	}
+	return s.repo.AddAction(&Action{
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		Action:      ActionHidden,
+		ModeratorID: moderatorID,
+		Reports:     reports,
+	})
+}
+
+// ListOpen возвращает объекты с открытыми жалобами и их общее количество
+func (s *ReportService) ListOpen(subjectType string, offset, limit int) ([]Summary, int64, error) {
+	if subjectType != "" {
+		if _, err := s.target(subjectType); err != nil {
+			return nil, 0, err
+		}
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	summaries, err := s.repo.ListOpen(subjectType, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch reports: %w", err)
+	}
+	total, err := s.repo.CountOpenSubjects(subjectType)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count reports: %w", err)
+	}
+
+	if summaries == nil {
+		summaries = []Summary{}
+	}
+	return summaries, total, nil
+}
+
+// GetSubjectReports возвращает все жалобы на объект и журнал действий по ним
+func (s *ReportService) GetSubjectReports(subjectType string, subjectID uint) (*SubjectReports, error) {
+	if _, err := s.target(subjectType); err != nil {
+		return nil, err
+	}
+
+	reports, err := s.repo.GetBySubject(subjectType, subjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reports: %w", err)
+	}
+	if len(reports) == 0 {
+		return nil, ErrSubjectNotFound
+	}
+	actions, err := s.repo.GetActions(subjectType, subjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch report actions: %w", err)
+	}
+	hidden, err := s.repo.IsHidden(subjectType, subjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch report actions: %w", err)
+	}
+
+	if actions == nil {
+		actions = []Action{}
+	}
+	return &SubjectReports{
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		Hidden:      hidden,
+		Reports:     reports,
+		Actions:     actions,
+	}, nil
+}
+
+// Resolve подтверждает открытые жалобы на объект и скрывает его, если он еще опубликован
+func (s *ReportService) Resolve(subjectType string, subjectID, moderatorID uint, note string) (*Action, error) {
+	target, err := s.target(subjectType)
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := s.repo.Close(subjectType, subjectID, StatusResolved, moderatorID, note)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.hide(target, subjectType, subjectID, &moderatorID, action.Reports); err != nil {
+		return nil, fmt.Errorf("failed to hide reported content: %w", err)
+	}
+	return action, nil
+}
+
+// Dismiss отклоняет открытые жалобы на объект.
+// Объект, скрытый по жалобам, возвращается в публикацию.
+func (s *ReportService) Dismiss(subjectType string, subjectID, moderatorID uint, note string) (*Action, error) {
+	target, err := s.target(subjectType)
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := s.repo.Close(subjectType, subjectID, StatusDismissed, moderatorID, note)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden, err := s.repo.IsHidden(subjectType, subjectID)
+	if err != nil || !hidden {
+		return action, err
+	}
+	if err := target.Unhide(subjectID); err != nil {
+		return nil, fmt.Errorf("failed to restore reported content: %w", err)
+	}
+	if err := s.repo.AddAction(&Action{
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		Action:      ActionRestored,
+		ModeratorID: &moderatorID,
+		Reports:     action.Reports,
+	}); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/reports"
+	mockRepo "gitlab.com/Nikolay-Yakunin/blog-service/internal/reports/repository/mock"
+)
+
+// targetStub запоминает скрытие и восстановление объектов
+type targetStub struct {
+	author   uint
+	hidden   []uint
+	unhidden []uint
+}
+
+func (t *targetStub) Author(id uint) (uint, error) {
+	if t.author == 0 {
+		return 0, reports.ErrSubjectNotFound
+	}
+	return t.author, nil
+}
+
+func (t *targetStub) Hide(id uint) (bool, error) {
+	t.hidden = append(t.hidden, id)
+	return true, nil
+}
+
+func (t *targetStub) Unhide(id uint) error {
+	t.unhidden = append(t.unhidden, id)
+	return nil
+}
+
+func TestReportService_Report(t *testing.T) {
+	tests := []struct {
+		name       string
+		author     uint
+		req        reports.ReportRequest
+		createErr  error
+		open       int64
+		wantErr    error
+		wantHidden bool
+	}{
+		{
+			name:   "Report below threshold",
+			author: 7,
+			req:    reports.ReportRequest{Reason: reports.ReasonSpam},
+			open:   2,
+		},
+		{
+			name:       "Threshold hides content",
+			author:     7,
+			req:        reports.ReportRequest{Reason: reports.ReasonAbuse, Text: "Оскорбления"},
+			open:       3,
+			wantHidden: true,
+		},
+		{
+			name:    "Other requires text",
+			author:  7,
+			req:     reports.ReportRequest{Reason: reports.ReasonOther},
+			wantErr: reports.ErrTextRequired,
+		},
+		{
+			name:    "Unknown reason",
+			author:  7,
+			req:     reports.ReportRequest{Reason: "boring"},
+			wantErr: reports.ErrInvalidReason,
+		},
+		{
+			name:    "Own content",
+			author:  42,
+			req:     reports.ReportRequest{Reason: reports.ReasonSpam},
+			wantErr: reports.ErrOwnContent,
+		},
+		{
+			name:    "Missing content",
+			req:     reports.ReportRequest{Reason: reports.ReasonSpam},
+			wantErr: reports.ErrSubjectNotFound,
+		},
+		{
+			name:      "Duplicate report",
+			author:    7,
+			req:       reports.ReportRequest{Reason: reports.ReasonSpam},
+			createErr: reports.ErrAlreadyReported,
+			wantErr:   reports.ErrAlreadyReported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.ReportsRepositoryMock)
+			comments := &targetStub{author: tt.author}
+			service := reports.NewReportService(repo, comments, &targetStub{}, config.ReportsConfig{HideThreshold: 3})
+
+			repo.On("Create", mock.Anything).Return(tt.createErr).Maybe()
+			repo.On("CountOpen", reports.SubjectComment, uint(17)).Return(tt.open, nil).Maybe()
+			repo.On("IsHidden", reports.SubjectComment, uint(17)).Return(false, nil).Maybe()
+			repo.On("AddAction", mock.Anything).Return(nil).Maybe()
+
+			report, err := service.Report(reports.SubjectComment, 17, 42, tt.req)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				repo.AssertNotCalled(t, "CountOpen", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.Equal(t, reports.StatusOpen, report.Status)
+			if tt.wantHidden {
+				assert.Equal(t, []uint{17}, comments.hidden)
+				repo.AssertCalled(t, "AddAction", mock.MatchedBy(func(a *reports.Action) bool {
+					return a.Action == reports.ActionHidden && a.ModeratorID == nil && a.Reports == 3
+				}))
+			} else {
+				assert.Empty(t, comments.hidden)
+			}
+		})
+	}
+}
+
+func TestReportService_Dismiss(t *testing.T) {
+	tests := []struct {
+		name         string
+		hidden       bool
+		wantUnhidden []uint
+	}{
+		{
+			name:         "Auto-hidden content is restored",
+			hidden:       true,
+			wantUnhidden: []uint{5},
+		},
+		{
+			name:   "Visible content is left as is",
+			hidden: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.ReportsRepositoryMock)
+			posts := &targetStub{author: 7}
+			service := reports.NewReportService(repo, &targetStub{}, posts, config.ReportsConfig{})
+
+			closed := &reports.Action{Action: reports.ActionDismissed, Reports: 3}
+			repo.On("Close", reports.SubjectPost, uint(5), reports.StatusDismissed, uint(1), "Обычная критика").Return(closed, nil)
+			repo.On("IsHidden", reports.SubjectPost, uint(5)).Return(tt.hidden, nil)
+			repo.On("AddAction", mock.Anything).Return(nil).Maybe()
+
+			action, err := service.Dismiss(reports.SubjectPost, 5, 1, "Обычная критика")
+			assert.NoError(t, err)
+			assert.Equal(t, closed, action)
+			assert.Equal(t, tt.wantUnhidden, posts.unhidden)
+			if tt.hidden {
+				repo.AssertCalled(t, "AddAction", mock.MatchedBy(func(a *reports.Action) bool {
+					return a.Action == reports.ActionRestored && *a.ModeratorID == 1
+				}))
+			}
+		})
+	}
+}
+
+func TestReportService_ResolveHidesContent(t *testing.T) {
+	repo := new(mockRepo.ReportsRepositoryMock)
+	comments := &targetStub{author: 7}
+	service := reports.NewReportService(repo, comments, &targetStub{}, config.ReportsConfig{})
+
+	repo.On("Close", reports.SubjectComment, uint(17), reports.StatusResolved, uint(1), "").
+		Return(&reports.Action{Action: reports.ActionResolved, Reports: 2}, nil)
+	repo.On("IsHidden", reports.SubjectComment, uint(17)).Return(false, nil)
+	repo.On("AddAction", mock.Anything).Return(nil)
+
+	_, err := service.Resolve(reports.SubjectComment, 17, 1, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{17}, comments.hidden)
+}
+
+func TestReportService_ResolveWithoutOpenReports(t *testing.T) {
+	repo := new(mockRepo.ReportsRepositoryMock)
+	service := reports.NewReportService(repo, &targetStub{}, &targetStub{}, config.ReportsConfig{})
+
+	repo.On("Close", reports.SubjectComment, uint(17), reports.StatusResolved, uint(1), "").
+		Return(nil, reports.ErrNoOpenReports)
+
+	_, err := service.Resolve(reports.SubjectComment, 17, 1, "")
+	assert.Equal(t, reports.ErrNoOpenReports, err)
+}
@@ -0,0 +1,93 @@
+package reports
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+)
+
+// commentTarget скрывает комментарии статусом hidden
+type commentTarget struct {
+	comments CommentModerator
+}
+
+// NewCommentTarget создает Target для жалоб на комментарии
+func NewCommentTarget(comments CommentModerator) Target {
+	return &commentTarget{comments: comments}
+}
+
+// Author возвращает автора комментария; удаленные комментарии считаются ненайденными
+func (t *commentTarget) Author(id uint) (uint, error) {
+	comment, err := t.comments.GetComment(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && comment.Status == comments.StatusDeleted {
+		return 0, ErrSubjectNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return comment.AuthorID, nil
+}
+
+// Hide скрывает опубликованный комментарий
+func (t *commentTarget) Hide(id uint) (bool, error) {
+	comment, err := t.comments.GetComment(id)
+	if err != nil {
+		return false, err
+	}
+	if comment.Status != comments.StatusActive {
+		return false, nil
+	}
+	if _, err := t.comments.SetCommentStatus(id, comments.StatusHidden, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Unhide возвращает в публикацию скрытый комментарий.
+// Комментарий, статус которого с тех пор изменил модератор, не меняется.
+func (t *commentTarget) Unhide(id uint) error {
+	comment, err := t.comments.GetComment(id)
+	if err != nil {
+		return err
+	}
+	if comment.Status != comments.StatusHidden {
+		return nil
+	}
+	_, err = t.comments.SetCommentStatus(id, comments.StatusActive, nil)
+	return err
+}
+
+// postTarget снимает посты с публикации
+type postTarget struct {
+	posts PostModerator
+}
+
+// NewPostTarget создает Target для жалоб на посты
+func NewPostTarget(posts PostModerator) Target {
+	return &postTarget{posts: posts}
+}
+
+// Author возвращает автора поста
+func (t *postTarget) Author(id uint) (uint, error) {
+	post, err := t.posts.GetPost(id)
+	if err == posts.ErrPostNotFound {
+		return 0, ErrSubjectNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return post.AuthorID, nil
+}
+
+// Hide снимает опубликованный пост с публикации
+func (t *postTarget) Hide(id uint) (bool, error) {
+	return t.posts.HidePost(id)
+}
+
+// Unhide возвращает пост в публикацию
+func (t *postTarget) Unhide(id uint) error {
+	return t.posts.UnhidePost(id)
+}
@@ -0,0 +1,105 @@
+package spam
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// minTrainedDocs - минимальное количество примеров каждого класса для работы классификатора
+	minTrainedDocs = 5
+	// interestingTokens - сколько самых показательных токенов участвует в оценке
+	interestingTokens = 15
+	// tokenStrength и tokenPrior задают сглаживание вероятности редких токенов (по Робинсону)
+	tokenStrength = 1.0
+	tokenPrior    = 0.5
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"')\]]+`)
+
+// extractLinks возвращает ссылки из текста комментария
+func extractLinks(text string) []string {
+	return linkPattern.FindAllString(text, -1)
+}
+
+// linkHost возвращает хост ссылки в нижнем регистре без www
+func linkHost(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// tokenize разбивает текст на уникальные токены: слова в нижнем регистре
+// и хосты ссылок с префиксом "host:"
+func tokenize(text string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	add := func(token string) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, link := range extractLinks(text) {
+		if host := linkHost(link); host != "" {
+			add("host:" + host)
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(linkPattern.ReplaceAllString(text, " ")), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if n := len([]rune(word)); n >= 3 && n <= 24 {
+			add(word)
+		}
+	}
+	return tokens
+}
+
+// bayesScore оценивает вероятность спама по статистике токенов.
+// Возвращает false, если классификатор еще не обучен.
+func bayesScore(tokens []string, counts map[string]TokenCount, spamDocs, hamDocs int64) (float64, bool) {
+	if spamDocs < minTrainedDocs || hamDocs < minTrainedDocs {
+		return 0, false
+	}
+
+	probs := make([]float64, 0, len(tokens))
+	for _, token := range tokens {
+		count, ok := counts[token]
+		if !ok {
+			continue
+		}
+		spamFreq := float64(count.SpamCount) / float64(spamDocs)
+		hamFreq := float64(count.HamCount) / float64(hamDocs)
+		if spamFreq+hamFreq == 0 {
+			continue
+		}
+
+		n := float64(count.SpamCount + count.HamCount)
+		p := spamFreq / (spamFreq + hamFreq)
+		p = (tokenStrength*tokenPrior + n*p) / (tokenStrength + n)
+		probs = append(probs, math.Min(0.99, math.Max(0.01, p)))
+	}
+
+	// Учитываем только токены, сильнее всего отклоняющиеся от нейтральных 0.5
+	sort.Slice(probs, func(i, j int) bool {
+		return math.Abs(probs[i]-0.5) > math.Abs(probs[j]-0.5)
+	})
+	if len(probs) > interestingTokens {
+		probs = probs[:interestingTokens]
+	}
+
+	var logOdds float64
+	for _, p := range probs {
+		logOdds += math.Log(p / (1 - p))
+	}
+	return 1 / (1 + math.Exp(-logOdds)), true
+}
@@ -0,0 +1,65 @@
+// Package spam оценивает новые комментарии на спам.
+//
+// Оценка складывается из эвристик (количество ссылок, запрещенные слова и домены,
+// повторы одного текста, возраст учетной записи) и наивного байесовского
+// классификатора, который обучается на решениях модераторов.
+//
+// Основные компоненты:
+//   - TokenCount, Training: статистика классификатора и обучающие примеры
+//   - Repository: интерфейс хранилища
+//   - Checker: реализация comments.SpamChecker
+package spam
+
+import (
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+)
+
+// TokenCount содержит количество спам- и не спам-комментариев, в которых встречался токен
+type TokenCount struct {
+	Token     string `gorm:"primaryKey;size:64"`
+	SpamCount int64  `gorm:"not null;default:0"`
+	HamCount  int64  `gorm:"not null;default:0"`
+}
+
+// TableName задает имя таблицы статистики токенов
+func (TokenCount) TableName() string {
+	return "spam_tokens"
+}
+
+// Training хранит решение модератора, на котором обучен классификатор.
+// Токены сохраняются, чтобы при смене решения вычесть их из статистики.
+type Training struct {
+	CommentID uint   `gorm:"primaryKey"`
+	Spam      bool   `gorm:"not null"`
+	Tokens    string `gorm:"type:text;not null"` // Токены через пробел
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName задает имя таблицы обучающих примеров
+func (Training) TableName() string {
+	return "spam_training"
+}
+
+// UserLookup предоставляет учетные записи авторов для проверки их возраста
+type UserLookup interface {
+	// GetUser получает пользователя по ID
+	GetUser(id uint) (*users.User, error)
+}
+
+// Repository описывает методы для работы с хранилищем классификатора
+type Repository interface {
+	// GetTokenCounts возвращает статистику известных токенов из списка
+	GetTokenCounts(tokens []string) (map[string]TokenCount, error)
+	// CountTrained возвращает количество обучающих примеров спама и не спама
+	CountTrained() (spam, ham int64, err error)
+	// GetTraining возвращает обучающий пример комментария или nil, если его нет
+	GetTraining(commentID uint) (*Training, error)
+	// SaveTraining сохраняет обучающий пример и обновляет статистику токенов.
+	// Если previous не nil, его токены сначала вычитаются из статистики.
+	SaveTraining(training *Training, previous *Training) error
+	// CountDuplicates возвращает количество комментариев автора с тем же текстом, созданных после since
+	CountDuplicates(authorID uint, content string, since time.Time) (int64, error)
+}
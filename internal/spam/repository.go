@@ -0,0 +1,122 @@
+package spam
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/database"
+)
+
+// SpamRepository реализует интерфейс Repository для работы с БД
+type SpamRepository struct {
+	database.BaseRepository
+}
+
+// NewSpamRepository создает новый экземпляр репозитория классификатора
+func NewSpamRepository(db *gorm.DB) Repository {
+	return &SpamRepository{
+		BaseRepository: database.NewBaseRepository(db),
+	}
+}
+
+// GetTokenCounts возвращает статистику известных токенов из списка
+func (r *SpamRepository) GetTokenCounts(tokens []string) (map[string]TokenCount, error) {
+	counts := make(map[string]TokenCount, len(tokens))
+	if len(tokens) == 0 {
+		return counts, nil
+	}
+
+	var rows []TokenCount
+	if err := r.DB.Where("token IN ?", tokens).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.Token] = row
+	}
+	return counts, nil
+}
+
+// CountTrained возвращает количество обучающих примеров спама и не спама
+func (r *SpamRepository) CountTrained() (int64, int64, error) {
+	var spam, ham int64
+	if err := r.DB.Model(&Training{}).Where("spam").Count(&spam).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := r.DB.Model(&Training{}).Where("NOT spam").Count(&ham).Error; err != nil {
+		return 0, 0, err
+	}
+	return spam, ham, nil
+}
+
+// GetTraining возвращает обучающий пример комментария.
+// Если пример не найден, возвращает (nil, nil).
+func (r *SpamRepository) GetTraining(commentID uint) (*Training, error) {
+	var training Training
+	if err := r.DB.First(&training, "comment_id = ?", commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &training, nil
+}
+
+// SaveTraining сохраняет обучающий пример и обновляет статистику токенов в одной транзакции
+func (r *SpamRepository) SaveTraining(training *Training, previous *Training) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if previous != nil {
+			column := countColumn(previous.Spam)
+			err := tx.Model(&TokenCount{}).
+				Where("token IN ?", strings.Fields(previous.Tokens)).
+				Update(column, gorm.Expr(column+" - 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		tokens := strings.Fields(training.Tokens)
+		if len(tokens) > 0 {
+			rows := make([]TokenCount, len(tokens))
+			for i, token := range tokens {
+				rows[i] = TokenCount{Token: token}
+				if training.Spam {
+					rows[i].SpamCount = 1
+				} else {
+					rows[i].HamCount = 1
+				}
+			}
+
+			column := countColumn(training.Spam)
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "token"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{column: gorm.Expr("spam_tokens." + column + " + 1")}),
+			}).Create(&rows).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Save(training).Error
+	})
+}
+
+// countColumn возвращает столбец статистики для класса примера
+func countColumn(spam bool) string {
+	if spam {
+		return "spam_count"
+	}
+	return "ham_count"
+}
+
+// CountDuplicates возвращает количество комментариев автора с тем же текстом, созданных после since
+func (r *SpamRepository) CountDuplicates(authorID uint, content string, since time.Time) (int64, error) {
+	var count int64
+	err := r.DB.Table("comments").
+		Where("author_id = ? AND content = ? AND created_at >= ?", authorID, content, since).
+		Count(&count).Error
+	return count, err
+}
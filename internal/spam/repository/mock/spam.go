@@ -0,0 +1,43 @@
+package mock
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/spam"
+)
+
+type SpamRepositoryMock struct {
+	mock.Mock
+}
+
+func (r *SpamRepositoryMock) GetTokenCounts(tokens []string) (map[string]spam.TokenCount, error) {
+	args := r.Called(tokens)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]spam.TokenCount), args.Error(1)
+}
+
+func (r *SpamRepositoryMock) CountTrained() (int64, int64, error) {
+	args := r.Called()
+	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
+}
+
+func (r *SpamRepositoryMock) GetTraining(commentID uint) (*spam.Training, error) {
+	args := r.Called(commentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*spam.Training), args.Error(1)
+}
+
+func (r *SpamRepositoryMock) SaveTraining(training *spam.Training, previous *spam.Training) error {
+	args := r.Called(training, previous)
+	return args.Error(0)
+}
+
+func (r *SpamRepositoryMock) CountDuplicates(authorID uint, content string, since time.Time) (int64, error) {
+	args := r.Called(authorID, content, since)
+	return args.Get(0).(int64), args.Error(1)
+}
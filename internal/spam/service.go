@@ -0,0 +1,165 @@
+package spam
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
+)
+
+// Веса признаков спама. Итоговая оценка равна 1 - Π(1 - вес) по сработавшим признакам,
+// поэтому несколько слабых признаков вместе дают высокую оценку.
+const (
+	weightBlockedDomain = 0.9
+	weightBlockedWord   = 0.6
+	weightTooManyLinks  = 0.5
+	weightDuplicate     = 0.5
+	weightNewAccount    = 0.3
+)
+
+// Checker реализует comments.SpamChecker на основе эвристик и байесовского классификатора
+type Checker struct {
+	repo  Repository
+	users UserLookup
+	cfg   config.SpamConfig
+	now   func() time.Time
+}
+
+// NewChecker создает новый экземпляр проверки комментариев на спам
+func NewChecker(repo Repository, users UserLookup, cfg config.SpamConfig) *Checker {
+	return &Checker{
+		repo:  repo,
+		users: users,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+// Check оценивает новый комментарий.
+// Байесовский классификатор только повышает оценку: вероятность спама p
+// добавляет признак с весом 2p - 1, если p > 0.5.
+func (c *Checker) Check(comment *comments.Comment) (*comments.SpamVerdict, error) {
+	verdict := &comments.SpamVerdict{}
+	keep := 1.0
+	signal := func(weight float64, reason string) {
+		keep *= 1 - weight
+		verdict.Reasons = append(verdict.Reasons, reason)
+	}
+
+	links := extractLinks(comment.Content)
+	if c.cfg.MaxLinks > 0 && len(links) > c.cfg.MaxLinks {
+		signal(weightTooManyLinks, fmt.Sprintf("too many links: %d", len(links)))
+	}
+	if domain := c.blockedDomain(links); domain != "" {
+		signal(weightBlockedDomain, "blocked domain: "+domain)
+	}
+	if words := c.blockedWords(comment.Content); len(words) > 0 {
+		signal(weightBlockedWord, "blocked words: "+strings.Join(words, ", "))
+	}
+
+	if c.cfg.DuplicateWindow > 0 {
+		duplicates, err := c.repo.CountDuplicates(comment.AuthorID, comment.Content, c.now().Add(-c.cfg.DuplicateWindow))
+		if err != nil {
+			return nil, fmt.Errorf("failed to count duplicates: %w", err)
+		}
+		if duplicates > 0 {
+			signal(weightDuplicate, fmt.Sprintf("duplicate content: %d", duplicates))
+		}
+	}
+
+	// У гостя нет учетной записи
+	if c.cfg.MinAccountAge > 0 && c.users != nil && !comment.Guest {
+		author, err := c.users.GetUser(comment.AuthorID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch author: %w", err)
+		}
+		// Время создания записи удаленного актора не говорит о возрасте его учетной записи
+		if author != nil && !author.IsRemote() && c.now().Sub(author.CreatedAt) < c.cfg.MinAccountAge {
+			signal(weightNewAccount, "new account")
+		}
+	}
+
+	score, err := c.classify(comment.Content)
+	if err != nil {
+		return nil, err
+	}
+	if score > 0.5 {
+		signal(2*score-1, fmt.Sprintf("classifier: %.2f", score))
+	}
+
+	verdict.Score = 1 - keep
+	return verdict, nil
+}
+
+// classify возвращает вероятность спама по байесовскому классификатору
+// или 0, если классификатор еще не обучен
+func (c *Checker) classify(content string) (float64, error) {
+	spamDocs, hamDocs, err := c.repo.CountTrained()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count training examples: %w", err)
+	}
+	if spamDocs < minTrainedDocs || hamDocs < minTrainedDocs {
+		return 0, nil
+	}
+
+	tokens := tokenize(content)
+	counts, err := c.repo.GetTokenCounts(tokens)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch token counts: %w", err)
+	}
+
+	score, _ := bayesScore(tokens, counts, spamDocs, hamDocs)
+	return score, nil
+}
+
+// blockedDomain возвращает первый запрещенный домен среди ссылок (с учетом поддоменов)
+func (c *Checker) blockedDomain(links []string) string {
+	for _, link := range links {
+		host := linkHost(link)
+		for _, domain := range c.cfg.BlockedDomains {
+			domain = strings.ToLower(domain)
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return domain
+			}
+		}
+	}
+	return ""
+}
+
+// blockedWords возвращает запрещенные слова и фразы, встречающиеся в тексте
+func (c *Checker) blockedWords(content string) []string {
+	content = strings.ToLower(content)
+
+	var found []string
+	for _, word := range c.cfg.BlockedWords {
+		if word != "" && strings.Contains(content, strings.ToLower(word)) {
+			found = append(found, word)
+		}
+	}
+	return found
+}
+
+// Learn обучает классификатор на решении модератора.
+// Повторное решение с тем же результатом игнорируется, а противоположное
+// заменяет прежний пример, поэтому ошибочную пометку можно отменить.
+func (c *Checker) Learn(comment *comments.Comment, spam bool) error {
+	previous, err := c.repo.GetTraining(comment.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch training example: %w", err)
+	}
+	if previous != nil && previous.Spam == spam {
+		return nil
+	}
+
+	training := &Training{
+		CommentID: comment.ID,
+		Spam:      spam,
+		Tokens:    strings.Join(tokenize(comment.Content), " "),
+	}
+	if previous != nil {
+		training.CreatedAt = previous.CreatedAt
+	}
+	return c.repo.SaveTraining(training, previous)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/comments"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/spam"
+	mockRepo "gitlab.com/Nikolay-Yakunin/blog-service/internal/spam/repository/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+)
+
+var testConfig = config.SpamConfig{
+	MaxLinks:        2,
+	BlockedWords:    []string{"casino"},
+	BlockedDomains:  []string{"spam.example"},
+	DuplicateWindow: time.Hour,
+	MinAccountAge:   24 * time.Hour,
+}
+
+// stubUsers хранит пользователей в памяти
+type stubUsers map[uint]*users.User
+
+func (u stubUsers) GetUser(id uint) (*users.User, error) {
+	return u[id], nil
+}
+
+func TestChecker_Check(t *testing.T) {
+	accounts := stubUsers{
+		1: {ID: 1, CreatedAt: time.Now().AddDate(-1, 0, 0)},
+		2: {ID: 2, CreatedAt: time.Now().Add(-time.Hour)},
+	}
+
+	tests := []struct {
+		name        string
+		comment     comments.Comment
+		duplicates  int64
+		wantScore   float64
+		wantReasons []string
+	}{
+		{
+			name:      "Clean comment",
+			comment:   comments.Comment{AuthorID: 1, Content: "Спасибо, очень полезная статья"},
+			wantScore: 0,
+		},
+		{
+			name:        "Too many links",
+			comment:     comments.Comment{AuthorID: 1, Content: "http://a.example http://b.example https://c.example"},
+			wantScore:   0.5,
+			wantReasons: []string{"too many links: 3"},
+		},
+		{
+			name:        "Blocked subdomain and word from a new account",
+			comment:     comments.Comment{AuthorID: 2, Content: "Best CASINO https://www.promo.spam.example/x"},
+			wantScore:   1 - 0.1*0.4*0.7,
+			wantReasons: []string{"blocked domain: spam.example", "blocked words: casino", "new account"},
+		},
+		{
+			name:        "Duplicate content",
+			comment:     comments.Comment{AuthorID: 1, Content: "Первый!"},
+			duplicates:  2,
+			wantScore:   0.5,
+			wantReasons: []string{"duplicate content: 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.SpamRepositoryMock)
+			checker := spam.NewChecker(repo, accounts, testConfig)
+
+			repo.On("CountDuplicates", tt.comment.AuthorID, tt.comment.Content, mock.AnythingOfType("time.Time")).Return(tt.duplicates, nil)
+			repo.On("CountTrained").Return(int64(0), int64(0), nil)
+
+			verdict, err := checker.Check(&tt.comment)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.wantScore, verdict.Score, 1e-9)
+			assert.Equal(t, tt.wantReasons, verdict.Reasons)
+		})
+	}
+}
+
+func TestChecker_CheckWithClassifier(t *testing.T) {
+	repo := new(mockRepo.SpamRepositoryMock)
+	checker := spam.NewChecker(repo, nil, config.SpamConfig{})
+
+	// "виагра" встречалась только в спаме, "статья" - только в обычных комментариях
+	repo.On("CountTrained").Return(int64(10), int64(10), nil)
+	repo.On("GetTokenCounts", mock.Anything).Return(map[string]spam.TokenCount{
+		"виагра": {Token: "виагра", SpamCount: 9},
+		"статья": {Token: "статья", HamCount: 9},
+	}, nil)
+
+	verdict, err := checker.Check(&comments.Comment{Content: "Дешевая виагра"})
+	assert.NoError(t, err)
+	assert.Greater(t, verdict.Score, 0.8)
+	assert.Len(t, verdict.Reasons, 1)
+
+	verdict, err = checker.Check(&comments.Comment{Content: "Хорошая статья"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, verdict.Score)
+}
+
+func TestChecker_Learn(t *testing.T) {
+	comment := &comments.Comment{ID: 5, Content: "Купите виагру https://Shop.example/buy"}
+
+	tests := []struct {
+		name     string
+		previous *spam.Training
+		spam     bool
+		wantSave bool
+	}{
+		{name: "First decision", spam: true, wantSave: true},
+		{name: "Same decision again", previous: &spam.Training{CommentID: 5, Spam: true}, spam: true},
+		{name: "Reversed decision", previous: &spam.Training{CommentID: 5, Spam: true, Tokens: "host:shop.example купите виагру"}, spam: false, wantSave: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.SpamRepositoryMock)
+			checker := spam.NewChecker(repo, nil, config.SpamConfig{})
+
+			repo.On("GetTraining", uint(5)).Return(tt.previous, nil)
+			repo.On("SaveTraining", mock.Anything, tt.previous).Return(nil).Maybe()
+
+			assert.NoError(t, checker.Learn(comment, tt.spam))
+			if !tt.wantSave {
+				repo.AssertNotCalled(t, "SaveTraining", mock.Anything, mock.Anything)
+				return
+			}
+
+			training := repo.Calls[len(repo.Calls)-1].Arguments.Get(0).(*spam.Training)
+			assert.Equal(t, tt.spam, training.Spam)
+			assert.Equal(t, "host:shop.example купите виагру", training.Tokens)
+		})
+	}
+}
@@ -36,6 +36,8 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 		{
 			authenticated.GET("/me", h.GetCurrentUser)
 			authenticated.PUT("/me", h.UpdateCurrentUser)
+			authenticated.PUT("/:id/block", h.BlockUser)
+			authenticated.DELETE("/:id/block", h.UnblockUser)
 		}
 
 		// Только для администраторов
@@ -311,6 +313,68 @@ func (h *Handler) DeactivateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "пользователь деактивирован"})
 }
 
+// BlockUser блокирует пользователя от имени текущего пользователя.
+// Заблокированный пользователь не сможет упоминать текущего в комментариях и постах.
+func (h *Handler) BlockUser(c *gin.Context) {
+	blockerID, blockedID, ok := h.blockParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.BlockUser(blockerID, blockedID); err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "пользователь не найден"})
+		case "cannot block yourself":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "нельзя заблокировать себя"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "пользователь заблокирован"})
+}
+
+// UnblockUser снимает блокировку пользователя
+func (h *Handler) UnblockUser(c *gin.Context) {
+	blockerID, blockedID, ok := h.blockParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.UnblockUser(blockerID, blockedID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "блокировка снята"})
+}
+
+// blockParams извлекает ID текущего пользователя и ID пользователя из пути.
+// При ошибке отправляет ответ и возвращает false.
+func (h *Handler) blockParams(c *gin.Context) (uint, uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return 0, 0, false
+	}
+
+	blockerID, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка получения информации о пользователе"})
+		return 0, 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат ID"})
+		return 0, 0, false
+	}
+
+	return blockerID, uint(id), true
+}
+
 // Middleware для проверки наличия аутентификации
 func AuthRequiredMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -350,3 +414,38 @@ func AdminRequiredMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RoleFromContext возвращает роль текущего пользователя, сохраненную AuthMiddleware.
+// Роль хранится в контексте как Role, поэтому c.GetString для нее не подходит.
+func RoleFromContext(c *gin.Context) Role {
+	value, exists := c.Get("userRole")
+	if !exists {
+		return RoleGuest
+	}
+
+	switch role := value.(type) {
+	case Role:
+		return role
+	case string:
+		return Role(role)
+	default:
+		return RoleGuest
+	}
+}
+
+// RequireRoles пропускает запрос только если роль пользователя входит в список.
+// Должен подключаться после AuthMiddleware.
+func RequireRoles(roles ...Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current := RoleFromContext(c)
+		for _, role := range roles {
+			if current == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		c.Abort()
+	}
+}
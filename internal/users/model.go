@@ -4,6 +4,7 @@
 //   - User: модель пользователя
 //   - Role: уровни доступа пользователей
 //   - Provider: поддерживаемые OAuth провайдеры
+//   - Block: блокировка одного пользователя другим
 //   - Repository: интерфейс хранилища
 //   - Service: интерфейс бизнес-логики
 package users
@@ -37,6 +38,10 @@ const (
 	ProviderVk       Provider = "vk"       // TODO: Нужно будет разобарться как у них создать приложение
 	ProviderGitlab   Provider = "gitlab"   // Возможно добавлю в будущем
 	ProviderFacebook Provider = "facebook" // Возможно добавлю в будущем
+
+	// ProviderActivityPub - удаленный пользователь федерации (автор ответа из Mastodon и т.п.).
+	// ProviderID содержит URI актора; войти под такой учетной записью нельзя.
+	ProviderActivityPub Provider = "activitypub"
 )
 
 // User представляет собой основную модель пользователя системы
@@ -77,6 +82,24 @@ type User struct {
 	DeletedAt *time.Time `json:"deleted_at,omitempty" gorm:"index"` // Время удаления (soft delete)
 }
 
+// IsRemote сообщает, что пользователь представляет удаленного актора федерации
+func (u *User) IsRemote() bool {
+	return u.Provider == ProviderActivityPub
+}
+
+// Block означает, что пользователь BlockerID заблокировал пользователя BlockedID.
+// Заблокированный пользователь не может адресовать блокирующему уведомления (например, упоминания).
+type Block struct {
+	BlockerID uint      `json:"blocker_id" gorm:"primaryKey"`
+	BlockedID uint      `json:"blocked_id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName задает имя таблицы блокировок
+func (Block) TableName() string {
+	return "user_blocks"
+}
+
 // Repository описывает методы для работы с хранилищем пользователей
 // Реализации должны обеспечивать потокобезопасность операций
 type Repository interface {
@@ -88,6 +111,8 @@ type Repository interface {
 	GetByEmail(email string) (*User, error)
 	// GetByProviderID возвращает пользователя по ID провайдера
 	GetByProviderID(provider Provider, providerID string) (*User, error)
+	// GetByUsername возвращает пользователя по имени пользователя
+	GetByUsername(username string) (*User, error)
 	// FindByRole возвращает список пользователей с указанной ролью
 	FindByRole(role Role) ([]User, error)
 	// FindActive возвращает список активных пользователей
@@ -96,6 +121,12 @@ type Repository interface {
 	Update(user *User) error
 	// Delete удаляет пользователя
 	Delete(id uint) error
+	// Block сохраняет блокировку; повторная блокировка не является ошибкой
+	Block(blockerID, blockedID uint) error
+	// Unblock снимает блокировку
+	Unblock(blockerID, blockedID uint) error
+	// IsBlocked сообщает, заблокировал ли blockerID пользователя blockedID
+	IsBlocked(blockerID, blockedID uint) (bool, error)
 }
 
 // Service описывает бизнес-логику работы с пользователями
@@ -105,6 +136,10 @@ type Service interface {
 	Register(provider Provider, providerData map[string]interface{}) (*User, error)
 	// GetUser получает пользователя по ID
 	GetUser(id uint) (*User, error)
+	// GetUserByUsername получает пользователя по имени пользователя
+	GetUserByUsername(username string) (*User, error)
+	// GetOrCreateRemoteUser возвращает учетную запись удаленного актора, создавая ее при необходимости
+	GetOrCreateRemoteUser(actorURI, username, avatar string) (*User, error)
 	// UpdateUser обновляет данные пользователя
 	UpdateUser(user *User) error
 	// VerifyUser повышает уровень доступа пользователя до верифицированного
@@ -113,4 +148,8 @@ type Service interface {
 	DeactivateUser(id uint) error
 	// UpdateLastLogin обновляет время последнего входа
 	UpdateLastLogin(id uint) error
+	// BlockUser блокирует пользователя от имени blockerID
+	BlockUser(blockerID, blockedID uint) error
+	// UnblockUser снимает блокировку пользователя
+	UnblockUser(blockerID, blockedID uint) error
 }
@@ -4,6 +4,7 @@ package users
 import (
 	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/database"
 )
@@ -119,3 +120,23 @@ func (r *UserRepository) Update(user *User) error {
 func (r *UserRepository) Delete(id uint) error {
 	return r.DB.Delete(&User{}, id).Error
 }
+
+// Block сохраняет блокировку пользователя blockedID пользователем blockerID
+func (r *UserRepository) Block(blockerID, blockedID uint) error {
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Block{BlockerID: blockerID, BlockedID: blockedID}).Error
+}
+
+// Unblock снимает блокировку
+func (r *UserRepository) Unblock(blockerID, blockedID uint) error {
+	return r.DB.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&Block{}).Error
+}
+
+// IsBlocked сообщает, заблокировал ли blockerID пользователя blockedID
+func (r *UserRepository) IsBlocked(blockerID, blockedID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&Block{}).
+		Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -29,6 +29,15 @@ func (r *UsersRepositoryMock) GetByProviderID(provider users.Provider, providerI
 	panic("unimplemented")
 }
 
+// GetByUsername implements users.Repository.
+func (r *UsersRepositoryMock) GetByUsername(username string) (*users.User, error) {
+	args := r.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*users.User), args.Error(1)
+}
+
 func (r *UsersRepositoryMock) Create(user *users.User) error {
 	args := r.Called(user)
 	return args.Error(0)
@@ -59,3 +68,21 @@ func (r *UsersRepositoryMock) Delete(id uint) error {
 	args := r.Called(id)
 	return args.Error(0)
 }
+
+// Block implements users.Repository.
+func (r *UsersRepositoryMock) Block(blockerID, blockedID uint) error {
+	args := r.Called(blockerID, blockedID)
+	return args.Error(0)
+}
+
+// Unblock implements users.Repository.
+func (r *UsersRepositoryMock) Unblock(blockerID, blockedID uint) error {
+	args := r.Called(blockerID, blockedID)
+	return args.Error(0)
+}
+
+// IsBlocked implements users.Repository.
+func (r *UsersRepositoryMock) IsBlocked(blockerID, blockedID uint) (bool, error) {
+	args := r.Called(blockerID, blockedID)
+	return args.Bool(0), args.Error(1)
+}
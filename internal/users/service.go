@@ -9,9 +9,13 @@ import (
 	"time"
 )
 
+// DeactivateListener вызывается после деактивации пользователя
+type DeactivateListener func(user User)
+
 // UserService реализует бизнес-логику работы с пользователями
 type UserService struct {
-	repo Repository
+	repo      Repository
+	listeners []DeactivateListener
 }
 
 // NewUserService создает новый экземпляр сервиса пользователей
@@ -21,6 +25,13 @@ func NewUserService(repo Repository) *UserService {
 	}
 }
 
+// OnDeactivate регистрирует обработчик деактивации пользователя.
+// Обработчики вызываются синхронно после сохранения.
+// Регистрировать обработчики нужно до начала обработки запросов.
+func (s *UserService) OnDeactivate(listener DeactivateListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
 // ValidateEmail проверяет корректность email адреса
 func (s *UserService) validateEmail(email string) error {
 	// Простая проверка на наличие @ и домена
@@ -135,6 +146,52 @@ func (s *UserService) GetUser(id uint) (*User, error) {
 	return s.repo.GetByID(id)
 }
 
+// GetUserByUsername возвращает пользователя по имени пользователя
+//
+// Возвращает nil, nil если пользователь не найден
+func (s *UserService) GetUserByUsername(username string) (*User, error) {
+	return s.repo.GetByUsername(username)
+}
+
+// GetOrCreateRemoteUser возвращает учетную запись удаленного актора ActivityPub.
+// username - полный адрес вида name@host; email заполняется служебным
+// значением, т.к. адрес удаленного пользователя неизвестен.
+// Аватар обновляется, если актор его сменил.
+func (s *UserService) GetOrCreateRemoteUser(actorURI, username, avatar string) (*User, error) {
+	existing, err := s.repo.GetByProviderID(ProviderActivityPub, actorURI)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.Avatar != avatar {
+			existing.Avatar = avatar
+			if err := s.repo.Update(existing); err != nil {
+				return nil, err
+			}
+		}
+		return existing, nil
+	}
+
+	now := time.Now()
+	user := &User{
+		Username:   username,
+		Email:      string(ProviderActivityPub) + ":" + username,
+		Provider:   ProviderActivityPub,
+		ProviderID: actorURI,
+		Avatar:     avatar,
+		Role:       RoleUser,
+		IsActive:   true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.repo.Create(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 // UpdateUser обновляет данные существующего пользователя
 //
 // Возвращает error если пользователь не найден или произошла ошибка обновления
@@ -171,7 +228,13 @@ func (s *UserService) DeactivateUser(id uint) error {
 	}
 
 	user.IsActive = false
-	return s.repo.Update(user)
+	if err := s.repo.Update(user); err != nil {
+		return err
+	}
+	for _, listener := range s.listeners {
+		listener(*user)
+	}
+	return nil
 }
 
 // UpdateLastLogin обновляет время последнего входа пользователя
@@ -191,6 +254,29 @@ func (s *UserService) UpdateLastLogin(id uint) error {
 	return s.repo.Update(user)
 }
 
+// BlockUser блокирует пользователя blockedID от имени blockerID
+//
+// Возвращает error если пользователь блокирует сам себя или не найден
+func (s *UserService) BlockUser(blockerID, blockedID uint) error {
+	if blockerID == blockedID {
+		return errors.New("cannot block yourself")
+	}
+	user, err := s.repo.GetByID(blockedID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+
+	return s.repo.Block(blockerID, blockedID)
+}
+
+// UnblockUser снимает блокировку пользователя blockedID
+func (s *UserService) UnblockUser(blockerID, blockedID uint) error {
+	return s.repo.Unblock(blockerID, blockedID)
+}
+
 // GetUsersByRole возвращает список пользователей с указанной ролью
 func (s *UserService) GetUsersByRole(role Role) ([]User, error) {
 	return s.repo.FindByRole(role)
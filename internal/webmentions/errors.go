@@ -0,0 +1,28 @@
+package webmentions
+
+import "errors"
+
+var (
+	ErrMentionNotFound   = errors.New("webmention not found")
+	ErrInvalidURL        = errors.New("source and target must be absolute http(s) URLs")
+	ErrSameURL           = errors.New("source and target must be different")
+	ErrUnsupportedTarget = errors.New("target is not a published post of this site")
+	ErrNotModeratable    = errors.New("webmention has not been verified yet")
+	ErrInvalidModeration = errors.New("webmention can only be approved or rejected")
+)
+
+// ErrorResponse представляет структуру ответа с ошибкой
+type ErrorResponse struct {
+	Code    int    `json:"code" example:"400" swagger:"description=HTTP код ошибки"`
+	Message string `json:"message" example:"Неверный формат данных" swagger:"description=Описание ошибки"`
+	Details string `json:"details,omitempty" example:"target is not a published post of this site" swagger:"description=Дополнительные детали ошибки"`
+}
+
+// NewErrorResponse создает новый экземпляр ErrorResponse
+func NewErrorResponse(code int, message string, details string) *ErrorResponse {
+	return &ErrorResponse{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
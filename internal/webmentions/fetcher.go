@@ -0,0 +1,54 @@
+package webmentions
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/safehttp"
+)
+
+// maxSourceSize ограничивает размер загружаемой страницы-источника
+const maxSourceSize = 1 << 20
+
+// HTTPFetcher загружает страницы-источники по HTTP
+type HTTPFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPFetcher создает загрузчик с указанным таймаутом запроса.
+// Адрес источника передает отправитель webmention, поэтому загрузчик
+// соединяется только с публичными адресами (в том числе после редиректов).
+func NewHTTPFetcher(timeout time.Duration) *HTTPFetcher {
+	return &HTTPFetcher{
+		client: safehttp.NewClient(timeout),
+	}
+}
+
+// Fetch загружает документ по URL, читая не более maxSourceSize байт
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (*FetchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "blog-service-webmention/1.0")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return &FetchResult{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+	}, nil
+}
@@ -0,0 +1,246 @@
+package webmentions
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/users"
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/middleware"
+)
+
+// Handler обрабатывает HTTP-запросы для работы с упоминаниями
+type Handler struct {
+	service Service
+	config  *config.Config
+}
+
+// NewHandler создает новый обработчик HTTP-запросов для упоминаний
+func NewHandler(service Service, cfg *config.Config) *Handler {
+	return &Handler{
+		service: service,
+		config:  cfg,
+	}
+}
+
+// Register регистрирует все пути обработки HTTP-запросов
+func (h *Handler) Register(router *gin.Engine) {
+	// Эндпоинт приема Webmention, объявляется в заголовке Link у постов
+	router.POST("/webmention", h.Receive)
+
+	// Одобренные упоминания поста
+	router.GET("/api/v1/posts/:id/webmentions", h.GetPostMentions)
+
+	// Модерация
+	moderation := router.Group("/api/v1/webmentions")
+	moderation.Use(middleware.AuthMiddleware(), users.RequireRoles(users.RoleModerator, users.RoleAdmin))
+	{
+		moderation.GET("", h.ListMentions)
+		moderation.PUT("/:id/approve", h.ApproveMention)
+		moderation.PUT("/:id/reject", h.RejectMention)
+		moderation.DELETE("/:id", h.DeleteMention)
+	}
+}
+
+// Receive принимает Webmention
+// Проверка источника выполняется асинхронно, поэтому ответ - 202 Accepted
+// @Summary Принять Webmention
+// @Tags webmentions
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param source formData string true "URL страницы, которая ссылается на пост"
+// @Param target formData string true "URL поста"
+// @Success 202 {object} Mention
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /webmention [post]
+func (h *Handler) Receive(c *gin.Context) {
+	mention, err := h.service.Receive(c.PostForm("source"), c.PostForm("target"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to accept webmention"
+
+		switch err {
+		case ErrInvalidURL, ErrSameURL, ErrUnsupportedTarget:
+			status = http.StatusBadRequest
+			message = "Invalid webmention"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, mention)
+}
+
+// GetPostMentions возвращает одобренные упоминания поста
+// @Summary Получить упоминания поста
+// @Tags webmentions
+// @Produce json
+// @Param id path int true "ID поста"
+// @Param offset query int false "Смещение"
+// @Param limit query int false "Количество записей"
+// @Success 200 {array} Mention
+// @Failure 400,500 {object} ErrorResponse
+// @Router /api/v1/posts/{id}/webmentions [get]
+func (h *Handler) GetPostMentions(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid post ID",
+			err.Error(),
+		))
+		return
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	mentions, err := h.service.GetPostMentions(uint(postID), offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to fetch webmentions",
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, mentions)
+}
+
+// ListMentions возвращает упоминания для модерации
+// @Security JWT
+// @Summary Очередь модерации упоминаний
+// @Tags webmentions
+// @Produce json
+// @Param status query string false "Статус (по умолчанию pending)"
+// @Param offset query int false "Смещение"
+// @Param limit query int false "Количество записей"
+// @Success 200 {array} Mention
+// @Failure 401,403,500 {object} ErrorResponse
+// @Router /api/v1/webmentions [get]
+func (h *Handler) ListMentions(c *gin.Context) {
+	status := Status(c.DefaultQuery("status", string(StatusPending)))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	mentions, err := h.service.ListMentions(status, offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(
+			http.StatusInternalServerError,
+			"Failed to fetch webmentions",
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, mentions)
+}
+
+// ApproveMention одобряет упоминание
+// @Security JWT
+// @Summary Одобрить упоминание
+// @Tags webmentions
+// @Param id path int true "ID упоминания"
+// @Success 200 {object} Mention
+// @Failure 400,401,403,404,409 {object} ErrorResponse
+// @Router /api/v1/webmentions/{id}/approve [put]
+func (h *Handler) ApproveMention(c *gin.Context) {
+	h.moderate(c, StatusApproved)
+}
+
+// RejectMention отклоняет упоминание
+// @Security JWT
+// @Summary Отклонить упоминание
+// @Tags webmentions
+// @Param id path int true "ID упоминания"
+// @Success 200 {object} Mention
+// @Failure 400,401,403,404,409 {object} ErrorResponse
+// @Router /api/v1/webmentions/{id}/reject [put]
+func (h *Handler) RejectMention(c *gin.Context) {
+	h.moderate(c, StatusRejected)
+}
+
+// moderate применяет решение модератора
+func (h *Handler) moderate(c *gin.Context, status Status) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid webmention ID",
+			err.Error(),
+		))
+		return
+	}
+
+	mention, err := h.service.Moderate(uint(id), status)
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := "Failed to moderate webmention"
+
+		switch err {
+		case ErrMentionNotFound:
+			code = http.StatusNotFound
+			message = "Webmention not found"
+		case ErrNotModeratable:
+			code = http.StatusConflict
+			message = "Webmention is not verified"
+		}
+
+		c.JSON(code, NewErrorResponse(
+			code,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, mention)
+}
+
+// DeleteMention удаляет упоминание
+// @Security JWT
+// @Summary Удалить упоминание
+// @Tags webmentions
+// @Param id path int true "ID упоминания"
+// @Success 204 "No Content"
+// @Failure 400,401,403,404 {object} ErrorResponse
+// @Router /api/v1/webmentions/{id} [delete]
+func (h *Handler) DeleteMention(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(
+			http.StatusBadRequest,
+			"Invalid webmention ID",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := h.service.DeleteMention(uint(id)); err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to delete webmention"
+
+		if err == ErrMentionNotFound {
+			status = http.StatusNotFound
+			message = "Webmention not found"
+		}
+
+		c.JSON(status, NewErrorResponse(
+			status,
+			message,
+			err.Error(),
+		))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,113 @@
+// Package webmentions реализует прием и отображение Webmention
+// (https://www.w3.org/TR/webmention/) для опубликованных постов.
+//
+// Основные компоненты:
+//   - Mention: полученное упоминание поста другим сайтом
+//   - Fetcher: загрузка страницы-источника (подменяется в тестах)
+//   - Repository: интерфейс хранилища
+//   - Service: прием, асинхронная проверка и модерация упоминаний
+package webmentions
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+)
+
+// Status определяет состояние упоминания
+// @Description Статус упоминания
+type Status string
+
+const (
+	// StatusQueued - упоминание принято и ожидает проверки источника
+	StatusQueued Status = "queued"
+	// StatusPending - источник проверен, упоминание ожидает модерации
+	StatusPending Status = "pending"
+	// StatusApproved - упоминание одобрено и отображается под постом
+	StatusApproved Status = "approved"
+	// StatusRejected - упоминание отклонено модератором
+	StatusRejected Status = "rejected"
+	// StatusInvalid - источник недоступен или не ссылается на пост
+	StatusInvalid Status = "invalid"
+)
+
+// Mention представляет упоминание поста на другом сайте
+// @Description Webmention к посту
+type Mention struct {
+	ID     uint   `json:"id" gorm:"primaryKey" example:"1"`
+	PostID uint   `json:"post_id" gorm:"index;not null" example:"5"`
+	Source string `json:"source" gorm:"size:2048;not null" example:"https://example.org/notes/42"`
+	Target string `json:"target" gorm:"size:2048;not null" example:"https://blog.example.com/posts/how-to-setup-swagger-in-go"`
+	Status Status `json:"status" gorm:"type:varchar(20);default:'queued'" example:"approved" enums:"queued,pending,approved,rejected,invalid"`
+
+	// Данные, извлеченные из микроформатов h-entry источника
+	AuthorName  string     `json:"author_name,omitempty" gorm:"size:255" example:"Иван Петров"`
+	AuthorURL   string     `json:"author_url,omitempty" gorm:"size:2048" example:"https://example.org"`
+	AuthorPhoto string     `json:"author_photo,omitempty" gorm:"size:2048" example:"https://example.org/avatar.jpg"`
+	Excerpt     string     `json:"excerpt,omitempty" gorm:"type:text" example:"Отличная статья про Swagger!"`
+	PublishedAt *time.Time `json:"published_at,omitempty" example:"2025-01-03T12:00:00Z"`
+
+	VerifiedAt *time.Time `json:"verified_at,omitempty" example:"2025-01-03T12:01:00Z"`
+	CreatedAt  time.Time  `json:"created_at" example:"2025-01-03T12:00:30Z"`
+	UpdatedAt  time.Time  `json:"updated_at" example:"2025-01-03T12:01:00Z"`
+}
+
+// TableName задает имя таблицы упоминаний
+func (Mention) TableName() string {
+	return "webmentions"
+}
+
+// FetchResult содержит ответ при загрузке страницы-источника
+type FetchResult struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// Fetcher загружает страницу-источник упоминания.
+// Выделен в интерфейс, чтобы проверку можно было тестировать без сети.
+type Fetcher interface {
+	// Fetch загружает документ по URL
+	Fetch(ctx context.Context, url string) (*FetchResult, error)
+}
+
+// PostLookup находит пост, на который ссылается упоминание
+type PostLookup interface {
+	// GetPostBySlug получает пост по его слагу
+	GetPostBySlug(slug string) (*posts.Post, error)
+}
+
+// Repository описывает методы для работы с хранилищем упоминаний
+type Repository interface {
+	// Create сохраняет новое упоминание
+	Create(mention *Mention) error
+	// GetByID возвращает упоминание по ID
+	GetByID(id uint) (*Mention, error)
+	// GetBySourceTarget возвращает упоминание по паре source/target
+	GetBySourceTarget(source, target string) (*Mention, error)
+	// ListByPost возвращает упоминания поста с указанным статусом
+	ListByPost(postID uint, status Status, offset, limit int) ([]Mention, error)
+	// ListByStatus возвращает упоминания с указанным статусом
+	ListByStatus(status Status, offset, limit int) ([]Mention, error)
+	// Update обновляет упоминание
+	Update(mention *Mention) error
+	// Delete удаляет упоминание
+	Delete(id uint) error
+}
+
+// Service описывает бизнес-логику работы с упоминаниями
+type Service interface {
+	// Receive принимает упоминание и ставит его в очередь на проверку
+	Receive(source, target string) (*Mention, error)
+	// Verify проверяет, что источник ссылается на пост, и разбирает его микроформаты
+	Verify(ctx context.Context, id uint) error
+	// GetPostMentions возвращает одобренные упоминания поста
+	GetPostMentions(postID uint, offset, limit int) ([]Mention, error)
+	// ListMentions возвращает упоминания с указанным статусом (для модерации)
+	ListMentions(status Status, offset, limit int) ([]Mention, error)
+	// Moderate одобряет или отклоняет проверенное упоминание
+	Moderate(id uint, status Status) (*Mention, error)
+	// DeleteMention удаляет упоминание
+	DeleteMention(id uint) error
+}
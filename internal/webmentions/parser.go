@@ -0,0 +1,234 @@
+package webmentions
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// maxExcerptLength ограничивает длину цитаты из источника (в символах)
+const maxExcerptLength = 280
+
+// sourceDocument содержит данные, извлеченные из страницы-источника
+type sourceDocument struct {
+	LinksToTarget bool
+	AuthorName    string
+	AuthorURL     string
+	AuthorPhoto   string
+	Excerpt       string
+	PublishedAt   *time.Time
+}
+
+// parseSource разбирает HTML источника: проверяет наличие ссылки на target
+// и извлекает автора, цитату и дату из микроформатов h-entry / h-card.
+func parseSource(body []byte, sourceURL, target string) (*sourceDocument, error) {
+	root, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(sourceURL)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &sourceDocument{
+		LinksToTarget: linksTo(root, base, normalizeURL(target)),
+	}
+
+	entry := findByClass(root, "h-entry")
+	if entry == nil {
+		return doc, nil
+	}
+
+	if author := findByClass(entry, "p-author"); author != nil {
+		parseAuthor(doc, author, base)
+	} else if card := findByClass(entry, "h-card"); card != nil {
+		parseAuthor(doc, card, base)
+	}
+
+	for _, class := range []string{"p-summary", "e-content", "p-name"} {
+		if node := findByClass(entry, class); node != nil {
+			if excerpt := truncate(collapseSpaces(textContent(node)), maxExcerptLength); excerpt != "" {
+				doc.Excerpt = excerpt
+				break
+			}
+		}
+	}
+
+	if published := findByClass(entry, "dt-published"); published != nil {
+		value := attr(published, "datetime")
+		if value == "" {
+			value = strings.TrimSpace(textContent(published))
+		}
+		doc.PublishedAt = parseTime(value)
+	}
+
+	return doc, nil
+}
+
+// parseAuthor заполняет автора из p-author: h-card или обычного текста/ссылки
+func parseAuthor(doc *sourceDocument, node *html.Node, base *url.URL) {
+	if !hasClass(node, "h-card") {
+		doc.AuthorName = collapseSpaces(textContent(node))
+		if href := attr(node, "href"); href != "" {
+			doc.AuthorURL = resolve(base, href)
+		}
+		return
+	}
+
+	if name := findByClass(node, "p-name"); name != nil {
+		doc.AuthorName = collapseSpaces(textContent(name))
+	} else {
+		doc.AuthorName = collapseSpaces(textContent(node))
+	}
+
+	if link := findByClass(node, "u-url"); link != nil {
+		doc.AuthorURL = resolve(base, attr(link, "href"))
+	} else if href := attr(node, "href"); href != "" {
+		doc.AuthorURL = resolve(base, href)
+	}
+
+	if photo := findByClass(node, "u-photo"); photo != nil {
+		doc.AuthorPhoto = resolve(base, attr(photo, "src"))
+	}
+}
+
+// linksTo проверяет, есть ли в документе ссылка или медиа, указывающие на target
+func linksTo(root *html.Node, base *url.URL, target string) bool {
+	found := false
+	walk(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		var ref string
+		switch n.Data {
+		case "a", "link":
+			ref = attr(n, "href")
+		case "img", "video", "audio", "source":
+			ref = attr(n, "src")
+		}
+		if ref != "" && normalizeURL(resolve(base, ref)) == target {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// findByClass ищет первый элемент-потомок (включая сам узел) с указанным классом
+func findByClass(root *html.Node, class string) *html.Node {
+	var result *html.Node
+	walk(root, func(n *html.Node) bool {
+		if n.Type == html.ElementNode && hasClass(n, class) {
+			result = n
+			return false
+		}
+		return true
+	})
+	return result
+}
+
+// walk обходит дерево в глубину, пока visit возвращает true
+func walk(n *html.Node, visit func(*html.Node) bool) bool {
+	if !visit(n) {
+		return false
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if !walk(child, visit) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasClass проверяет наличие класса у элемента
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// attr возвращает значение атрибута элемента
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textContent собирает текст элемента без содержимого script и style
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var collect func(node *html.Node)
+	collect = func(node *html.Node) {
+		switch {
+		case node.Type == html.TextNode:
+			sb.WriteString(node.Data)
+			sb.WriteByte(' ')
+		case node.Type == html.ElementNode && (node.Data == "script" || node.Data == "style"):
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(n)
+	return sb.String()
+}
+
+// resolve превращает относительную ссылку в абсолютную
+func resolve(base *url.URL, ref string) string {
+	parsed, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(parsed).String()
+}
+
+// normalizeURL приводит URL к виду для сравнения: без фрагмента,
+// с хостом в нижнем регистре и без завершающего слеша
+func normalizeURL(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return raw
+	}
+	parsed.Fragment = ""
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	return parsed.String()
+}
+
+// collapseSpaces схлопывает пробельные символы
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncate обрезает строку до limit символов
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+// parseTime разбирает дату из dt-published
+func parseTime(value string) *time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package webmentions
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/database"
+)
+
+// MentionRepository реализует интерфейс Repository для работы с БД
+type MentionRepository struct {
+	database.BaseRepository
+}
+
+// NewMentionRepository создает новый экземпляр репозитория упоминаний
+func NewMentionRepository(db *gorm.DB) Repository {
+	return &MentionRepository{
+		BaseRepository: database.NewBaseRepository(db),
+	}
+}
+
+// Create сохраняет новое упоминание
+func (r *MentionRepository) Create(mention *Mention) error {
+	return r.DB.Create(mention).Error
+}
+
+// GetByID возвращает упоминание по ID.
+// Если упоминание не найдено, возвращает (nil, nil).
+func (r *MentionRepository) GetByID(id uint) (*Mention, error) {
+	var mention Mention
+	if err := r.DB.First(&mention, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mention, nil
+}
+
+// GetBySourceTarget возвращает упоминание по паре source/target.
+// Если упоминание не найдено, возвращает (nil, nil).
+func (r *MentionRepository) GetBySourceTarget(source, target string) (*Mention, error) {
+	var mention Mention
+	if err := r.DB.Where("source = ? AND target = ?", source, target).First(&mention).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mention, nil
+}
+
+// ListByPost возвращает упоминания поста с указанным статусом, новые первыми
+func (r *MentionRepository) ListByPost(postID uint, status Status, offset, limit int) ([]Mention, error) {
+	var mentions []Mention
+	err := r.DB.Where("post_id = ? AND status = ?", postID, status).
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&mentions).Error
+	return mentions, err
+}
+
+// ListByStatus возвращает упоминания с указанным статусом, старые первыми,
+// чтобы очередь модерации и проверки разбиралась по порядку поступления
+func (r *MentionRepository) ListByStatus(status Status, offset, limit int) ([]Mention, error) {
+	var mentions []Mention
+	err := r.DB.Where("status = ?", status).
+		Order("created_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&mentions).Error
+	return mentions, err
+}
+
+// Update обновляет упоминание
+func (r *MentionRepository) Update(mention *Mention) error {
+	return r.DB.Save(mention).Error
+}
+
+// Delete удаляет упоминание
+func (r *MentionRepository) Delete(id uint) error {
+	return r.DB.Delete(&Mention{}, id).Error
+}
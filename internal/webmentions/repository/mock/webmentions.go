@@ -0,0 +1,57 @@
+package mock
+
+import (
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/webmentions"
+)
+
+type WebmentionsRepositoryMock struct {
+	mock.Mock
+}
+
+func (r *WebmentionsRepositoryMock) Create(mention *webmentions.Mention) error {
+	args := r.Called(mention)
+	return args.Error(0)
+}
+
+func (r *WebmentionsRepositoryMock) GetByID(id uint) (*webmentions.Mention, error) {
+	args := r.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*webmentions.Mention), args.Error(1)
+}
+
+func (r *WebmentionsRepositoryMock) GetBySourceTarget(source, target string) (*webmentions.Mention, error) {
+	args := r.Called(source, target)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*webmentions.Mention), args.Error(1)
+}
+
+func (r *WebmentionsRepositoryMock) ListByPost(postID uint, status webmentions.Status, offset, limit int) ([]webmentions.Mention, error) {
+	args := r.Called(postID, status, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]webmentions.Mention), args.Error(1)
+}
+
+func (r *WebmentionsRepositoryMock) ListByStatus(status webmentions.Status, offset, limit int) ([]webmentions.Mention, error) {
+	args := r.Called(status, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]webmentions.Mention), args.Error(1)
+}
+
+func (r *WebmentionsRepositoryMock) Update(mention *webmentions.Mention) error {
+	args := r.Called(mention)
+	return args.Error(0)
+}
+
+func (r *WebmentionsRepositoryMock) Delete(id uint) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
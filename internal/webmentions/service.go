@@ -0,0 +1,250 @@
+package webmentions
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+)
+
+const (
+	// queueSize - емкость очереди проверки в памяти
+	queueSize = 100
+	// verifyTimeout ограничивает время проверки одного упоминания
+	verifyTimeout = 30 * time.Second
+	// sweepInterval - период повторной постановки в очередь зависших упоминаний
+	sweepInterval = 5 * time.Minute
+)
+
+// MentionService реализует бизнес-логику работы с упоминаниями.
+// Проверка источника выполняется асинхронно воркером Run: Receive только
+// сохраняет упоминание со статусом queued и кладет его ID в очередь.
+type MentionService struct {
+	repo    Repository
+	posts   PostLookup
+	fetcher Fetcher
+	site    config.SiteConfig
+	queue   chan uint
+}
+
+// NewMentionService создает новый экземпляр сервиса упоминаний
+func NewMentionService(repo Repository, posts PostLookup, fetcher Fetcher, site config.SiteConfig) *MentionService {
+	return &MentionService{
+		repo:    repo,
+		posts:   posts,
+		fetcher: fetcher,
+		site:    site,
+		queue:   make(chan uint, queueSize),
+	}
+}
+
+// Receive принимает упоминание и ставит его в очередь на проверку.
+// Повторная отправка той же пары source/target приводит к повторной проверке.
+func (s *MentionService) Receive(source, target string) (*Mention, error) {
+	if !isHTTPURL(source) || !isHTTPURL(target) {
+		return nil, ErrInvalidURL
+	}
+	if normalizeURL(source) == normalizeURL(target) {
+		return nil, ErrSameURL
+	}
+
+	slug, ok := s.site.SlugFromURL(target)
+	if !ok {
+		return nil, ErrUnsupportedTarget
+	}
+	post, err := s.posts.GetPostBySlug(slug)
+	if err != nil {
+		if err == posts.ErrPostNotFound {
+			return nil, ErrUnsupportedTarget
+		}
+		return nil, err
+	}
+	if post.Status != posts.StatusPublished {
+		return nil, ErrUnsupportedTarget
+	}
+
+	mention, err := s.repo.GetBySourceTarget(source, target)
+	if err != nil {
+		return nil, err
+	}
+	if mention == nil {
+		mention = &Mention{
+			PostID: post.ID,
+			Source: source,
+			Target: target,
+			Status: StatusQueued,
+		}
+		if err := s.repo.Create(mention); err != nil {
+			return nil, err
+		}
+	}
+
+	s.enqueue(mention.ID)
+	return mention, nil
+}
+
+// Verify загружает источник и проверяет, что он ссылается на пост.
+// Проверенное упоминание попадает на модерацию (одобренное остается одобренным),
+// а источник без ссылки или недоступный помечается как invalid.
+func (s *MentionService) Verify(ctx context.Context, id uint) error {
+	mention, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if mention == nil {
+		return ErrMentionNotFound
+	}
+
+	result, err := s.fetcher.Fetch(ctx, mention.Source)
+	if err != nil {
+		mention.Status = StatusInvalid
+		return s.repo.Update(mention)
+	}
+
+	var doc *sourceDocument
+	if result.StatusCode >= http.StatusOK && result.StatusCode < http.StatusMultipleChoices {
+		doc, err = parseSource(result.Body, mention.Source, mention.Target)
+		if err != nil {
+			doc = nil
+		}
+	}
+
+	now := time.Now()
+	mention.VerifiedAt = &now
+
+	// 410 Gone, ошибка загрузки или отсутствие ссылки - упоминание удалено источником
+	if doc == nil || !doc.LinksToTarget {
+		mention.Status = StatusInvalid
+		return s.repo.Update(mention)
+	}
+
+	mention.AuthorName = doc.AuthorName
+	mention.AuthorURL = doc.AuthorURL
+	mention.AuthorPhoto = doc.AuthorPhoto
+	mention.Excerpt = doc.Excerpt
+	mention.PublishedAt = doc.PublishedAt
+	if mention.Status != StatusApproved && mention.Status != StatusRejected {
+		mention.Status = StatusPending
+	}
+	return s.repo.Update(mention)
+}
+
+// Run обрабатывает очередь проверки до отмены ctx.
+// При запуске и затем периодически подбирает из БД упоминания со статусом
+// queued, которые не попали в очередь (переполнение, перезапуск сервиса).
+func (s *MentionService) Run(ctx context.Context) {
+	s.sweep()
+
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep()
+		case id := <-s.queue:
+			verifyCtx, cancel := context.WithTimeout(ctx, verifyTimeout)
+			if err := s.Verify(verifyCtx, id); err != nil {
+				log.Printf("webmention %d: verification failed: %v", id, err)
+			}
+			cancel()
+		}
+	}
+}
+
+// sweep ставит в очередь упоминания, ожидающие проверки
+func (s *MentionService) sweep() {
+	queued, err := s.repo.ListByStatus(StatusQueued, 0, queueSize)
+	if err != nil {
+		log.Printf("webmention: failed to load queued mentions: %v", err)
+		return
+	}
+	for _, mention := range queued {
+		s.enqueue(mention.ID)
+	}
+}
+
+// enqueue кладет ID в очередь без блокировки.
+// Если очередь переполнена, упоминание остается queued и будет подобрано sweep.
+func (s *MentionService) enqueue(id uint) {
+	select {
+	case s.queue <- id:
+	default:
+	}
+}
+
+// GetPostMentions возвращает одобренные упоминания поста
+func (s *MentionService) GetPostMentions(postID uint, offset, limit int) ([]Mention, error) {
+	mentions, err := s.repo.ListByPost(postID, StatusApproved, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if mentions == nil {
+		mentions = []Mention{}
+	}
+	return mentions, nil
+}
+
+// ListMentions возвращает упоминания с указанным статусом
+func (s *MentionService) ListMentions(status Status, offset, limit int) ([]Mention, error) {
+	mentions, err := s.repo.ListByStatus(status, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if mentions == nil {
+		mentions = []Mention{}
+	}
+	return mentions, nil
+}
+
+// Moderate одобряет или отклоняет упоминание.
+// Модерировать можно только упоминания, прошедшие проверку источника.
+func (s *MentionService) Moderate(id uint, status Status) (*Mention, error) {
+	if status != StatusApproved && status != StatusRejected {
+		return nil, ErrInvalidModeration
+	}
+
+	mention, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if mention == nil {
+		return nil, ErrMentionNotFound
+	}
+	if mention.Status == StatusQueued || mention.Status == StatusInvalid {
+		return nil, ErrNotModeratable
+	}
+
+	mention.Status = status
+	if err := s.repo.Update(mention); err != nil {
+		return nil, err
+	}
+	return mention, nil
+}
+
+// DeleteMention удаляет упоминание
+func (s *MentionService) DeleteMention(id uint) error {
+	mention, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if mention == nil {
+		return ErrMentionNotFound
+	}
+	return s.repo.Delete(id)
+}
+
+// isHTTPURL проверяет, что строка - абсолютный http(s) URL
+func isHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/Nikolay-Yakunin/blog-service/config"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/posts"
+	"gitlab.com/Nikolay-Yakunin/blog-service/internal/webmentions"
+	mockRepo "gitlab.com/Nikolay-Yakunin/blog-service/internal/webmentions/repository/mock"
+)
+
+const (
+	testSource = "https://example.org/notes/42"
+	testTarget = "https://blog.example.com/posts/hello-world"
+)
+
+var testSite = config.SiteConfig{
+	APIURL:  "https://api.example.com",
+	PostURL: "https://blog.example.com/posts/{slug}",
+}
+
+// stubFetcher возвращает заранее заданный ответ вместо загрузки по сети
+type stubFetcher struct {
+	result *webmentions.FetchResult
+	err    error
+}
+
+func (f *stubFetcher) Fetch(ctx context.Context, url string) (*webmentions.FetchResult, error) {
+	return f.result, f.err
+}
+
+// stubPosts находит посты по слагу в памяти
+type stubPosts map[string]*posts.Post
+
+func (p stubPosts) GetPostBySlug(slug string) (*posts.Post, error) {
+	post, ok := p[slug]
+	if !ok {
+		return nil, posts.ErrPostNotFound
+	}
+	return post, nil
+}
+
+func TestMentionService_Receive(t *testing.T) {
+	lookup := stubPosts{
+		"hello-world": {ID: 1, Slug: "hello-world", Status: posts.StatusPublished},
+		"draft":       {ID: 2, Slug: "draft", Status: posts.StatusDraft},
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		target  string
+		wantErr error
+	}{
+		{
+			name:    "Success receive",
+			source:  testSource,
+			target:  testTarget,
+			wantErr: nil,
+		},
+		{
+			name:    "Invalid source",
+			source:  "ftp://example.org/file",
+			target:  testTarget,
+			wantErr: webmentions.ErrInvalidURL,
+		},
+		{
+			name:    "Same source and target",
+			source:  testTarget,
+			target:  testTarget + "/",
+			wantErr: webmentions.ErrSameURL,
+		},
+		{
+			name:    "Foreign target",
+			source:  testSource,
+			target:  "https://other.example.com/posts/hello-world",
+			wantErr: webmentions.ErrUnsupportedTarget,
+		},
+		{
+			name:    "Unknown post",
+			source:  testSource,
+			target:  "https://blog.example.com/posts/missing",
+			wantErr: webmentions.ErrUnsupportedTarget,
+		},
+		{
+			name:    "Unpublished post",
+			source:  testSource,
+			target:  "https://blog.example.com/posts/draft",
+			wantErr: webmentions.ErrUnsupportedTarget,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.WebmentionsRepositoryMock)
+			service := webmentions.NewMentionService(repo, lookup, &stubFetcher{}, testSite)
+
+			repo.On("GetBySourceTarget", tt.source, tt.target).Return(nil, nil).Maybe()
+			repo.On("Create", mock.AnythingOfType("*webmentions.Mention")).Return(nil).Maybe()
+
+			mention, err := service.Receive(tt.source, tt.target)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, uint(1), mention.PostID)
+			assert.Equal(t, webmentions.StatusQueued, mention.Status)
+		})
+	}
+}
+
+func TestMentionService_Verify(t *testing.T) {
+	hEntry := `<html><body>
+<article class="h-entry">
+  <a class="p-author h-card" href="https://example.org"><img class="u-photo" src="/avatar.jpg" alt="">Иван Петров</a>
+  <time class="dt-published" datetime="2025-01-03T12:00:00Z">3 января</time>
+  <div class="e-content">Отличная статья про <a href="` + testTarget + `">Swagger</a>!</div>
+</article>
+</body></html>`
+
+	tests := []struct {
+		name       string
+		status     webmentions.Status
+		result     *webmentions.FetchResult
+		fetchErr   error
+		wantStatus webmentions.Status
+		wantAuthor string
+	}{
+		{
+			name:       "Valid h-entry",
+			status:     webmentions.StatusQueued,
+			result:     &webmentions.FetchResult{StatusCode: http.StatusOK, ContentType: "text/html", Body: []byte(hEntry)},
+			wantStatus: webmentions.StatusPending,
+			wantAuthor: "Иван Петров",
+		},
+		{
+			name:       "Approved mention stays approved",
+			status:     webmentions.StatusApproved,
+			result:     &webmentions.FetchResult{StatusCode: http.StatusOK, ContentType: "text/html", Body: []byte(hEntry)},
+			wantStatus: webmentions.StatusApproved,
+			wantAuthor: "Иван Петров",
+		},
+		{
+			name:       "Source without link",
+			status:     webmentions.StatusQueued,
+			result:     &webmentions.FetchResult{StatusCode: http.StatusOK, ContentType: "text/html", Body: []byte(`<p>Ничего</p>`)},
+			wantStatus: webmentions.StatusInvalid,
+		},
+		{
+			name:       "Source gone",
+			status:     webmentions.StatusApproved,
+			result:     &webmentions.FetchResult{StatusCode: http.StatusGone},
+			wantStatus: webmentions.StatusInvalid,
+		},
+		{
+			name:       "Fetch error",
+			status:     webmentions.StatusQueued,
+			fetchErr:   errors.New("connection refused"),
+			wantStatus: webmentions.StatusInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.WebmentionsRepositoryMock)
+			fetcher := &stubFetcher{result: tt.result, err: tt.fetchErr}
+			service := webmentions.NewMentionService(repo, stubPosts{}, fetcher, testSite)
+
+			mention := &webmentions.Mention{ID: 7, PostID: 1, Source: testSource, Target: testTarget, Status: tt.status}
+			repo.On("GetByID", uint(7)).Return(mention, nil)
+			repo.On("Update", mention).Return(nil)
+
+			err := service.Verify(context.Background(), 7)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, mention.Status)
+			assert.Equal(t, tt.wantAuthor, mention.AuthorName)
+			if tt.wantAuthor != "" {
+				assert.Equal(t, "https://example.org", mention.AuthorURL)
+				assert.Equal(t, "https://example.org/avatar.jpg", mention.AuthorPhoto)
+				assert.Contains(t, mention.Excerpt, "Отличная статья")
+				assert.NotNil(t, mention.PublishedAt)
+			}
+		})
+	}
+}
+
+func TestMentionService_Moderate(t *testing.T) {
+	tests := []struct {
+		name    string
+		current webmentions.Status
+		status  webmentions.Status
+		wantErr error
+	}{
+		{"Approve pending", webmentions.StatusPending, webmentions.StatusApproved, nil},
+		{"Reject approved", webmentions.StatusApproved, webmentions.StatusRejected, nil},
+		{"Queued is not moderatable", webmentions.StatusQueued, webmentions.StatusApproved, webmentions.ErrNotModeratable},
+		{"Invalid target status", webmentions.StatusPending, webmentions.StatusQueued, webmentions.ErrInvalidModeration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo.WebmentionsRepositoryMock)
+			service := webmentions.NewMentionService(repo, stubPosts{}, &stubFetcher{}, testSite)
+
+			mention := &webmentions.Mention{ID: 3, Status: tt.current}
+			repo.On("GetByID", uint(3)).Return(mention, nil).Maybe()
+			repo.On("Update", mention).Return(nil).Maybe()
+
+			got, err := service.Moderate(3, tt.status)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, got.Status)
+		})
+	}
+}
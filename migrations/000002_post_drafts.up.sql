@@ -0,0 +1,18 @@
+-- Автосохраненные черновики постов (по одному на пользователя и пост)
+CREATE TABLE IF NOT EXISTS post_drafts (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER,
+    author_id INTEGER NOT NULL,
+    title VARCHAR(255),
+    description VARCHAR(500),
+    raw_content TEXT,
+    tags TEXT[],
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- post_id IS NULL означает черновик нового поста, поэтому используем COALESCE
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_drafts_author_post ON post_drafts(author_id, COALESCE(post_id, 0));
+CREATE INDEX IF NOT EXISTS idx_post_drafts_post_id ON post_drafts(post_id);
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS set_timestamp_posts ON posts;
+
+CREATE TRIGGER set_timestamp_posts
+BEFORE UPDATE ON posts
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+DROP FUNCTION IF EXISTS trigger_set_timestamp_posts();
@@ -0,0 +1,20 @@
+-- updated_at используется как Last-Modified, поэтому увеличение
+-- счетчика просмотров не должно сдвигать время изменения поста
+CREATE OR REPLACE FUNCTION trigger_set_timestamp_posts()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF (to_jsonb(NEW) - 'view_count' - 'updated_at') = (to_jsonb(OLD) - 'view_count' - 'updated_at') THEN
+    NEW.updated_at = OLD.updated_at;
+  ELSE
+    NEW.updated_at = NOW();
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS set_timestamp_posts ON posts;
+
+CREATE TRIGGER set_timestamp_posts
+BEFORE UPDATE ON posts
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp_posts();
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_posts_featured;
+DROP INDEX IF EXISTS idx_posts_pinned;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS featured_order;
+ALTER TABLE posts DROP COLUMN IF EXISTS featured_until;
+ALTER TABLE posts DROP COLUMN IF EXISTS is_featured;
+ALTER TABLE posts DROP COLUMN IF EXISTS pin_order;
+ALTER TABLE posts DROP COLUMN IF EXISTS pinned_until;
+ALTER TABLE posts DROP COLUMN IF EXISTS is_pinned;
@@ -0,0 +1,11 @@
+-- Закрепленные и избранные посты
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS is_pinned BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS pinned_until TIMESTAMPTZ;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS pin_order INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS is_featured BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS featured_until TIMESTAMPTZ;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS featured_order INTEGER NOT NULL DEFAULT 0;
+
+-- Частичные индексы: закрепленных и избранных постов немного
+CREATE INDEX IF NOT EXISTS idx_posts_pinned ON posts(pin_order) WHERE is_pinned;
+CREATE INDEX IF NOT EXISTS idx_posts_featured ON posts(featured_order) WHERE is_featured;
@@ -0,0 +1,22 @@
+-- Входящие Webmention к постам
+CREATE TABLE IF NOT EXISTS webmentions (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL,
+    source VARCHAR(2048) NOT NULL,
+    target VARCHAR(2048) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    author_name VARCHAR(255),
+    author_url VARCHAR(2048),
+    author_photo VARCHAR(2048),
+    excerpt TEXT,
+    published_at TIMESTAMPTZ,
+    verified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+-- Повторная отправка той же пары source/target обновляет существующую запись
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webmentions_source_target ON webmentions(source, target);
+CREATE INDEX IF NOT EXISTS idx_webmentions_post_id ON webmentions(post_id);
+CREATE INDEX IF NOT EXISTS idx_webmentions_status ON webmentions(status);
@@ -0,0 +1,33 @@
+-- Ключи авторов для подписи запросов ActivityPub
+CREATE TABLE IF NOT EXISTS activitypub_keys (
+    user_id INTEGER PRIMARY KEY,
+    public_key_pem TEXT NOT NULL,
+    private_key_pem TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Удаленные подписчики авторов
+CREATE TABLE IF NOT EXISTS activitypub_followers (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    actor_uri VARCHAR(2048) NOT NULL,
+    inbox VARCHAR(2048) NOT NULL,
+    shared_inbox VARCHAR(2048),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_activitypub_followers_user_actor ON activitypub_followers(user_id, actor_uri);
+
+-- Входящие ответы из федерации, сохраненные как комментарии
+CREATE TABLE IF NOT EXISTS activitypub_replies (
+    id SERIAL PRIMARY KEY,
+    note_uri VARCHAR(2048) NOT NULL UNIQUE,
+    comment_id INTEGER NOT NULL,
+    actor_uri VARCHAR(2048) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_activitypub_replies_comment_id ON activitypub_replies(comment_id);
@@ -0,0 +1,8 @@
+-- Посты из корзины удаляются окончательно: без deleted_at их нельзя отличить от живых
+DELETE FROM posts WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_posts_slug_live;
+ALTER TABLE posts ADD CONSTRAINT posts_slug_key UNIQUE (slug);
+
+DROP INDEX IF EXISTS idx_posts_deleted_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS deleted_at;
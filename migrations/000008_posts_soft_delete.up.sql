@@ -0,0 +1,8 @@
+-- Корзина: мягкое удаление постов
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts(deleted_at);
+
+-- Слаг уникален только среди неудаленных постов, чтобы пост в корзине не занимал его
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_slug_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_slug_live ON posts(slug) WHERE deleted_at IS NULL;
@@ -0,0 +1,18 @@
+-- Исходящие ссылки постов и результаты их проверки
+CREATE TABLE IF NOT EXISTS post_links (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error VARCHAR(500) NOT NULL DEFAULT '',
+    broken BOOLEAN NOT NULL DEFAULT false,
+    checked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+-- Каждая ссылка хранится в посте один раз
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_links_post_url ON post_links(post_id, url);
+-- Отчет по битым ссылкам
+CREATE INDEX IF NOT EXISTS idx_post_links_broken ON post_links(checked_at DESC) WHERE broken;
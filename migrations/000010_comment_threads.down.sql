@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_comments_post_roots;
+DROP INDEX IF EXISTS idx_comments_path;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS reply_to_id;
+ALTER TABLE comments DROP COLUMN IF EXISTS depth;
+ALTER TABLE comments DROP COLUMN IF EXISTS path;
@@ -0,0 +1,25 @@
+-- Ветки комментариев произвольной глубины: материализованный путь и глубина
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS path TEXT NOT NULL DEFAULT '';
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS depth INTEGER NOT NULL DEFAULT 0;
+-- Исходный адресат ответа, если ответ был поднят на последний допустимый уровень
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS reply_to_id INTEGER REFERENCES comments(id) ON DELETE SET NULL;
+
+WITH RECURSIVE tree AS (
+    SELECT id, LPAD(id::text, 10, '0') || '/' AS path, 0 AS depth
+    FROM comments
+    WHERE parent_id IS NULL
+    UNION ALL
+    SELECT c.id, tree.path || LPAD(c.id::text, 10, '0') || '/', tree.depth + 1
+    FROM comments c
+    JOIN tree ON c.parent_id = tree.id
+)
+UPDATE comments SET path = tree.path, depth = tree.depth
+FROM tree
+WHERE comments.id = tree.id;
+
+UPDATE comments SET reply_to_id = parent_id WHERE parent_id IS NOT NULL AND reply_to_id IS NULL;
+
+-- Выборка поддерева по префиксу пути
+CREATE INDEX IF NOT EXISTS idx_comments_path ON comments(path text_pattern_ops);
+-- Пагинация корневых комментариев поста
+CREATE INDEX IF NOT EXISTS idx_comments_post_roots ON comments(post_id, created_at DESC) WHERE parent_id IS NULL;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS notifications;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS comment_moderation;
+
+DROP INDEX IF EXISTS idx_comments_status_created;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS moderated_at;
+ALTER TABLE comments DROP COLUMN IF EXISTS moderated_by;
+ALTER TABLE comments DROP COLUMN IF EXISTS moderation_reason;
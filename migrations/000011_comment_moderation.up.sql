@@ -0,0 +1,23 @@
+-- Очередь модерации комментариев: решение модератора и политика поста
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS moderation_reason VARCHAR(500);
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS moderated_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS moderated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_comments_status_created ON comments(status, created_at);
+
+-- Пустая политика - политика сайта из config.yaml
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS comment_moderation VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Уведомления пользователей
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    subject_id INTEGER NOT NULL DEFAULT 0,
+    message TEXT NOT NULL,
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(user_id) WHERE read_at IS NULL;
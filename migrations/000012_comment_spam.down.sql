@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS spam_training;
+DROP TABLE IF EXISTS spam_tokens;
+
+DROP INDEX IF EXISTS idx_comments_author_created;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS spam_reasons;
+ALTER TABLE comments DROP COLUMN IF EXISTS spam_score;
@@ -0,0 +1,21 @@
+-- Проверка комментариев на спам: оценка при создании и статистика классификатора
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS spam_score DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS spam_reasons VARCHAR(500);
+
+-- Поиск повторов текста автора
+CREATE INDEX IF NOT EXISTS idx_comments_author_created ON comments(author_id, created_at);
+
+CREATE TABLE IF NOT EXISTS spam_tokens (
+    token VARCHAR(64) PRIMARY KEY,
+    spam_count BIGINT NOT NULL DEFAULT 0,
+    ham_count BIGINT NOT NULL DEFAULT 0
+);
+
+-- Решения модераторов, на которых обучен классификатор
+CREATE TABLE IF NOT EXISTS spam_training (
+    comment_id INTEGER PRIMARY KEY REFERENCES comments(id) ON DELETE CASCADE,
+    spam BOOLEAN NOT NULL,
+    tokens TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_comments_post_score;
+DROP INDEX IF EXISTS idx_comment_likes_user;
+DROP TABLE IF EXISTS comment_likes;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS score;
+ALTER TABLE comments DROP COLUMN IF EXISTS dislikes;
@@ -0,0 +1,3 @@
+-- HTML комментариев, отрендеренный из Markdown. Комментарии, сохраненные раньше,
+-- рендерятся при чтении, пока их не отредактируют.
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS html_content TEXT NOT NULL DEFAULT '';
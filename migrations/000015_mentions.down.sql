@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS user_blocks;
+
+DROP INDEX IF EXISTS idx_mentions_user_id;
+DROP TABLE IF EXISTS mentions;
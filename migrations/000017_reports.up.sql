@@ -0,0 +1,33 @@
+-- Жалобы читателей на комментарии и посты
+CREATE TABLE IF NOT EXISTS reports (
+    id SERIAL PRIMARY KEY,
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id INTEGER NOT NULL,
+    reporter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(20) NOT NULL,
+    text TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Одна открытая жалоба пользователя на объект
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_open_reporter
+    ON reports(subject_type, subject_id, reporter_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_reports_subject ON reports(subject_type, subject_id);
+CREATE INDEX IF NOT EXISTS idx_reports_reporter_id ON reports(reporter_id);
+
+-- Журнал действий по жалобам: скрытие, восстановление и решения модераторов
+CREATE TABLE IF NOT EXISTS report_actions (
+    id SERIAL PRIMARY KEY,
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    moderator_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reports INTEGER NOT NULL DEFAULT 0,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_actions_subject ON report_actions(subject_type, subject_id);
@@ -0,0 +1,2 @@
+-- Режим комментариев поста: open, closed, members или moderated
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS comment_mode VARCHAR(20) NOT NULL DEFAULT 'open';
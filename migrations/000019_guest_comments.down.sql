@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_comments_unconfirmed;
+DROP INDEX IF EXISTS idx_comments_confirm_token;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS confirm_expires_at;
+ALTER TABLE comments DROP COLUMN IF EXISTS confirm_token_hash;
+
+-- Комментарии гостей без автора не укладываются в прежнюю схему
+DELETE FROM comments WHERE author_id IS NULL;
+ALTER TABLE comments DROP CONSTRAINT IF EXISTS chk_comments_author;
+ALTER TABLE comments DROP COLUMN IF EXISTS guest_email;
+ALTER TABLE comments DROP COLUMN IF EXISTS guest_name;
+ALTER TABLE comments DROP COLUMN IF EXISTS guest;
+ALTER TABLE comments ALTER COLUMN author_id SET NOT NULL;
@@ -0,0 +1,13 @@
+-- Комментарии гостей: автор не зарегистрирован (author_id NULL), имя и email указываются при отправке
+ALTER TABLE comments ALTER COLUMN author_id DROP NOT NULL;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS guest BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS guest_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS guest_email VARCHAR(254) NOT NULL DEFAULT '';
+ALTER TABLE comments ADD CONSTRAINT chk_comments_author CHECK (author_id IS NOT NULL OR guest);
+
+-- Подтверждение email гостя: хеш токена из письма и срок действия ссылки
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS confirm_token_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS confirm_expires_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comments_confirm_token ON comments(confirm_token_hash) WHERE confirm_token_hash <> '';
+CREATE INDEX IF NOT EXISTS idx_comments_unconfirmed ON comments(confirm_expires_at) WHERE status = 'unconfirmed';
@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_comments_post_roots ON comments(post_id, created_at DESC) WHERE parent_id IS NULL;
+DROP INDEX IF EXISTS idx_comments_parent_created;
+DROP INDEX IF EXISTS idx_comments_post_roots_likes;
+DROP INDEX IF EXISTS idx_comments_post_roots_created;
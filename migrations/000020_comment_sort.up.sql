@@ -0,0 +1,7 @@
+-- Курсорная пагинация корневых комментариев по времени в обе стороны
+CREATE INDEX IF NOT EXISTS idx_comments_post_roots_created ON comments(post_id, created_at, id) WHERE parent_id IS NULL;
+-- Сортировка корневых комментариев по лайкам (top)
+CREATE INDEX IF NOT EXISTS idx_comments_post_roots_likes ON comments(post_id, (likes::float8), id) WHERE parent_id IS NULL;
+-- Страницы ответов в порядке времени
+CREATE INDEX IF NOT EXISTS idx_comments_parent_created ON comments(parent_id, created_at, id) WHERE parent_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_comments_post_roots;
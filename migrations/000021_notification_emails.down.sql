@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS comment_follows;
+
+ALTER TABLE notification_preferences DROP COLUMN IF EXISTS email;
+
+DROP INDEX IF EXISTS idx_notifications_user_emailed;
+DROP INDEX IF EXISTS idx_notifications_email_pending;
+ALTER TABLE notifications DROP COLUMN IF EXISTS emailed_at;
+ALTER TABLE notifications DROP COLUMN IF EXISTS email_pending;
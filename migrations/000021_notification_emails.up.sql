@@ -0,0 +1,19 @@
+-- Очередь писем с уведомлениями
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS email_pending BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS emailed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_email_pending ON notifications(user_id, created_at) WHERE email_pending;
+CREATE INDEX IF NOT EXISTS idx_notifications_user_emailed ON notifications(user_id, emailed_at) WHERE emailed_at IS NOT NULL;
+
+-- Отправка типа уведомлений на email; NULL - как задано в config.yaml
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS email BOOLEAN;
+
+-- Подписки пользователей на новые комментарии к постам
+CREATE TABLE IF NOT EXISTS comment_follows (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_follows_post ON comment_follows(post_id);
@@ -0,0 +1 @@
+ALTER TABLE posts DROP COLUMN IF EXISTS hidden_by_moderation;
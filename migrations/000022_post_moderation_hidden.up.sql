@@ -0,0 +1,11 @@
+-- Пост снят с публикации модерацией: автор не может опубликовать его снова
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS hidden_by_moderation BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Посты, уже скрытые по жалобам: последнее действие модерации - скрытие
+UPDATE posts p SET hidden_by_moderation = TRUE
+WHERE p.status = 'archived' AND (
+    SELECT a.action FROM report_actions a
+    WHERE a.subject_type = 'post' AND a.subject_id = p.id AND a.action IN ('hidden', 'restored')
+    ORDER BY a.created_at DESC, a.id DESC
+    LIMIT 1
+) = 'hidden';
@@ -0,0 +1,250 @@
+// Package events рассылает события читателям в реальном времени через
+// Server-Sent Events (SSE).
+//
+// Сервисы публикуют события в темы (например, комментарии одного поста),
+// а HTTP-обработчики подписывают на них клиентов. Hub хранит последние события
+// каждой темы, поэтому клиент, переподключившийся с заголовком Last-Event-ID,
+// получает пропущенные события. Если часть из них уже вытеснена из истории
+// или ID выдан до перезапуска сервиса, клиент получает событие reset и должен
+// перезагрузить данные целиком.
+//
+// Основные компоненты:
+//   - Config: параметры потоков событий
+//   - Event: событие темы
+//   - Hub: публикация событий и подписка на темы в памяти процесса
+//   - Subscription: подписка одного клиента
+//   - Hub.Serve: передача событий темы клиенту в формате text/event-stream
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrTooManyConnections возвращается, если у клиента уже открыто максимальное число потоков
+var ErrTooManyConnections = errors.New("too many connections")
+
+// EventReset сообщает клиенту, что часть событий утеряна и данные нужно перезагрузить
+const EventReset = "reset"
+
+const (
+	// subscriberBuffer - сколько событий ждут отправки медленному клиенту;
+	// при переполнении клиент отключается и переподключается с Last-Event-ID
+	subscriberBuffer = 64
+	// sweepInterval - период очистки истории тем без подписчиков
+	sweepInterval = time.Minute
+
+	defaultHeartbeat = 30 * time.Second
+	defaultHistory   = 100
+	defaultRetention = 10 * time.Minute
+)
+
+// Config описывает параметры потоков событий
+type Config struct {
+	Heartbeat      time.Duration `mapstructure:"heartbeat"`       // Период пустого комментария, не дающего прокси закрыть соединение
+	History        int           `mapstructure:"history"`         // Сколько последних событий темы хранится для Last-Event-ID
+	Retention      time.Duration `mapstructure:"retention"`       // Сколько хранится история темы без подписчиков
+	MaxConnections int           `mapstructure:"max_connections"` // Потоков на пользователя или IP, 0 - без ограничения
+}
+
+// Event описывает событие темы
+type Event struct {
+	ID   uint64 // Возрастает в пределах Hub, передается клиенту в поле id
+	Type string // Тип события, например comment.created
+	Data []byte // JSON-данные события
+}
+
+// topic хранит историю и подписчиков одной темы
+type topic struct {
+	history     []Event
+	evicted     uint64 // ID последнего события, вытесненного из истории
+	subscribers map[*Subscription]struct{}
+	idleSince   time.Time // Когда отключился последний подписчик или пришло последнее событие
+}
+
+// Hub рассылает события подписчикам тем.
+// Хранит историю и подписки в памяти одного процесса: клиенты экземпляра
+// получают только события, опубликованные этим экземпляром.
+type Hub struct {
+	cfg Config
+
+	mu        sync.Mutex
+	start     uint64 // Первый ID событий этого процесса
+	seq       uint64 // ID последнего события
+	topics    map[string]*topic
+	clients   map[string]int // Открытые потоки по клиентам
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewHub создает Hub. Нулевые параметры конфигурации заменяются значениями по умолчанию.
+func NewHub(cfg Config) *Hub {
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = defaultHeartbeat
+	}
+	if cfg.History <= 0 {
+		cfg.History = defaultHistory
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaultRetention
+	}
+
+	// ID начинаются со времени запуска, чтобы ID, выданные до перезапуска,
+	// не совпадали с новыми и распознавались как устаревшие
+	now := time.Now()
+	start := uint64(now.UnixMicro())
+	return &Hub{
+		cfg:       cfg,
+		start:     start,
+		seq:       start,
+		topics:    make(map[string]*topic),
+		clients:   make(map[string]int),
+		lastSweep: now,
+		now:       time.Now,
+	}
+}
+
+// Publish рассылает событие kind с данными data подписчикам темы и сохраняет его в истории.
+// Ошибка кодирования данных записывается в журнал: публикация не должна мешать
+// операции, о которой она сообщает.
+func (h *Hub) Publish(name, kind string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Failed to encode %s event for %s: %v", kind, name, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	h.sweep(now)
+
+	h.seq++
+	event := Event{ID: h.seq, Type: kind, Data: payload}
+
+	t := h.topic(name)
+	t.history = append(t.history, event)
+	if over := len(t.history) - h.cfg.History; over > 0 {
+		t.evicted = t.history[over-1].ID
+		t.history = append([]Event(nil), t.history[over:]...)
+	}
+	t.idleSince = now
+
+	for sub := range t.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			// Клиент не успевает читать: отключаем его, а пропущенное он
+			// получит из истории при переподключении
+			delete(t.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscribe подписывает клиента на тему. client - ключ для ограничения числа потоков
+// (пользователь или IP). lastID - последнее полученное клиентом событие, 0 - подписка
+// без возобновления. Подписку нужно закрыть вызовом Close.
+func (h *Hub) Subscribe(name, client string, lastID uint64) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cfg.MaxConnections > 0 && h.clients[client] >= h.cfg.MaxConnections {
+		return nil, ErrTooManyConnections
+	}
+	h.clients[client]++
+
+	t := h.topic(name)
+	sub := &Subscription{
+		hub:    h,
+		topic:  name,
+		client: client,
+		events: make(chan Event, subscriberBuffer),
+		LastID: h.seq,
+	}
+	if lastID > 0 {
+		if lastID < h.start || lastID > h.seq || lastID < t.evicted {
+			sub.Reset = true
+		} else {
+			for _, event := range t.history {
+				if event.ID > lastID {
+					sub.Missed = append(sub.Missed, event)
+				}
+			}
+		}
+	}
+	t.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// topic возвращает тему, создавая ее при первом обращении. Вызывается под h.mu.
+// Новая тема могла существовать раньше и быть удалена вместе с историей,
+// поэтому все события до ее создания считаются вытесненными.
+func (h *Hub) topic(name string) *topic {
+	t, ok := h.topics[name]
+	if !ok {
+		t = &topic{evicted: h.seq, subscribers: make(map[*Subscription]struct{}), idleSince: h.now()}
+		h.topics[name] = t
+	}
+	return t
+}
+
+// sweep удаляет темы без подписчиков, в которых давно не было событий.
+// Вызывается под h.mu не чаще раза в sweepInterval.
+func (h *Hub) sweep(now time.Time) {
+	if now.Sub(h.lastSweep) < sweepInterval {
+		return
+	}
+	h.lastSweep = now
+
+	for name, t := range h.topics {
+		if len(t.subscribers) == 0 && now.Sub(t.idleSince) >= h.cfg.Retention {
+			delete(h.topics, name)
+		}
+	}
+}
+
+// Subscription - подписка одного клиента на тему
+type Subscription struct {
+	hub    *Hub
+	topic  string
+	client string
+	events chan Event
+	once   sync.Once
+
+	Missed []Event // События после Last-Event-ID, которые нужно отправить до новых
+	Reset  bool    // Пропущенные события утеряны: клиенту нужно перезагрузить данные
+	LastID uint64  // ID последнего события Hub на момент подписки
+}
+
+// Events возвращает канал новых событий темы.
+// Канал закрывается, если клиент не успевает читать события.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close отменяет подписку и освобождает место в ограничении потоков клиента
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		h := s.hub
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.clients[s.client]--; h.clients[s.client] <= 0 {
+			delete(h.clients, s.client)
+		}
+		if t, ok := h.topics[s.topic]; ok {
+			if _, subscribed := t.subscribers[s]; subscribed {
+				delete(t.subscribers, s)
+				close(s.events)
+			}
+			if len(t.subscribers) == 0 {
+				t.idleSince = h.now()
+			}
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package events
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHubPublishSubscribe(t *testing.T) {
+	h := NewHub(Config{})
+	sub, err := h.Subscribe("post:1", "ip:1", 0)
+	if err != nil {
+		t.Fatalf("подписка не удалась: %v", err)
+	}
+	defer sub.Close()
+
+	h.Publish("post:2", "comment.created", map[string]int{"id": 1})
+	h.Publish("post:1", "comment.created", map[string]int{"id": 2})
+
+	select {
+	case event := <-sub.Events():
+		if event.Type != "comment.created" || string(event.Data) != `{"id":2}` || event.ID <= sub.LastID {
+			t.Errorf("неверное событие: %+v", event)
+		}
+	default:
+		t.Fatal("событие темы не доставлено")
+	}
+	select {
+	case event := <-sub.Events():
+		t.Errorf("событие другой темы доставлено: %+v", event)
+	default:
+	}
+}
+
+func TestHubResume(t *testing.T) {
+	h := NewHub(Config{History: 2})
+	first, _ := h.Subscribe("post:1", "ip:1", 0)
+	first.Close()
+
+	for i := 1; i <= 3; i++ {
+		h.Publish("post:1", "comment.created", i)
+	}
+
+	// Пропущены события 2 и 3, они еще в истории
+	sub, _ := h.Subscribe("post:1", "ip:1", first.LastID+1)
+	defer sub.Close()
+	if sub.Reset || len(sub.Missed) != 2 || string(sub.Missed[0].Data) != "2" || string(sub.Missed[1].Data) != "3" {
+		t.Errorf("неверные пропущенные события: reset=%v, %+v", sub.Reset, sub.Missed)
+	}
+
+	// Событие 1 вытеснено из истории
+	if sub, _ := h.Subscribe("post:1", "ip:1", first.LastID); !sub.Reset {
+		t.Error("вытесненное событие должно приводить к reset")
+	} else {
+		sub.Close()
+	}
+
+	// ID до перезапуска сервиса и ID из будущего
+	for _, lastID := range []uint64{h.start - 1, h.seq + 1} {
+		if sub, _ := h.Subscribe("post:1", "ip:1", lastID); !sub.Reset {
+			t.Errorf("ID %d должен приводить к reset", lastID)
+		} else {
+			sub.Close()
+		}
+	}
+}
+
+func TestHubSweep(t *testing.T) {
+	now := time.Now()
+	h := NewHub(Config{Retention: time.Minute})
+	h.now = func() time.Time { return now }
+
+	sub, _ := h.Subscribe("post:1", "ip:1", 0)
+	sub.Close()
+	h.Publish("post:1", "comment.created", 1)
+
+	now = now.Add(2 * time.Minute)
+	h.Publish("post:2", "comment.created", 2)
+	if _, ok := h.topics["post:1"]; ok {
+		t.Fatal("тема без подписчиков должна удаляться")
+	}
+
+	// История удаленной темы утеряна: клиенту нужно перезагрузить данные
+	if sub, _ := h.Subscribe("post:1", "ip:1", sub.LastID); !sub.Reset {
+		t.Error("возобновление удаленной темы должно приводить к reset")
+	}
+}
+
+func TestHubMaxConnections(t *testing.T) {
+	h := NewHub(Config{MaxConnections: 2})
+	a, _ := h.Subscribe("post:1", "ip:1", 0)
+	if _, err := h.Subscribe("posts", "ip:1", 0); err != nil {
+		t.Fatalf("второй поток должен открываться: %v", err)
+	}
+	if _, err := h.Subscribe("post:2", "ip:1", 0); err != ErrTooManyConnections {
+		t.Fatalf("ожидалась ErrTooManyConnections, получено %v", err)
+	}
+	if _, err := h.Subscribe("post:2", "ip:2", 0); err != nil {
+		t.Errorf("ограничение должно считаться по клиенту: %v", err)
+	}
+
+	// Повторное закрытие не освобождает чужое место
+	a.Close()
+	a.Close()
+	if _, err := h.Subscribe("post:2", "ip:1", 0); err != nil {
+		t.Errorf("закрытие подписки должно освобождать место: %v", err)
+	}
+	if _, err := h.Subscribe("post:3", "ip:1", 0); err != ErrTooManyConnections {
+		t.Errorf("ожидалась ErrTooManyConnections, получено %v", err)
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	h := NewHub(Config{History: 1000})
+	sub, _ := h.Subscribe("post:1", "ip:1", 0)
+	defer sub.Close()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		h.Publish("post:1", "comment.created", i)
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("получено %d событий до отключения, ожидалось %d", received, subscriberBuffer)
+	}
+}
+
+func TestServe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHub(Config{})
+	first, _ := h.Subscribe("post:1", "ip:1", 0)
+	first.Close()
+	h.Publish("post:1", "comment.created", map[string]int{"id": 1})
+	h.Publish("post:1", "comment.deleted", map[string]int{"id": 1})
+
+	// Отключенный клиент получает только пропущенные события
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", strconv.FormatUint(first.LastID+1, 10))
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	h.Serve(c, "post:1")
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("неверный Content-Type: %q", ct)
+	}
+	want := "id: " + strconv.FormatUint(first.LastID+2, 10) + "\nevent: comment.deleted\ndata: {\"id\":1}\n\n" +
+		"id: " + strconv.FormatUint(first.LastID+2, 10) + "\n\n"
+	if w.Body.String() != want {
+		t.Errorf("неверный поток:\n%q\nожидалось\n%q", w.Body.String(), want)
+	}
+	if strings.Contains(w.Body.String(), "comment.created") {
+		t.Error("уже полученное событие отправлено повторно")
+	}
+	if h.clients["ip:"+c.ClientIP()] != 0 {
+		t.Error("поток должен освобождать место после отключения")
+	}
+}
+
+func TestServeTooManyConnections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHub(Config{MaxConnections: 1})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/stream", nil)
+	c.Set("userID", uint(7))
+	sub, _ := h.Subscribe("posts", "user:7", 0)
+	defer sub.Close()
+
+	h.Serve(c, "post:1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("ожидался 429, получен %d", w.Code)
+	}
+}
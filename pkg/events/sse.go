@@ -0,0 +1,90 @@
+package events
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Serve подписывает клиента на тему и передает ему события в формате
+// text/event-stream, пока клиент не отключится. Последнее полученное событие
+// берется из заголовка Last-Event-ID или параметра lastEventId (для клиентов,
+// которые не могут передать заголовок). Клиент определяется по пользователю,
+// если перед обработчиком подключен OptionalAuthMiddleware, иначе по IP;
+// сверх ограничения потоков клиента возвращается 429.
+func (h *Hub) Serve(c *gin.Context, topic string) {
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("lastEventId")
+	}
+	// Некорректный ID равносилен подписке без возобновления
+	after, _ := strconv.ParseUint(lastID, 10, 64)
+
+	sub, err := h.Subscribe(topic, clientKey(c), after)
+	if err != nil {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Отключает буферизацию ответа в nginx
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	if sub.Reset {
+		writeEvent(w, Event{ID: sub.LastID, Type: EventReset, Data: []byte("{}")})
+	} else {
+		for _, event := range sub.Missed {
+			writeEvent(w, event)
+		}
+		// Запоминаем в клиенте текущий ID, чтобы при переподключении он получил
+		// события, опубликованные после подписки, даже если не получил ни одного
+		fmt.Fprintf(w, "id: %d\n\n", sub.LastID)
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(h.cfg.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Клиент не успевал читать события и отключен;
+				// он переподключится с Last-Event-ID
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		w.Flush()
+	}
+}
+
+// writeEvent записывает событие в формате SSE.
+// Data - JSON без переводов строк, поэтому занимает одну строку data.
+func writeEvent(w gin.ResponseWriter, event Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
+
+// clientKey возвращает ключ ограничения потоков: ID пользователя из JWT или IP клиента
+func clientKey(c *gin.Context) string {
+	if userID := c.GetUint("userID"); userID != 0 {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return "ip:" + c.ClientIP()
+}
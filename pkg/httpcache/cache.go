@@ -0,0 +1,142 @@
+package httpcache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Policy описывает значение заголовка Cache-Control для группы маршрутов.
+// Нулевое значение означает "no-cache": ответ можно хранить, но перед
+// использованием его нужно перепроверить по ETag/Last-Modified.
+type Policy struct {
+	Public               bool          `mapstructure:"public"`                 // Разрешить кэширование в общих кэшах (CDN)
+	MaxAge               time.Duration `mapstructure:"max_age"`                // Время жизни в браузере
+	SharedMaxAge         time.Duration `mapstructure:"s_maxage"`               // Время жизни в CDN
+	StaleWhileRevalidate time.Duration `mapstructure:"stale_while_revalidate"` // Окно отдачи устаревшего ответа при перепроверке
+}
+
+// CacheControl формирует значение заголовка Cache-Control
+func (p Policy) CacheControl() string {
+	directives := []string{"private"}
+	if p.Public {
+		directives[0] = "public"
+	}
+
+	if p.MaxAge <= 0 && p.SharedMaxAge <= 0 {
+		return strings.Join(append(directives, "no-cache"), ", ")
+	}
+
+	directives = append(directives, "max-age="+seconds(p.MaxAge))
+	if p.Public && p.SharedMaxAge > 0 {
+		directives = append(directives, "s-maxage="+seconds(p.SharedMaxAge))
+	}
+	if p.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+seconds(p.StaleWhileRevalidate))
+	}
+	return strings.Join(directives, ", ")
+}
+
+// Private возвращает политику для персонализированного ответа:
+// его можно хранить только в кэше браузера, но не в общих кэшах
+func (p Policy) Private() Policy {
+	p.Public = false
+	p.SharedMaxAge = 0
+	return p
+}
+
+// Validators содержит валидаторы представления ресурса
+type Validators struct {
+	ETag         string    // Сильный или слабый ETag (в кавычках)
+	LastModified time.Time // Время последнего изменения, нулевое - не отправлять
+}
+
+// WeakETag формирует слабый ETag из произвольных частей представления.
+// Используется для коллекций, где сильное сравнение не имеет смысла.
+func WeakETag(parts ...interface{}) string {
+	return `W/"` + hashParts(parts) + `"`
+}
+
+// RepresentationETag формирует сильный ETag ресурса из версии и частей представления,
+// которые меняются без изменения версии (вычисляемых полей), например "3-5f2a...".
+// Версию из такого тега извлекает ParseIfMatch, поэтому клиент может передать его в If-Match.
+func RepresentationETag(version uint, parts ...interface{}) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + "-" + hashParts(parts) + `"`
+}
+
+// hashParts возвращает укороченный SHA-1 частей представления
+func hashParts(parts []interface{}) string {
+	h := sha1.New()
+	for _, part := range parts {
+		fmt.Fprintf(h, "%v|", part)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:12])
+}
+
+// Respond выставляет заголовки кэширования и обрабатывает условный запрос.
+// Если представление у клиента актуально, отправляет 304 Not Modified и
+// возвращает true - в этом случае обработчик должен завершиться без тела.
+func Respond(c *gin.Context, policy Policy, v Validators) bool {
+	c.Header("Cache-Control", policy.CacheControl())
+	if v.ETag != "" {
+		c.Header("ETag", v.ETag)
+	}
+	if !v.LastModified.IsZero() {
+		c.Header("Last-Modified", v.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if !isNotModified(c.Request, v) {
+		return false
+	}
+
+	c.Status(http.StatusNotModified)
+	c.Writer.WriteHeaderNow()
+	c.Abort()
+	return true
+}
+
+// isNotModified реализует проверку If-None-Match / If-Modified-Since (RFC 9110).
+// If-None-Match имеет приоритет: If-Modified-Since учитывается только без него.
+func isNotModified(r *http.Request, v Validators) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return v.ETag != "" && matchesWeak(inm, v.ETag)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || v.LastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// Last-Modified передается с точностью до секунды
+	return !v.LastModified.Truncate(time.Second).After(since)
+}
+
+// matchesWeak проверяет список тегов из If-None-Match слабым сравнением
+func matchesWeak(header, etag string) bool {
+	target := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == target {
+			return true
+		}
+	}
+	return false
+}
+
+// seconds переводит длительность в целое число секунд для директив Cache-Control
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
@@ -0,0 +1,93 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPolicyCacheControl(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		want   string
+	}{
+		{name: "zero value", policy: Policy{}, want: "private, no-cache"},
+		{
+			name:   "public with CDN",
+			policy: Policy{Public: true, MaxAge: time.Minute, SharedMaxAge: 5 * time.Minute, StaleWhileRevalidate: 30 * time.Second},
+			want:   "public, max-age=60, s-maxage=300, stale-while-revalidate=30",
+		},
+		{
+			name:   "private ignores s-maxage",
+			policy: Policy{MaxAge: 10 * time.Second, SharedMaxAge: time.Minute},
+			want:   "private, max-age=10",
+		},
+		{
+			name:   "personalized response",
+			policy: Policy{Public: true, MaxAge: time.Minute, SharedMaxAge: 5 * time.Minute}.Private(),
+			want:   "private, max-age=60",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.CacheControl(); got != tt.want {
+				t.Errorf("ожидалось %q, получено: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRespond(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modified := time.Date(2025, 1, 2, 3, 4, 5, 600, time.UTC)
+	validators := Validators{ETag: `"3"`, LastModified: modified}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "no conditions", want: http.StatusOK},
+		{name: "matching etag", headers: map[string]string{"If-None-Match": `"3"`}, want: http.StatusNotModified},
+		{name: "weak match", headers: map[string]string{"If-None-Match": `W/"1", W/"3"`}, want: http.StatusNotModified},
+		{name: "stale etag", headers: map[string]string{"If-None-Match": `"2"`}, want: http.StatusOK},
+		{
+			name: "etag takes precedence over date",
+			headers: map[string]string{
+				"If-None-Match":     `"2"`,
+				"If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat),
+			},
+			want: http.StatusOK,
+		},
+		{name: "not modified since", headers: map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, want: http.StatusNotModified},
+		{name: "modified since", headers: map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				c.Request.Header.Set(k, v)
+			}
+
+			if !Respond(c, Policy{}, validators) {
+				c.Status(http.StatusOK)
+				c.Writer.WriteHeaderNow()
+			}
+
+			if w.Code != tt.want {
+				t.Errorf("ожидался статус %d, получено: %d", tt.want, w.Code)
+			}
+			if w.Header().Get("ETag") != `"3"` {
+				t.Errorf("ожидался ETag \"3\", получено: %s", w.Header().Get("ETag"))
+			}
+		})
+	}
+}
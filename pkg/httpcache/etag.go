@@ -0,0 +1,51 @@
+// Package httpcache содержит утилиты для условных HTTP-запросов:
+// формирование ETag, разбор If-Match, обработку If-None-Match /
+// If-Modified-Since и политики Cache-Control.
+package httpcache
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrMissingIfMatch возвращается, если заголовок If-Match не передан
+	ErrMissingIfMatch = errors.New("If-Match header is required")
+	// ErrInvalidIfMatch возвращается, если If-Match не содержит версию ресурса
+	ErrInvalidIfMatch = errors.New("If-Match header must contain a resource ETag")
+	// ErrWeakIfMatch возвращается для слабого тега в If-Match: RFC 9110 требует
+	// для If-Match сильного сравнения, а слабый тег ему никогда не соответствует
+	ErrWeakIfMatch = errors.New("If-Match header must contain a strong ETag")
+)
+
+// VersionETag формирует сильный ETag из версии ресурса, например "3"
+func VersionETag(version uint) string {
+	return strconv.Quote(strconv.FormatUint(uint64(version), 10))
+}
+
+// ParseIfMatch извлекает версию ресурса из заголовка If-Match.
+// Принимает сильные теги версии ("3") и теги представления из
+// RepresentationETag ("3-5f2a..."), из которых берется версия.
+// Слабые теги (W/"3") отклоняются с ErrWeakIfMatch; "*" и списки тегов
+// не поддерживаются, т.к. клиент должен явно указать версию, которую он редактирует.
+func ParseIfMatch(header string) (uint, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, ErrMissingIfMatch
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		return 0, ErrWeakIfMatch
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, ErrInvalidIfMatch
+	}
+
+	value, _, _ := strings.Cut(header[1:len(header)-1], "-")
+	version, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, ErrInvalidIfMatch
+	}
+	return uint(version), nil
+}
@@ -0,0 +1,59 @@
+package httpcache
+
+import "testing"
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    uint
+		wantErr error
+	}{
+		{name: "strong tag", header: `"3"`, want: 3},
+		{name: "representation tag", header: `"5-0a1b2c"`, want: 5},
+		{name: "spaces", header: `  "12" `, want: 12},
+		{name: "missing", header: "", wantErr: ErrMissingIfMatch},
+		{name: "wildcard", header: "*", wantErr: ErrInvalidIfMatch},
+		{name: "unquoted", header: "3", wantErr: ErrInvalidIfMatch},
+		{name: "not a number", header: `"abc"`, wantErr: ErrInvalidIfMatch},
+		{name: "weak tag", header: `W/"7"`, wantErr: ErrWeakIfMatch},
+		{name: "weak representation tag", header: `W/"5-0a1b2c"`, wantErr: ErrWeakIfMatch},
+		{name: "tag without version", header: `"0a1b2c"`, wantErr: ErrInvalidIfMatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIfMatch(tt.header)
+			if err != tt.wantErr {
+				t.Fatalf("ожидалась ошибка %v, получено: %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("ожидалась версия %d, получено: %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVersionETagRoundTrip(t *testing.T) {
+	version, err := ParseIfMatch(VersionETag(42))
+	if err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if version != 42 {
+		t.Errorf("ожидалась версия 42, получено: %d", version)
+	}
+}
+
+func TestRepresentationETag(t *testing.T) {
+	tag := RepresentationETag(42, "comments_open", true)
+	if tag == RepresentationETag(42, "comments_open", false) {
+		t.Error("ETag должен меняться вместе с представлением")
+	}
+	version, err := ParseIfMatch(tag)
+	if err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if version != 42 {
+		t.Errorf("ожидалась версия 42, получено: %d", version)
+	}
+}
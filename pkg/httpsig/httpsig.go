@@ -0,0 +1,177 @@
+// Package httpsig реализует подпись и проверку HTTP-запросов по схеме
+// draft-cavage-http-signatures (rsa-sha256), которую используют
+// серверы ActivityPub (Mastodon, Pleroma и др.).
+package httpsig
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MaxClockSkew - допустимое расхождение заголовка Date с текущим временем
+const MaxClockSkew = 12 * time.Hour
+
+var (
+	// ErrMissingSignature возвращается, если заголовок Signature не передан
+	ErrMissingSignature = errors.New("signature header is required")
+	// ErrMalformedSignature возвращается, если заголовок Signature не удалось разобрать
+	ErrMalformedSignature = errors.New("malformed signature header")
+	// ErrUnsignedHeaders возвращается, если подпись не покрывает обязательные заголовки
+	ErrUnsignedHeaders = errors.New("signature does not cover required headers")
+	// ErrDateOutOfRange возвращается, если Date слишком далек от текущего времени
+	ErrDateOutOfRange = errors.New("signature date is out of range")
+	// ErrDigestMismatch возвращается, если Digest не совпадает с телом запроса
+	ErrDigestMismatch = errors.New("digest does not match body")
+	// ErrInvalidSignature возвращается, если подпись не прошла проверку
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// KeyLookup возвращает открытый ключ по keyId из подписи
+type KeyLookup func(keyID string) (*rsa.PublicKey, error)
+
+// Digest формирует значение заголовка Digest для тела запроса
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Sign подписывает запрос ключом key.
+// Подписываются (request-target), host, date и, если передано тело, digest.
+// Недостающие заголовки Date и Digest выставляются автоматически.
+func Sign(req *http.Request, keyID string, key *rsa.PrivateKey, body []byte) error {
+	if req.Header.Get("Date") == "" {
+		req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		req.Header.Set("Digest", Digest(body))
+		headers = append(headers, "digest")
+	}
+
+	hash := sha256.Sum256([]byte(signingString(req, headers)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(
+		`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID,
+		strings.Join(headers, " "),
+		base64.StdEncoding.EncodeToString(signature),
+	))
+	return nil
+}
+
+// Verify проверяет подпись запроса и возвращает keyId подписавшего.
+// body - уже прочитанное тело запроса; для запросов с телом подпись
+// обязана покрывать заголовок digest.
+func Verify(req *http.Request, body []byte, lookup KeyLookup) (string, error) {
+	header := req.Header.Get("Signature")
+	if header == "" {
+		return "", ErrMissingSignature
+	}
+
+	params := parseParams(header)
+	keyID, signatureB64 := params["keyId"], params["signature"]
+	if keyID == "" || signatureB64 == "" {
+		return "", ErrMalformedSignature
+	}
+	if alg := params["algorithm"]; alg != "" && alg != "rsa-sha256" && alg != "hs2019" {
+		return "", ErrMalformedSignature
+	}
+
+	headers := strings.Fields(strings.ToLower(params["headers"]))
+	if len(headers) == 0 {
+		headers = []string{"date"}
+	}
+	required := []string{"(request-target)", "host", "date"}
+	if len(body) > 0 {
+		required = append(required, "digest")
+	}
+	for _, name := range required {
+		if !contains(headers, name) {
+			return "", ErrUnsignedHeaders
+		}
+	}
+
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return "", ErrDateOutOfRange
+	}
+	if skew := time.Since(date); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return "", ErrDateOutOfRange
+	}
+
+	if contains(headers, "digest") && req.Header.Get("Digest") != Digest(body) {
+		return "", ErrDigestMismatch
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(signatureB64)
+	if err != nil {
+		return "", ErrMalformedSignature
+	}
+
+	key, err := lookup(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(signingString(req, headers)))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature); err != nil {
+		return "", ErrInvalidSignature
+	}
+	return keyID, nil
+}
+
+// signingString собирает строку для подписи из перечисленных заголовков
+func signingString(req *http.Request, headers []string) string {
+	lines := make([]string, 0, len(headers))
+	for _, name := range headers {
+		var value string
+		switch name {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		default:
+			value = strings.Join(req.Header.Values(name), ", ")
+		}
+		lines = append(lines, name+": "+value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseParams разбирает заголовок вида key1="value1",key2="value2"
+func parseParams(header string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		params[key] = strings.Trim(value, `"`)
+	}
+	return params
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
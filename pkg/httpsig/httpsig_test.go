@@ -0,0 +1,119 @@
+package httpsig
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testKeyID = "https://example.org/users/alice#main-key"
+
+func newTestKey(t *testing.T) (*rsa.PrivateKey, KeyLookup) {
+	t.Helper()
+	privatePEM, publicPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("не удалось создать ключ: %v", err)
+	}
+	key, err := ParsePrivateKey(privatePEM)
+	if err != nil {
+		t.Fatalf("не удалось разобрать закрытый ключ: %v", err)
+	}
+	public, err := ParsePublicKey(publicPEM)
+	if err != nil {
+		t.Fatalf("не удалось разобрать открытый ключ: %v", err)
+	}
+	return key, func(keyID string) (*rsa.PublicKey, error) {
+		if keyID != testKeyID {
+			return nil, errors.New("unknown key")
+		}
+		return public, nil
+	}
+}
+
+func newSignedRequest(t *testing.T, key *rsa.PrivateKey, body []byte) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "https://blog.example.com/ap/users/bob/inbox", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Sign(req, testKeyID, key, body); err != nil {
+		t.Fatalf("не удалось подписать запрос: %v", err)
+	}
+	return req
+}
+
+func TestSignVerify(t *testing.T) {
+	key, lookup := newTestKey(t)
+	_, otherLookup := newTestKey(t)
+	body := []byte(`{"type":"Follow"}`)
+
+	tests := []struct {
+		name    string
+		prepare func(req *http.Request) []byte
+		lookup  KeyLookup
+		wantErr error
+	}{
+		{
+			name:    "valid signature",
+			prepare: func(req *http.Request) []byte { return body },
+			lookup:  lookup,
+		},
+		{
+			name:    "tampered body",
+			prepare: func(req *http.Request) []byte { return []byte(`{"type":"Undo"}`) },
+			lookup:  lookup,
+			wantErr: ErrDigestMismatch,
+		},
+		{
+			name: "tampered path",
+			prepare: func(req *http.Request) []byte {
+				req.URL.Path = "/ap/users/eve/inbox"
+				return body
+			},
+			lookup:  lookup,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "wrong key",
+			prepare: func(req *http.Request) []byte { return body },
+			lookup:  otherLookup,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "missing signature",
+			prepare: func(req *http.Request) []byte {
+				req.Header.Del("Signature")
+				return body
+			},
+			lookup:  lookup,
+			wantErr: ErrMissingSignature,
+		},
+		{
+			name: "stale date",
+			prepare: func(req *http.Request) []byte {
+				req.Header.Set("Date", time.Now().Add(-2*MaxClockSkew).UTC().Format(http.TimeFormat))
+				return body
+			},
+			lookup:  lookup,
+			wantErr: ErrDateOutOfRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newSignedRequest(t, key, body)
+			got := tt.prepare(req)
+
+			keyID, err := Verify(req, got, tt.lookup)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ожидалась ошибка %v, получено: %v", tt.wantErr, err)
+			}
+			if err == nil && keyID != testKeyID {
+				t.Errorf("ожидался keyId %q, получено: %q", testKeyID, keyID)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package mail отправляет письма пользователям.
+//
+// Сервисы зависят только от интерфейса Sender. Если SMTP-сервер не задан,
+// New возвращает LogSender, который пишет письма в журнал (для разработки).
+//
+// Основные компоненты:
+//   - Config: параметры SMTP-сервера
+//   - Message: письмо
+//   - Sender: отправка писем
+//   - SMTPSender: отправка через SMTP-сервер
+//   - LogSender: запись писем в журнал
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidAddress возвращается для некорректного адреса получателя
+var ErrInvalidAddress = errors.New("invalid email address")
+
+// Config описывает параметры SMTP-сервера
+type Config struct {
+	Host     string `mapstructure:"host"` // Пустой хост - письма пишутся в журнал
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"` // Пустое имя - без авторизации
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"` // Адрес отправителя, например "Блог <noreply@example.com>"
+}
+
+// Message описывает письмо
+type Message struct {
+	To      string // Адрес получателя
+	Subject string
+	Body    string // Текст письма (text/plain)
+	// Дополнительные заголовки, например List-Unsubscribe; значения должны быть в ASCII
+	Headers map[string]string
+}
+
+// Sender отправляет письма
+type Sender interface {
+	// Send отправляет письмо
+	Send(msg Message) error
+}
+
+// New возвращает SMTPSender или LogSender, если SMTP-сервер не задан
+func New(cfg Config) Sender {
+	if cfg.Host == "" {
+		return LogSender{}
+	}
+	return &SMTPSender{cfg: cfg}
+}
+
+// ValidAddress сообщает, что address - корректный адрес без имени, например "user@example.com"
+func ValidAddress(address string) bool {
+	parsed, err := mail.ParseAddress(address)
+	return err == nil && parsed.Address == address
+}
+
+// SMTPSender отправляет письма через SMTP-сервер
+type SMTPSender struct {
+	cfg Config
+}
+
+// Send отправляет письмо через SMTP-сервер
+func (s *SMTPSender) Send(msg Message) error {
+	if !ValidAddress(msg.To) {
+		return ErrInvalidAddress
+	}
+	from, err := mail.ParseAddress(s.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	if err := smtp.SendMail(addr, auth, from.Address, []string{msg.To}, build(from, msg, time.Now())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// build формирует письмо с заголовками в формате RFC 5322
+func build(from *mail.Address, msg Message, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	names := make([]string, 0, len(msg.Headers))
+	for name := range msg.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// Перевод строки в значении добавил бы произвольные заголовки
+		value := strings.NewReplacer("\r", "", "\n", "").Replace(msg.Headers[name])
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.Write(bytes.ReplaceAll([]byte(msg.Body), []byte("\n"), []byte("\r\n")))
+	return buf.Bytes()
+}
+
+// LogSender пишет письма в журнал вместо отправки
+type LogSender struct{}
+
+// Send пишет письмо в журнал
+func (LogSender) Send(msg Message) error {
+	if !ValidAddress(msg.To) {
+		return ErrInvalidAddress
+	}
+	log.Printf("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
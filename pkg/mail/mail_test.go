@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMessage(t *testing.T) {
+	from := &mail.Address{Name: "Блог", Address: "noreply@example.com"}
+	msg := Message{
+		To:      "guest@example.com",
+		Subject: "Подтвердите комментарий",
+		Body:    "Строка 1\nСтрока 2",
+		Headers: map[string]string{
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+			"List-Unsubscribe":      "<https://example.com/unsubscribe?token=abc>\r\nBcc: x@y.z",
+		},
+	}
+
+	raw := string(build(from, msg, time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)))
+	headers, body, ok := strings.Cut(raw, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("нет разделителя заголовков и текста: %q", raw)
+	}
+
+	for _, want := range []string{
+		"From: =?utf-8?q?=D0=91=D0=BB=D0=BE=D0=B3?= <noreply@example.com>",
+		"To: guest@example.com",
+		"Subject: =?utf-8?q?",
+		"Date: Wed, 01 Jan 2025 12:00:00 +0000",
+		"Content-Type: text/plain; charset=utf-8",
+		"List-Unsubscribe: <https://example.com/unsubscribe?token=abc>Bcc: x@y.z\r\n",
+		"List-Unsubscribe-Post: List-Unsubscribe=One-Click",
+	} {
+		if !strings.Contains(headers, want) {
+			t.Errorf("в заголовках нет %q:\n%s", want, headers)
+		}
+	}
+	if body != "Строка 1\r\nСтрока 2" {
+		t.Errorf("неверный текст письма: %q", body)
+	}
+
+	// Заголовок Subject декодируется в исходную тему
+	parsed, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("тема: получено %q (%v), ожидалось %q", subject, err, msg.Subject)
+	}
+}
+
+func TestValidAddress(t *testing.T) {
+	tests := map[string]bool{
+		"guest@example.com":         true,
+		"Гость <guest@example.com>": false,
+		"guest@":                    false,
+		"":                          false,
+		"a@b.c\r\nBcc: x@y.z":       false,
+	}
+	for address, want := range tests {
+		if got := ValidAddress(address); got != want {
+			t.Errorf("ValidAddress(%q) = %v, ожидалось %v", address, got, want)
+		}
+	}
+}
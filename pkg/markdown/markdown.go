@@ -0,0 +1,64 @@
+// Package markdown рендерит пользовательский Markdown в санитизированный HTML.
+//
+// Renderer объединяет разбор Markdown и политику санитизации: посты используют
+// полный набор UGC-разметки, комментарии - ограниченное подмножество, в котором
+// ссылки получают rel="nofollow ugc".
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/russross/blackfriday/v2"
+)
+
+// Renderer конвертирует Markdown в HTML и очищает результат политикой санитизации
+type Renderer struct {
+	extensions blackfriday.Extensions
+	policy     *bluemonday.Policy
+	rel        *strings.Replacer // Дополняет rel ссылок после санитизации, может быть nil
+}
+
+// NewPostRenderer создает рендерер постов: расширения Markdown по умолчанию и UGC-политика
+func NewPostRenderer() *Renderer {
+	return &Renderer{
+		extensions: blackfriday.CommonExtensions,
+		policy:     bluemonday.UGCPolicy(),
+	}
+}
+
+// NewCommentRenderer создает рендерер комментариев.
+// Разрешены выделение, ссылки, код (строчный и блоки с языком), цитаты и переносы строк;
+// заголовки, изображения, таблицы и произвольный HTML превращаются в текст.
+func NewCommentRenderer() *Renderer {
+	return &Renderer{
+		extensions: blackfriday.NoIntraEmphasis | blackfriday.FencedCode |
+			blackfriday.Autolink | blackfriday.Strikethrough | blackfriday.HardLineBreak,
+		policy: CommentPolicy,
+		rel:    strings.NewReplacer(`rel="nofollow"`, `rel="nofollow ugc"`),
+	}
+}
+
+// CommentPolicy - политика санитизации комментариев.
+// Атрибут rel выставляет сама политика, поэтому пользовательский rel всегда отбрасывается.
+var CommentPolicy = func() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "em", "strong", "del", "code", "pre", "blockquote")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w-]+$`)).OnElements("code")
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowStandardURLs()
+	p.RequireNoFollowOnLinks(true)
+	p.AddSpaceWhenStrippingTag(true)
+	return p
+}()
+
+// Render конвертирует Markdown в санитизированный HTML
+func (r *Renderer) Render(src string) string {
+	unsafe := blackfriday.Run([]byte(src), blackfriday.WithExtensions(r.extensions))
+	html := string(r.policy.SanitizeBytes(unsafe))
+	if r.rel != nil {
+		html = r.rel.Replace(html)
+	}
+	return html
+}
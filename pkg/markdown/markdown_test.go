@@ -0,0 +1,82 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommentRendererAllowsSubset(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "emphasis",
+			markdown: "*важно* и **очень**",
+			want:     "<p><em>важно</em> и <strong>очень</strong></p>",
+		},
+		{
+			name:     "inline code",
+			markdown: "вызовите `make test`",
+			want:     "<p>вызовите <code>make test</code></p>",
+		},
+		{
+			name:     "fenced code",
+			markdown: "```go\nfmt.Println(\"<b>\")\n```",
+			want:     `<pre><code class="language-go">fmt.Println(&#34;&lt;b&gt;&#34;)`,
+		},
+		{
+			name:     "quote",
+			markdown: "> цитата",
+			want:     "<blockquote>",
+		},
+		{
+			name:     "link",
+			markdown: "[сайт](https://example.com)",
+			want:     `<a href="https://example.com" rel="nofollow ugc">сайт</a>`,
+		},
+	}
+
+	r := NewCommentRenderer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.Render(tt.markdown)
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("ожидалось %q в %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCommentRendererStripsUnsafeMarkup(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		banned   string
+	}{
+		{name: "script", markdown: "<script>alert(1)</script>", banned: "<script"},
+		{name: "javascript link", markdown: "[x](javascript:alert(1))", banned: "javascript:"},
+		{name: "heading", markdown: "# Заголовок", banned: "<h1"},
+		{name: "image", markdown: "![](https://example.com/a.png)", banned: "<img"},
+		{name: "own rel", markdown: `<a href="https://example.com" rel="me">x</a>`, banned: `rel="me"`},
+	}
+
+	r := NewCommentRenderer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.Render(tt.markdown)
+			if strings.Contains(got, tt.banned) {
+				t.Errorf("%q не должно попасть в HTML: %q", tt.banned, got)
+			}
+		})
+	}
+}
+
+func TestPostRendererKeepsUGCMarkup(t *testing.T) {
+	got := NewPostRenderer().Render("# Заголовок\n\n![](https://example.com/a.png)")
+
+	if !strings.Contains(got, "<h1>Заголовок</h1>") || !strings.Contains(got, `<img src="https://example.com/a.png"`) {
+		t.Errorf("посты должны сохранять заголовки и изображения: %s", got)
+	}
+}
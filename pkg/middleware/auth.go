@@ -20,20 +20,9 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		claims := &jwtlib.Claims{}
-
-		secretKey := os.Getenv("JWT_SECRET_KEY")
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secretKey), nil
-		})
-
-		if err != nil || !token.Valid {
-			detail := ""
-			if err != nil {
-				detail = err.Error()
-			}
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token", "details": detail})
+		claims, err := parseToken(authHeader)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token", "details": err.Error()})
 			c.Abort()
 			return
 		}
@@ -44,3 +33,36 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalAuthMiddleware заполняет данные пользователя, если передан действительный токен,
+// и пропускает запрос анонимно в остальных случаях. Используется на публичных маршрутах,
+// ответ которых дополняется данными текущего пользователя.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			if claims, err := parseToken(authHeader); err == nil {
+				c.Set("userID", claims.UserID)
+				c.Set("userRole", claims.Role)
+			}
+		}
+		c.Next()
+	}
+}
+
+// parseToken проверяет JWT из заголовка Authorization и возвращает его claims
+func parseToken(authHeader string) (*jwtlib.Claims, error) {
+	tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
+	claims := &jwtlib.Claims{}
+
+	secretKey := os.Getenv("JWT_SECRET_KEY")
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secretKey), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
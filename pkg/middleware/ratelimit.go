@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gitlab.com/Nikolay-Yakunin/blog-service/pkg/ratelimit"
+)
+
+// RateLimit ограничивает частоту запросов группы маршрутов scope.
+// Запросы считаются по пользователю, если перед ним подключен AuthMiddleware, иначе по IP.
+// В ответ добавляются заголовки RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset,
+// отклоненный запрос получает 429 и Retry-After. Если store равен nil или ограничение
+// не задано, запросы не ограничиваются; при ошибке хранилища запрос пропускается.
+func RateLimit(store ratelimit.Store, scope string, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil || !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		result, err := store.Take(rateLimitKey(c, scope), limit)
+		if err != nil {
+			log.Printf("Rate limit store error for %s: %v", scope, err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(result.Reset))
+		if !result.Allowed {
+			c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitKey возвращает ключ корзины: ID пользователя из JWT или IP клиента
+func rateLimitKey(c *gin.Context, scope string) string {
+	if userID := c.GetUint("userID"); userID != 0 {
+		return fmt.Sprintf("%s:user:%d", scope, userID)
+	}
+	return scope + ":ip:" + c.ClientIP()
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,182 @@
+// Package pow реализует задачи proof-of-work, которыми сервер отсеивает ботов
+// без сторонних капч.
+//
+// Сервер выдает подписанную задачу со сроком действия и сложностью. Клиент
+// подбирает nonce, при котором SHA-256 от "задача:nonce" начинается с
+// Difficulty нулевых бит, и отправляет задачу вместе с nonce. Задачи
+// не хранятся до решения: подпись подтверждает, что задачу выдал сервер,
+// а повторно использовать решенную задачу нельзя до истечения ее срока.
+//
+// Основные компоненты:
+//   - Challenge: задача для клиента
+//   - Issuer: выдача и проверка задач
+//   - Solve: эталонный подбор nonce
+package pow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxNonceLength ограничивает длину nonce, присланного клиентом
+const maxNonceLength = 64
+
+var (
+	ErrInvalidChallenge = errors.New("invalid challenge")
+	ErrChallengeExpired = errors.New("challenge expired")
+	ErrChallengeUsed    = errors.New("challenge already used")
+	ErrInsufficientWork = errors.New("nonce does not solve the challenge")
+)
+
+// Challenge описывает задачу proof-of-work
+// @Description Задача proof-of-work
+type Challenge struct {
+	Token      string    `json:"challenge" example:"q8N3vXc1TbK0yJm5Yw0R3A.1735732800.18.Zx8aQ2..."`
+	Difficulty int       `json:"difficulty" example:"18"` // Сколько старших бит хеша должны быть нулевыми
+	ExpiresAt  time.Time `json:"expires_at" example:"2025-01-01T12:00:00Z"`
+}
+
+// Issuer выдает задачи и проверяет их решения.
+// Использованные задачи хранятся в памяти процесса до истечения их срока,
+// поэтому для нескольких экземпляров сервиса решение можно повторить на другом экземпляре.
+type Issuer struct {
+	secret     []byte
+	difficulty int
+	ttl        time.Duration
+
+	mu   sync.Mutex
+	used map[string]time.Time // Использованные задачи и их срок действия
+	now  func() time.Time
+}
+
+// NewIssuer создает выдачу задач сложностью difficulty бит со сроком действия ttl.
+// Ключ подписи генерируется при запуске: выданные ранее задачи после перезапуска недействительны.
+func NewIssuer(difficulty int, ttl time.Duration) *Issuer {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic("pow: failed to generate secret: " + err.Error())
+	}
+	return &Issuer{
+		secret:     secret,
+		difficulty: difficulty,
+		ttl:        ttl,
+		used:       make(map[string]time.Time),
+		now:        time.Now,
+	}
+}
+
+// Issue выдает новую задачу
+func (i *Issuer) Issue() Challenge {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		panic("pow: failed to generate challenge: " + err.Error())
+	}
+
+	expires := i.now().Add(i.ttl).Truncate(time.Second)
+	payload := base64.RawURLEncoding.EncodeToString(random) + "." +
+		strconv.FormatInt(expires.Unix(), 10) + "." +
+		strconv.Itoa(i.difficulty)
+	return Challenge{
+		Token:      payload + "." + i.sign(payload),
+		Difficulty: i.difficulty,
+		ExpiresAt:  expires,
+	}
+}
+
+// Verify проверяет решение задачи и отмечает задачу использованной
+func (i *Issuer) Verify(token, nonce string) error {
+	payload, signature, ok := cut(token)
+	if !ok || !hmac.Equal([]byte(signature), []byte(i.sign(payload))) {
+		return ErrInvalidChallenge
+	}
+	parts := strings.Split(payload, ".")
+	if len(parts) != 3 {
+		return ErrInvalidChallenge
+	}
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return ErrInvalidChallenge
+	}
+	difficulty, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return ErrInvalidChallenge
+	}
+
+	expires := time.Unix(unix, 0)
+	now := i.now()
+	if !now.Before(expires) {
+		return ErrChallengeExpired
+	}
+	if nonce == "" || len(nonce) > maxNonceLength || !Check(token, nonce, difficulty) {
+		return ErrInsufficientWork
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for used, until := range i.used {
+		if !now.Before(until) {
+			delete(i.used, used)
+		}
+	}
+	if _, ok := i.used[token]; ok {
+		return ErrChallengeUsed
+	}
+	i.used[token] = expires
+	return nil
+}
+
+// sign возвращает подпись полезной нагрузки задачи
+func (i *Issuer) sign(payload string) string {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cut отделяет подпись от полезной нагрузки задачи
+func cut(token string) (payload, signature string, ok bool) {
+	idx := strings.LastIndexByte(token, '.')
+	if idx <= 0 || idx == len(token)-1 {
+		return "", "", false
+	}
+	return token[:idx], token[idx+1:], true
+}
+
+// Check сообщает, что SHA-256 от "token:nonce" начинается с difficulty нулевых бит
+func Check(token, nonce string, difficulty int) bool {
+	sum := sha256.Sum256([]byte(token + ":" + nonce))
+	return leadingZeros(sum[:]) >= difficulty
+}
+
+// leadingZeros возвращает количество старших нулевых бит хеша
+func leadingZeros(sum []byte) int {
+	zeros := 0
+	for len(sum) >= 8 {
+		word := binary.BigEndian.Uint64(sum)
+		zeros += bits.LeadingZeros64(word)
+		if word != 0 {
+			return zeros
+		}
+		sum = sum[8:]
+	}
+	return zeros
+}
+
+// Solve подбирает nonce для задачи. Эталонная реализация клиента:
+// в среднем требует 2^difficulty вычислений хеша.
+func Solve(challenge Challenge) string {
+	for n := uint64(0); ; n++ {
+		nonce := strconv.FormatUint(n, 36)
+		if Check(challenge.Token, nonce, challenge.Difficulty) {
+			return nonce
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package pow
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// clock - управляемое время для тестов
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestIssuer(difficulty int) (*Issuer, *clock) {
+	c := &clock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	i := NewIssuer(difficulty, 5*time.Minute)
+	i.now = c.now
+	return i, c
+}
+
+func TestIssuerVerifySolution(t *testing.T) {
+	i, _ := newTestIssuer(8)
+	challenge := i.Issue()
+	if challenge.Difficulty != 8 || !challenge.ExpiresAt.Equal(i.now().Add(5*time.Minute)) {
+		t.Fatalf("неверные параметры задачи: %+v", challenge)
+	}
+
+	if err := i.Verify(challenge.Token, Solve(challenge)); err != nil {
+		t.Fatalf("решение должно быть принято: %v", err)
+	}
+	if err := i.Verify(challenge.Token, Solve(challenge)); err != ErrChallengeUsed {
+		t.Errorf("повторное решение: получено %v, ожидалось %v", err, ErrChallengeUsed)
+	}
+}
+
+func TestIssuerRejectsWrongNonce(t *testing.T) {
+	i, _ := newTestIssuer(16)
+	challenge := i.Issue()
+
+	// Подбираем nonce, который не решает задачу
+	nonce := "x"
+	for Check(challenge.Token, nonce, 16) {
+		nonce += "x"
+	}
+	if err := i.Verify(challenge.Token, nonce); err != ErrInsufficientWork {
+		t.Errorf("получено %v, ожидалось %v", err, ErrInsufficientWork)
+	}
+}
+
+func TestIssuerRejectsExpiredChallenge(t *testing.T) {
+	i, c := newTestIssuer(4)
+	challenge := i.Issue()
+	nonce := Solve(challenge)
+
+	c.t = c.t.Add(5 * time.Minute)
+	if err := i.Verify(challenge.Token, nonce); err != ErrChallengeExpired {
+		t.Errorf("получено %v, ожидалось %v", err, ErrChallengeExpired)
+	}
+}
+
+func TestIssuerRejectsForgedChallenge(t *testing.T) {
+	i, _ := newTestIssuer(12)
+	challenge := i.Issue()
+
+	// Клиент снизил сложность в задаче: подпись перестает совпадать
+	parts := strings.Split(challenge.Token, ".")
+	parts[2] = "0"
+	forged := Challenge{Token: strings.Join(parts, "."), Difficulty: 0}
+	if err := i.Verify(forged.Token, Solve(forged)); err != ErrInvalidChallenge {
+		t.Errorf("получено %v, ожидалось %v", err, ErrInvalidChallenge)
+	}
+
+	// Задача другого экземпляра с другим ключом
+	other, _ := newTestIssuer(4)
+	foreign := other.Issue()
+	if err := i.Verify(foreign.Token, Solve(foreign)); err != ErrInvalidChallenge {
+		t.Errorf("получено %v, ожидалось %v", err, ErrInvalidChallenge)
+	}
+}
+
+func TestLeadingZeros(t *testing.T) {
+	sum := make([]byte, 32)
+	if got := leadingZeros(sum); got != 256 {
+		t.Errorf("нулевой хеш: получено %d, ожидалось 256", got)
+	}
+	sum[9] = 0x10
+	if got := leadingZeros(sum); got != 75 {
+		t.Errorf("получено %d, ожидалось 75", got)
+	}
+}
@@ -0,0 +1,25 @@
+// Package query читает параметры строки запроса (пагинацию, лимиты)
+// и приводит их к допустимым значениям.
+package query
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Int читает целый query-параметр и приводит его к диапазону [min, max].
+// Некорректное значение заменяется на def; max < 0 означает отсутствие верхней границы.
+func Int(c *gin.Context, name string, def, min, max int) int {
+	value, err := strconv.Atoi(c.DefaultQuery(name, strconv.Itoa(def)))
+	if err != nil {
+		return def
+	}
+	if value < min {
+		return min
+	}
+	if max >= 0 && value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,36 @@
+package query
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name  string
+		query string
+		max   int
+		want  int
+	}{
+		{name: "default", query: "", max: 100, want: 10},
+		{name: "value in range", query: "?limit=25", max: 100, want: 25},
+		{name: "above maximum", query: "?limit=100000", max: 100, want: 100},
+		{name: "below minimum", query: "?limit=-5", max: 100, want: 1},
+		{name: "not a number", query: "?limit=abc", max: 100, want: 10},
+		{name: "no upper bound", query: "?limit=100000", max: -1, want: 100000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/"+tt.query, nil)
+			if got := Int(c, "limit", 10, 1, tt.max); got != tt.want {
+				t.Errorf("ожидалось %d, получено: %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Package ratelimit ограничивает частоту запросов по алгоритму token bucket.
+//
+// У каждого ключа (пользователя или IP-адреса в группе маршрутов) своя корзина
+// на Burst токенов, которая пополняется на Requests токенов за Period.
+// Запрос забирает один токен; если корзина пуста, запрос отклоняется.
+//
+// Основные компоненты:
+//   - Limit: ограничение для группы маршрутов
+//   - Store: хранилище корзин (реализация в памяти или общее для нескольких экземпляров)
+//   - MemoryStore: хранилище в памяти одного процесса
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval - период удаления из памяти корзин, которые снова заполнились
+const sweepInterval = time.Minute
+
+// Limit описывает ограничение частоты запросов для группы маршрутов.
+// Нулевое значение Requests или Period отключает ограничение.
+type Limit struct {
+	Requests int           `mapstructure:"requests"` // Сколько запросов разрешено за Period
+	Period   time.Duration `mapstructure:"period"`   // Период пополнения корзины на Requests токенов
+	Burst    int           `mapstructure:"burst"`    // Размер корзины, 0 - равен Requests
+}
+
+// Enabled сообщает, что ограничение задано
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Capacity возвращает размер корзины
+func (l Limit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// rate возвращает скорость пополнения корзины в токенах в секунду
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result описывает решение по запросу и состояние корзины после него
+type Result struct {
+	Allowed    bool          // Запрос разрешен
+	Limit      int           // Размер корзины
+	Remaining  int           // Сколько запросов еще можно выполнить сразу
+	Reset      time.Duration // Через сколько корзина заполнится полностью
+	RetryAfter time.Duration // Через сколько появится токен (для отклоненного запроса)
+}
+
+// Store хранит корзины токенов. Реализация для нескольких экземпляров сервиса
+// (например, в Redis) должна выполнять Take атомарно.
+type Store interface {
+	// Take забирает токен из корзины ключа, если он есть
+	Take(key string, limit Limit) (Result, error)
+}
+
+// bucket - корзина токенов одного ключа
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // Время, когда корзина заполнится без новых запросов
+}
+
+// MemoryStore хранит корзины в памяти процесса.
+// Подходит для одного экземпляра сервиса: при перезапуске корзины сбрасываются.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore создает хранилище корзин в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take забирает токен из корзины ключа, если он есть
+func (s *MemoryStore) Take(key string, limit Limit) (Result, error) {
+	capacity := float64(limit.Capacity())
+	rate := limit.rate()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+	}
+	b.updated = now
+
+	result := Result{Limit: limit.Capacity()}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((capacity - b.tokens) / rate)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep удаляет корзины, которые заполнились: они не отличаются от новых
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if !b.full.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// seconds переводит секунды в time.Duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
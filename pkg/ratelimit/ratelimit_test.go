@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// clock - управляемое время для тестов
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestStore() (*MemoryStore, *clock) {
+	c := &clock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.now = c.now
+	return s, c
+}
+
+func TestMemoryStoreBurstAndRefill(t *testing.T) {
+	s, c := newTestStore()
+	limit := Limit{Requests: 6, Period: time.Minute, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		if r, _ := s.Take("user:1", limit); !r.Allowed {
+			t.Fatalf("запрос %d должен пройти в пределах burst", i+1)
+		}
+	}
+
+	r, _ := s.Take("user:1", limit)
+	if r.Allowed {
+		t.Fatal("запрос сверх burst должен быть отклонен")
+	}
+	if r.Remaining != 0 || r.RetryAfter != 10*time.Second || r.Limit != 2 {
+		t.Errorf("неверное состояние корзины: %+v", r)
+	}
+
+	// Токен пополняется раз в 10 секунд
+	c.t = c.t.Add(10 * time.Second)
+	if r, _ := s.Take("user:1", limit); !r.Allowed {
+		t.Error("после пополнения запрос должен пройти")
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	s, _ := newTestStore()
+	limit := Limit{Requests: 1, Period: time.Hour}
+
+	s.Take("ip:10.0.0.1", limit)
+	if r, _ := s.Take("ip:10.0.0.1", limit); r.Allowed {
+		t.Error("второй запрос с того же IP должен быть отклонен")
+	}
+	if r, _ := s.Take("ip:10.0.0.2", limit); !r.Allowed {
+		t.Error("запрос с другого IP должен пройти")
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	s, c := newTestStore()
+	limit := Limit{Requests: 60, Period: time.Minute}
+
+	s.Take("user:1", limit)
+	c.t = c.t.Add(2 * sweepInterval)
+	s.Take("user:2", limit)
+
+	if _, ok := s.buckets["user:1"]; ok {
+		t.Error("заполнившаяся корзина должна быть удалена")
+	}
+}
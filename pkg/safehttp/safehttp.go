@@ -0,0 +1,118 @@
+// Package safehttp создает HTTP-клиенты для запросов по адресам, полученным
+// от внешних пользователей и серверов (страницы-источники webmention,
+// акторы и inbox ActivityPub, ссылки в постах).
+//
+// Клиент соединяется только с публичными IP-адресами: адрес проверяется
+// после разрешения имени, непосредственно перед соединением, поэтому запрос
+// нельзя направить во внутреннюю сеть ни ссылкой на localhost или
+// 169.254.169.254, ни редиректом, ни DNS-записью, указывающей на частный адрес.
+//
+// Основные компоненты:
+//   - NewClient: HTTP-клиент, соединяющийся только с публичными адресами
+//   - NewTransport: транспорт такого клиента
+//   - IsPublic: проверка, что адрес доступен из интернета
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress возвращается при попытке соединиться с непубличным адресом
+var ErrForbiddenAddress = errors.New("address is not public")
+
+// maxRedirects ограничивает число переходов по редиректам, как в http.Client по умолчанию
+const maxRedirects = 10
+
+// reserved - диапазоны, не маршрутизируемые в интернете, которые не покрывают
+// методы netip.Addr (IsPrivate, IsLoopback и др.)
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "Этот" сетевой сегмент
+	netip.MustParsePrefix("100.64.0.0/10"),   // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // Назначения IETF
+	netip.MustParsePrefix("192.0.2.0/24"),    // TEST-NET-1
+	netip.MustParsePrefix("198.18.0.0/15"),   // Тестирование производительности
+	netip.MustParsePrefix("198.51.100.0/24"), // TEST-NET-2
+	netip.MustParsePrefix("203.0.113.0/24"),  // TEST-NET-3
+	netip.MustParsePrefix("240.0.0.0/4"),     // Зарезервировано, включая broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64: ведет на встроенный IPv4-адрес
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // Локальный NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // Документация
+}
+
+// IsPublic сообщает, доступен ли адрес из интернета.
+// Непубличны loopback, частные сети (RFC 1918, fc00::/7), link-local
+// (включая адрес метаданных облака 169.254.169.254), multicast и зарезервированные диапазоны.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// control проверяет адрес непосредственно перед соединением, уже после разрешения имени
+func control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !IsPublic(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// NewTransport создает транспорт, соединяющийся только с публичными адресами.
+// Прокси из окружения не используется: через него проверка адреса была бы обойдена.
+func NewTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   control,
+	}
+	return &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// NewClient создает HTTP-клиент с таймаутом запроса, соединяющийся только с публичными адресами.
+// Редиректы допускаются только на http и https и не на непубличные IP-адреса.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     NewTransport(),
+		CheckRedirect: checkRedirect,
+	}
+}
+
+// checkRedirect отклоняет редиректы на другие схемы и на непубличные IP-адреса.
+// Имена хостов проверяются при соединении, после разрешения.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+	}
+	if addr, err := netip.ParseAddr(req.URL.Hostname()); err == nil && !IsPublic(addr) {
+		return fmt.Errorf("%w: redirect to %s", ErrForbiddenAddress, addr)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package safehttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPublic(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("IsPublic(%s) = %v, ожидалось %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestClientRejectsPrivateAddress(t *testing.T) {
+	called := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer ts.Close()
+
+	// Сервер слушает loopback; имя localhost проверяется после разрешения
+	server, _ := url.Parse(ts.URL)
+	for _, link := range []string{ts.URL, "http://localhost:" + server.Port()} {
+		_, err := NewClient(time.Second).Get(link)
+		if !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("%s: ожидалась ErrForbiddenAddress, получено %v", link, err)
+		}
+	}
+	if called {
+		t.Error("запрос не должен доходить до непубличного адреса")
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://example.com/next", false},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://[::1]:8080/", true},
+		{"file:///etc/passwd", true},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		if err := checkRedirect(req, make([]*http.Request, 1)); (err != nil) != tt.wantErr {
+			t.Errorf("%s: ошибка %v, ожидалась ошибка: %v", tt.url, err, tt.wantErr)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	if err := checkRedirect(req, make([]*http.Request, maxRedirects)); err == nil {
+		t.Error("число редиректов должно ограничиваться")
+	}
+}
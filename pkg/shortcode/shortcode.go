@@ -0,0 +1,185 @@
+// Package shortcode разворачивает шорткоды вида {{< name arg1 arg2 >}}
+// в Markdown в разметку встраиваемого контента.
+//
+// Шорткоды заменяются плейсхолдерами до рендеринга Markdown, а разметка
+// вставок подставляется уже после санитизации основного HTML. Каждая
+// вставка отдельно проходит через политику EmbedPolicy, поэтому в итоговый
+// HTML попадают только разрешенные элементы и адреса.
+package shortcode
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// Handler формирует разметку шорткода по его аргументам.
+// Возвращает false, если аргументы некорректны: тогда шорткод остается в тексте как есть.
+type Handler func(args []string) (string, bool)
+
+// Registry хранит обработчики шорткодов по именам
+type Registry struct {
+	handlers map[string]Handler
+}
+
+// NewRegistry создает реестр со встроенными шорткодами youtube и gist
+func NewRegistry() *Registry {
+	r := &Registry{handlers: make(map[string]Handler)}
+	r.Register("youtube", YouTube)
+	r.Register("gist", Gist)
+	return r
+}
+
+// Register добавляет обработчик шорткода.
+// Регистрировать обработчики нужно до начала рендеринга.
+func (r *Registry) Register(name string, handler Handler) {
+	r.handlers[name] = handler
+}
+
+// Символы из области частного использования Unicode не встречаются
+// в обычном тексте и не изменяются ни Markdown, ни санитайзером
+const (
+	placeholderOpen  = "\uE000embed"
+	placeholderClose = "\uE001"
+)
+
+var (
+	// shortcodePattern находит {{< name args... >}}
+	shortcodePattern = regexp.MustCompile(`\{\{<\s*([a-z][a-z0-9_-]*)((?:\s+[^\s>]+)*)\s*>\}\}`)
+	// codeSpanPattern находит инлайн-код, внутри которого шорткоды не разворачиваются
+	codeSpanPattern = regexp.MustCompile("`[^`\n]*`")
+	// placeholderPattern находит плейсхолдер вставки в отрендеренном HTML
+	placeholderPattern = regexp.MustCompile(`(?:<p>)?` + placeholderOpen + `(\d+)` + placeholderClose + `(?:</p>)?`)
+)
+
+// Embeds содержит разметку вставок в порядке их плейсхолдеров
+type Embeds []string
+
+// Extract заменяет известные шорткоды плейсхолдерами.
+// Шорткоды внутри блоков кода и инлайн-кода не разворачиваются.
+func (r *Registry) Extract(markdown string) (string, Embeds) {
+	var embeds Embeds
+	var out strings.Builder
+
+	fence := ""
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			out.WriteString(line)
+			continue
+		case strings.HasPrefix(trimmed, "```"):
+			fence = "```"
+			out.WriteString(line)
+			continue
+		case strings.HasPrefix(trimmed, "~~~"):
+			fence = "~~~"
+			out.WriteString(line)
+			continue
+		}
+
+		out.WriteString(r.expandLine(line, &embeds))
+	}
+
+	return out.String(), embeds
+}
+
+// expandLine разворачивает шорткоды в строке вне инлайн-кода
+func (r *Registry) expandLine(line string, embeds *Embeds) string {
+	var out strings.Builder
+	last := 0
+	for _, span := range codeSpanPattern.FindAllStringIndex(line, -1) {
+		out.WriteString(r.expandText(line[last:span[0]], embeds))
+		out.WriteString(line[span[0]:span[1]])
+		last = span[1]
+	}
+	out.WriteString(r.expandText(line[last:], embeds))
+	return out.String()
+}
+
+// expandText заменяет шорткоды в тексте плейсхолдерами
+func (r *Registry) expandText(text string, embeds *Embeds) string {
+	return shortcodePattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := shortcodePattern.FindStringSubmatch(match)
+		handler, ok := r.handlers[parts[1]]
+		if !ok {
+			return match
+		}
+
+		markup, ok := handler(strings.Fields(parts[2]))
+		if !ok {
+			return match
+		}
+
+		*embeds = append(*embeds, EmbedPolicy.Sanitize(markup))
+		return placeholderOpen + strconv.Itoa(len(*embeds)-1) + placeholderClose
+	})
+}
+
+// Restore подставляет разметку вставок на место плейсхолдеров в HTML.
+// Плейсхолдер, оказавшийся отдельным абзацем, заменяется вместе с тегами <p>.
+func (e Embeds) Restore(rendered string) string {
+	if len(e) == 0 {
+		return rendered
+	}
+	return placeholderPattern.ReplaceAllStringFunc(rendered, func(match string) string {
+		parts := placeholderPattern.FindStringSubmatch(match)
+		index, err := strconv.Atoi(parts[1])
+		if err != nil || index >= len(e) {
+			return ""
+		}
+		return e[index]
+	})
+}
+
+var (
+	youtubeID   = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	githubLogin = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})$`)
+	gistID      = regexp.MustCompile(`^[0-9a-f]{1,64}$`)
+)
+
+// YouTube встраивает видео: {{< youtube VIDEO_ID >}}
+func YouTube(args []string) (string, bool) {
+	if len(args) != 1 || !youtubeID.MatchString(args[0]) {
+		return "", false
+	}
+	return fmt.Sprintf(
+		`<figure class="embed embed-youtube"><iframe src="https://www.youtube-nocookie.com/embed/%s" width="560" height="315" title="YouTube" loading="lazy" allowfullscreen></iframe></figure>`,
+		args[0],
+	), true
+}
+
+// Gist вставляет ссылку на GitHub Gist: {{< gist USER ID >}}
+// Скрипты в HTML поста не допускаются, поэтому клиент сам решает,
+// подгружать ли содержимое гиста по ссылке с классом embed-gist.
+func Gist(args []string) (string, bool) {
+	if len(args) != 2 || !githubLogin.MatchString(args[0]) || !gistID.MatchString(args[1]) {
+		return "", false
+	}
+	link := fmt.Sprintf("https://gist.github.com/%s/%s", args[0], args[1])
+	return fmt.Sprintf(
+		`<figure class="embed embed-gist"><a href="%s">%s</a></figure>`,
+		link, html.EscapeString(args[0]+"/"+args[1]),
+	), true
+}
+
+// EmbedPolicy - политика санитизации разметки вставок.
+// Помимо UGC-разметки разрешает iframe только с плеером YouTube.
+var EmbedPolicy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowElements("figure", "figcaption", "iframe")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^embed(?: embed-[a-z]+)?$`)).OnElements("figure", "a")
+	p.AllowAttrs("src").Matching(regexp.MustCompile(`^https://www\.youtube-nocookie\.com/embed/[A-Za-z0-9_-]{11}$`)).OnElements("iframe")
+	p.AllowAttrs("width", "height").Matching(bluemonday.Number).OnElements("iframe")
+	p.AllowAttrs("title").OnElements("iframe")
+	p.AllowAttrs("loading").Matching(regexp.MustCompile(`^lazy$`)).OnElements("iframe")
+	p.AllowAttrs("allowfullscreen").Matching(regexp.MustCompile(`^$`)).OnElements("iframe")
+	return p
+}()
@@ -0,0 +1,80 @@
+package shortcode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/russross/blackfriday/v2"
+)
+
+// render повторяет конвейер рендеринга постов
+func render(r *Registry, markdown string) string {
+	text, embeds := r.Extract(markdown)
+	html := bluemonday.UGCPolicy().SanitizeBytes(blackfriday.Run([]byte(text)))
+	return embeds.Restore(string(html))
+}
+
+func TestRenderYouTube(t *testing.T) {
+	got := render(NewRegistry(), "Смотрите:\n\n{{< youtube dQw4w9WgXcQ >}}\n")
+
+	if !strings.Contains(got, `<iframe src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ"`) {
+		t.Fatalf("ожидался iframe с плеером, получено: %s", got)
+	}
+	if strings.Contains(got, "<p><figure") {
+		t.Errorf("вставка не должна оборачиваться в абзац: %s", got)
+	}
+}
+
+func TestRenderGist(t *testing.T) {
+	got := render(NewRegistry(), "{{< gist octocat 6cad326836d38bd3a7ae >}}")
+
+	if !strings.Contains(got, `href="https://gist.github.com/octocat/6cad326836d38bd3a7ae"`) {
+		t.Errorf("ожидалась ссылка на гист, получено: %s", got)
+	}
+}
+
+func TestRenderKeepsInvalidShortcodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+	}{
+		{name: "unknown shortcode", markdown: "{{< vimeo 123 >}}"},
+		{name: "bad youtube id", markdown: `{{< youtube "><script>alert(1)</script> >}}`},
+		{name: "bad gist id", markdown: "{{< gist octocat ../../evil >}}"},
+		{name: "missing args", markdown: "{{< youtube >}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := render(NewRegistry(), tt.markdown)
+			if strings.Contains(got, "<iframe") || strings.Contains(got, "<script") || strings.Contains(got, "embed-") {
+				t.Errorf("шорткод не должен разворачиваться, получено: %s", got)
+			}
+		})
+	}
+}
+
+func TestExtractSkipsCode(t *testing.T) {
+	markdown := "Инлайн `{{< youtube dQw4w9WgXcQ >}}`\n\n```\n{{< youtube dQw4w9WgXcQ >}}\n```\n"
+
+	text, embeds := NewRegistry().Extract(markdown)
+	if len(embeds) != 0 {
+		t.Fatalf("шорткоды в коде не должны разворачиваться, получено вставок: %d", len(embeds))
+	}
+	if text != markdown {
+		t.Errorf("текст не должен меняться, получено: %q", text)
+	}
+}
+
+func TestCustomHandlerIsSanitized(t *testing.T) {
+	r := NewRegistry()
+	r.Register("evil", func(args []string) (string, bool) {
+		return `<figure class="embed"><iframe src="https://evil.example/"></iframe><script>alert(1)</script></figure>`, true
+	})
+
+	got := render(r, "{{< evil >}}")
+	if strings.Contains(got, "evil.example") || strings.Contains(got, "<script") {
+		t.Errorf("разметка вставки должна проходить санитизацию, получено: %s", got)
+	}
+}